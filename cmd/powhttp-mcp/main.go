@@ -2,40 +2,63 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/usestring/powhttp-mcp/pkg/capture"
 	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/mcpsrv"
 )
 
 func main() {
+	// --capture may be repeated; each file becomes its own session
+	var capturePaths []string
+	flag.Func("capture", "serve traffic from a HAR or JSONL file instead of the powhttp app (repeatable)", func(path string) error {
+		capturePaths = append(capturePaths, path)
+		return nil
+	})
+	flag.Parse()
+
 	// Set up context with signal handling
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Create powhttp API client
+	// Create the traffic source: capture files when given, otherwise the powhttp API.
 	// Base URL and HTTP client timeout are configured via environment variables
 	// (POWHTTP_BASE_URL defaults to http://localhost:7777)
-	powClient := client.New()
+	var source client.DataSource = client.New()
+	if len(capturePaths) > 0 {
+		src, err := capture.Open(capturePaths...)
+		if err != nil {
+			slog.Error("failed to load capture files", "error", err)
+			os.Exit(1)
+		}
+		source = src
+	}
 
 	// Create MCP server with all builtin tools
 	// Configuration is loaded from environment variables:
 	// - LOG_LEVEL: debug, info, warn, error (default: info)
 	// - LOG_FILE: path to log file (default: stderr only)
 	// - POWHTTP_BASE_URL: powhttp API base URL
+	// - MCP_HTTP_ADDR: serve over HTTP on this address instead of stdio
 	// - etc. (see internal/config for all options)
-	server, err := mcpsrv.NewServer(powClient)
+	server, err := mcpsrv.NewServer(source)
 	if err != nil {
 		slog.Error("failed to create MCP server", "error", err)
 		os.Exit(1)
 	}
 	defer server.Close()
 
-	// Run the server with stdio transport
-	slog.Info("starting powhttp MCP server on stdio")
+	// Run the server with stdio transport, or HTTP when MCP_HTTP_ADDR is set
+	if addr := server.HTTPAddr(); addr != "" {
+		slog.Info("starting powhttp MCP server on http", "addr", addr, "streamable_path", "/mcp", "sse_path", "/sse")
+	} else {
+		slog.Info("starting powhttp MCP server on stdio")
+	}
 	if err := server.Run(ctx); err != nil && err != context.Canceled {
 		slog.Error("server error", "error", err)
 		os.Exit(1)
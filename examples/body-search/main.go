@@ -106,7 +106,7 @@ func bodySearchHandler(d *mcpsrv.Deps) func(context.Context, *mcp.CallToolReques
 			// Search request body
 			if input.InBody == "" || input.InBody == "both" || input.InBody == "request" {
 				if entry.Request.Body != nil {
-					if match := searchBody(entry.Request.Body, queryLower, result.Summary, "request"); match != nil {
+					if match := searchBody(entry.Request.Body, entry.Request.Headers, queryLower, result.Summary, "request"); match != nil {
 						matches = append(matches, *match)
 						continue
 					}
@@ -116,7 +116,7 @@ func bodySearchHandler(d *mcpsrv.Deps) func(context.Context, *mcp.CallToolReques
 			// Search response body
 			if input.InBody == "" || input.InBody == "both" || input.InBody == "response" {
 				if entry.Response != nil && entry.Response.Body != nil {
-					if match := searchBody(entry.Response.Body, queryLower, result.Summary, "response"); match != nil {
+					if match := searchBody(entry.Response.Body, entry.Response.Headers, queryLower, result.Summary, "response"); match != nil {
 						matches = append(matches, *match)
 					}
 				}
@@ -127,8 +127,8 @@ func bodySearchHandler(d *mcpsrv.Deps) func(context.Context, *mcp.CallToolReques
 	}
 }
 
-func searchBody(encodedBody *string, query string, summary *types.EntrySummary, foundIn string) *BodyMatch {
-	body, err := client.DecodeBody(encodedBody)
+func searchBody(encodedBody *string, headers client.Headers, query string, summary *types.EntrySummary, foundIn string) *BodyMatch {
+	body, err := client.DecodeContent(encodedBody, client.ContentEncoding(headers))
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,89 @@
+package brotli
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// bitReader reads the stream's bits least significant first (RFC 7932
+// section 2). Running out of input panics with a decodeError.
+type bitReader struct {
+	r    io.ByteReader
+	bits uint64 // buffered bits, next bit lowest
+	n    uint   // number of buffered bits
+	err  error  // first error from r
+}
+
+func newBitReader(r io.Reader) bitReader {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return bitReader{r: br}
+}
+
+// tryFill buffers at least n bits (n <= 56) if the input has them.
+func (b *bitReader) tryFill(n uint) bool {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if b.err == nil {
+				b.err = err
+			}
+			return false
+		}
+		b.bits |= uint64(c) << b.n
+		b.n += 8
+	}
+	return true
+}
+
+// fill buffers at least n bits, failing at the end of the input.
+func (b *bitReader) fill(n uint) {
+	if b.tryFill(n) {
+		return
+	}
+	err := b.err
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	panic(decodeError{err})
+}
+
+// read consumes the next n bits (n <= 32).
+func (b *bitReader) read(n uint) int {
+	if n == 0 {
+		return 0
+	}
+	b.fill(n)
+	v := int(b.bits & (1<<n - 1))
+	b.bits >>= n
+	b.n -= n
+	return v
+}
+
+// peek returns the next n bits (n <= 32) without consuming them, zero-padded
+// past the end of the input, and the number of bits actually buffered.
+func (b *bitReader) peek(n uint) (int, uint) {
+	b.tryFill(n)
+	return int(b.bits & (1<<n - 1)), b.n
+}
+
+// drop consumes n bits returned by peek.
+func (b *bitReader) drop(n uint) {
+	b.bits >>= n
+	b.n -= n
+}
+
+// align skips to the next byte boundary. The skipped bits must be zero.
+func (b *bitReader) align() {
+	if b.read(b.n%8) != 0 {
+		fail("nonzero padding bits")
+	}
+}
+
+// readByte reads a whole byte after align.
+func (b *bitReader) readByte() byte {
+	return byte(b.read(8))
+}
@@ -0,0 +1,402 @@
+// Package brotli provides a decompressor for brotli streams, described in
+// RFC 7932. It does not support the large window extension.
+package brotli
+
+import (
+	"errors"
+	"io"
+)
+
+// decodeChunk is how much output Read decodes ahead of the caller.
+const decodeChunk = 32 << 10
+
+// decodeError carries a decoding failure out of the bit reader and the
+// decoding steps to Read.
+type decodeError struct {
+	err error
+}
+
+// fail aborts decoding of a malformed stream.
+func fail(msg string) {
+	panic(decodeError{errors.New("brotli: invalid stream: " + msg)})
+}
+
+// Reader implements [io.Reader] to read a brotli compressed stream.
+type Reader struct {
+	br  bitReader
+	err error // sticky; io.EOF once the stream has been read
+
+	window int    // largest backward distance
+	hist   []byte // recent output for back-references, then output not yet read
+	rd     int    // start of unread output in hist
+	total  int    // bytes decoded so far
+
+	started   bool // stream header read
+	last      bool // current meta-block is the last one
+	done      bool // last meta-block decoded
+	raw       bool // current meta-block is uncompressed
+	remaining int  // bytes left in the current meta-block
+
+	dist [4]int // last four distances, most recent first
+	mb   metaBlock
+}
+
+// metaBlock holds the prefix codes and state of a compressed meta-block.
+type metaBlock struct {
+	literal, command, distance blockSwitch
+
+	postfix uint // NPOSTFIX
+	direct  int  // NDIRECT
+
+	modes         []uint8 // context mode by literal block type
+	literalMap    []uint8 // literal context map
+	distanceMap   []uint8 // distance context map
+	literalCodes  []*huffman
+	commandCodes  []*huffman
+	distanceCodes []*huffman
+}
+
+// blockSwitch tracks the block type of one category of symbols.
+type blockSwitch struct {
+	types     int // NBLTYPES
+	typeCode  *huffman
+	countCode *huffman
+	current   int
+	previous  int
+	count     int // symbols left in the current block
+}
+
+// NewReader creates a new Reader that decompresses data from the given reader.
+func NewReader(input io.Reader) *Reader {
+	return &Reader{
+		br:   newBitReader(input),
+		dist: [4]int{4, 11, 15, 16},
+	}
+}
+
+// Read implements [io.Reader].
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.rd == len(r.hist) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.decode()
+	}
+	n := copy(p, r.hist[r.rd:])
+	r.rd += n
+	return n, nil
+}
+
+// decode decodes up to decodeChunk bytes of output. It returns io.EOF after
+// the last meta-block.
+func (r *Reader) decode() (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			de, ok := v.(decodeError)
+			if !ok {
+				panic(v)
+			}
+			err = de.err
+		}
+	}()
+
+	r.compact()
+	for len(r.hist)-r.rd < decodeChunk && !r.done {
+		r.step()
+	}
+	if r.done {
+		return io.EOF
+	}
+	return nil
+}
+
+// compact drops read output that back-references can no longer reach.
+func (r *Reader) compact() {
+	drop := min(r.rd, len(r.hist)-r.window)
+	if drop > 0 && drop >= len(r.hist)/2 {
+		n := copy(r.hist, r.hist[drop:])
+		r.hist = r.hist[:n]
+		r.rd -= drop
+	}
+}
+
+// step decodes the next header, command, or run of uncompressed bytes.
+func (r *Reader) step() {
+	switch {
+	case !r.started:
+		r.readStreamHeader()
+	case r.remaining == 0 && r.last:
+		r.done = true
+	case r.remaining == 0:
+		r.readMetaBlockHeader()
+	case r.raw:
+		n := min(r.remaining, decodeChunk)
+		for range n {
+			r.hist = append(r.hist, r.br.readByte())
+		}
+		r.total += n
+		r.remaining -= n
+	default:
+		r.command()
+	}
+}
+
+// readStreamHeader reads the window size (RFC 7932 section 9.1).
+func (r *Reader) readStreamHeader() {
+	wbits := 16
+	if r.br.read(1) == 1 {
+		if n := r.br.read(3); n != 0 {
+			wbits = 17 + n
+		} else {
+			switch n := r.br.read(3); n {
+			case 0:
+				wbits = 17
+			case 1:
+				fail("large window streams are not supported")
+			default:
+				wbits = 8 + n
+			}
+		}
+	}
+	r.window = 1<<wbits - 16
+	r.started = true
+}
+
+// readMetaBlockHeader reads a meta-block header (RFC 7932 section 9.2),
+// skipping metadata.
+func (r *Reader) readMetaBlockHeader() {
+	br := &r.br
+	r.last = br.read(1) == 1
+	if r.last && br.read(1) == 1 {
+		r.done = true
+		return
+	}
+
+	nibbles := br.read(2) + 4
+	if nibbles == 7 {
+		// Metadata, which carries no output
+		if br.read(1) != 0 {
+			fail("reserved bit set")
+		}
+		skipBytes := br.read(2)
+		skip := 0
+		for i := range skipBytes {
+			b := br.read(8)
+			if i > 0 && i == skipBytes-1 && b == 0 {
+				fail("metadata length has a leading zero byte")
+			}
+			skip |= b << (8 * i)
+		}
+		if skipBytes > 0 {
+			skip++
+		}
+		br.align()
+		for range skip {
+			br.readByte()
+		}
+		return
+	}
+
+	mlen := 0
+	for i := range nibbles {
+		v := br.read(4)
+		if i > 3 && i == nibbles-1 && v == 0 {
+			fail("meta-block length has a leading zero nibble")
+		}
+		mlen |= v << (4 * i)
+	}
+	r.remaining = mlen + 1
+
+	r.raw = !r.last && br.read(1) == 1
+	if r.raw {
+		br.align()
+		return
+	}
+	r.readCodes()
+}
+
+// readCodes reads the block switches, context maps, and prefix codes of a
+// compressed meta-block.
+func (r *Reader) readCodes() {
+	br := &r.br
+	mb := &r.mb
+	mb.literal.readHeader(br)
+	mb.command.readHeader(br)
+	mb.distance.readHeader(br)
+
+	mb.postfix = uint(br.read(2))
+	mb.direct = br.read(4) << mb.postfix
+
+	mb.modes = make([]uint8, mb.literal.types)
+	for i := range mb.modes {
+		mb.modes[i] = uint8(br.read(2))
+	}
+
+	var literalTrees, distanceTrees int
+	mb.literalMap, literalTrees = readContextMap(br, 64*mb.literal.types)
+	mb.distanceMap, distanceTrees = readContextMap(br, 4*mb.distance.types)
+
+	mb.literalCodes = readPrefixCodes(br, literalTrees, 256)
+	mb.commandCodes = readPrefixCodes(br, mb.command.types, 704)
+	mb.distanceCodes = readPrefixCodes(br, distanceTrees, 16+mb.direct+48<<mb.postfix)
+}
+
+// readPrefixCodes reads n prefix codes over alphabetSize symbols.
+func readPrefixCodes(br *bitReader, n, alphabetSize int) []*huffman {
+	codes := make([]*huffman, n)
+	for i := range codes {
+		codes[i] = readPrefixCode(br, alphabetSize)
+	}
+	return codes
+}
+
+// readHeader reads the number of block types of a category and, if there
+// are several, the codes for switching between them and the first block
+// count.
+func (b *blockSwitch) readHeader(br *bitReader) {
+	*b = blockSwitch{types: readVarLenUint8(br) + 1, previous: 1, count: 1 << 28}
+	if b.types < 2 {
+		return
+	}
+	b.typeCode = readPrefixCode(br, b.types+2)
+	b.countCode = readPrefixCode(br, len(blockCountCodes))
+	b.count = readLength(br, b.countCode.decode(br), blockCountCodes[:])
+}
+
+// next counts off one symbol, reading a block switch command if the
+// current block is used up, and returns the block type.
+func (b *blockSwitch) next(br *bitReader) int {
+	if b.count == 0 {
+		t := b.typeCode.decode(br)
+		switch t {
+		case 0:
+			t = b.previous
+		case 1:
+			t = b.current + 1
+		default:
+			t -= 2
+		}
+		if t >= b.types {
+			t -= b.types
+		}
+		b.previous, b.current = b.current, t
+		b.count = readLength(br, b.countCode.decode(br), blockCountCodes[:])
+	}
+	b.count--
+	return b.current
+}
+
+// readLength reads the extra bits of a length code.
+func readLength(br *bitReader, code int, codes []lengthCode) int {
+	c := codes[code]
+	return c.base + br.read(c.extra)
+}
+
+// command decodes one insert-and-copy command (RFC 7932 section 10).
+func (r *Reader) command() {
+	br := &r.br
+	mb := &r.mb
+
+	cmd := mb.commandCodes[mb.command.next(br)].decode(br)
+	cell := cmd >> 6
+	insert := readLength(br, commandInsertBase[cell]+cmd>>3&7, insertLengthCodes[:])
+	length := readLength(br, commandCopyBase[cell]+cmd&7, copyLengthCodes[:])
+
+	if insert > r.remaining {
+		fail("insert length exceeds the meta-block")
+	}
+	for range insert {
+		var p1, p2 byte
+		if n := len(r.hist); n > 1 {
+			p1, p2 = r.hist[n-1], r.hist[n-2]
+		} else if n == 1 {
+			p1 = r.hist[0]
+		}
+		btype := mb.literal.next(br)
+		tree := mb.literalMap[64*btype+literalContext(mb.modes[btype], p1, p2)]
+		r.hist = append(r.hist, byte(mb.literalCodes[tree].decode(br)))
+	}
+	r.total += insert
+	r.remaining -= insert
+	if r.remaining == 0 {
+		// The meta-block ends within this command; its copy is ignored.
+		return
+	}
+
+	code := 0
+	distance := r.dist[0]
+	if cmd >= 128 {
+		btype := mb.distance.next(br)
+		tree := mb.distanceMap[4*btype+min(length, 5)-2]
+		code = mb.distanceCodes[tree].decode(br)
+		distance = r.distance(code)
+	}
+
+	if maxDistance := min(r.total, r.window); distance > maxDistance {
+		r.dictionaryWord(length, distance-maxDistance-1)
+		return
+	}
+	if length > r.remaining {
+		fail("copy length exceeds the meta-block")
+	}
+	start := len(r.hist) - distance
+	for i := range length {
+		r.hist = append(r.hist, r.hist[start+i])
+	}
+	r.total += length
+	r.remaining -= length
+	if code != 0 {
+		r.dist = [4]int{distance, r.dist[0], r.dist[1], r.dist[2]}
+	}
+}
+
+// shortDistances are the last distance index and offset of distance codes
+// 0 to 15.
+var shortDistances = [16]struct{ index, offset int }{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0},
+	{0, -1}, {0, 1}, {0, -2}, {0, 2}, {0, -3}, {0, 3},
+	{1, -1}, {1, 1}, {1, -2}, {1, 2}, {1, -3}, {1, 3},
+}
+
+// distance converts a distance code to a distance (RFC 7932 section 4).
+func (r *Reader) distance(code int) int {
+	mb := &r.mb
+	switch {
+	case code < 16:
+		s := shortDistances[code]
+		d := r.dist[s.index] + s.offset
+		if d <= 0 {
+			fail("distance is not positive")
+		}
+		return d
+	case code < 16+mb.direct:
+		return code - 15
+	}
+	code -= 16 + mb.direct
+	postfix := code & (1<<mb.postfix - 1)
+	hcode := code >> mb.postfix
+	nbits := uint(hcode>>1) + 1
+	offset := (2+hcode&1)<<nbits - 4
+	return (offset+r.br.read(nbits))<<mb.postfix + postfix + mb.direct + 1
+}
+
+// dictionaryWord appends static dictionary word number id of the given
+// length, as a reference past the window encodes it (RFC 7932 section 8).
+func (r *Reader) dictionaryWord(length, id int) {
+	if length < 4 || length > 24 {
+		fail("invalid dictionary word length")
+	}
+	bits := dictionarySizeBits[length]
+	t := id >> bits
+	if t >= len(transforms) {
+		fail("invalid dictionary word transform")
+	}
+	n := len(r.hist)
+	r.hist = appendWord(r.hist, length, id&(1<<bits-1), t)
+	n = len(r.hist) - n
+	if n > r.remaining {
+		fail("dictionary word exceeds the meta-block")
+	}
+	r.total += n
+	r.remaining -= n
+}
@@ -0,0 +1,85 @@
+package brotli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSamples(t *testing.T) {
+	samples, err := os.ReadDir("testdata")
+	require.NoError(t, err)
+
+	for _, sample := range samples {
+		name := sample.Name()
+		if !strings.HasSuffix(name, ".br") {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", name))
+			require.NoError(t, err)
+			defer f.Close()
+
+			h := sha256.New()
+			_, err = io.Copy(h, NewReader(f))
+			require.NoError(t, err)
+
+			want, _, _ := strings.Cut(name, ".")
+			assert.Equal(t, want, hex.EncodeToString(h.Sum(nil))[:8])
+		})
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	sum := sha256.Sum256(dictionary)
+	assert.Len(t, dictionary, 122784)
+	assert.Equal(t, "20e42eb1b511c21806d4d227d07e5dd06877d8ce7b3a817f378f313653f35c70", hex.EncodeToString(sum[:]))
+}
+
+func TestAppendWord(t *testing.T) {
+	// "time" is the first 4-byte word
+	assert.Equal(t, "time", string(appendWord(nil, 4, 0, 0)))
+	assert.Equal(t, "Time ", string(appendWord(nil, 4, 0, 4)))
+	assert.Equal(t, "ime", string(appendWord(nil, 4, 0, 3)))
+	assert.Equal(t, " TIME", string(appendWord(nil, 4, 0, 85)))
+}
+
+func TestTruncated(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "184fdb17.json-records.br"))
+	require.NoError(t, err)
+
+	for n := range len(data) {
+		_, err := io.ReadAll(NewReader(bytes.NewReader(data[:n])))
+		assert.Error(t, err, "truncated to %d bytes", n)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"large window", []byte{0x11, 0x00}, "large window"},
+		{"empty input", nil, io.ErrUnexpectedEOF.Error()},
+		{"truncated", []byte{0x1b, 0x0e, 0x00, 0xf8}, io.ErrUnexpectedEOF.Error()},
+		{"reserved bit", []byte{0x1c}, "brotli: invalid stream: reserved bit set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(NewReader(bytes.NewReader(tt.input)))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
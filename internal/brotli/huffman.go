@@ -0,0 +1,282 @@
+package brotli
+
+import "math/bits"
+
+const (
+	// maxCodeLength is the longest prefix code length.
+	maxCodeLength = 15
+	// tableBits is the number of bits resolved by a single table lookup.
+	tableBits = 8
+)
+
+// codeLengthOrder is the order code length code lengths are sent in.
+var codeLengthOrder = [18]uint8{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// codeLengthPrefix maps the next four bits of a complex prefix code header
+// to the bits used and the code length code length they encode (RFC 7932
+// section 3.5).
+var codeLengthPrefix = [16]struct{ bits, value uint8 }{
+	{2, 0}, {2, 4}, {2, 3}, {3, 2}, {2, 0}, {2, 4}, {2, 3}, {4, 1},
+	{2, 0}, {2, 4}, {2, 3}, {3, 2}, {2, 0}, {2, 4}, {2, 3}, {4, 5},
+}
+
+// huffman is a canonical prefix code.
+type huffman struct {
+	single  bool                   // one symbol, coded with zero bits
+	table   [1 << tableBits]uint32 // by the next tableBits bits: symbol<<4 | length, 0 for longer codes
+	count   [maxCodeLength + 1]uint16
+	symbols []uint16 // by code length, then symbol
+}
+
+// newHuffman builds the canonical code for the given code lengths.
+func newHuffman(lengths []uint8) *huffman {
+	h := &huffman{}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	h.count[0] = 0
+
+	var offsets [maxCodeLength + 2]int
+	for l := 1; l <= maxCodeLength; l++ {
+		offsets[l+1] = offsets[l] + int(h.count[l])
+	}
+	h.symbols = make([]uint16, offsets[maxCodeLength+1])
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbols[offsets[l]] = uint16(sym)
+			offsets[l]++
+		}
+	}
+	if len(h.symbols) == 1 {
+		h.single = true
+		return h
+	}
+
+	code, i := 0, 0
+	for l := 1; l <= tableBits; l++ {
+		for range h.count[l] {
+			entry := uint32(h.symbols[i])<<4 | uint32(l)
+			rev := int(bits.Reverse16(uint16(code)) >> (16 - l))
+			for j := rev; j < len(h.table); j += 1 << l {
+				h.table[j] = entry
+			}
+			code++
+			i++
+		}
+		code <<= 1
+	}
+	return h
+}
+
+// decode reads one symbol.
+func (h *huffman) decode(br *bitReader) int {
+	if h.single {
+		return int(h.symbols[0])
+	}
+	v, avail := br.peek(tableBits)
+	if e := h.table[v]; e != 0 && uint(e&15) <= avail {
+		br.drop(uint(e & 15))
+		return int(e >> 4)
+	}
+
+	// Codes longer than the table, or near the end of the input: walk the
+	// canonical code a bit at a time.
+	code, first, index := 0, 0, 0
+	for l := 1; l <= maxCodeLength; l++ {
+		code |= br.read(1)
+		count := int(h.count[l])
+		if code-first < count {
+			return int(h.symbols[index+code-first])
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	fail("invalid prefix code")
+	return 0
+}
+
+// readPrefixCode reads a prefix code over alphabetSize symbols (RFC 7932
+// section 3.4 and 3.5).
+func readPrefixCode(br *bitReader, alphabetSize int) *huffman {
+	lengths := make([]uint8, alphabetSize)
+	hskip := br.read(2)
+	if hskip == 1 {
+		readSimpleCodeLengths(br, lengths)
+		return newHuffman(lengths)
+	}
+
+	// Code lengths of the code length alphabet
+	var clens [18]uint8
+	space, codes := 32, 0
+	for _, sym := range codeLengthOrder[hskip:] {
+		v, avail := br.peek(4)
+		p := codeLengthPrefix[v]
+		if uint(p.bits) > avail {
+			br.fill(uint(p.bits))
+		}
+		br.drop(uint(p.bits))
+		clens[sym] = p.value
+		if p.value != 0 {
+			space -= 32 >> p.value
+			codes++
+			if space <= 0 {
+				break
+			}
+		}
+	}
+	if codes != 1 && space != 0 {
+		fail("invalid code length code")
+	}
+	clcode := newHuffman(clens[:])
+
+	// Symbol code lengths, with 16 repeating the previous nonzero length
+	// and 17 repeating zero
+	sym, prev, space := 0, uint8(8), 32768
+	repeat, repeatLen := 0, uint8(0)
+	for sym < alphabetSize && space > 0 {
+		cl := clcode.decode(br)
+		if cl < 16 {
+			repeat = 0
+			if cl != 0 {
+				lengths[sym] = uint8(cl)
+				prev = uint8(cl)
+				space -= 32768 >> cl
+			}
+			sym++
+			continue
+		}
+
+		extra, newLen := uint(2), prev
+		if cl == 17 {
+			extra, newLen = 3, 0
+		}
+		if repeatLen != newLen {
+			repeat, repeatLen = 0, newLen
+		}
+		old := repeat
+		if repeat > 0 {
+			repeat = (repeat - 2) << extra
+		}
+		repeat += br.read(extra) + 3
+		delta := repeat - old
+		if sym+delta > alphabetSize {
+			fail("code length repeat past the alphabet")
+		}
+		for i := range delta {
+			lengths[sym+i] = repeatLen
+		}
+		if repeatLen != 0 {
+			space -= delta << (15 - repeatLen)
+		}
+		sym += delta
+	}
+	if space != 0 {
+		fail("incomplete prefix code")
+	}
+	return newHuffman(lengths)
+}
+
+// readSimpleCodeLengths reads the one to four symbols of a simple prefix
+// code and assigns their code lengths.
+func readSimpleCodeLengths(br *bitReader, lengths []uint8) {
+	n := br.read(2) + 1
+	width := uint(bits.Len(uint(len(lengths) - 1)))
+	var syms [4]int
+	for i := range n {
+		syms[i] = br.read(width)
+		if syms[i] >= len(lengths) {
+			fail("simple prefix code symbol out of range")
+		}
+		for _, s := range syms[:i] {
+			if s == syms[i] {
+				fail("duplicate simple prefix code symbol")
+			}
+		}
+	}
+
+	var ls []uint8
+	switch n {
+	case 1:
+		ls = []uint8{1} // the single symbol is coded with zero bits
+	case 2:
+		ls = []uint8{1, 1}
+	case 3:
+		ls = []uint8{1, 2, 2}
+	case 4:
+		if br.read(1) == 0 {
+			ls = []uint8{2, 2, 2, 2}
+		} else {
+			ls = []uint8{1, 2, 3, 3}
+		}
+	}
+	for i, l := range ls {
+		lengths[syms[i]] = l
+	}
+}
+
+// readContextMap reads a context map of the given size (RFC 7932 section
+// 7.3) and returns it with the number of prefix codes it refers to.
+func readContextMap(br *bitReader, size int) ([]uint8, int) {
+	trees := readVarLenUint8(br) + 1
+	cmap := make([]uint8, size)
+	if trees < 2 {
+		return cmap, trees
+	}
+
+	rleMax := 0
+	if br.read(1) == 1 {
+		rleMax = br.read(4) + 1
+	}
+	code := readPrefixCode(br, trees+rleMax)
+	for i := 0; i < size; {
+		switch v := code.decode(br); {
+		case v == 0:
+			i++
+		case v <= rleMax:
+			i += 1<<v + br.read(uint(v))
+			if i > size {
+				fail("context map run past the end")
+			}
+		default:
+			cmap[i] = uint8(v - rleMax)
+			i++
+		}
+	}
+
+	if br.read(1) == 1 {
+		inverseMoveToFront(cmap)
+	}
+	for _, v := range cmap {
+		if int(v) >= trees {
+			fail("context map refers to a missing prefix code")
+		}
+	}
+	return cmap, trees
+}
+
+// inverseMoveToFront undoes the move-to-front transform of a context map.
+func inverseMoveToFront(v []uint8) {
+	var mtf [256]uint8
+	for i := range mtf {
+		mtf[i] = uint8(i)
+	}
+	for i, index := range v {
+		value := mtf[index]
+		v[i] = value
+		copy(mtf[1:index+1], mtf[:index])
+		mtf[0] = value
+	}
+}
+
+// readVarLenUint8 reads a value from 0 to 255 (RFC 7932 section 9.2).
+func readVarLenUint8(br *bitReader) int {
+	if br.read(1) == 0 {
+		return 0
+	}
+	n := uint(br.read(3))
+	if n == 0 {
+		return 1
+	}
+	return 1<<n + br.read(n)
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// ExportCache provides thread-safe LRU caching for generated export documents
+// (HAR, OpenAPI) served as resources by export ID.
+type ExportCache struct {
+	cache *lru.Cache[string, any]
+}
+
+// NewExportCache creates a new LRU cache with the specified maximum number of documents.
+func NewExportCache(maxItems int) (*ExportCache, error) {
+	c, err := lru.New[string, any](maxItems)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportCache{cache: c}, nil
+}
+
+// Get retrieves a document by export ID.
+// Returns the document and true if found, nil and false otherwise.
+func (c *ExportCache) Get(exportID string) (any, bool) {
+	return c.cache.Get(exportID)
+}
+
+// Put adds or updates a document, evicting the least recently used one when full.
+func (c *ExportCache) Put(exportID string, doc any) {
+	c.cache.Add(exportID, doc)
+}
+
+// Len returns the current number of documents in the cache.
+func (c *ExportCache) Len() int {
+	return c.cache.Len()
+}
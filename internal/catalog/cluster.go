@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -35,7 +36,11 @@ func NewClusterEngine(idx *indexer.Indexer, cfg *config.Config, store *ClusterSt
 // Extract builds endpoint clusters from indexed entries.
 func (c *ClusterEngine) Extract(ctx context.Context, req *types.ExtractRequest) (*types.ExtractResponse, error) {
 	// Ensure index is fresh
-	if err := c.indexer.RefreshIfStale(ctx, req.SessionID); err != nil {
+	sessions, err := c.indexer.ResolveSessions(ctx, req.SessionID, req.SessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.indexer.RefreshSessionsIfStale(ctx, sessions); err != nil {
 		return nil, fmt.Errorf("refreshing index: %w", err)
 	}
 
@@ -47,7 +52,7 @@ func (c *ClusterEngine) Extract(ctx context.Context, req *types.ExtractRequest)
 	}
 
 	// Get candidate doc IDs based on scope
-	candidates := c.applyScopeFilters(req.Scope)
+	candidates := c.applyScopeFilters(sessions, req.Scope)
 
 	// Build clusters from candidates
 	clusterMap := make(map[types.ClusterKey]*clusterBuilder)
@@ -88,6 +93,9 @@ func (c *ClusterEngine) Extract(ctx context.Context, req *types.ExtractRequest)
 			clusterMap[key] = builder
 		}
 		builder.entryIDs = append(builder.entryIDs, meta.EntryID)
+		if len(req.SessionIDs) > 0 && !slices.Contains(builder.sessionIDs, meta.SessionID) {
+			builder.sessionIDs = append(builder.sessionIDs, meta.SessionID)
+		}
 		if meta.RespContentType != "" {
 			builder.contentTypes[meta.RespContentType]++
 		}
@@ -151,6 +159,7 @@ func (c *ClusterEngine) Extract(ctx context.Context, req *types.ExtractRequest)
 			Category:        b.category,
 			Stats:           computeClusterStats(b),
 			ExampleEntryIDs: examples,
+			SessionIDs:      b.sessionIDs,
 		}
 
 		// Compute content type hint: most common response content type
@@ -188,6 +197,7 @@ type clusterBuilder struct {
 	totalRespBytes int64       // sum of all response body sizes
 	hasAuth        bool        // any entry had auth signals
 	category       types.EndpointCategory
+	sessionIDs     []string // sessions seen, tracked for multi-session extraction
 }
 
 // applyOptionsDefaults applies default values to ClusterOptions.
@@ -224,9 +234,9 @@ func applyOptionsDefaults(opts *types.ClusterOptions) *types.ClusterOptions {
 	return result
 }
 
-// applyScopeFilters returns a bitmap of doc IDs matching the scope.
-func (c *ClusterEngine) applyScopeFilters(scope *types.ClusterScope) *roaring.Bitmap {
-	result := c.indexer.AllDocIDs()
+// applyScopeFilters returns a bitmap of the sessions' doc IDs matching the scope.
+func (c *ClusterEngine) applyScopeFilters(sessions []string, scope *types.ClusterScope) *roaring.Bitmap {
+	result := c.indexer.SessionDocIDs(sessions)
 
 	if scope == nil {
 		return result
@@ -331,6 +341,22 @@ func computeClusterID(key types.ClusterKey) string {
 	return hex.EncodeToString(hash[:])[:12]
 }
 
+// ClusterID returns the cluster ID extract_endpoints assigns, with default
+// options, to an entry with the given host, method, and path.
+func ClusterID(host, method, path string) string {
+	return computeClusterID(types.ClusterKey{
+		Host:         host,
+		Method:       method,
+		PathTemplate: PathTemplate(path),
+	})
+}
+
+// PathTemplate returns the path template extract_endpoints derives, with
+// default options, from a request path (e.g. "/users/{id}").
+func PathTemplate(path string) string {
+	return buildPathTemplate(path, true)
+}
+
 // applyPostClusterFilters removes clusters that don't match post-clustering filters.
 func applyPostClusterFilters(builders []*clusterBuilder, filters *types.ClusterFilters) []*clusterBuilder {
 	if filters == nil {
@@ -363,7 +389,7 @@ func computeClusterStats(b *clusterBuilder) types.ClusterStats {
 	var nonSuccess int
 	total := len(b.entryIDs)
 	for code, count := range b.statusCounts {
-		bucket := statusBucket(code)
+		bucket := StatusBucket(code)
 		stats.StatusProfile[bucket] += count
 		if code < 200 || code >= 300 {
 			nonSuccess += count
@@ -378,8 +404,8 @@ func computeClusterStats(b *clusterBuilder) types.ClusterStats {
 	return stats
 }
 
-// statusBucket maps an HTTP status code to its class bucket.
-func statusBucket(code int) string {
+// StatusBucket maps an HTTP status code to its class bucket.
+func StatusBucket(code int) string {
 	switch {
 	case code >= 100 && code < 200:
 		return "1xx"
@@ -326,7 +326,7 @@ func TestStatusBucket(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("status_%d", tt.code), func(t *testing.T) {
-			assert.Equal(t, tt.expected, statusBucket(tt.code))
+			assert.Equal(t, tt.expected, StatusBucket(tt.code))
 		})
 	}
 }
@@ -2,7 +2,6 @@ package catalog
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"net/url"
 	"sort"
@@ -19,7 +18,7 @@ import (
 // DescribeEngine generates detailed endpoint descriptions.
 type DescribeEngine struct {
 	indexer      *indexer.Indexer
-	client       *client.Client
+	client       client.DataSource
 	cache        *cache.EntryCache
 	config       *config.Config
 	store        *ClusterStore
@@ -27,7 +26,7 @@ type DescribeEngine struct {
 }
 
 // NewDescribeEngine creates a new DescribeEngine.
-func NewDescribeEngine(idx *indexer.Indexer, c *client.Client, cache *cache.EntryCache, cfg *config.Config, store *ClusterStore) *DescribeEngine {
+func NewDescribeEngine(idx *indexer.Indexer, c client.DataSource, cache *cache.EntryCache, cfg *config.Config, store *ClusterStore) *DescribeEngine {
 	return &DescribeEngine{
 		indexer:      idx,
 		client:       c,
@@ -121,8 +120,13 @@ func (d *DescribeEngine) fetchEntries(ctx context.Context, sessionID string, ent
 			}
 		}
 
-		// Fetch from API
-		entry, err := d.client.GetEntry(ctx, sessionID, entryID)
+		// Fetch from API, from the entry's own session when it is indexed
+		// (clusters extracted across sessions mix entries of several)
+		fetchSession := sessionID
+		if meta := d.indexer.GetMetaByEntryID(entryID); meta != nil && meta.SessionID != "" {
+			fetchSession = meta.SessionID
+		}
+		entry, err := d.client.GetEntry(ctx, fetchSession, entryID)
 		if err != nil {
 			// Skip entries that can't be fetched
 			continue
@@ -333,7 +337,7 @@ func (d *DescribeEngine) extractBodyShape(entries []*client.SessionEntry, target
 		}
 
 		// Decode body
-		bodyBytes, err := base64.StdEncoding.DecodeString(*bodyPtr)
+		bodyBytes, err := client.DecodeContent(bodyPtr, client.ContentEncoding(headers))
 		if err != nil {
 			continue
 		}
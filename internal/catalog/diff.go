@@ -0,0 +1,386 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/internal/cache"
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/internal/entryfetch"
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/contenttype"
+	"github.com/usestring/powhttp-mcp/pkg/jsonschema"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	defaultDiffSamples = 10
+	maxDiffSamples     = 50
+	maxDiffClusters    = 2000
+
+	// statusShiftThreshold is the change in a status class's share of an
+	// endpoint's traffic that counts as a status profile shift.
+	statusShiftThreshold = 0.2
+)
+
+// CatalogDiffEngine compares the endpoint catalogs of two captures.
+type CatalogDiffEngine struct {
+	indexer *indexer.Indexer
+	client  client.DataSource
+	cache   *cache.EntryCache
+	config  *config.Config
+}
+
+// NewCatalogDiffEngine creates a new CatalogDiffEngine.
+func NewCatalogDiffEngine(idx *indexer.Indexer, c client.DataSource, cache *cache.EntryCache, cfg *config.Config) *CatalogDiffEngine {
+	return &CatalogDiffEngine{
+		indexer: idx,
+		client:  c,
+		cache:   cache,
+		config:  cfg,
+	}
+}
+
+// sideCatalog is the extracted endpoint catalog of one side of a diff.
+type sideCatalog struct {
+	sessionID string
+	clusters  map[string]types.Cluster // keyed by cluster_id
+	entryIDs  map[string][]string      // cluster_id -> all entry IDs
+}
+
+// Diff extracts the endpoint catalog of both sides and reports endpoints
+// that were added or removed, and per-endpoint changes to query keys, auth
+// signals, status profile, and request/response body schemas.
+func (e *CatalogDiffEngine) Diff(ctx context.Context, req *types.CatalogDiffRequest) (*types.CatalogDiff, error) {
+	samples := req.Samples
+	if samples <= 0 {
+		samples = defaultDiffSamples
+	}
+	samples = min(samples, maxDiffSamples)
+
+	before, err := e.extract(ctx, req.Before, req.Scope, req.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("extracting before: %w", err)
+	}
+	after, err := e.extract(ctx, req.After, req.Scope, req.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("extracting after: %w", err)
+	}
+
+	diff := &types.CatalogDiff{
+		BeforeEndpoints: len(before.clusters),
+		AfterEndpoints:  len(after.clusters),
+	}
+	for _, id := range sortedClusterIDs(after.clusters) {
+		if _, ok := before.clusters[id]; !ok {
+			diff.Added = append(diff.Added, after.clusters[id])
+		}
+	}
+	for _, id := range sortedClusterIDs(before.clusters) {
+		if _, ok := after.clusters[id]; !ok {
+			diff.Removed = append(diff.Removed, before.clusters[id])
+			continue
+		}
+		change, err := e.compareEndpoint(ctx, before, after, id, samples)
+		if err != nil {
+			return nil, err
+		}
+		if change == nil {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, *change)
+	}
+
+	return diff, nil
+}
+
+// extract clusters one side into a private store, so the diff neither
+// depends on nor replaces the clusters of earlier extract_endpoints calls.
+func (e *CatalogDiffEngine) extract(ctx context.Context, side types.CatalogSide, scope *types.ClusterScope, filters *types.ClusterFilters) (*sideCatalog, error) {
+	var sideScope types.ClusterScope
+	if scope != nil {
+		sideScope = *scope
+	}
+	sideScope.TimeWindowMs = 0
+	sideScope.SinceMs = side.SinceMs
+	sideScope.UntilMs = side.UntilMs
+
+	store := NewClusterStore()
+	resp, err := NewClusterEngine(e.indexer, e.config, store).Extract(ctx, &types.ExtractRequest{
+		SessionID: side.SessionID,
+		Scope:     &sideScope,
+		Filters:   filters,
+		Options: &types.ClusterOptions{
+			NormalizeIDs:           true,
+			StripVolatileQueryKeys: true,
+			MaxClusters:            maxDiffClusters,
+		},
+		Limit: maxDiffClusters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &sideCatalog{
+		sessionID: side.SessionID,
+		clusters:  make(map[string]types.Cluster, len(resp.Clusters)),
+		entryIDs:  make(map[string][]string, len(resp.Clusters)),
+	}
+	for _, cluster := range resp.Clusters {
+		c.clusters[cluster.ID] = cluster
+		if stored, ok := store.GetCluster(cluster.ID); ok {
+			c.entryIDs[cluster.ID] = stored.EntryIDs
+		}
+	}
+	return c, nil
+}
+
+// compareEndpoint returns the changes to an endpoint present on both sides,
+// or nil if none were detected.
+func (e *CatalogDiffEngine) compareEndpoint(ctx context.Context, before, after *sideCatalog, clusterID string, samples int) (*types.EndpointChange, error) {
+	cb, ca := before.clusters[clusterID], after.clusters[clusterID]
+	metasBefore := e.metas(before.entryIDs[clusterID])
+	metasAfter := e.metas(after.entryIDs[clusterID])
+
+	change := &types.EndpointChange{
+		ClusterID:    clusterID,
+		Host:         ca.Host,
+		Method:       ca.Method,
+		PathTemplate: ca.PathTemplate,
+		BeforeCount:  cb.Count,
+		AfterCount:   ca.Count,
+	}
+	changed := false
+
+	change.QueryKeysAdded, change.QueryKeysRemoved = setDiff(queryKeySet(metasBefore), queryKeySet(metasAfter))
+	changed = len(change.QueryKeysAdded) > 0 || len(change.QueryKeysRemoved) > 0
+
+	authBefore, authAfter := metaAuthSignals(metasBefore), metaAuthSignals(metasAfter)
+	if !authSignalsEqual(authBefore, authAfter) {
+		change.Auth = &types.AuthChange{Before: authBefore, After: authAfter}
+		changed = true
+	}
+
+	if statusShifted(cb.Stats.StatusProfile, ca.Stats.StatusProfile) {
+		change.Status = &types.StatusChange{
+			Before:          cb.Stats.StatusProfile,
+			After:           ca.Stats.StatusProfile,
+			BeforeErrorRate: cb.Stats.ErrorRate,
+			AfterErrorRate:  ca.Stats.ErrorRate,
+		}
+		changed = true
+	}
+
+	reqBefore, respBefore, err := e.sampleSchemas(ctx, before.sessionID, before.entryIDs[clusterID], samples)
+	if err != nil {
+		return nil, err
+	}
+	reqAfter, respAfter, err := e.sampleSchemas(ctx, after.sessionID, after.entryIDs[clusterID], samples)
+	if err != nil {
+		return nil, err
+	}
+	change.RequestSchema = schemaChanges(reqBefore, reqAfter)
+	change.ResponseSchema = schemaChanges(respBefore, respAfter)
+	changed = changed || len(change.RequestSchema) > 0 || len(change.ResponseSchema) > 0
+
+	if !changed {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// metas returns the index metadata of the given entries.
+func (e *CatalogDiffEngine) metas(entryIDs []string) []*indexer.EntryMeta {
+	metas := make([]*indexer.EntryMeta, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		if meta := e.indexer.GetMetaByEntryID(id); meta != nil {
+			metas = append(metas, meta)
+		}
+	}
+	return metas
+}
+
+// sampleSchemas infers the request and response body schemas of up to n
+// entries spread across entryIDs. Non-JSON and unfetchable bodies are
+// skipped; a nil schema means no JSON body was sampled.
+func (e *CatalogDiffEngine) sampleSchemas(ctx context.Context, sessionID string, entryIDs []string, n int) (req, resp *jsonschema.InferredSchema, err error) {
+	var reqBodies, respBodies [][]byte
+	for _, id := range selectExamples(entryIDs, n) {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		fetchSession := sessionID
+		if meta := e.indexer.GetMetaByEntryID(id); meta != nil && meta.SessionID != "" {
+			fetchSession = meta.SessionID
+		}
+		entry, err := entryfetch.FetchEntry(ctx, e.client, e.cache, fetchSession, id)
+		if err != nil {
+			continue
+		}
+		if body := jsonBody(entry, "request"); body != nil {
+			reqBodies = append(reqBodies, body)
+		}
+		if body := jsonBody(entry, "response"); body != nil {
+			respBodies = append(respBodies, body)
+		}
+	}
+
+	if req, err = jsonschema.Infer(reqBodies...); err != nil {
+		return nil, nil, fmt.Errorf("inferring request schema: %w", err)
+	}
+	if resp, err = jsonschema.Infer(respBodies...); err != nil {
+		return nil, nil, fmt.Errorf("inferring response schema: %w", err)
+	}
+	return req, resp, nil
+}
+
+// jsonBody returns the decoded body of the target if it is JSON, else nil.
+func jsonBody(entry *client.SessionEntry, target string) []byte {
+	body, ct, err := entryfetch.DecodeBody(entry, target)
+	if err != nil || len(body) == 0 || !contenttype.IsJSON(ct) {
+		return nil
+	}
+	return body
+}
+
+// schemaChanges diffs two inferred body schemas. A body sampled on only one
+// side is reported as a whole-body addition or removal.
+func schemaChanges(before, after *jsonschema.InferredSchema) []types.SchemaChange {
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		return []types.SchemaChange{{Change: jsonschema.ChangeAdded, After: after.Schema.Type}}
+	case after == nil:
+		return []types.SchemaChange{{Change: jsonschema.ChangeRemoved, Before: before.Schema.Type}}
+	}
+
+	fields := jsonschema.Diff(before.Schema, after.Schema)
+	changes := make([]types.SchemaChange, 0, len(fields))
+	for _, f := range fields {
+		changes = append(changes, types.SchemaChange{
+			Path:   f.Path,
+			Change: f.Change,
+			Before: f.Before,
+			After:  f.After,
+		})
+	}
+	return changes
+}
+
+// queryKeySet returns the query parameter names seen across entries.
+func queryKeySet(metas []*indexer.EntryMeta) map[string]bool {
+	keys := make(map[string]bool)
+	for _, m := range metas {
+		parsed, err := url.Parse(m.URL)
+		if err != nil {
+			continue
+		}
+		for key := range parsed.Query() {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// setDiff returns the sorted keys only in after (added) and only in before
+// (removed).
+func setDiff(before, after map[string]bool) (added, removed []string) {
+	for k := range after {
+		if !before[k] {
+			added = append(added, k)
+		}
+	}
+	for k := range before {
+		if !after[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// metaAuthSignals detects the same auth signals as detectAuthSignals from
+// index metadata, so every entry of the cluster is considered.
+func metaAuthSignals(metas []*indexer.EntryMeta) types.AuthSignals {
+	signals := types.AuthSignals{CustomAuthHeaders: make([]string, 0)}
+	for _, m := range metas {
+		if slices.Contains(m.HeaderNamesLower, "cookie") {
+			signals.CookiesPresent = true
+		}
+		if strings.HasPrefix(strings.ToLower(m.AuthHeader), "bearer ") {
+			signals.BearerPresent = true
+		}
+		for name := range m.APIKeys {
+			if !slices.Contains(signals.CustomAuthHeaders, name) {
+				signals.CustomAuthHeaders = append(signals.CustomAuthHeaders, name)
+			}
+		}
+	}
+	sort.Strings(signals.CustomAuthHeaders)
+	return signals
+}
+
+// authSignalsEqual reports whether two sets of auth signals match.
+func authSignalsEqual(a, b types.AuthSignals) bool {
+	return a.CookiesPresent == b.CookiesPresent &&
+		a.BearerPresent == b.BearerPresent &&
+		slices.Equal(a.CustomAuthHeaders, b.CustomAuthHeaders)
+}
+
+// statusShifted reports whether the share of any status class differs by at
+// least statusShiftThreshold between two status profiles.
+func statusShifted(before, after map[string]int) bool {
+	total := func(p map[string]int) int {
+		n := 0
+		for _, c := range p {
+			n += c
+		}
+		return n
+	}
+	tb, ta := total(before), total(after)
+	if tb == 0 || ta == 0 {
+		return false
+	}
+
+	classes := make(map[string]bool)
+	for k := range before {
+		classes[k] = true
+	}
+	for k := range after {
+		classes[k] = true
+	}
+	for class := range classes {
+		shareBefore := float64(before[class]) / float64(tb)
+		shareAfter := float64(after[class]) / float64(ta)
+		if math.Abs(shareAfter-shareBefore) >= statusShiftThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedClusterIDs returns the cluster IDs ordered by request count
+// descending, then by ID.
+func sortedClusterIDs(clusters map[string]types.Cluster) []string {
+	ids := make([]string, 0, len(clusters))
+	for id := range clusters {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ci, cj := clusters[ids[i]], clusters[ids[j]]
+		if ci.Count != cj.Count {
+			return ci.Count > cj.Count
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
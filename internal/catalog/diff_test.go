@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/jsonschema"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestStatusShifted(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after map[string]int
+		want          bool
+	}{
+		{"same profile", map[string]int{"2xx": 10}, map[string]int{"2xx": 40}, false},
+		{"small drift", map[string]int{"2xx": 9, "4xx": 1}, map[string]int{"2xx": 8, "4xx": 2}, false},
+		{"errors appear", map[string]int{"2xx": 10}, map[string]int{"2xx": 5, "4xx": 5}, true},
+		{"moved to redirects", map[string]int{"2xx": 4}, map[string]int{"3xx": 4}, true},
+		{"no statuses on one side", map[string]int{}, map[string]int{"5xx": 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, statusShifted(tt.before, tt.after))
+		})
+	}
+}
+
+func TestSetDiff(t *testing.T) {
+	added, removed := setDiff(
+		map[string]bool{"page": true, "q": true, "legacy": true},
+		map[string]bool{"page": true, "q": true, "cursor": true, "fields": true},
+	)
+	assert.Equal(t, []string{"cursor", "fields"}, added)
+	assert.Equal(t, []string{"legacy"}, removed)
+
+	added, removed = setDiff(map[string]bool{"a": true}, map[string]bool{"a": true})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
+
+func TestQueryKeySet(t *testing.T) {
+	keys := queryKeySet([]*indexer.EntryMeta{
+		{URL: "https://api.example.com/items?page=1&q=x"},
+		{URL: "https://api.example.com/items?cursor=abc"},
+		{URL: "https://api.example.com/items"},
+	})
+	assert.Equal(t, map[string]bool{"page": true, "q": true, "cursor": true}, keys)
+}
+
+func TestMetaAuthSignals(t *testing.T) {
+	signals := metaAuthSignals([]*indexer.EntryMeta{
+		{HeaderNamesLower: []string{"accept"}, AuthHeader: "Bearer abc"},
+		{HeaderNamesLower: []string{"cookie"}, APIKeys: map[string]string{"x-auth-token": "t"}},
+		{APIKeys: map[string]string{"x-api-key": "k", "x-auth-token": "u"}},
+	})
+	assert.True(t, signals.BearerPresent)
+	assert.True(t, signals.CookiesPresent)
+	assert.Equal(t, []string{"x-api-key", "x-auth-token"}, signals.CustomAuthHeaders)
+
+	none := metaAuthSignals([]*indexer.EntryMeta{{AuthHeader: "Basic dXNlcjpwYXNz"}})
+	assert.False(t, none.BearerPresent)
+	assert.True(t, authSignalsEqual(none, metaAuthSignals(nil)))
+	assert.False(t, authSignalsEqual(none, signals))
+}
+
+func TestSchemaChanges(t *testing.T) {
+	before, err := jsonschema.Infer([]byte(`{"id": 1, "name": "a"}`))
+	require.NoError(t, err)
+	after, err := jsonschema.Infer([]byte(`{"id": "1", "name": "a", "email": "a@b.c"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []types.SchemaChange{
+		{Path: "email", Change: jsonschema.ChangeAdded, After: "string"},
+		{Path: "id", Change: jsonschema.ChangeType, Before: "integer", After: "string"},
+	}, schemaChanges(before, after))
+
+	// A body sampled on one side only is a whole-body change
+	assert.Equal(t, []types.SchemaChange{{Change: jsonschema.ChangeAdded, After: "object"}}, schemaChanges(nil, after))
+	assert.Equal(t, []types.SchemaChange{{Change: jsonschema.ChangeRemoved, Before: "object"}}, schemaChanges(before, nil))
+	assert.Nil(t, schemaChanges(nil, nil))
+}
@@ -0,0 +1,199 @@
+// Package clientdb holds reference fingerprints of known clients and
+// identifies which one a captured request most resembles.
+package clientdb
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// builtinFS holds the bundled profiles, one JSON array per file.
+//
+//go:embed data/*.json
+var builtinFS embed.FS
+
+// ErrInvalidProfile is returned by Add for profiles that cannot be stored.
+var ErrInvalidProfile = errors.New("invalid client profile")
+
+// validID matches profile IDs: lowercase letters, digits, '.', '_', and '-'.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// DB holds the built-in profiles and those added by the user. User profiles
+// are persisted to a JSON file when one is configured.
+type DB struct {
+	mu       sync.RWMutex
+	builtin  []types.ClientProfile
+	user     []types.ClientProfile
+	userFile string // "" keeps user profiles in memory only
+}
+
+// New loads the built-in profiles and, if userFile is set and exists, the
+// user profiles saved there. On error the DB still holds the built-in
+// profiles.
+func New(userFile string) (*DB, error) {
+	db := &DB{userFile: userFile}
+
+	builtin, err := loadBuiltin()
+	if err != nil {
+		return db, err
+	}
+	db.builtin = builtin
+
+	if userFile == "" {
+		return db, nil
+	}
+	data, err := os.ReadFile(userFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return db, fmt.Errorf("reading client profiles: %w", err)
+	}
+	var user []types.ClientProfile
+	if err := json.Unmarshal(data, &user); err != nil {
+		return db, fmt.Errorf("decoding client profiles %s: %w", userFile, err)
+	}
+	for i := range user {
+		user[i].Source = types.ClientProfileUser
+	}
+	db.user = user
+	return db, nil
+}
+
+// loadBuiltin decodes the embedded profiles.
+func loadBuiltin() ([]types.ClientProfile, error) {
+	files, err := fs.Glob(builtinFS, "data/*.json")
+	if err != nil {
+		return nil, err
+	}
+	var profiles []types.ClientProfile
+	for _, name := range files {
+		data, err := builtinFS.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var batch []types.ClientProfile
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", name, err)
+		}
+		for i := range batch {
+			batch[i].Source = types.ClientProfileBuiltin
+		}
+		profiles = append(profiles, batch...)
+	}
+	return profiles, nil
+}
+
+// Profiles returns all profiles, built-in first.
+func (db *DB) Profiles() []types.ClientProfile {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	out := make([]types.ClientProfile, 0, len(db.builtin)+len(db.user))
+	out = append(out, db.builtin...)
+	return append(out, db.user...)
+}
+
+// Persistent reports whether added profiles are saved to disk.
+func (db *DB) Persistent() bool {
+	return db.userFile != ""
+}
+
+// Add stores a user profile, replacing the user profile with the same ID.
+// Built-in IDs cannot be reused. When a user file is configured, all user
+// profiles are written to it before the change takes effect.
+func (db *DB) Add(p types.ClientProfile) (types.ClientProfile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return p, fmt.Errorf("%w: name is required", ErrInvalidProfile)
+	}
+	if p.ID == "" {
+		p.ID = slug(p.Name)
+	}
+	if !validID.MatchString(p.ID) {
+		return p, fmt.Errorf("%w: id %q must be lowercase letters, digits, '.', '_', or '-'", ErrInvalidProfile, p.ID)
+	}
+	if len(p.JA3)+len(p.JA4)+len(p.Akamai)+len(p.HeaderOrders)+len(p.UserAgent) == 0 {
+		return p, fmt.Errorf("%w: no signals to match on", ErrInvalidProfile)
+	}
+	if p.Family == "" {
+		p.Family = "unknown"
+	}
+	p.Source = types.ClientProfileUser
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, b := range db.builtin {
+		if b.ID == p.ID {
+			return p, fmt.Errorf("%w: id %q is a built-in profile", ErrInvalidProfile, p.ID)
+		}
+	}
+
+	user := make([]types.ClientProfile, 0, len(db.user)+1)
+	for _, existing := range db.user {
+		if existing.ID != p.ID {
+			user = append(user, existing)
+		}
+	}
+	user = append(user, p)
+
+	if db.userFile != "" {
+		if err := writeProfiles(db.userFile, user); err != nil {
+			return p, fmt.Errorf("saving client profiles: %w", err)
+		}
+	}
+	db.user = user
+	return p, nil
+}
+
+// writeProfiles writes profiles to path atomically (temp file + rename).
+func writeProfiles(path string, profiles []types.ClientProfile) error {
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "client-profiles-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// slug derives a profile ID from a name: "My Scraper 2" -> "my-scraper-2".
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
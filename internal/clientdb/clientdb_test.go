@@ -0,0 +1,103 @@
+package clientdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestNew_Builtin(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+
+	profiles := db.Profiles()
+	require.NotEmpty(t, profiles)
+	ids := make(map[string]bool)
+	for _, p := range profiles {
+		assert.False(t, ids[p.ID], "duplicate profile %s", p.ID)
+		ids[p.ID] = true
+		assert.Regexp(t, validID, p.ID)
+		assert.NotEmpty(t, p.Name, p.ID)
+		assert.Contains(t, []string{"browser", "library", "tool"}, p.Family, p.ID)
+		assert.Equal(t, types.ClientProfileBuiltin, p.Source)
+		assert.NotEmpty(t, len(p.JA3)+len(p.JA4)+len(p.Akamai)+len(p.HeaderOrders)+len(p.UserAgent), p.ID)
+	}
+	for _, id := range []string{"chrome", "firefox", "safari", "go-net-http", "python-requests", "curl", "okhttp"} {
+		assert.True(t, ids[id], id)
+	}
+	assert.False(t, db.Persistent())
+}
+
+func TestAdd_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles", "clients.json")
+	db, err := New(path)
+	require.NoError(t, err)
+	require.True(t, db.Persistent())
+
+	saved, err := db.Add(types.ClientProfile{Name: "My Scraper 2", Akamai: []string{"2:0|1|0|m,a,s,p"}})
+	require.NoError(t, err)
+	assert.Equal(t, "my-scraper-2", saved.ID)
+	assert.Equal(t, "unknown", saved.Family)
+	assert.Equal(t, types.ClientProfileUser, saved.Source)
+
+	// Saving the same ID replaces the profile
+	_, err = db.Add(types.ClientProfile{ID: "my-scraper-2", Name: "My Scraper 2", Family: "app", UserAgent: []string{"scraper/2"}})
+	require.NoError(t, err)
+
+	reloaded, err := New(path)
+	require.NoError(t, err)
+	var user []types.ClientProfile
+	for _, p := range reloaded.Profiles() {
+		if p.Source == types.ClientProfileUser {
+			user = append(user, p)
+		}
+	}
+	require.Len(t, user, 1)
+	assert.Equal(t, "app", user[0].Family)
+	assert.Equal(t, []string{"scraper/2"}, user[0].UserAgent)
+	assert.Empty(t, user[0].Akamai)
+}
+
+func TestAdd_Invalid(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+	signals := types.ClientProfile{UserAgent: []string{"x"}}
+
+	tests := []struct {
+		name    string
+		profile types.ClientProfile
+	}{
+		{"no name", signals},
+		{"bad id", types.ClientProfile{ID: "Bad ID", Name: "x", UserAgent: []string{"x"}}},
+		{"builtin id", types.ClientProfile{ID: "chrome", Name: "x", UserAgent: []string{"x"}}},
+		{"no signals", types.ClientProfile{Name: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Add(tt.profile)
+			assert.ErrorIs(t, err, ErrInvalidProfile)
+		})
+	}
+	assert.Len(t, db.Profiles(), len(db.builtin))
+}
+
+func TestNew_BadUserFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clients.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	db, err := New(path)
+	assert.Error(t, err)
+	require.NotNil(t, db)
+	assert.NotEmpty(t, db.Profiles(), "built-in profiles survive a bad user file")
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "my-scraper-2", slug("My Scraper 2"))
+	assert.Equal(t, "okhttp-4.12", slug("  OkHttp 4.12!"))
+	assert.Equal(t, "", slug("!!!"))
+}
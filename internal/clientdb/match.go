@@ -0,0 +1,268 @@
+package clientdb
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/internal/search"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// Signal names used in ClientMatch.
+const (
+	SignalJA3         = "ja3"
+	SignalJA4         = "ja4"
+	SignalAkamai      = "akamai"
+	SignalHeaderOrder = "header_order"
+	SignalUserAgent   = "user_agent"
+)
+
+// signalWeights weighs each signal by how hard it is to fake: the TLS and
+// HTTP/2 stacks over the headers a client chooses to send.
+var signalWeights = map[string]float64{
+	SignalJA4:         0.35,
+	SignalAkamai:      0.25,
+	SignalJA3:         0.15,
+	SignalHeaderOrder: 0.15,
+	SignalUserAgent:   0.10,
+}
+
+// minComparedWeight is the signal weight below which a score is scaled
+// down, so that a profile matching only on weak signals does not score 1.
+const minComparedWeight = 0.5
+
+// Signals extracts the signals of a fingerprinted request.
+func Signals(fp *types.Fingerprint) types.ClientSignals {
+	var s types.ClientSignals
+	if fp.Entry != nil {
+		s.JA3 = fp.Entry.TLS.JA3
+		s.JA4 = fp.Entry.TLS.JA4
+	}
+	if tls := fp.TLSSummary; tls != nil {
+		if tls.JA3 != "" {
+			s.JA3 = tls.JA3
+		}
+		if tls.JA4 != "" {
+			s.JA4 = tls.JA4
+		}
+	}
+	if fp.HTTP2Summary != nil {
+		s.Akamai = fp.HTTP2Summary.Akamai
+	}
+
+	seen := make(map[string]bool)
+	for _, pair := range fp.HeadersOrdered {
+		if len(pair) < 2 || strings.HasPrefix(pair[0], ":") {
+			continue
+		}
+		name := strings.ToLower(pair[0])
+		if name == "user-agent" && s.UserAgent == "" {
+			s.UserAgent = pair[1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			s.HeaderOrder = append(s.HeaderOrder, name)
+		}
+	}
+	return s
+}
+
+// ProfileFromSignals builds a profile that matches exactly the given signals.
+func ProfileFromSignals(s types.ClientSignals) types.ClientProfile {
+	var p types.ClientProfile
+	if s.JA3 != "" {
+		p.JA3 = []string{s.JA3}
+	}
+	if s.JA4 != "" {
+		p.JA4 = []string{s.JA4}
+	}
+	if s.Akamai != "" {
+		p.Akamai = []string{s.Akamai}
+	}
+	if len(s.HeaderOrder) > 0 {
+		p.HeaderOrders = [][]string{s.HeaderOrder}
+	}
+	if s.UserAgent != "" {
+		p.UserAgent = []string{s.UserAgent}
+	}
+	return p
+}
+
+// Identify scores the request's signals against every profile and returns
+// the matches scoring at least minScore, best first, at most limit.
+func (db *DB) Identify(s types.ClientSignals, minScore float64, limit int) []types.ClientMatch {
+	profiles := db.Profiles()
+	vocabulary := headerVocabulary(profiles)
+
+	var matches []types.ClientMatch
+	for _, p := range profiles {
+		m, ok := score(p, s, vocabulary)
+		if ok && m.Score >= minScore {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// headerVocabulary is the set of header names any profile orders. Other
+// headers are specific to the application, not the client, and are ignored
+// when comparing header order.
+func headerVocabulary(profiles []types.ClientProfile) map[string]bool {
+	vocabulary := make(map[string]bool)
+	for _, p := range profiles {
+		for _, order := range p.HeaderOrders {
+			for _, name := range order {
+				vocabulary[strings.ToLower(name)] = true
+			}
+		}
+	}
+	return vocabulary
+}
+
+// score compares a profile with the signals both sides have. It reports
+// false when no signal could be compared.
+func score(p types.ClientProfile, s types.ClientSignals, vocabulary map[string]bool) (types.ClientMatch, bool) {
+	m := types.ClientMatch{
+		ProfileID: p.ID,
+		Name:      p.Name,
+		Family:    p.Family,
+		Platform:  p.Platform,
+		Source:    p.Source,
+	}
+
+	var total, compared float64
+	add := func(signal string, similarity float64) {
+		w := signalWeights[signal]
+		total += w * similarity
+		compared += w
+		switch {
+		case similarity >= 1:
+			m.Matched = append(m.Matched, signal)
+		case similarity > 0:
+			m.Partial = append(m.Partial, signal)
+		default:
+			m.Mismatched = append(m.Mismatched, signal)
+		}
+	}
+
+	if s.JA4 != "" && len(p.JA4) > 0 {
+		add(SignalJA4, bestOf(p.JA4, func(pattern string) float64 { return sectionSimilarity(pattern, s.JA4, "_") }))
+	}
+	if s.Akamai != "" && len(p.Akamai) > 0 {
+		add(SignalAkamai, bestOf(p.Akamai, func(pattern string) float64 { return sectionSimilarity(pattern, s.Akamai, "|") }))
+	}
+	if s.JA3 != "" && len(p.JA3) > 0 {
+		add(SignalJA3, bestOf(p.JA3, func(pattern string) float64 { return exact(pattern, s.JA3) }))
+	}
+	if observed := filterNames(s.HeaderOrder, vocabulary); len(observed) > 0 && len(p.HeaderOrders) > 0 {
+		best := 0.0
+		for _, order := range p.HeaderOrders {
+			best = math.Max(best, orderSimilarity(order, observed))
+		}
+		add(SignalHeaderOrder, best)
+	}
+	if s.UserAgent != "" && len(p.UserAgent) > 0 {
+		add(SignalUserAgent, bestOf(p.UserAgent, func(pattern string) float64 { return exact(pattern, s.UserAgent) }))
+	}
+
+	if compared == 0 {
+		return m, false
+	}
+	m.Score = math.Round(total/math.Max(compared, minComparedWeight)*1000) / 1000
+	return m, true
+}
+
+// bestOf returns the highest similarity of any pattern.
+func bestOf(patterns []string, similarity func(pattern string) float64) float64 {
+	best := 0.0
+	for _, pattern := range patterns {
+		best = math.Max(best, similarity(pattern))
+	}
+	return best
+}
+
+// exact returns 1 if value matches the case-insensitive glob pattern, else 0.
+func exact(pattern, value string) float64 {
+	if search.GlobMatch(strings.ToLower(pattern), strings.ToLower(value)) {
+		return 1
+	}
+	return 0
+}
+
+// sectionSimilarity compares fingerprints made of sep-separated sections,
+// such as JA4's three parts or the Akamai fingerprint's four: the share of
+// sections that match, or 1 if the whole value matches. Sections the pattern
+// leaves as "*" say nothing about the client and are not counted.
+func sectionSimilarity(pattern, value, sep string) float64 {
+	if exact(pattern, value) == 1 {
+		return 1
+	}
+	patterns := strings.Split(pattern, sep)
+	values := strings.Split(value, sep)
+	if len(patterns) != len(values) {
+		return 0
+	}
+	matched, counted := 0, 0
+	for i := range patterns {
+		if patterns[i] == "*" {
+			continue
+		}
+		counted++
+		if exact(patterns[i], values[i]) == 1 {
+			matched++
+		}
+	}
+	if counted == 0 {
+		return 0
+	}
+	return float64(matched) / float64(counted)
+}
+
+// filterNames keeps the names in vocabulary, in order.
+func filterNames(names []string, vocabulary map[string]bool) []string {
+	var out []string
+	for _, name := range names {
+		if vocabulary[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// orderSimilarity is the length of the longest common subsequence of two
+// header orders over the longer of the two, so missing, extra, and
+// reordered headers all lower it.
+func orderSimilarity(expected, observed []string) float64 {
+	n := max(len(expected), len(observed))
+	if n == 0 {
+		return 0
+	}
+	return float64(lcs(expected, observed)) / float64(n)
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case strings.EqualFold(a[i], b[j]):
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,121 @@
+package clientdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func chromeSignals() types.ClientSignals {
+	return types.ClientSignals{
+		JA4:    "t13d1516h2_8daaf6152771_02713d6af862",
+		Akamai: "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p",
+		HeaderOrder: []string{"sec-ch-ua", "sec-ch-ua-mobile", "sec-ch-ua-platform", "upgrade-insecure-requests",
+			"user-agent", "accept", "sec-fetch-site", "sec-fetch-mode", "sec-fetch-user", "sec-fetch-dest",
+			"accept-encoding", "accept-language", "cookie", "priority"},
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	}
+}
+
+func TestIdentify_Browser(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+
+	matches := db.Identify(chromeSignals(), 0.3, 3)
+	require.NotEmpty(t, matches)
+	best := matches[0]
+	assert.Equal(t, "chrome", best.ProfileID)
+	assert.Equal(t, 1.0, best.Score)
+	assert.ElementsMatch(t, []string{SignalJA4, SignalAkamai, SignalHeaderOrder, SignalUserAgent}, best.Matched)
+}
+
+func TestIdentify_Library(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+
+	// Go's HTTP/2 stack behind a browser User-Agent
+	signals := types.ClientSignals{
+		JA4:         "t13d1311h2_f57a46bbacb6_ab7e3b40a677",
+		Akamai:      "2:0;4:4194304;6:10485760|1073741824|0|a,m,p,s",
+		HeaderOrder: []string{"user-agent", "accept-encoding"},
+		UserAgent:   "Mozilla/5.0 Chrome/131.0.0.0 Safari/537.36",
+	}
+	matches := db.Identify(signals, 0, 0)
+	require.NotEmpty(t, matches)
+	best := matches[0]
+	assert.Equal(t, "go-net-http", best.ProfileID)
+	assert.Contains(t, best.Matched, SignalAkamai)
+	assert.Contains(t, best.Mismatched, SignalUserAgent)
+
+	for _, m := range matches {
+		if m.ProfileID == "chrome" {
+			assert.Less(t, m.Score, best.Score)
+			assert.Contains(t, m.Mismatched, SignalJA4)
+		}
+	}
+}
+
+func TestIdentify_UserProfile(t *testing.T) {
+	db, err := New("")
+	require.NoError(t, err)
+
+	signals := types.ClientSignals{JA3: "abc", HeaderOrder: []string{"x-app-version", "user-agent"}, UserAgent: "MyApp/5.2"}
+	profile := ProfileFromSignals(signals)
+	profile.Name = "MyApp 5.2"
+	_, err = db.Add(profile)
+	require.NoError(t, err)
+
+	matches := db.Identify(signals, 0.5, 1)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "myapp-5.2", matches[0].ProfileID)
+	assert.Equal(t, types.ClientProfileUser, matches[0].Source)
+	assert.ElementsMatch(t, []string{SignalJA3, SignalHeaderOrder, SignalUserAgent}, matches[0].Matched)
+	// Without JA4 or HTTP/2 the compared signals weigh 0.4 of the 0.5 needed for a full score
+	assert.Equal(t, 0.8, matches[0].Score)
+}
+
+func TestScore_WeakSignalsAreCapped(t *testing.T) {
+	p := types.ClientProfile{ID: "ua", UserAgent: []string{"curl/*"}}
+
+	m, ok := score(p, types.ClientSignals{UserAgent: "curl/8.4.0"}, nil)
+	require.True(t, ok)
+	assert.Equal(t, 0.2, m.Score)
+
+	_, ok = score(p, types.ClientSignals{JA4: "t13d"}, nil)
+	assert.False(t, ok, "nothing to compare")
+}
+
+func TestSectionSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, sectionSimilarity("t13d15??h2_8daaf6152771_*", "t13d1516h2_8daaf6152771_02713d6af862", "_"))
+	assert.InDelta(t, 2.0/3, sectionSimilarity("t13d1516h2_8daaf6152771_aaaaaaaaaaaa", "t13d1516h2_8daaf6152771_02713d6af862", "_"), 1e-9)
+	assert.Equal(t, 0.75, sectionSimilarity("1:65536|15663105|0|m,a,s,p", "1:65536|15663105|0|m,p,a,s", "|"))
+	assert.Equal(t, 0.0, sectionSimilarity("a_b", "a_b_c", "_"))
+	assert.Equal(t, 0.0, sectionSimilarity("t13d15??h2_8daaf6152771_*", "t13d1311h2_f57a46bbacb6_ab7e3b40a677", "_"))
+}
+
+func TestOrderSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, orderSimilarity([]string{"a", "b", "c"}, []string{"a", "b", "c"}))
+	assert.InDelta(t, 2.0/3, orderSimilarity([]string{"a", "b", "c"}, []string{"a", "c", "b"}), 1e-9)
+	assert.Equal(t, 0.5, orderSimilarity([]string{"a", "b"}, []string{"a", "b", "c", "d"}))
+	assert.Equal(t, 0.0, orderSimilarity(nil, nil))
+}
+
+func TestSignals(t *testing.T) {
+	fp := &types.Fingerprint{
+		Entry: &types.EntrySummary{TLS: types.TLSSummary{JA3: "ja3-entry", JA4: "ja4-entry"}},
+		HeadersOrdered: [][]string{
+			{":method", "GET"}, {"User-Agent", "curl/8.4.0"}, {"Accept", "*/*"}, {"accept", "text/html"},
+		},
+		TLSSummary:   &types.TLSFingerprint{JA4: "ja4-local"},
+		HTTP2Summary: &types.HTTP2Fingerprint{Akamai: "3:100|1|0|m,p,s,a"},
+	}
+	s := Signals(fp)
+	assert.Equal(t, "ja3-entry", s.JA3)
+	assert.Equal(t, "ja4-local", s.JA4)
+	assert.Equal(t, "3:100|1|0|m,p,s,a", s.Akamai)
+	assert.Equal(t, []string{"user-agent", "accept"}, s.HeaderOrder)
+	assert.Equal(t, "curl/8.4.0", s.UserAgent)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/usestring/powhttp-mcp/pkg/types"
@@ -60,7 +61,11 @@ func (d *DiffEngine) Diff(ctx context.Context, req *types.DiffRequest) (*types.D
 		return nil, fmt.Errorf("generating baseline fingerprint: %w", err)
 	}
 
-	candidateFP, err := d.fingerprinter.Generate(ctx, req.SessionID, req.CandidateEntryID, fpOpts)
+	candidateSessionID := req.CandidateSessionID
+	if candidateSessionID == "" {
+		candidateSessionID = req.SessionID
+	}
+	candidateFP, err := d.fingerprinter.Generate(ctx, candidateSessionID, req.CandidateEntryID, fpOpts)
 	if err != nil {
 		return nil, fmt.Errorf("generating candidate fingerprint: %w", err)
 	}
@@ -115,9 +120,109 @@ func (d *DiffEngine) Diff(ctx context.Context, req *types.DiffRequest) (*types.D
 		}
 	}
 
+	// Compare the casing and repeats of HTTP/1.x header names, which HTTP/2 erases
+	if baselineFP.HTTP1Headers != nil && candidateFP.HTTP1Headers != nil {
+		result.ImportantDiffs.HeaderCasing = diffHeaderCasing(baselineFP.HTTP1Headers.Names, candidateFP.HTTP1Headers.Names)
+		result.ImportantDiffs.DuplicateHeaders = diffDuplicateHeaders(baselineFP.HTTP1Headers.Duplicates, candidateFP.HTTP1Headers.Duplicates)
+	}
+
+	// Compare cookie names and order
+	if opts.CompareCookies {
+		result.ImportantDiffs.Cookies = diffCookies(baselineFP.HeadersOrdered, candidateFP.HeadersOrdered)
+	}
+
 	return result, nil
 }
 
+// diffHeaderCasing compares the casing of the header names both sides sent,
+// by the first line of each.
+func diffHeaderCasing(baseline, candidate []string) []types.HeaderCasingDiff {
+	candidateCasing := make(map[string]string, len(candidate))
+	for _, name := range candidate {
+		lower := strings.ToLower(name)
+		if _, seen := candidateCasing[lower]; !seen {
+			candidateCasing[lower] = name
+		}
+	}
+
+	var diffs []types.HeaderCasingDiff
+	seen := make(map[string]bool, len(baseline))
+	for _, name := range baseline {
+		lower := strings.ToLower(name)
+		if seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		if other, ok := candidateCasing[lower]; ok && other != name {
+			diffs = append(diffs, types.HeaderCasingDiff{Name: lower, Baseline: name, Candidate: other})
+		}
+	}
+	return diffs
+}
+
+// diffDuplicateHeaders returns both sides' repeated headers if they differ.
+func diffDuplicateHeaders(baseline, candidate []string) *types.DuplicateHeadersDiff {
+	if slices.Equal(slices.Sorted(slices.Values(baseline)), slices.Sorted(slices.Values(candidate))) {
+		return nil
+	}
+	return &types.DuplicateHeadersDiff{Baseline: baseline, Candidate: candidate}
+}
+
+// diffCookies compares the names and order of the cookies in Cookie headers,
+// and how many Cookie lines carry them.
+func diffCookies(baseline, candidate [][]string) *types.CookieDiff {
+	baselineNames, candidateNames := cookieNames(baseline), cookieNames(candidate)
+	diff := &types.CookieDiff{
+		BaselineHeaders:  countHeader(baseline, "cookie"),
+		CandidateHeaders: countHeader(candidate, "cookie"),
+	}
+
+	inBaseline := make(map[string]bool, len(baselineNames))
+	for _, name := range baselineNames {
+		inBaseline[name] = true
+	}
+	inCandidate := make(map[string]bool, len(candidateNames))
+	for _, name := range candidateNames {
+		inCandidate[name] = true
+	}
+	var sharedBaseline, sharedCandidate []string
+	for _, name := range baselineNames {
+		if inCandidate[name] {
+			sharedBaseline = append(sharedBaseline, name)
+		} else {
+			diff.Missing = append(diff.Missing, name)
+		}
+	}
+	for _, name := range candidateNames {
+		if inBaseline[name] {
+			sharedCandidate = append(sharedCandidate, name)
+		} else {
+			diff.Extra = append(diff.Extra, name)
+		}
+	}
+	if !slices.Equal(sharedBaseline, sharedCandidate) {
+		diff.Reordered = true
+		diff.BaselineOrder = baselineNames
+		diff.CandidateOrder = candidateNames
+	}
+
+	if len(diff.Missing) == 0 && len(diff.Extra) == 0 && !diff.Reordered && diff.BaselineHeaders == diff.CandidateHeaders {
+		return nil
+	}
+	return diff
+}
+
+// countHeader counts the lines of a header, by lowercase name.
+func countHeader(headers [][]string, name string) int {
+	n := 0
+	for _, pair := range headers {
+		if strings.EqualFold(pair[0], name) {
+			n++
+		}
+	}
+	return n
+}
+
 // diffHeaders compares header presence and values.
 func diffHeaders(baseline, candidate map[string][]string, ignore []string) (missing, extra []string, changed []types.HeaderValueDiff, ignored []string) {
 	ignoreSet := make(map[string]struct{}, len(ignore))
@@ -328,6 +433,14 @@ func diffTLS(baseline, candidate *types.TLSFingerprint) *types.TLSDiff {
 		hasChanges = true
 	}
 
+	// ClientHello field by field, when both hellos were recorded
+	if baseline != nil && candidate != nil {
+		diff.ClientHello = diffClientHello(baseline.ClientHello, candidate.ClientHello)
+		if diff.ClientHello != nil {
+			hasChanges = true
+		}
+	}
+
 	if !hasChanges {
 		return nil
 	}
@@ -335,6 +448,119 @@ func diffTLS(baseline, candidate *types.TLSFingerprint) *types.TLSDiff {
 	return diff
 }
 
+// diffClientHello compares two ClientHello breakdowns list by list and
+// describes each difference in Changes. It returns nil if either hello is
+// missing or they do not differ.
+func diffClientHello(baseline, candidate *types.TLSHelloBreakdown) *types.TLSHelloDiff {
+	if baseline == nil || candidate == nil {
+		return nil
+	}
+
+	diff := &types.TLSHelloDiff{}
+	lists := []struct {
+		noun                string
+		baseline, candidate []string
+		field               **types.TLSListDiff
+	}{
+		{"cipher suite", baseline.CipherSuites, candidate.CipherSuites, &diff.CipherSuites},
+		{"extension", baseline.Extensions, candidate.Extensions, &diff.Extensions},
+		{"supported version", baseline.SupportedVersions, candidate.SupportedVersions, &diff.SupportedVersions},
+		{"supported group", baseline.SupportedGroups, candidate.SupportedGroups, &diff.SupportedGroups},
+		{"signature algorithm", baseline.SignatureAlgorithms, candidate.SignatureAlgorithms, &diff.SignatureAlgorithms},
+		{"ALPN protocol", baseline.ALPN, candidate.ALPN, &diff.ALPN},
+		{"key share group", baseline.KeyShareGroups, candidate.KeyShareGroups, &diff.KeyShareGroups},
+	}
+	for _, list := range lists {
+		listDiff := diffTLSList(list.baseline, list.candidate)
+		if listDiff == nil {
+			continue
+		}
+		*list.field = listDiff
+		for _, v := range listDiff.Removed {
+			diff.Changes = append(diff.Changes, fmt.Sprintf("candidate omits %s %s", list.noun, v))
+		}
+		for _, v := range listDiff.Added {
+			diff.Changes = append(diff.Changes, fmt.Sprintf("candidate adds %s %s", list.noun, v))
+		}
+		if listDiff.Reordered {
+			diff.Changes = append(diff.Changes, fmt.Sprintf("candidate orders %ss differently", list.noun))
+		}
+	}
+
+	if baseline.RecordSizeLimit != candidate.RecordSizeLimit {
+		diff.RecordSizeLimitDiff = true
+		diff.BaselineRecordSizeLimit = baseline.RecordSizeLimit
+		diff.CandidateRecordSizeLimit = candidate.RecordSizeLimit
+		diff.Changes = append(diff.Changes, fmt.Sprintf("record size limit %s in baseline, %s in candidate",
+			recordSizeLimit(baseline.RecordSizeLimit), recordSizeLimit(candidate.RecordSizeLimit)))
+	}
+
+	if len(diff.Changes) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// diffTLSList compares two ordered lists of ClientHello values. Each value
+// counts once, however often it repeats, and GREASE is left out of the
+// order since clients place it at random.
+func diffTLSList(baseline, candidate []string) *types.TLSListDiff {
+	inBaseline := make(map[string]bool, len(baseline))
+	for _, v := range baseline {
+		inBaseline[v] = true
+	}
+	inCandidate := make(map[string]bool, len(candidate))
+	for _, v := range candidate {
+		inCandidate[v] = true
+	}
+
+	diff := &types.TLSListDiff{}
+	for _, v := range candidate {
+		if !inBaseline[v] && !slices.Contains(diff.Added, v) {
+			diff.Added = append(diff.Added, v)
+		}
+	}
+	for _, v := range baseline {
+		if !inCandidate[v] && !slices.Contains(diff.Removed, v) {
+			diff.Removed = append(diff.Removed, v)
+		}
+	}
+
+	if !slices.Equal(sharedOrder(baseline, inCandidate), sharedOrder(candidate, inBaseline)) {
+		diff.Reordered = true
+		diff.BaselineOrder = baseline
+		diff.CandidateOrder = candidate
+	}
+
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && !diff.Reordered {
+		return nil
+	}
+	return diff
+}
+
+// sharedOrder returns the first occurrence of each non-GREASE value of list
+// that the other list also has, in order.
+func sharedOrder(list []string, inOther map[string]bool) []string {
+	var out []string
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		if v == "GREASE" || !inOther[v] || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// recordSizeLimit renders a record_size_limit value, 0 meaning not sent.
+func recordSizeLimit(limit int) string {
+	if limit == 0 {
+		return "not sent"
+	}
+	return strconv.Itoa(limit)
+}
+
 // diffHTTP2 compares HTTP/2 metadata.
 func diffHTTP2(baseline, candidate *types.Fingerprint) *types.HTTP2Diff {
 	baselineH2 := baseline.HTTP2Summary
@@ -365,6 +591,11 @@ func diffHTTP2(baseline, candidate *types.Fingerprint) *types.HTTP2Diff {
 		hasChanges = true
 	}
 
+	// Compare the Akamai fingerprint, and each of its parts
+	if baselineH2 != nil && candidateH2 != nil && diffAkamai(diff, baselineH2, candidateH2) {
+		hasChanges = true
+	}
+
 	if !hasChanges {
 		return nil
 	}
@@ -372,6 +603,100 @@ func diffHTTP2(baseline, candidate *types.Fingerprint) *types.HTTP2Diff {
 	return diff
 }
 
+// diffAkamai compares the Akamai HTTP/2 fingerprints field by field and
+// reports whether any differ. Parts a side did not record are not compared.
+func diffAkamai(diff *types.HTTP2Diff, baseline, candidate *types.HTTP2Fingerprint) bool {
+	hasChanges := false
+
+	if baseline.Akamai != candidate.Akamai && (baseline.Akamai != "" || candidate.Akamai != "") {
+		diff.AkamaiDifferent = true
+		diff.BaselineAkamai = baseline.Akamai
+		diff.CandidateAkamai = candidate.Akamai
+		hasChanges = true
+	}
+
+	if baseline.PseudoHeaderOrder != candidate.PseudoHeaderOrder && baseline.PseudoHeaderOrder != "" && candidate.PseudoHeaderOrder != "" {
+		diff.PseudoHeaderOrderDiff = true
+		diff.BaselinePseudoHeaderOrder = baseline.PseudoHeaderOrder
+		diff.CandidatePseudoHeaderOrder = candidate.PseudoHeaderOrder
+		hasChanges = true
+	}
+
+	// The remaining parts come from connection frames, which both sides need
+	if len(baseline.Settings) == 0 || len(candidate.Settings) == 0 {
+		return hasChanges
+	}
+
+	diff.SettingsDiff = diffHTTP2Settings(baseline.Settings, candidate.Settings)
+	if len(diff.SettingsDiff) > 0 {
+		hasChanges = true
+	}
+
+	baselineOrder, candidateOrder := http2SettingsOrder(baseline.Settings), http2SettingsOrder(candidate.Settings)
+	if baselineOrder != candidateOrder {
+		diff.SettingsOrderDiff = true
+		diff.BaselineSettingsOrder = baselineOrder
+		diff.CandidateSettingsOrder = candidateOrder
+		hasChanges = true
+	}
+
+	if baseline.WindowUpdate != candidate.WindowUpdate {
+		diff.WindowUpdateDiff = true
+		diff.BaselineWindowUpdate = baseline.WindowUpdate
+		diff.CandidateWindowUpdate = candidate.WindowUpdate
+		hasChanges = true
+	}
+
+	baselinePriorities, candidatePriorities := formatHTTP2Priorities(baseline.Priorities), formatHTTP2Priorities(candidate.Priorities)
+	if baselinePriorities != candidatePriorities {
+		diff.PrioritiesDiff = true
+		diff.BaselinePriorities = baselinePriorities
+		diff.CandidatePriorities = candidatePriorities
+		hasChanges = true
+	}
+
+	return hasChanges
+}
+
+// diffHTTP2Settings lists the SETTINGS parameters whose values differ or that
+// only one side sent, in baseline order followed by candidate-only parameters.
+func diffHTTP2Settings(baseline, candidate []types.HTTP2Setting) []types.HTTP2SettingDiff {
+	candidateValues := make(map[int]int64, len(candidate))
+	for _, s := range candidate {
+		candidateValues[s.ID] = s.Value
+	}
+	baselineIDs := make(map[int]bool, len(baseline))
+
+	var diffs []types.HTTP2SettingDiff
+	for _, s := range baseline {
+		baselineIDs[s.ID] = true
+		value, ok := candidateValues[s.ID]
+		if ok && value == s.Value {
+			continue
+		}
+		d := types.HTTP2SettingDiff{Name: s.Name, Baseline: &s.Value}
+		if ok {
+			d.Candidate = &value
+		}
+		diffs = append(diffs, d)
+	}
+	for _, s := range candidate {
+		if !baselineIDs[s.ID] {
+			diffs = append(diffs, types.HTTP2SettingDiff{Name: s.Name, Candidate: &s.Value})
+		}
+	}
+	return diffs
+}
+
+// http2SettingsOrder lists SETTINGS identifiers in the order they were sent.
+func http2SettingsOrder(settings []types.HTTP2Setting) string {
+	ids := make([]string, 0, len(settings))
+	for _, s := range settings {
+		ids = append(ids, strconv.Itoa(s.ID))
+	}
+	return strings.Join(ids, ",")
+}
+
 // pseudoHeadersEqual compares two sets of pseudo-headers.
 func pseudoHeadersEqual(a, b [][]string) bool {
 	if len(a) != len(b) {
@@ -478,6 +478,149 @@ func TestDiffTLS(t *testing.T) {
 	}
 }
 
+func TestHTTP1Headers(t *testing.T) {
+	h := http1Headers([][]string{
+		{"Host", "example.com"},
+		{"User-Agent", "Go-http-client/1.1"},
+		{"Cookie", "session=abc; theme=dark"},
+		{"Accept-Encoding", "gzip"},
+		{"cookie", "=skipped; csrf=1"},
+	})
+	assert.Equal(t, []string{"Host", "User-Agent", "Cookie", "Accept-Encoding", "cookie"}, h.Names)
+	assert.Equal(t, []string{"cookie"}, h.Duplicates)
+	assert.Equal(t, 2, h.CookieHeaders)
+	assert.Equal(t, []string{"session", "theme", "csrf"}, h.CookieNames)
+}
+
+func TestDiffHeaderCasing(t *testing.T) {
+	browser := []string{"Host", "Connection", "user-agent", "Accept", "Accept"}
+	program := []string{"Host", "User-Agent", "ACCEPT", "X-Extra"}
+
+	assert.Equal(t, []types.HeaderCasingDiff{
+		{Name: "user-agent", Baseline: "user-agent", Candidate: "User-Agent"},
+		{Name: "accept", Baseline: "Accept", Candidate: "ACCEPT"},
+	}, diffHeaderCasing(browser, program))
+	assert.Nil(t, diffHeaderCasing(browser, browser))
+}
+
+func TestDiffDuplicateHeaders(t *testing.T) {
+	assert.Nil(t, diffDuplicateHeaders(nil, nil))
+	assert.Nil(t, diffDuplicateHeaders([]string{"cookie", "accept"}, []string{"accept", "cookie"}))
+	assert.Equal(t, &types.DuplicateHeadersDiff{Candidate: []string{"cookie"}}, diffDuplicateHeaders(nil, []string{"cookie"}))
+}
+
+func TestDiffCookies(t *testing.T) {
+	baseline := [][]string{{"User-Agent", "x"}, {"Cookie", "a=1; b=2; c=3"}}
+
+	tests := []struct {
+		name      string
+		candidate [][]string
+		want      *types.CookieDiff
+	}{
+		{
+			name:      "same names, other values",
+			candidate: [][]string{{"Cookie", "a=9; b=9; c=9"}},
+			want:      nil,
+		},
+		{
+			name:      "split over two lines",
+			candidate: [][]string{{"Cookie", "a=1"}, {"Cookie", "b=2; c=3"}},
+			want:      &types.CookieDiff{BaselineHeaders: 1, CandidateHeaders: 2},
+		},
+		{
+			name:      "missing, extra, and reordered",
+			candidate: [][]string{{"cookie", "c=3; a=1; d=4"}},
+			want: &types.CookieDiff{
+				Missing:          []string{"b"},
+				Extra:            []string{"d"},
+				Reordered:        true,
+				BaselineOrder:    []string{"a", "b", "c"},
+				CandidateOrder:   []string{"c", "a", "d"},
+				BaselineHeaders:  1,
+				CandidateHeaders: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffCookies(baseline, tt.candidate))
+		})
+	}
+}
+
+func TestDiffClientHello(t *testing.T) {
+	chrome := &types.TLSHelloBreakdown{
+		CipherSuites: []string{"GREASE", "TLS_AES_128_GCM_SHA256 (0x1301)", "TLS_AES_256_GCM_SHA384 (0x1302)"},
+		Extensions: []string{"GREASE", "server_name (0x0000)", "application_settings (0x4469)",
+			"key_share (0x0033)", "GREASE"},
+		SupportedGroups: []string{"GREASE", "x25519 (0x001d)", "secp256r1 (0x0017)"},
+		ALPN:            []string{"h2", "http/1.1"},
+		KeyShareGroups:  []string{"GREASE", "x25519 (0x001d)"},
+	}
+
+	t.Run("GREASE values and positions are ignored", func(t *testing.T) {
+		other := *chrome
+		other.Extensions = []string{"server_name (0x0000)", "GREASE", "application_settings (0x4469)", "key_share (0x0033)"}
+		assert.Nil(t, diffClientHello(chrome, &other))
+	})
+
+	t.Run("missing hello", func(t *testing.T) {
+		assert.Nil(t, diffClientHello(chrome, nil))
+	})
+
+	t.Run("differences", func(t *testing.T) {
+		scraper := &types.TLSHelloBreakdown{
+			CipherSuites:    []string{"TLS_AES_256_GCM_SHA384 (0x1302)", "TLS_AES_128_GCM_SHA256 (0x1301)"},
+			Extensions:      []string{"server_name (0x0000)", "key_share (0x0033)", "record_size_limit (0x001c)"},
+			SupportedGroups: []string{"GREASE", "x25519 (0x001d)", "secp256r1 (0x0017)"},
+			ALPN:            []string{"h2", "http/1.1"},
+			KeyShareGroups:  []string{"secp256r1 (0x0017)", "x25519 (0x001d)"},
+			RecordSizeLimit: 16385,
+		}
+		diff := diffClientHello(chrome, scraper)
+		require.NotNil(t, diff)
+
+		require.NotNil(t, diff.CipherSuites)
+		assert.Equal(t, []string{"GREASE"}, diff.CipherSuites.Removed)
+		assert.True(t, diff.CipherSuites.Reordered)
+		assert.Equal(t, chrome.CipherSuites, diff.CipherSuites.BaselineOrder)
+
+		require.NotNil(t, diff.Extensions)
+		assert.Equal(t, []string{"GREASE", "application_settings (0x4469)"}, diff.Extensions.Removed)
+		assert.Equal(t, []string{"record_size_limit (0x001c)"}, diff.Extensions.Added)
+		assert.False(t, diff.Extensions.Reordered)
+
+		assert.Nil(t, diff.SupportedGroups)
+		assert.Nil(t, diff.ALPN)
+
+		require.NotNil(t, diff.KeyShareGroups)
+		assert.Equal(t, []string{"secp256r1 (0x0017)"}, diff.KeyShareGroups.Added)
+		assert.False(t, diff.KeyShareGroups.Reordered, "only x25519 is shared")
+
+		assert.True(t, diff.RecordSizeLimitDiff)
+		assert.Equal(t, 16385, diff.CandidateRecordSizeLimit)
+
+		assert.Contains(t, diff.Changes, "candidate omits extension application_settings (0x4469)")
+		assert.Contains(t, diff.Changes, "candidate orders cipher suites differently")
+		assert.Contains(t, diff.Changes, "record size limit not sent in baseline, 16385 in candidate")
+	})
+}
+
+func TestDiffTLS_ClientHello(t *testing.T) {
+	baseline := &types.TLSFingerprint{JA4: "t13d0202h2_a_b", ClientHello: &types.TLSHelloBreakdown{
+		Extensions: []string{"server_name (0x0000)", "key_share (0x0033)"},
+	}}
+	candidate := &types.TLSFingerprint{JA4: "t13d0202h2_a_b", ClientHello: &types.TLSHelloBreakdown{
+		Extensions: []string{"key_share (0x0033)", "server_name (0x0000)"},
+	}}
+
+	diff := diffTLS(baseline, candidate)
+	require.NotNil(t, diff, "JA4 sorts extensions, so only the hello diff sees the order")
+	assert.False(t, diff.JA4Different)
+	require.NotNil(t, diff.ClientHello)
+	assert.Equal(t, []string{"candidate orders extensions differently"}, diff.ClientHello.Changes)
+}
+
 func TestPseudoHeadersEqual(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -545,3 +688,61 @@ func TestPseudoHeadersEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffHTTP2Akamai(t *testing.T) {
+	chrome := &types.HTTP2Fingerprint{
+		StreamID: 1,
+		Akamai:   "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p",
+		Settings: []types.HTTP2Setting{
+			{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 65536},
+			{ID: 2, Name: "ENABLE_PUSH", Value: 0},
+			{ID: 4, Name: "INITIAL_WINDOW_SIZE", Value: 6291456},
+			{ID: 6, Name: "MAX_HEADER_LIST_SIZE", Value: 262144},
+		},
+		WindowUpdate:      15663105,
+		PseudoHeaderOrder: "m,a,s,p",
+	}
+	goClient := &types.HTTP2Fingerprint{
+		StreamID: 1,
+		Akamai:   "2:0;4:4194304;6:10485760;1:65536|1073741824|0|m,p,a,s",
+		Settings: []types.HTTP2Setting{
+			{ID: 2, Name: "ENABLE_PUSH", Value: 0},
+			{ID: 4, Name: "INITIAL_WINDOW_SIZE", Value: 4194304},
+			{ID: 6, Name: "MAX_HEADER_LIST_SIZE", Value: 10485760},
+			{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 65536},
+		},
+		WindowUpdate:      1073741824,
+		PseudoHeaderOrder: "m,p,a,s",
+	}
+
+	diff := diffHTTP2(&types.Fingerprint{HTTP2Summary: chrome}, &types.Fingerprint{HTTP2Summary: goClient})
+	require.NotNil(t, diff)
+	assert.True(t, diff.AkamaiDifferent)
+	assert.Equal(t, chrome.Akamai, diff.BaselineAkamai)
+	assert.Equal(t, goClient.Akamai, diff.CandidateAkamai)
+
+	int64Ptr := func(v int64) *int64 { return &v }
+	assert.Equal(t, []types.HTTP2SettingDiff{
+		{Name: "INITIAL_WINDOW_SIZE", Baseline: int64Ptr(6291456), Candidate: int64Ptr(4194304)},
+		{Name: "MAX_HEADER_LIST_SIZE", Baseline: int64Ptr(262144), Candidate: int64Ptr(10485760)},
+	}, diff.SettingsDiff)
+	assert.True(t, diff.SettingsOrderDiff)
+	assert.Equal(t, "1,2,4,6", diff.BaselineSettingsOrder)
+	assert.Equal(t, "2,4,6,1", diff.CandidateSettingsOrder)
+	assert.True(t, diff.WindowUpdateDiff)
+	assert.False(t, diff.PrioritiesDiff)
+	assert.True(t, diff.PseudoHeaderOrderDiff)
+	assert.Equal(t, "m,p,a,s", diff.CandidatePseudoHeaderOrder)
+
+	// Settings only one side sent
+	assert.Equal(t, []types.HTTP2SettingDiff{
+		{Name: "ENABLE_PUSH", Baseline: int64Ptr(0)},
+		{Name: "MAX_CONCURRENT_STREAMS", Candidate: int64Ptr(100)},
+	}, diffHTTP2Settings(
+		[]types.HTTP2Setting{{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 4096}, {ID: 2, Name: "ENABLE_PUSH", Value: 0}},
+		[]types.HTTP2Setting{{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 4096}, {ID: 3, Name: "MAX_CONCURRENT_STREAMS", Value: 100}},
+	))
+
+	// Identical fingerprints produce no diff
+	assert.Nil(t, diffHTTP2(&types.Fingerprint{HTTP2Summary: chrome}, &types.Fingerprint{HTTP2Summary: chrome}))
+}
@@ -13,20 +13,21 @@ import (
 	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/ja4"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 
 // FingerprintEngine generates fingerprints for HTTP entries.
 type FingerprintEngine struct {
-	client *client.Client
+	client client.DataSource
 	cache  *cache.EntryCache
 	config *config.Config
 }
 
 
 // NewFingerprintEngine creates a new FingerprintEngine.
-func NewFingerprintEngine(c *client.Client, entryCache *cache.EntryCache, cfg *config.Config) *FingerprintEngine {
+func NewFingerprintEngine(c client.DataSource, entryCache *cache.EntryCache, cfg *config.Config) *FingerprintEngine {
 	return &FingerprintEngine{
 		client: c,
 		cache:  entryCache,
@@ -73,6 +74,11 @@ func (f *FingerprintEngine) Generate(ctx context.Context, sessionID, entryID str
 		HTTP2PseudoHeaders: pseudoHeaders,
 		Body:               bodyFP,
 	}
+	fp.JA4H, fp.JA4HRaw = ja4.JA4H(summary.Method, entry.HTTPVersion, headersOrdered)
+	fp.JA4L, _ = ja4.JA4L(entry.Timings)
+	if isHTTP1(entry.HTTPVersion) {
+		fp.HTTP1Headers = http1Headers(headersOrdered)
+	}
 
 	// Fetch TLS summary if requested and available
 	if opts.IncludeTLSSummary && entry.TLS.ConnectionID != nil {
@@ -84,7 +90,7 @@ func (f *FingerprintEngine) Generate(ctx context.Context, sessionID, entryID str
 
 	// Fetch HTTP/2 summary if requested and available
 	if opts.IncludeHTTP2Summary && entry.HTTP2 != nil {
-		h2Summary, err := f.fetchHTTP2Summary(ctx, entry.HTTP2.ConnectionID, entry.HTTP2.StreamID)
+		h2Summary, err := f.fetchHTTP2Summary(ctx, entry.HTTP2.ConnectionID, entry.HTTP2.StreamID, pseudoHeaders)
 		if err == nil {
 			fp.HTTP2Summary = h2Summary
 		}
@@ -194,6 +200,45 @@ func normalizeHeaders(headers client.Headers) map[string][]string {
 	return result
 }
 
+// http1Headers records the header names of an HTTP/1.x request as sent, the
+// names repeated on several lines, and the cookies in order.
+func http1Headers(headers [][]string) *types.HTTP1Headers {
+	h := &types.HTTP1Headers{CookieNames: cookieNames(headers)}
+	counts := make(map[string]int)
+	for _, pair := range headers {
+		if strings.HasPrefix(pair[0], ":") {
+			continue
+		}
+		h.Names = append(h.Names, pair[0])
+		name := strings.ToLower(pair[0])
+		counts[name]++
+		if counts[name] == 2 {
+			h.Duplicates = append(h.Duplicates, name)
+		}
+		if name == "cookie" {
+			h.CookieHeaders++
+		}
+	}
+	return h
+}
+
+// cookieNames lists the names of the cookies in Cookie headers, in order.
+func cookieNames(headers [][]string) []string {
+	var names []string
+	for _, pair := range headers {
+		if !strings.EqualFold(pair[0], "cookie") {
+			continue
+		}
+		for _, cookie := range strings.Split(pair[1], ";") {
+			name, _, _ := strings.Cut(strings.TrimSpace(cookie), "=")
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
 // extractPseudoHeaders extracts HTTP/2 pseudo-headers (starting with ':').
 func extractPseudoHeaders(headers client.Headers) [][]string {
 	var result [][]string
@@ -213,7 +258,7 @@ func computeBodyFingerprint(entry *client.SessionEntry, maxBytes int) types.Body
 	if entry.Request.Body != nil {
 		fp.ReqBytes = computeBodySize(entry.Request.Body)
 		if fp.ReqBytes > 0 && fp.ReqBytes <= maxBytes {
-			fp.ReqHash = hashBody(entry.Request.Body)
+			fp.ReqHash = hashBody(entry.Request.Body, entry.Request.Headers)
 		}
 	}
 
@@ -221,20 +266,21 @@ func computeBodyFingerprint(entry *client.SessionEntry, maxBytes int) types.Body
 	if entry.Response != nil && entry.Response.Body != nil {
 		fp.RespBytes = computeBodySize(entry.Response.Body)
 		if fp.RespBytes > 0 && fp.RespBytes <= maxBytes {
-			fp.RespHash = hashBody(entry.Response.Body)
+			fp.RespHash = hashBody(entry.Response.Body, entry.Response.Headers)
 		}
 	}
 
 	return fp
 }
 
-// hashBody computes SHA256 of a decoded base64 body.
-func hashBody(encoded *string) string {
+// hashBody computes SHA256 of a decoded and decompressed body, so that the
+// same content compares equal whatever its Content-Encoding.
+func hashBody(encoded *string, headers client.Headers) string {
 	if encoded == nil || *encoded == "" {
 		return ""
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(*encoded)
+	decoded, err := client.DecodeContent(encoded, client.ContentEncoding(headers))
 	if err != nil {
 		return ""
 	}
@@ -290,6 +336,10 @@ func (f *FingerprintEngine) fetchTLSSummary(ctx context.Context, connectionID st
 					sh := event.Msg.Handshake.ServerHello
 					summary.TLSVersion = sh.Version.Name
 					summary.CipherSuite = sh.CipherSuite.Name
+					if hello, err := ja4.ParseServerHello(sh); err == nil {
+						summary.JA4S, summary.JA4SRaw = ja4.JA4S(hello)
+						summary.ServerHello = hello.Breakdown()
+					}
 				}
 			case client.TLSHandshakeClientHello:
 				if event.Msg.Handshake.ClientHello != nil {
@@ -297,6 +347,18 @@ func (f *FingerprintEngine) fetchTLSSummary(ctx context.Context, connectionID st
 					if summary.TLSVersion == "" {
 						summary.TLSVersion = ch.Version.Name
 					}
+					if hello, err := ja4.ParseClientHello(ch); err == nil {
+						local, raw := ja4.JA4(hello, isHTTP3(entry.HTTPVersion))
+						if summary.JA4 == "" {
+							summary.JA4 = local
+						}
+						summary.JA4Raw = raw
+						summary.ClientHello = hello.Breakdown()
+					}
+				}
+			case client.TLSHandshakeCertificate:
+				if cert := event.Msg.Handshake.Certificate; cert != nil && event.Side == client.TLSSideServer {
+					summary.JA4X = ja4.ChainJA4X(cert)
 				}
 			}
 		}
@@ -305,12 +367,33 @@ func (f *FingerprintEngine) fetchTLSSummary(ctx context.Context, connectionID st
 	return summary, nil
 }
 
-// fetchHTTP2Summary gets HTTP/2 stream details from frame data.
-func (f *FingerprintEngine) fetchHTTP2Summary(ctx context.Context, connID string, streamID int) (*types.HTTP2Fingerprint, error) {
+// isHTTP1 reports whether an HTTP version is HTTP/1.0 or HTTP/1.1.
+func isHTTP1(httpVersion string) bool {
+	return strings.HasPrefix(strings.ToLower(httpVersion), "http/1")
+}
+
+// isHTTP3 reports whether an HTTP version is HTTP/3, which runs TLS over QUIC.
+func isHTTP3(httpVersion string) bool {
+	v := strings.ToLower(httpVersion)
+	return v == "h3" || strings.HasPrefix(v, "http/3")
+}
+
+// fetchHTTP2Summary gets HTTP/2 stream details from frame data, and the
+// Akamai fingerprint from the connection's frames.
+func (f *FingerprintEngine) fetchHTTP2Summary(ctx context.Context, connID string, streamID int, pseudoHeaders [][]string) (*types.HTTP2Fingerprint, error) {
 	summary := &types.HTTP2Fingerprint{
-		ConnectionID: connID,
-		StreamID:     streamID,
-		FrameCounts:  make(map[string]int),
+		ConnectionID:      connID,
+		StreamID:          streamID,
+		FrameCounts:       make(map[string]int),
+		PseudoHeaderOrder: pseudoHeaderOrder(pseudoHeaders),
+	}
+
+	// Connection-level frames: SETTINGS, WINDOW_UPDATE and PRIORITY
+	if conn, err := f.fetchHTTP2Connection(ctx, connID); err == nil {
+		summary.Settings = conn.Settings
+		summary.WindowUpdate = conn.WindowUpdate
+		summary.Priorities = conn.Priorities
+		summary.Akamai = akamaiFingerprint(conn, summary.PseudoHeaderOrder)
 	}
 
 	// Fetch HTTP/2 frames
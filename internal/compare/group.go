@@ -0,0 +1,400 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	defaultGroupMinScore = 0.5
+	defaultGroupLimit    = 25
+	maxGroupValues       = 5 // Values listed per group and separator
+)
+
+// separatorPriority orders separators of equal score: transport-level
+// features first, since they are the hardest for a program to change.
+var separatorPriority = map[string]int{
+	types.SeparatorJA4:           0,
+	types.SeparatorJA3:           1,
+	types.SeparatorHTTPVersion:   2,
+	types.SeparatorH2Settings:    3,
+	types.SeparatorH2PseudoOrder: 4,
+	types.SeparatorH2Setting:     5,
+	types.SeparatorHeaderPresent: 6,
+	types.SeparatorHeaderCasing:  7,
+	types.SeparatorHeaderOrder:   8,
+	types.SeparatorHeaderValue:   9,
+}
+
+// featureKey identifies one categorical feature of an entry.
+type featureKey struct {
+	kind string
+	name string
+}
+
+// entryFeatures holds the features observed on one entry.
+type entryFeatures struct {
+	values  map[featureKey]string
+	headers []string // Lowercase header names in order, without pseudo-headers, ignored headers, or repeats
+}
+
+// DiffGroups fingerprints two groups of entries and ranks the features whose
+// values are distributed differently between them: header presence, order,
+// values, and HTTP/1.x casing, JA3/JA4, HTTP version, and HTTP/2 settings and pseudo-header
+// order. Per-request noise averages out, so what remains are the patterns
+// that consistently tell the groups apart.
+func (d *DiffEngine) DiffGroups(ctx context.Context, req *types.GroupDiffRequest) (*types.GroupDiffResult, error) {
+	minScore := req.MinScore
+	if minScore <= 0 {
+		minScore = defaultGroupMinScore
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultGroupLimit
+	}
+	ignore := req.IgnoreHeaders
+	if ignore == nil {
+		ignore = DefaultIgnoreHeaders
+	}
+	ignoreSet := make(map[string]bool, len(ignore))
+	for _, h := range ignore {
+		ignoreSet[strings.ToLower(h)] = true
+	}
+
+	settings := make(map[string][]types.HTTP2Setting) // connection ID -> client SETTINGS
+	baseline, err := d.observeGroup(ctx, req.SessionID, req.BaselineEntryIDs, ignoreSet, settings)
+	if err != nil {
+		return nil, fmt.Errorf("baseline group: %w", err)
+	}
+	candidate, err := d.observeGroup(ctx, req.SessionID, req.CandidateEntryIDs, ignoreSet, settings)
+	if err != nil {
+		return nil, fmt.Errorf("candidate group: %w", err)
+	}
+
+	var separators []types.Separator
+	for _, s := range scoreGroups(baseline, candidate) {
+		if s.Score >= minScore {
+			separators = append(separators, s)
+		}
+	}
+	sort.SliceStable(separators, func(i, j int) bool {
+		a, b := separators[i], separators[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if separatorPriority[a.Kind] != separatorPriority[b.Kind] {
+			return separatorPriority[a.Kind] < separatorPriority[b.Kind]
+		}
+		return a.Name < b.Name
+	})
+
+	result := &types.GroupDiffResult{
+		BaselineEntries:  len(baseline),
+		CandidateEntries: len(candidate),
+		SeparatorCount:   len(separators),
+	}
+	if len(separators) > limit {
+		separators = separators[:limit]
+	}
+	result.Separators = separators
+	return result, nil
+}
+
+// observeGroup fingerprints the entries of a group. Entries that cannot be
+// fetched are skipped; a group with none left is an error.
+func (d *DiffEngine) observeGroup(ctx context.Context, sessionID string, entryIDs []string, ignore map[string]bool, settings map[string][]types.HTTP2Setting) ([]entryFeatures, error) {
+	if len(entryIDs) == 0 {
+		return nil, errors.New("no entries selected")
+	}
+
+	fpOpts := &types.FingerprintOptions{}
+	var group []entryFeatures
+	var lastErr error
+	for _, id := range entryIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fp, err := d.fingerprinter.Generate(ctx, sessionID, id, fpOpts)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var h2Settings []types.HTTP2Setting
+		if fp.Entry.HTTP2.ConnectionID != "" {
+			connID := fp.Entry.HTTP2.ConnectionID
+			cached, ok := settings[connID]
+			if !ok {
+				if conn, err := d.fingerprinter.fetchHTTP2Connection(ctx, connID); err == nil {
+					cached = conn.Settings
+				}
+				settings[connID] = cached
+			}
+			h2Settings = cached
+		}
+		group = append(group, observeEntry(fp, h2Settings, ignore))
+	}
+	if len(group) == 0 {
+		return nil, fmt.Errorf("none of %d entries could be fetched: %w", len(entryIDs), lastErr)
+	}
+	return group, nil
+}
+
+// observeEntry extracts the categorical features of one fingerprinted entry.
+func observeEntry(fp *types.Fingerprint, settings []types.HTTP2Setting, ignore map[string]bool) entryFeatures {
+	ef := entryFeatures{values: make(map[featureKey]string)}
+
+	ef.values[featureKey{kind: types.SeparatorHTTPVersion}] = strings.ToLower(fp.Entry.HTTPVersion)
+	ef.values[featureKey{kind: types.SeparatorJA3}] = valueOrNone(fp.Entry.TLS.JA3)
+	ef.values[featureKey{kind: types.SeparatorJA4}] = valueOrNone(fp.Entry.TLS.JA4)
+
+	values := make(map[string][]string)
+	for _, pair := range fp.HeadersOrdered {
+		name := strings.ToLower(pair[0])
+		if strings.HasPrefix(name, ":") || ignore[name] {
+			continue
+		}
+		if _, seen := values[name]; !seen {
+			ef.headers = append(ef.headers, name)
+			if fp.HTTP1Headers != nil {
+				ef.values[featureKey{kind: types.SeparatorHeaderCasing, name: name}] = pair[0]
+			}
+		}
+		values[name] = append(values[name], pair[1])
+	}
+	for name, v := range values {
+		ef.values[featureKey{kind: types.SeparatorHeaderValue, name: name}] = strings.Join(v, ", ")
+	}
+
+	if len(fp.HTTP2PseudoHeaders) > 0 {
+		names := make([]string, 0, len(fp.HTTP2PseudoHeaders))
+		for _, pair := range fp.HTTP2PseudoHeaders {
+			names = append(names, pair[0])
+		}
+		ef.values[featureKey{kind: types.SeparatorH2PseudoOrder}] = strings.Join(names, ",")
+	}
+	if len(settings) > 0 {
+		ef.values[featureKey{kind: types.SeparatorH2Settings}] = formatHTTP2Settings(settings)
+		for _, s := range settings {
+			ef.values[featureKey{kind: types.SeparatorH2Setting, name: s.Name}] = fmt.Sprint(s.Value)
+		}
+	}
+
+	return ef
+}
+
+// valueOrNone returns v, or "none" if it is empty.
+func valueOrNone(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
+
+// scoreGroups scores every feature observed in either group.
+func scoreGroups(baseline, candidate []entryFeatures) []types.Separator {
+	var separators []types.Separator
+
+	// Categorical features, over the entries that have them
+	keys := make(map[featureKey]bool)
+	for _, group := range [][]entryFeatures{baseline, candidate} {
+		for _, ef := range group {
+			for k := range ef.values {
+				keys[k] = true
+			}
+		}
+	}
+	for k := range keys {
+		b, c := countValues(baseline, k), countValues(candidate, k)
+		if len(b) == 0 || len(c) == 0 {
+			continue
+		}
+		// Per-request values (tokens, timestamps, content lengths) would
+		// separate any two groups; only low-cardinality values are compared
+		if k.kind == types.SeparatorHeaderValue && (volatile(b) || volatile(c)) {
+			continue
+		}
+		separators = append(separators, newSeparator(k.kind, k.name, b, c))
+	}
+
+	// Header presence, over all entries
+	names := make(map[string]bool)
+	for _, group := range [][]entryFeatures{baseline, candidate} {
+		for _, ef := range group {
+			for _, name := range ef.headers {
+				names[name] = true
+			}
+		}
+	}
+	for name := range names {
+		separators = append(separators, newSeparator(types.SeparatorHeaderPresent, name,
+			countPresence(baseline, name), countPresence(candidate, name)))
+	}
+
+	// Relative order of header pairs common to both groups
+	common := commonHeaders(baseline, candidate)
+	for i, a := range common {
+		for _, b := range common[i+1:] {
+			bc, cc := countOrder(baseline, a, b), countOrder(candidate, a, b)
+			if len(bc) == 0 || len(cc) == 0 {
+				continue
+			}
+			separators = append(separators, newSeparator(types.SeparatorHeaderOrder, a+" vs "+b, bc, cc))
+		}
+	}
+
+	return separators
+}
+
+// countValues counts the values of a feature across the entries that have it.
+func countValues(group []entryFeatures, k featureKey) map[string]int {
+	counts := make(map[string]int)
+	for _, ef := range group {
+		if v, ok := ef.values[k]; ok {
+			counts[v]++
+		}
+	}
+	return counts
+}
+
+// countPresence counts the entries that sent a header and those that did not.
+func countPresence(group []entryFeatures, name string) map[string]int {
+	counts := make(map[string]int)
+	for _, ef := range group {
+		if headerIndex(ef.headers, name) >= 0 {
+			counts["present"]++
+		} else {
+			counts["absent"]++
+		}
+	}
+	return counts
+}
+
+// countOrder counts which of two headers came first, across entries that
+// sent both.
+func countOrder(group []entryFeatures, a, b string) map[string]int {
+	counts := make(map[string]int)
+	for _, ef := range group {
+		ia, ib := headerIndex(ef.headers, a), headerIndex(ef.headers, b)
+		if ia < 0 || ib < 0 {
+			continue
+		}
+		if ia < ib {
+			counts[a+" < "+b]++
+		} else {
+			counts[b+" < "+a]++
+		}
+	}
+	return counts
+}
+
+// commonHeaders returns the sorted headers sent by at least half the entries
+// of both groups.
+func commonHeaders(baseline, candidate []entryFeatures) []string {
+	frequent := func(group []entryFeatures) map[string]bool {
+		counts := make(map[string]int)
+		for _, ef := range group {
+			for _, name := range ef.headers {
+				counts[name]++
+			}
+		}
+		set := make(map[string]bool)
+		for name, n := range counts {
+			if n*2 >= len(group) {
+				set[name] = true
+			}
+		}
+		return set
+	}
+	b, c := frequent(baseline), frequent(candidate)
+	var names []string
+	for name := range b {
+		if c[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// headerIndex returns the position of a header in an ordered list, or -1.
+func headerIndex(headers []string, name string) int {
+	for i, h := range headers {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// volatile reports whether most observations of a feature have distinct
+// values.
+func volatile(counts map[string]int) bool {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return len(counts) > 1 && len(counts)*2 > total
+}
+
+// newSeparator scores a feature by the total variation distance between
+// the groups' value distributions and lists the most common values of each.
+func newSeparator(kind, name string, baseline, candidate map[string]int) types.Separator {
+	bTotal, cTotal := sumCounts(baseline), sumCounts(candidate)
+	values := make(map[string]bool)
+	for v := range baseline {
+		values[v] = true
+	}
+	for v := range candidate {
+		values[v] = true
+	}
+	var distance float64
+	for v := range values {
+		distance += math.Abs(float64(baseline[v])/float64(bTotal) - float64(candidate[v])/float64(cTotal))
+	}
+
+	return types.Separator{
+		Kind:      kind,
+		Name:      name,
+		Score:     math.Round(distance/2*1000) / 1000,
+		Baseline:  topValues(baseline, bTotal),
+		Candidate: topValues(candidate, cTotal),
+	}
+}
+
+// sumCounts returns the total of all counts.
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// topValues returns the most common values with their shares.
+func topValues(counts map[string]int, total int) []types.ValueShare {
+	shares := make([]types.ValueShare, 0, len(counts))
+	for v, n := range counts {
+		shares = append(shares, types.ValueShare{
+			Value: v,
+			Count: n,
+			Share: math.Round(float64(n)/float64(total)*1000) / 1000,
+		})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Count != shares[j].Count {
+			return shares[i].Count > shares[j].Count
+		}
+		return shares[i].Value < shares[j].Value
+	})
+	if len(shares) > maxGroupValues {
+		shares = shares[:maxGroupValues]
+	}
+	return shares
+}
@@ -0,0 +1,111 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// groupEntry builds the features of one entry with the given JA4, headers
+// in order, and a per-request header value.
+func groupEntry(ja4, nonce string, headers ...string) entryFeatures {
+	ef := entryFeatures{values: map[featureKey]string{
+		{kind: types.SeparatorJA4}:                          ja4,
+		{kind: types.SeparatorHTTPVersion}:                  "h2",
+		{kind: types.SeparatorHeaderValue, name: "x-nonce"}: nonce,
+	}}
+	for _, h := range headers {
+		ef.headers = append(ef.headers, h)
+		ef.values[featureKey{kind: types.SeparatorHeaderValue, name: h}] = "v"
+	}
+	return ef
+}
+
+func TestScoreGroups(t *testing.T) {
+	baseline := []entryFeatures{
+		groupEntry("t13d1516h2_a", "1", "sec-ch-ua", "user-agent", "accept"),
+		groupEntry("t13d1516h2_a", "2", "sec-ch-ua", "user-agent", "accept"),
+		groupEntry("t13d1516h2_a", "3", "sec-ch-ua", "user-agent", "accept"),
+		groupEntry("t13d1516h2_a", "4", "user-agent", "accept"),
+	}
+	candidate := []entryFeatures{
+		groupEntry("t13d0912h2_b", "5", "accept", "user-agent"),
+		groupEntry("t13d0912h2_b", "6", "accept", "user-agent"),
+		groupEntry("t13d1516h2_a", "7", "accept", "user-agent"),
+		groupEntry("t13d0912h2_b", "8", "accept", "user-agent"),
+	}
+
+	scores := make(map[string]types.Separator)
+	for _, s := range scoreGroups(baseline, candidate) {
+		scores[s.Kind+" "+s.Name] = s
+	}
+
+	ja4 := scores["ja4 "]
+	assert.Equal(t, 0.75, ja4.Score)
+	assert.Equal(t, []types.ValueShare{{Value: "t13d1516h2_a", Count: 4, Share: 1}}, ja4.Baseline)
+	assert.Equal(t, []types.ValueShare{
+		{Value: "t13d0912h2_b", Count: 3, Share: 0.75},
+		{Value: "t13d1516h2_a", Count: 1, Share: 0.25},
+	}, ja4.Candidate)
+
+	assert.Equal(t, 0.75, scores["header_presence sec-ch-ua"].Score)
+	assert.Equal(t, 1.0, scores["header_order accept vs user-agent"].Score)
+	assert.Equal(t, 0.0, scores["http_version "].Score)
+	assert.Equal(t, 0.0, scores["header_presence accept"].Score)
+
+	// Per-request values separate any two groups and are not compared
+	_, ok := scores["header_value x-nonce"]
+	assert.False(t, ok)
+	// sec-ch-ua is not common to both groups, so its order is not compared
+	_, ok = scores["header_order sec-ch-ua vs user-agent"]
+	assert.False(t, ok)
+}
+
+func TestObserveEntry(t *testing.T) {
+	fp := &types.Fingerprint{
+		Entry: &types.EntrySummary{
+			HTTPVersion: "HTTP/2",
+			TLS:         types.TLSSummary{JA4: "t13d1516h2_a"},
+		},
+		HeadersOrdered: [][]string{
+			{":method", "GET"},
+			{"User-Agent", "Mozilla"},
+			{"Cookie", "a=1"},
+			{"Accept", "*/*"},
+			{"accept", "text/html"},
+		},
+		HTTP2PseudoHeaders: [][]string{{":method", "GET"}, {":authority", "x"}, {":scheme", "https"}, {":path", "/"}},
+	}
+
+	ef := observeEntry(fp, []types.HTTP2Setting{{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 65536}, {ID: 4, Name: "INITIAL_WINDOW_SIZE", Value: 6291456}}, map[string]bool{"cookie": true})
+	assert.Equal(t, []string{"user-agent", "accept"}, ef.headers)
+
+	want := map[featureKey]string{
+		{kind: types.SeparatorHTTPVersion}:                            "http/2",
+		{kind: types.SeparatorJA3}:                                    "none",
+		{kind: types.SeparatorJA4}:                                    "t13d1516h2_a",
+		{kind: types.SeparatorHeaderValue, name: "user-agent"}:        "Mozilla",
+		{kind: types.SeparatorHeaderValue, name: "accept"}:            "*/*, text/html",
+		{kind: types.SeparatorH2PseudoOrder}:                          ":method,:authority,:scheme,:path",
+		{kind: types.SeparatorH2Settings}:                             "1:65536;4:6291456",
+		{kind: types.SeparatorH2Setting, name: "HEADER_TABLE_SIZE"}:   "65536",
+		{kind: types.SeparatorH2Setting, name: "INITIAL_WINDOW_SIZE"}: "6291456",
+	}
+	require.Len(t, ef.values, len(want))
+	assert.Equal(t, want, ef.values)
+}
+
+func TestObserveEntry_HTTP1Casing(t *testing.T) {
+	fp := &types.Fingerprint{
+		Entry:          &types.EntrySummary{HTTPVersion: "http/1.1"},
+		HeadersOrdered: [][]string{{"Host", "x"}, {"User-Agent", "Go-http-client/1.1"}, {"user-agent", "again"}},
+		HTTP1Headers:   &types.HTTP1Headers{Names: []string{"Host", "User-Agent", "user-agent"}},
+	}
+
+	ef := observeEntry(fp, nil, nil)
+	assert.Equal(t, "Host", ef.values[featureKey{kind: types.SeparatorHeaderCasing, name: "host"}])
+	assert.Equal(t, "User-Agent", ef.values[featureKey{kind: types.SeparatorHeaderCasing, name: "user-agent"}])
+}
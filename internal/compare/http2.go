@@ -0,0 +1,277 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// maxPriorityStreams is how many of a connection's first streams are read for
+// PRIORITY frames. Browsers that build a priority tree do it before their
+// first requests, on the lowest stream IDs.
+const maxPriorityStreams = 10
+
+// http2SettingNames maps SETTINGS identifiers (RFC 9113 and extensions) to names.
+var http2SettingNames = map[int]string{
+	1: "HEADER_TABLE_SIZE",
+	2: "ENABLE_PUSH",
+	3: "MAX_CONCURRENT_STREAMS",
+	4: "INITIAL_WINDOW_SIZE",
+	5: "MAX_FRAME_SIZE",
+	6: "MAX_HEADER_LIST_SIZE",
+	8: "ENABLE_CONNECT_PROTOCOL",
+	9: "NO_RFC7540_PRIORITIES",
+}
+
+// http2SettingName returns the registered name of a SETTINGS identifier, or
+// the identifier itself.
+func http2SettingName(id int) string {
+	if name, ok := http2SettingNames[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+// http2Connection holds the connection-level frames the client sent, which
+// make up most of the Akamai HTTP/2 fingerprint.
+type http2Connection struct {
+	Settings     []types.HTTP2Setting
+	WindowUpdate int64
+	Priorities   []types.HTTP2Priority
+}
+
+// http2Frame is the part of an HTTP/2 frame event read for fingerprinting.
+// Frames follow the layout of TLS events: a side, a type, and type-specific
+// content. Frames without a content object carry their fields inline.
+type http2Frame struct {
+	Side    string          `json:"side"`
+	Type    string          `json:"type"`
+	Content json.RawMessage `json:"content"`
+}
+
+// body returns the type-specific fields of the frame.
+func (f *http2Frame) body(raw json.RawMessage) json.RawMessage {
+	if len(f.Content) > 0 && !bytes.Equal(f.Content, []byte("null")) {
+		return f.Content
+	}
+	return raw
+}
+
+// is reports whether the client sent a frame of the given type. Types
+// compare without case or separators, so "WINDOW_UPDATE" matches
+// "windowUpdate".
+func (f *http2Frame) is(frameType string) bool {
+	if f.Side != "" && f.Side != "client" {
+		return false
+	}
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(f.Type))
+	return normalized == frameType
+}
+
+// http2SettingID decodes a SETTINGS identifier given as a number, a
+// {"value", "name"} pair, or a registered name.
+type http2SettingID int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (id *http2SettingID) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*id = http2SettingID(n)
+		return nil
+	}
+	var named struct {
+		Value int `json:"value"`
+	}
+	if err := json.Unmarshal(data, &named); err == nil && named.Value != 0 {
+		*id = http2SettingID(named.Value)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("decoding HTTP/2 setting identifier: %w", err)
+	}
+	name = strings.TrimPrefix(strings.ToUpper(name), "SETTINGS_")
+	for n, known := range http2SettingNames {
+		if known == name {
+			*id = http2SettingID(n)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown HTTP/2 setting %q", name)
+}
+
+// parseHTTP2Settings returns the parameters of the first non-empty SETTINGS
+// frame sent by the client, in the order they were sent.
+func parseHTTP2Settings(frames []json.RawMessage) []types.HTTP2Setting {
+	for _, raw := range frames {
+		var frame http2Frame
+		if err := json.Unmarshal(raw, &frame); err != nil || !frame.is("settings") {
+			continue
+		}
+		var content struct {
+			Settings []struct {
+				ID    http2SettingID `json:"id"`
+				Value int64          `json:"value"`
+			} `json:"settings"`
+		}
+		if err := json.Unmarshal(frame.body(raw), &content); err != nil || len(content.Settings) == 0 {
+			continue
+		}
+		settings := make([]types.HTTP2Setting, 0, len(content.Settings))
+		for _, s := range content.Settings {
+			settings = append(settings, types.HTTP2Setting{ID: int(s.ID), Name: http2SettingName(int(s.ID)), Value: s.Value})
+		}
+		return settings
+	}
+	return nil
+}
+
+// parseHTTP2WindowUpdate returns the increment of the first WINDOW_UPDATE
+// frame sent by the client, or 0.
+func parseHTTP2WindowUpdate(frames []json.RawMessage) int64 {
+	for _, raw := range frames {
+		var frame http2Frame
+		if err := json.Unmarshal(raw, &frame); err != nil || !frame.is("windowupdate") {
+			continue
+		}
+		var content struct {
+			Increment           int64 `json:"increment"`
+			WindowSizeIncrement int64 `json:"windowSizeIncrement"`
+		}
+		if err := json.Unmarshal(frame.body(raw), &content); err != nil {
+			continue
+		}
+		if increment := max(content.Increment, content.WindowSizeIncrement); increment > 0 {
+			return increment
+		}
+	}
+	return 0
+}
+
+// parseHTTP2Priorities returns the PRIORITY frames the client sent on a stream.
+func parseHTTP2Priorities(frames []json.RawMessage, streamID int) []types.HTTP2Priority {
+	var priorities []types.HTTP2Priority
+	for _, raw := range frames {
+		var frame http2Frame
+		if err := json.Unmarshal(raw, &frame); err != nil || !frame.is("priority") {
+			continue
+		}
+		var content struct {
+			Exclusive        bool `json:"exclusive"`
+			Dependency       int  `json:"dependency"`
+			StreamDependency int  `json:"streamDependency"`
+			Weight           int  `json:"weight"`
+		}
+		if err := json.Unmarshal(frame.body(raw), &content); err != nil {
+			continue
+		}
+		priorities = append(priorities, types.HTTP2Priority{
+			StreamID:  streamID,
+			Exclusive: content.Exclusive,
+			DependsOn: max(content.Dependency, content.StreamDependency),
+			Weight:    content.Weight,
+		})
+	}
+	return priorities
+}
+
+// formatHTTP2Settings renders settings as "id:value" pairs joined by ";",
+// the SETTINGS part of an Akamai HTTP/2 fingerprint.
+func formatHTTP2Settings(settings []types.HTTP2Setting) string {
+	parts := make([]string, 0, len(settings))
+	for _, s := range settings {
+		parts = append(parts, fmt.Sprintf("%d:%d", s.ID, s.Value))
+	}
+	return strings.Join(parts, ";")
+}
+
+// formatHTTP2Priorities renders PRIORITY frames as
+// "stream:exclusive:depends_on:weight" joined by ",", or "0" if there are
+// none, the PRIORITY part of an Akamai HTTP/2 fingerprint.
+func formatHTTP2Priorities(priorities []types.HTTP2Priority) string {
+	if len(priorities) == 0 {
+		return "0"
+	}
+	parts := make([]string, 0, len(priorities))
+	for _, p := range priorities {
+		exclusive := 0
+		if p.Exclusive {
+			exclusive = 1
+		}
+		parts = append(parts, fmt.Sprintf("%d:%d:%d:%d", p.StreamID, exclusive, p.DependsOn, p.Weight))
+	}
+	return strings.Join(parts, ",")
+}
+
+// pseudoHeaderOrder renders pseudo-headers by their first letter, e.g.
+// "m,a,s,p" for :method, :authority, :scheme, :path.
+func pseudoHeaderOrder(pseudoHeaders [][]string) string {
+	letters := make([]string, 0, len(pseudoHeaders))
+	for _, pair := range pseudoHeaders {
+		if name := strings.TrimPrefix(pair[0], ":"); name != "" {
+			letters = append(letters, name[:1])
+		}
+	}
+	return strings.Join(letters, ",")
+}
+
+// akamaiFingerprint renders the Akamai HTTP/2 fingerprint
+// SETTINGS|WINDOW_UPDATE|PRIORITY|pseudo-header order. It is empty when the
+// client's SETTINGS are unknown, since the frames were then not recorded.
+func akamaiFingerprint(conn *http2Connection, pseudoOrder string) string {
+	if conn == nil || len(conn.Settings) == 0 {
+		return ""
+	}
+	windowUpdate := "00"
+	if conn.WindowUpdate > 0 {
+		windowUpdate = strconv.FormatInt(conn.WindowUpdate, 10)
+	}
+	return strings.Join([]string{
+		formatHTTP2Settings(conn.Settings),
+		windowUpdate,
+		formatHTTP2Priorities(conn.Priorities),
+		pseudoOrder,
+	}, "|")
+}
+
+// fetchHTTP2Connection reads the client's connection-level frames (stream 0)
+// and the PRIORITY frames on the connection's first streams.
+func (f *FingerprintEngine) fetchHTTP2Connection(ctx context.Context, connID string) (*http2Connection, error) {
+	frames, err := f.client.GetHTTP2Stream(ctx, connID, 0)
+	if err != nil {
+		return nil, err
+	}
+	conn := &http2Connection{
+		Settings:     parseHTTP2Settings(frames),
+		WindowUpdate: parseHTTP2WindowUpdate(frames),
+	}
+
+	// PRIORITY frames are best effort: without them the fingerprint reads "0"
+	streamIDs, err := f.client.ListHTTP2StreamIDs(ctx, connID)
+	if err != nil {
+		return conn, nil
+	}
+	sort.Ints(streamIDs)
+	read := 0
+	for _, id := range streamIDs {
+		if id == 0 {
+			continue
+		}
+		if read == maxPriorityStreams {
+			break
+		}
+		read++
+		streamFrames, err := f.client.GetHTTP2Stream(ctx, connID, id)
+		if err != nil {
+			continue
+		}
+		conn.Priorities = append(conn.Priorities, parseHTTP2Priorities(streamFrames, id)...)
+	}
+	return conn, nil
+}
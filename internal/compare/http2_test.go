@@ -0,0 +1,83 @@
+package compare
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestParseHTTP2Settings(t *testing.T) {
+	frames := []json.RawMessage{
+		json.RawMessage(`{"side":"server","type":"settings","content":{"settings":[{"id":3,"value":100}]}}`),
+		json.RawMessage(`{"side":"client","type":"SETTINGS","content":{"settings":[]}}`),
+		json.RawMessage(`{"side":"client","type":"settings","content":{"settings":[` +
+			`{"id":1,"value":65536},` +
+			`{"id":{"value":2,"name":"ENABLE_PUSH"},"value":0},` +
+			`{"id":"SETTINGS_INITIAL_WINDOW_SIZE","value":6291456},` +
+			`{"id":"max_header_list_size","value":262144}]}}`),
+	}
+	settings := parseHTTP2Settings(frames)
+	assert.Equal(t, []types.HTTP2Setting{
+		{ID: 1, Name: "HEADER_TABLE_SIZE", Value: 65536},
+		{ID: 2, Name: "ENABLE_PUSH", Value: 0},
+		{ID: 4, Name: "INITIAL_WINDOW_SIZE", Value: 6291456},
+		{ID: 6, Name: "MAX_HEADER_LIST_SIZE", Value: 262144},
+	}, settings)
+	assert.Equal(t, "1:65536;2:0;4:6291456;6:262144", formatHTTP2Settings(settings))
+	assert.Equal(t, "42", http2SettingName(42))
+
+	// Fields may be inline rather than under content
+	inline := parseHTTP2Settings([]json.RawMessage{
+		json.RawMessage(`{"type":"settings","settings":[{"id":3,"value":1000}]}`),
+	})
+	assert.Equal(t, []types.HTTP2Setting{{ID: 3, Name: "MAX_CONCURRENT_STREAMS", Value: 1000}}, inline)
+
+	assert.Nil(t, parseHTTP2Settings([]json.RawMessage{json.RawMessage(`{"type":"window_update"}`)}))
+}
+
+func TestParseHTTP2WindowUpdate(t *testing.T) {
+	assert.Equal(t, int64(15663105), parseHTTP2WindowUpdate([]json.RawMessage{
+		json.RawMessage(`{"side":"server","type":"WINDOW_UPDATE","content":{"increment":1}}`),
+		json.RawMessage(`{"side":"client","type":"WINDOW_UPDATE","content":{"increment":15663105}}`),
+		json.RawMessage(`{"side":"client","type":"WINDOW_UPDATE","content":{"increment":2}}`),
+	}))
+	assert.Equal(t, int64(12517377), parseHTTP2WindowUpdate([]json.RawMessage{
+		json.RawMessage(`{"type":"windowUpdate","windowSizeIncrement":12517377}`),
+	}))
+	assert.Zero(t, parseHTTP2WindowUpdate(nil))
+}
+
+func TestAkamaiFingerprint(t *testing.T) {
+	priorities := parseHTTP2Priorities([]json.RawMessage{
+		json.RawMessage(`{"side":"client","type":"PRIORITY","content":{"exclusive":false,"dependency":0,"weight":201}}`),
+		json.RawMessage(`{"side":"client","type":"HEADERS"}`),
+	}, 3)
+	assert.Equal(t, []types.HTTP2Priority{{StreamID: 3, Weight: 201}}, priorities)
+
+	order := pseudoHeaderOrder([][]string{{":method", "GET"}, {":path", "/"}, {":authority", "x"}, {":scheme", "https"}})
+	assert.Equal(t, "m,p,a,s", order)
+
+	conn := &http2Connection{
+		Settings: []types.HTTP2Setting{
+			{ID: 1, Value: 65536},
+			{ID: 4, Value: 131072},
+			{ID: 5, Value: 16384},
+		},
+		WindowUpdate: 12517377,
+		Priorities: append(priorities,
+			types.HTTP2Priority{StreamID: 5, Weight: 101},
+			types.HTTP2Priority{StreamID: 13, Exclusive: true, DependsOn: 5, Weight: 241},
+		),
+	}
+	assert.Equal(t, "1:65536;4:131072;5:16384|12517377|3:0:0:201,5:0:0:101,13:1:5:241|m,p,a,s", akamaiFingerprint(conn, order))
+
+	conn.WindowUpdate, conn.Priorities = 0, nil
+	assert.Equal(t, "1:65536;4:131072;5:16384|00|0|m,p,a,s", akamaiFingerprint(conn, order))
+
+	// Without the client's SETTINGS the frames were not recorded
+	assert.Empty(t, akamaiFingerprint(&http2Connection{WindowUpdate: 1}, order))
+	assert.Empty(t, akamaiFingerprint(nil, order))
+}
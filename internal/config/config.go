@@ -4,6 +4,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/usestring/powhttp-mcp/pkg/jsoncompact"
@@ -38,9 +39,23 @@ type Config struct {
 	TLSMaxEventsDefault  int           // TLS_MAX_EVENTS_DEFAULT, default 200
 	H2MaxEventsDefault   int           // H2_MAX_EVENTS_DEFAULT, default 200
 	EntryCacheMaxItems   int           // ENTRY_CACHE_MAX_ITEMS, default 512
+	ExportCacheMaxItems  int           // EXPORT_CACHE_MAX_ITEMS, default 8
+	ExportDir            string        // EXPORT_DIR, default "" (exports are not written to disk)
 	ResourceMaxBodyBytes int           // RESOURCE_MAX_BODY_BYTES, default 65536 (64KB)
 	IndexBody            bool          // INDEX_BODY, default false
 	IndexBodyMaxBytes    int           // INDEX_BODY_MAX_BYTES, default 65536
+	IndexSnapshotDir     string        // INDEX_SNAPSHOT_DIR, default "" (snapshots disabled)
+	IndexSnapshotEntries bool          // INDEX_SNAPSHOT_ENTRIES, default false (store cached entries with bodies)
+	ClientProfilesFile   string        // CLIENT_PROFILES_FILE, default "" (user client profiles are kept in memory only)
+
+	// Index snapshot batching (saves walk the whole session, so small refreshes are batched)
+	IndexSnapshotInterval   time.Duration // INDEX_SNAPSHOT_INTERVAL_MS, default 60000ms (1m) between saves
+	IndexSnapshotMinEntries int           // INDEX_SNAPSHOT_MIN_ENTRIES, default 5000 (save sooner once this many entries are pending)
+
+	// Replay (requests are re-sent through PowHTTPProxyURL)
+	ReplayTimeout     time.Duration // REPLAY_TIMEOUT_MS, default 30000ms (30s)
+	ReplayCaptureWait time.Duration // REPLAY_CAPTURE_WAIT_MS, default 5000ms (how long to wait for powhttp to record a replay)
+	ReplayInsecureTLS bool          // REPLAY_INSECURE_TLS, default false (skip verifying the proxy's interception certificates)
 
 	// Compaction defaults (for AI-optimized responses)
 	CompactMaxArrayItems int // COMPACT_MAX_ARRAY_ITEMS
@@ -65,6 +80,12 @@ type Config struct {
 	LogMaxBackups int    // LOG_MAX_BACKUPS, default 3
 	LogMaxAgeDays int    // LOG_MAX_AGE_DAYS, default 28
 	LogCompress   bool   // LOG_COMPRESS, default true
+
+	// HTTP transport (stdio is used when HTTPAddr is empty)
+	HTTPAddr           string   // MCP_HTTP_ADDR, default "" (stdio)
+	HTTPAllowedOrigins []string // MCP_HTTP_ALLOWED_ORIGINS, comma-separated, default none (localhost only)
+	HTTPAllowedHosts   []string // MCP_HTTP_ALLOWED_HOSTS, comma-separated, default none (loopback only when bound to loopback)
+	HTTPBearerToken    string   // MCP_HTTP_BEARER_TOKEN, default "" (no auth, loopback only)
 }
 
 // Load reads configuration from environment variables with sensible defaults.
@@ -82,9 +103,21 @@ func Load() *Config {
 		TLSMaxEventsDefault:  getEnvInt("TLS_MAX_EVENTS_DEFAULT", 200),
 		H2MaxEventsDefault:   getEnvInt("H2_MAX_EVENTS_DEFAULT", 200),
 		EntryCacheMaxItems:   getEnvInt("ENTRY_CACHE_MAX_ITEMS", 512),
+		ExportCacheMaxItems:  getEnvInt("EXPORT_CACHE_MAX_ITEMS", 8),
+		ExportDir:            getEnvString("EXPORT_DIR", ""),
 		ResourceMaxBodyBytes: getEnvInt("RESOURCE_MAX_BODY_BYTES", 65536),
 		IndexBody:            getEnvBool("INDEX_BODY", false),
 		IndexBodyMaxBytes:    getEnvInt("INDEX_BODY_MAX_BYTES", 65536),
+		IndexSnapshotDir:     getEnvString("INDEX_SNAPSHOT_DIR", ""),
+		IndexSnapshotEntries: getEnvBool("INDEX_SNAPSHOT_ENTRIES", false),
+		ClientProfilesFile:   getEnvString("CLIENT_PROFILES_FILE", ""),
+
+		IndexSnapshotInterval:   getEnvDurationMs("INDEX_SNAPSHOT_INTERVAL_MS", 60000),
+		IndexSnapshotMinEntries: getEnvInt("INDEX_SNAPSHOT_MIN_ENTRIES", 5000),
+
+		ReplayTimeout:     getEnvDurationMs("REPLAY_TIMEOUT_MS", 30000),
+		ReplayCaptureWait: getEnvDurationMs("REPLAY_CAPTURE_WAIT_MS", 5000),
+		ReplayInsecureTLS: getEnvBool("REPLAY_INSECURE_TLS", false),
 
 		// Compaction defaults (from jsoncompact package)
 		CompactMaxArrayItems: getEnvInt("COMPACT_MAX_ARRAY_ITEMS", jsoncompact.DefaultMaxArrayItems),
@@ -107,6 +140,11 @@ func Load() *Config {
 		LogMaxBackups: getEnvInt("LOG_MAX_BACKUPS", 5),
 		LogMaxAgeDays: getEnvInt("LOG_MAX_AGE_DAYS", 28),
 		LogCompress:   getEnvBool("LOG_COMPRESS", true),
+
+		HTTPAddr:           getEnvString("MCP_HTTP_ADDR", ""),
+		HTTPAllowedOrigins: getEnvStringSlice("MCP_HTTP_ALLOWED_ORIGINS"),
+		HTTPAllowedHosts:   getEnvStringSlice("MCP_HTTP_ALLOWED_HOSTS"),
+		HTTPBearerToken:    getEnvString("MCP_HTTP_BEARER_TOKEN", ""),
 	}
 }
 
@@ -129,6 +167,17 @@ func getEnvString(key, defaultVal string) string {
 	return defaultVal
 }
 
+// getEnvStringSlice splits a comma-separated variable, dropping empty items.
+func getEnvStringSlice(key string) []string {
+	var out []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
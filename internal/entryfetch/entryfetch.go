@@ -2,7 +2,6 @@ package entryfetch
 
 import (
 	"context"
-	"encoding/base64"
 
 	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/pkg/client"
@@ -10,7 +9,7 @@ import (
 
 // FetchEntry retrieves an entry by ID, checking the cache first.
 // If not cached, it fetches from the API client and caches the result.
-func FetchEntry(ctx context.Context, c *client.Client, ec *cache.EntryCache, sessionID, entryID string) (*client.SessionEntry, error) {
+func FetchEntry(ctx context.Context, c client.DataSource, ec *cache.EntryCache, sessionID, entryID string) (*client.SessionEntry, error) {
 	if cached, ok := ec.Get(entryID); ok {
 		return cached, nil
 	}
@@ -24,35 +23,49 @@ func FetchEntry(ctx context.Context, c *client.Client, ec *cache.EntryCache, ses
 	return entry, nil
 }
 
-// DecodeBody extracts and base64-decodes the body for a given target
-// ("request" or "response"). Returns the decoded bytes and the content-type
-// header value. Does not filter by content type.
+// DecodeBody extracts and decodes the body for a given target ("request" or
+// "response"): base64, then any gzip, deflate, zstd, or br Content-Encoding.
+// Returns the decoded bytes and the content-type header value. Does not
+// filter by content type.
 //
 // Returns (nil, "", nil) when the entry has no response (target "response")
 // or the body is nil/empty.
 func DecodeBody(entry *client.SessionEntry, target string) ([]byte, string, error) {
 	var body *string
-	var contentType string
+	var headers client.Headers
 
 	if target == "request" {
 		body = entry.Request.Body
-		contentType = entry.Request.Headers.Get("content-type")
+		headers = entry.Request.Headers
 	} else {
 		if entry.Response == nil {
 			return nil, "", nil
 		}
 		body = entry.Response.Body
-		contentType = entry.Response.Headers.Get("content-type")
+		headers = entry.Response.Headers
 	}
+	contentType := headers.Get("content-type")
 
 	if body == nil || *body == "" {
 		return nil, contentType, nil
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(*body)
+	decoded, err := client.DecodeContent(body, client.ContentEncoding(headers))
 	if err != nil {
 		return nil, contentType, err
 	}
 
 	return decoded, contentType, nil
 }
+
+// ContentEncoding returns the normalized Content-Encoding of the target's
+// body ("request" or "response"), or "" if it is not compressed.
+func ContentEncoding(entry *client.SessionEntry, target string) string {
+	if target == "request" {
+		return client.ContentEncoding(entry.Request.Headers)
+	}
+	if entry.Response == nil {
+		return ""
+	}
+	return client.ContentEncoding(entry.Response.Headers)
+}
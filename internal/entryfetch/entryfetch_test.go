@@ -1,8 +1,13 @@
 package entryfetch
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/base64"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -114,3 +119,101 @@ func TestDecodeBody_InvalidBase64(t *testing.T) {
 	assert.Nil(t, body)
 	assert.Equal(t, "application/json", ct)
 }
+
+// zstdRawFrame wraps data in a single-segment zstd frame with one raw block.
+func zstdRawFrame(data []byte) []byte {
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x20, byte(len(data))}
+	header := 1 | len(data)<<3 // last block, raw
+	frame = append(frame, byte(header), byte(header>>8), byte(header>>16))
+	return append(frame, data...)
+}
+
+func compressedEntry(encoding string, body []byte) *client.SessionEntry {
+	entry := makeEntry("e1")
+	entry.Response.Headers = append(entry.Response.Headers, []string{"Content-Encoding", encoding})
+	entry.Response.Body = client.EncodeBody(body)
+	return entry
+}
+
+func TestDecodeBody_ContentEncoding(t *testing.T) {
+	plain := []byte(`{"key":"value"}`)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(plain)
+	gw.Close()
+
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(plain)
+	zw.Close()
+
+	var raw bytes.Buffer
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write(plain)
+	fw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"gzip", "gzip", gz.Bytes()},
+		{"x-gzip", "x-gzip", gz.Bytes()},
+		{"zlib deflate", "deflate", zl.Bytes()},
+		{"raw deflate", "deflate", raw.Bytes()},
+		{"zstd", "zstd", zstdRawFrame(plain)},
+		{"br", "br", []byte{0x0f, 0x07, 0x80, 0x7b, 0x22, 0x6b, 0x65, 0x79, 0x22, 0x3a, 0x22, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x7d, 0x03}},
+		{"identity", "identity", plain},
+		{"stacked", "deflate, gzip", func() []byte {
+			var b bytes.Buffer
+			w := gzip.NewWriter(&b)
+			w.Write(zl.Bytes())
+			w.Close()
+			return b.Bytes()
+		}()},
+		// HAR files hold decoded text while keeping the original header.
+		{"already decoded gzip", "gzip", plain},
+		{"already decoded br", "br", plain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _, err := DecodeBody(compressedEntry(tt.encoding, tt.body), "response")
+			require.NoError(t, err)
+			assert.Equal(t, plain, body)
+		})
+	}
+}
+
+func TestDecodeBody_UnsupportedEncoding(t *testing.T) {
+	_, _, err := DecodeBody(compressedEntry("compress", []byte{0x1f, 0x9d, 0x90, 0x7b}), "response")
+	assert.ErrorIs(t, err, client.ErrUnsupportedEncoding)
+}
+
+func TestDecodeBody_TruncatedBrotli(t *testing.T) {
+	_, _, err := DecodeBody(compressedEntry("br", []byte{0x1b, 0x0e, 0x00, 0xf8, 0xa5, 0x40}), "response")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestDecodeBody_DecompressionBomb(t *testing.T) {
+	var gz bytes.Buffer
+	gw, _ := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	gw.Write(make([]byte, client.MaxDecodedBodyBytes+1))
+	gw.Close()
+
+	_, _, err := DecodeBody(compressedEntry("gzip", gz.Bytes()), "response")
+	assert.ErrorIs(t, err, client.ErrBodyTooLarge)
+
+	// MaxDecodedBodyBytes+1 zero bytes
+	br := []byte{0xcf, 0xff, 0xff, 0x7f, 0xf8, 0x27, 0x00, 0xe2, 0xb1, 0x40, 0x20, 0xf7, 0xfe, 0x9f, 0xff, 0xff, 0xff, 0xf0, 0x4f, 0x00, 0xc4, 0x61, 0x01, 0x80, 0xee, 0xfd, 0x3f, 0x00, 0x00, 0x02, 0x00, 0x03}
+	_, _, err = DecodeBody(compressedEntry("br", br), "response")
+	assert.ErrorIs(t, err, client.ErrBodyTooLarge)
+}
+
+func TestContentEncoding(t *testing.T) {
+	entry := compressedEntry("GZIP", nil)
+	entry.Response.Headers = append(entry.Response.Headers, []string{"content-encoding", "identity, br"})
+
+	assert.Equal(t, "gzip, br", ContentEncoding(entry, "response"))
+	assert.Empty(t, ContentEncoding(entry, "request"))
+}
@@ -0,0 +1,399 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/usestring/powhttp-mcp/internal/entryfetch"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	// maxLineageBodyBytes caps how much of each body is scanned for values.
+	maxLineageBodyBytes = 1 << 20
+	// maxLineageEntries caps how many entries Trace fetches for lineage.
+	maxLineageEntries = 200
+	// maxJSONDepth stops walking deeply nested bodies.
+	maxJSONDepth = 32
+	// maxValuePreview is the longest value shown on an edge before truncation.
+	maxValuePreview = 48
+)
+
+// valueField is one value at a location in a request or response. Paths are
+// prefixed by where the value lives: body.<json path>, header.<name>,
+// cookie.<name>, set-cookie.<name>, query.<name>, form.<name>, path[<i>],
+// html.meta[<name>], html.input[<name>], or url.
+type valueField struct {
+	Path  string
+	Value string
+}
+
+// valueOrigin is the response that first revealed a value.
+type valueOrigin struct {
+	pos  int
+	path string
+}
+
+// valuePropagationEdges links each response value that reappears in a later
+// request, annotated with the path on both sides. A value is attributed to
+// the earliest response that revealed it; values the client sent before any
+// response revealed them are its own (API keys, device IDs) and are skipped.
+// entries must be sorted by start time.
+func valuePropagationEdges(entries []*client.SessionEntry) []types.FlowEdge {
+	var edges []types.FlowEdge
+	seen := make(map[string]bool)
+	origins := make(map[string]valueOrigin)
+	var originOrder []string // Values in discovery order, for raw body scans
+	sent := make(map[string]bool)
+
+	addEdge := func(src valueOrigin, to int, toPath, value string) {
+		from := entries[src.pos].ID
+		key := from + "->" + entries[to].ID + ":" + src.path + ">" + toPath
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		edges = append(edges, types.FlowEdge{
+			From:     from,
+			To:       entries[to].ID,
+			Reason:   types.EdgeReasonValuePropagation,
+			FromPath: src.path,
+			ToPath:   toPath,
+			Value:    previewValue(value),
+		})
+	}
+
+	for i, e := range entries {
+		// A request can only use values revealed by earlier responses.
+		fields, rawBody := requestValues(e)
+		for _, f := range fields {
+			if !isCandidateValue(f.Value) {
+				continue
+			}
+			src, ok := origins[f.Value]
+			if !ok {
+				sent[f.Value] = true
+				continue
+			}
+			addEdge(src, i, f.Path, f.Value)
+		}
+		if rawBody != "" {
+			for _, v := range originOrder {
+				if strings.Contains(rawBody, v) {
+					addEdge(origins[v], i, "body", v)
+				}
+			}
+		}
+
+		for _, f := range responseValues(e) {
+			if !isCandidateValue(f.Value) || sent[f.Value] {
+				continue
+			}
+			if _, ok := origins[f.Value]; ok {
+				continue
+			}
+			origins[f.Value] = valueOrigin{pos: i, path: f.Path}
+			originOrder = append(originOrder, f.Value)
+		}
+	}
+
+	return edges
+}
+
+// lineageEntries fetches entries for lineage analysis, sorted by start time.
+// Entries that cannot be fetched are skipped; a canceled context is an error.
+func (f *FlowEngine) lineageEntries(ctx context.Context, sessionID string, entryIDs []string) ([]*client.SessionEntry, error) {
+	entries := make([]*client.SessionEntry, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		entry, err := entryfetch.FetchEntry(ctx, f.client, f.cache, sessionID, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timings.StartedAt < entries[j].Timings.StartedAt
+	})
+	return entries, nil
+}
+
+// Lineage finds value_propagation edges among the given entries of a session:
+// response values (IDs, CSRF tokens, nonces, JWTs, signed URLs) that later
+// requests send back in their URL, headers, cookies, or body.
+func (f *FlowEngine) Lineage(ctx context.Context, sessionID string, entryIDs []string) ([]types.FlowEdge, error) {
+	entries, err := f.lineageEntries(ctx, sessionID, entryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("fetching entries: %w", err)
+	}
+	return valuePropagationEdges(entries), nil
+}
+
+// boringResponseHeaders never carry values a client feeds back. Location is
+// covered by redirect edges and Set-Cookie is parsed separately.
+var boringResponseHeaders = map[string]bool{
+	"date": true, "expires": true, "last-modified": true, "etag": true, "age": true,
+	"location": true, "set-cookie": true, "content-type": true, "content-length": true,
+	"content-encoding": true, "cache-control": true, "vary": true, "server": true,
+	"via": true, "alt-svc": true, "connection": true, "keep-alive": true,
+	"strict-transport-security": true, "content-security-policy": true,
+	"report-to": true, "nel": true, "server-timing": true,
+}
+
+// boringRequestHeaders carry client or transport settings rather than values
+// learned from responses. Cookie is parsed separately.
+var boringRequestHeaders = map[string]bool{
+	"host": true, "cookie": true, "content-type": true, "content-length": true,
+	"user-agent": true, "accept": true, "accept-encoding": true, "accept-language": true,
+	"connection": true, "referer": true, "origin": true, "if-none-match": true,
+	"if-modified-since": true, "sec-ch-ua": true, "sec-ch-ua-platform": true,
+	"sec-ch-ua-mobile": true, "sec-fetch-dest": true, "sec-fetch-mode": true,
+	"sec-fetch-site": true, "sec-fetch-user": true, "upgrade-insecure-requests": true,
+	"priority": true, "te": true,
+}
+
+// responseValues lists candidate source values in a response.
+func responseValues(e *client.SessionEntry) []valueField {
+	if e.Response == nil {
+		return nil
+	}
+	var fields []valueField
+	for _, h := range e.Response.Headers {
+		if len(h) < 2 {
+			continue
+		}
+		name := strings.ToLower(h[0])
+		switch {
+		case name == "set-cookie":
+			if cname, cvalue, ok := strings.Cut(strings.SplitN(h[1], ";", 2)[0], "="); ok {
+				fields = append(fields, valueField{Path: "set-cookie." + strings.TrimSpace(cname), Value: strings.TrimSpace(cvalue)})
+			}
+		case !boringResponseHeaders[name] && !strings.HasPrefix(name, ":"):
+			fields = append(fields, valueField{Path: "header." + name, Value: h[1]})
+		}
+	}
+
+	body, contentType, err := entryfetch.DecodeBody(e, "response")
+	if err != nil || len(body) == 0 || len(body) > maxLineageBodyBytes {
+		return fields
+	}
+	switch {
+	case isJSONBody(contentType, body):
+		fields = append(fields, jsonValues(body, "body")...)
+	case strings.Contains(strings.ToLower(contentType), "html"):
+		fields = append(fields, htmlValues(body)...)
+	}
+	return fields
+}
+
+// requestValues lists the values a request sends. Bodies that are neither
+// JSON nor form-encoded are returned as text for substring matching.
+func requestValues(e *client.SessionEntry) ([]valueField, string) {
+	var fields []valueField
+
+	if u, err := url.Parse(e.URL); err == nil {
+		u.Fragment = ""
+		fields = append(fields, valueField{Path: "url", Value: u.String()})
+		i := 0
+		for _, seg := range strings.Split(u.EscapedPath(), "/") {
+			if seg == "" {
+				continue
+			}
+			if unescaped, err := url.PathUnescape(seg); err == nil {
+				seg = unescaped
+			}
+			fields = append(fields, valueField{Path: "path[" + strconv.Itoa(i) + "]", Value: seg})
+			i++
+		}
+		fields = append(fields, formValues(u.Query(), "query")...)
+	}
+
+	for _, h := range e.Request.Headers {
+		if len(h) < 2 {
+			continue
+		}
+		name := strings.ToLower(h[0])
+		switch {
+		case name == "cookie":
+			for _, pair := range strings.Split(h[1], ";") {
+				if cname, cvalue, ok := strings.Cut(pair, "="); ok {
+					fields = append(fields, valueField{Path: "cookie." + strings.TrimSpace(cname), Value: strings.TrimSpace(cvalue)})
+				}
+			}
+		case !boringRequestHeaders[name] && !strings.HasPrefix(name, ":"):
+			fields = append(fields, valueField{Path: "header." + name, Value: h[1]})
+			// "Bearer <token>", "Token <token>"
+			if _, token, ok := strings.Cut(h[1], " "); ok {
+				fields = append(fields, valueField{Path: "header." + name, Value: strings.TrimSpace(token)})
+			}
+		}
+	}
+
+	body, contentType, err := entryfetch.DecodeBody(e, "request")
+	if err != nil || len(body) == 0 || len(body) > maxLineageBodyBytes {
+		return fields, ""
+	}
+	switch {
+	case isJSONBody(contentType, body):
+		fields = append(fields, jsonValues(body, "body")...)
+	case strings.Contains(strings.ToLower(contentType), "x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			fields = append(fields, formValues(form, "form")...)
+		}
+	case utf8.Valid(body):
+		return fields, string(body)
+	}
+	return fields, ""
+}
+
+// formValues flattens query or form parameters in key order.
+func formValues(values url.Values, prefix string) []valueField {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var fields []valueField
+	for _, k := range keys {
+		for _, v := range values[k] {
+			fields = append(fields, valueField{Path: prefix + "." + k, Value: v})
+		}
+	}
+	return fields
+}
+
+func isJSONBody(contentType string, body []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
+
+// jsonValues flattens the scalar leaves of a JSON document. Numbers keep
+// their literal text so that IDs match the way they are sent.
+func jsonValues(body []byte, prefix string) []valueField {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	var fields []valueField
+	var walk func(v any, path string, depth int)
+	walk = func(v any, path string, depth int) {
+		if depth > maxJSONDepth {
+			return
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(val[k], path+"."+k, depth+1)
+			}
+		case []any:
+			for i, item := range val {
+				walk(item, path+"["+strconv.Itoa(i)+"]", depth+1)
+			}
+		case string:
+			fields = append(fields, valueField{Path: path, Value: val})
+		case json.Number:
+			fields = append(fields, valueField{Path: path, Value: val.String()})
+		}
+	}
+	walk(doc, prefix, 0)
+	return fields
+}
+
+var (
+	htmlTagRe  = regexp.MustCompile(`(?is)<(meta|input)\s[^>]*>`)
+	htmlAttrRe = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// htmlValues extracts <meta name content> and <input name value> pairs,
+// where pages usually embed CSRF tokens and nonces.
+func htmlValues(body []byte) []valueField {
+	var fields []valueField
+	for _, m := range htmlTagRe.FindAllSubmatch(body, -1) {
+		attrs := make(map[string]string)
+		for _, a := range htmlAttrRe.FindAllSubmatch(m[0], -1) {
+			attrs[strings.ToLower(string(a[1]))] = string(a[2]) + string(a[3])
+		}
+		tag := strings.ToLower(string(m[1]))
+		name, value := attrs["name"], attrs["value"]
+		if tag == "meta" {
+			value = attrs["content"]
+			if name == "" {
+				name = attrs["property"]
+			}
+		}
+		if name != "" && value != "" {
+			fields = append(fields, valueField{Path: "html." + tag + "[" + name + "]", Value: value})
+		}
+	}
+	return fields
+}
+
+// isCandidateValue reports whether a value is distinctive enough that seeing
+// it again is evidence of propagation: numeric IDs of six or more digits
+// (but not epoch timestamps), URLs, and token-like strings of eight or more
+// characters that mix digits and letters or upper and lower case.
+func isCandidateValue(v string) bool {
+	if len(v) < 6 || len(v) > 4096 {
+		return false
+	}
+	var digits, lower, upper, space int
+	for _, r := range v {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsSpace(r):
+			space++
+		}
+	}
+	if space > 0 {
+		return false
+	}
+	if digits == len(v) {
+		// Unix timestamps in seconds or milliseconds are everywhere and unrelated.
+		return !((len(v) == 10 || len(v) == 13) && v[0] == '1')
+	}
+	if strings.HasPrefix(v, "https://") || strings.HasPrefix(v, "http://") {
+		return true
+	}
+	if len(v) < 8 {
+		return false
+	}
+	return (digits > 0 && lower+upper > 0) || (len(v) >= 16 && lower > 0 && upper > 0)
+}
+
+func previewValue(v string) string {
+	if len(v) <= maxValuePreview {
+		return v
+	}
+	n := maxValuePreview
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n] + "..."
+}
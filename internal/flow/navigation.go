@@ -0,0 +1,154 @@
+package flow
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// navigationEdges links entries that a browser or client chained together:
+// redirects via Location, and page loads via Referer or Origin. Unlike the
+// other edge kinds these are meaningful across hosts. entries must be sorted
+// by timestamp.
+func navigationEdges(entries []*indexer.EntryMeta) []types.FlowEdge {
+	var edges []types.FlowEdge
+
+	// Entries by normalized URL, in timestamp order
+	byURL := make(map[string][]int)
+	for i, e := range entries {
+		if key := normalizeURL(e.URL); key != "" {
+			byURL[key] = append(byURL[key], i)
+		}
+	}
+
+	for i, e := range entries {
+		// Redirect: the first later request for the resolved Location
+		if e.Status >= 300 && e.Status < 400 && e.Location != "" {
+			if target := resolveLocation(e.URL, e.Location); target != "" {
+				for _, j := range byURL[target] {
+					if j > i {
+						edges = append(edges, types.FlowEdge{From: e.EntryID, To: entries[j].EntryID, Reason: types.EdgeReasonRedirect})
+						break
+					}
+				}
+			}
+		}
+
+		// Referer: the latest earlier request for the referring URL. Referrer
+		// policies often cut Referer down to the origin, and CORS requests may
+		// only carry Origin, so fall back to the latest page on that origin.
+		var page *indexer.EntryMeta
+		if e.Referer != "" {
+			if j := latestBefore(byURL[normalizeURL(e.Referer)], i); j >= 0 {
+				page = entries[j]
+			}
+		}
+		if page == nil {
+			origin := ""
+			switch {
+			case e.Referer != "" && isOriginOnly(e.Referer):
+				origin = originOf(e.Referer)
+			case e.Referer == "" && e.Origin != "" && e.Origin != "null":
+				origin = originOf(e.Origin)
+			}
+			if origin != "" {
+				for j := i - 1; j >= 0; j-- {
+					if isHTMLPage(entries[j]) && originOf(entries[j].URL) == origin {
+						page = entries[j]
+						break
+					}
+				}
+			}
+		}
+		if page != nil && page.EntryID != e.EntryID {
+			reason := types.EdgeReasonReferer
+			if isHTMLPage(page) && !isDocumentRequest(e) {
+				reason = types.EdgeReasonInitiatorPage
+			}
+			edges = append(edges, types.FlowEdge{From: page.EntryID, To: e.EntryID, Reason: reason})
+		}
+	}
+
+	return edges
+}
+
+// latestBefore returns the largest index in positions that is less than i, or -1.
+func latestBefore(positions []int, i int) int {
+	best := -1
+	for _, j := range positions {
+		if j >= i {
+			break
+		}
+		best = j
+	}
+	return best
+}
+
+// normalizeURL canonicalizes a URL for matching: lowercase scheme and host,
+// default ports and fragments dropped, empty path as "/".
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "https" && u.Port() == "443") || (u.Scheme == "http" && u.Port() == "80") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// resolveLocation resolves a Location header against the request URL.
+func resolveLocation(requestURL, location string) string {
+	base, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(strings.TrimSpace(location))
+	if err != nil {
+		return ""
+	}
+	return normalizeURL(base.ResolveReference(ref).String())
+}
+
+// originOf returns scheme://host of a URL, or "" if it has no host.
+func originOf(raw string) string {
+	u, err := url.Parse(normalizeURL(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// isOriginOnly reports whether a Referer carries no path or query.
+func isOriginOnly(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// isHTMLPage reports whether an entry loaded an HTML document.
+func isHTMLPage(e *indexer.EntryMeta) bool {
+	return e.RespContentType == "text/html" || e.RespContentType == "application/xhtml+xml"
+}
+
+// isDocumentRequest reports whether an entry is a navigation rather than a
+// subresource. Sec-Fetch-Dest decides when present; otherwise an HTML
+// response is taken as a navigation.
+func isDocumentRequest(e *indexer.EntryMeta) bool {
+	switch e.FetchDest {
+	case "document", "iframe", "frame":
+		return true
+	case "":
+		return isHTMLPage(e)
+	default:
+		return false
+	}
+}
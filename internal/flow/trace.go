@@ -8,21 +8,28 @@ import (
 
 	"github.com/RoaringBitmap/roaring/v2"
 
+	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 // FlowEngine reconstructs request flow graphs.
 type FlowEngine struct {
 	indexer *indexer.Indexer
+	client  client.DataSource
+	cache   *cache.EntryCache
 	config  *config.Config
 }
 
-// NewFlowEngine creates a new FlowEngine.
-func NewFlowEngine(idx *indexer.Indexer, cfg *config.Config) *FlowEngine {
+// NewFlowEngine creates a new FlowEngine. The client and cache are used to
+// fetch bodies for value propagation analysis.
+func NewFlowEngine(idx *indexer.Indexer, c client.DataSource, cache *cache.EntryCache, cfg *config.Config) *FlowEngine {
 	return &FlowEngine{
 		indexer: idx,
+		client:  c,
+		cache:   cache,
 		config:  cfg,
 	}
 }
@@ -75,6 +82,13 @@ func (f *FlowEngine) Trace(ctx context.Context, req *types.TraceRequest) (*types
 
 	// Build edges
 	edges := f.buildEdges(entries, seed)
+	if opts.ValuePropagation {
+		lineage, err := f.Lineage(ctx, req.SessionID, lineageEntryIDs(entries, seed))
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, lineage...)
+	}
 
 	// Build initial graph
 	graph := &types.FlowGraph{
@@ -114,10 +128,37 @@ func applyTraceOptionsDefaults(opts *types.TraceOptions) *types.TraceOptions {
 	}
 	result.SamePIDOnly = opts.SamePIDOnly
 	result.SameHostOnly = opts.SameHostOnly
+	result.ValuePropagation = opts.ValuePropagation
 
 	return result
 }
 
+// lineageEntryIDs picks the entries to fetch for value propagation: all of
+// them, or the maxLineageEntries closest in time to the seed.
+func lineageEntryIDs(entries []*indexer.EntryMeta, seed *indexer.EntryMeta) []string {
+	picked := entries
+	if len(entries) > maxLineageEntries {
+		picked = make([]*indexer.EntryMeta, len(entries))
+		copy(picked, entries)
+		sort.SliceStable(picked, func(i, j int) bool {
+			return abs(picked[i].TsMs-seed.TsMs) < abs(picked[j].TsMs-seed.TsMs)
+		})
+		picked = picked[:maxLineageEntries]
+	}
+	ids := make([]string, len(picked))
+	for i, meta := range picked {
+		ids[i] = meta.EntryID
+	}
+	return ids
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 // findRelatedEntries finds entries related to the seed using various heuristics.
 func (f *FlowEngine) findRelatedEntries(seed *indexer.EntryMeta, opts *types.TraceOptions) *roaring.Bitmap {
 	result := roaring.New()
@@ -144,9 +185,10 @@ func (f *FlowEngine) findRelatedEntries(seed *indexer.EntryMeta, opts *types.Tra
 	sinceMs := seed.TsMs - timeWindow/2
 	untilMs := seed.TsMs + timeWindow/2
 
-	// Start with all docs, then filter
-	allDocs := f.indexer.AllDocIDs()
-	iter := allDocs.Iterator()
+	// Start with the seed's session, then filter
+	var window []*indexer.EntryMeta
+	sessionDocs := f.indexer.SessionDocIDs([]string{seed.SessionID})
+	iter := sessionDocs.Iterator()
 	for iter.HasNext() {
 		docID := iter.Next()
 		meta := f.indexer.GetMeta(docID)
@@ -163,6 +205,7 @@ func (f *FlowEngine) findRelatedEntries(seed *indexer.EntryMeta, opts *types.Tra
 		if opts.SamePIDOnly && meta.PID != seed.PID {
 			continue
 		}
+		window = append(window, meta)
 
 		// Host filter
 		if opts.SameHostOnly && meta.Host != seed.Host {
@@ -172,9 +215,57 @@ func (f *FlowEngine) findRelatedEntries(seed *indexer.EntryMeta, opts *types.Tra
 		result.Add(docID)
 	}
 
+	// Redirects and page loads cross hosts (OAuth, SSO, checkout), so follow
+	// the seed's navigation out of its host even when SameHostOnly is set.
+	if opts.SameHostOnly {
+		expandAlongNavigation(result, window, seed)
+	}
+
+	// Connection IDs are not guaranteed unique across sessions
+	result.And(sessionDocs)
 	return result
 }
 
+// expandAlongNavigation adds to result the entries in window that the seed
+// led to through redirect, referer, or initiator_page edges, and the redirect
+// chain that led to the seed. Edges are followed in their direction only, so
+// other pages loaded from the seed's referring page stay out.
+func expandAlongNavigation(result *roaring.Bitmap, window []*indexer.EntryMeta, seed *indexer.EntryMeta) {
+	sort.Slice(window, func(i, j int) bool {
+		return window[i].TsMs < window[j].TsMs
+	})
+	docIDs := make(map[string]uint32, len(window))
+	for _, meta := range window {
+		docIDs[meta.EntryID] = meta.DocID
+	}
+	children := make(map[uint32][]uint32)
+	redirectedFrom := make(map[uint32][]uint32)
+	for _, edge := range navigationEdges(window) {
+		from, to := docIDs[edge.From], docIDs[edge.To]
+		children[from] = append(children[from], to)
+		if edge.Reason == types.EdgeReasonRedirect {
+			redirectedFrom[to] = append(redirectedFrom[to], from)
+		}
+	}
+
+	walk := func(adjacent map[uint32][]uint32) {
+		seen := roaring.BitmapOf(seed.DocID)
+		queue := []uint32{seed.DocID}
+		for len(queue) > 0 {
+			curr := queue[0]
+			queue = queue[1:]
+			for _, next := range adjacent[curr] {
+				if seen.CheckedAdd(next) {
+					result.Add(next)
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	walk(children)
+	walk(redirectedFrom)
+}
+
 // buildEdges determines relationships between entries.
 func (f *FlowEngine) buildEdges(entries []*indexer.EntryMeta, seed *indexer.EntryMeta) []types.FlowEdge {
 	edges := make([]types.FlowEdge, 0)
@@ -317,6 +408,11 @@ func (f *FlowEngine) buildEdges(entries []*indexer.EntryMeta, seed *indexer.Entr
 		}
 	}
 
+	// Add redirect, referer, and initiator page edges
+	for _, edge := range navigationEdges(entries) {
+		addEdge(edge.From, edge.To, edge.Reason)
+	}
+
 	// Add temporal edges for sequential entries
 	for i := 0; i < len(entries)-1; i++ {
 		// Only add temporal edge if no stronger relationship exists
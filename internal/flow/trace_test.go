@@ -6,7 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
@@ -232,6 +234,75 @@ func TestPruneGraphEdgeCases(t *testing.T) {
 	})
 }
 
+func TestFindRelatedEntriesFollowsNavigationAcrossHosts(t *testing.T) {
+	idx := indexer.New(nil, nil, &config.Config{})
+	entry := func(id, url string, ts int64, status int, req, resp client.Headers) *indexer.EntryMeta {
+		method := "GET"
+		docID := idx.Index(&client.SessionEntry{
+			ID:       id,
+			URL:      url,
+			Request:  client.Request{Method: &method, Headers: req},
+			Response: &client.Response{StatusCode: &status, Headers: resp},
+			Timings:  client.Timings{StartedAt: ts},
+		})
+		return idx.GetMeta(docID)
+	}
+
+	seed := entry("login", "https://app.example.com/login", 1000, 302, nil,
+		client.Headers{{"Location", "https://sso.example.net/authorize"}})
+	entry("authorize", "https://sso.example.net/authorize", 1100, 302, nil,
+		client.Headers{{"Location", "https://app.example.com/callback?code=x"}})
+	entry("callback", "https://app.example.com/callback?code=x", 1200, 200, nil,
+		client.Headers{{"Content-Type", "text/html"}})
+	entry("asset", "https://cdn.example.net/app.js", 1300, 200,
+		client.Headers{{"Referer", "https://app.example.com/callback?code=x"}, {"Sec-Fetch-Dest", "script"}}, nil)
+	entry("unrelated", "https://ads.example.org/pixel", 1400, 200, nil, nil)
+
+	engine := &FlowEngine{indexer: idx}
+	related := engine.findRelatedEntries(seed, applyTraceOptionsDefaults(nil))
+
+	var ids []string
+	for _, docID := range related.ToArray() {
+		ids = append(ids, idx.GetMeta(docID).EntryID)
+	}
+	assert.ElementsMatch(t, []string{"login", "authorize", "callback", "asset"}, ids)
+}
+
+func TestFindRelatedEntriesSkipsPagesSharingTheSeedReferer(t *testing.T) {
+	idx := indexer.New(nil, nil, &config.Config{})
+	entry := func(id, url string, ts int64, req client.Headers) *indexer.EntryMeta {
+		method := "GET"
+		status := 200
+		docID := idx.Index(&client.SessionEntry{
+			ID:       id,
+			URL:      url,
+			Request:  client.Request{Method: &method, Headers: req},
+			Response: &client.Response{StatusCode: &status, Headers: client.Headers{{"Content-Type", "text/html"}}},
+			Timings:  client.Timings{StartedAt: ts},
+		})
+		return idx.GetMeta(docID)
+	}
+
+	entry("dashboard", "https://app.example.com/dashboard", 1000, nil)
+	seed := entry("checkout", "https://app.example.com/checkout", 1100,
+		client.Headers{{"Referer", "https://app.example.com/dashboard"}, {"Sec-Fetch-Dest", "document"}})
+	entry("widget", "https://widget.example.org/embed", 1200,
+		client.Headers{{"Referer", "https://app.example.com/dashboard"}, {"Sec-Fetch-Dest", "iframe"}})
+	entry("payment", "https://pay.example.net/form", 1300,
+		client.Headers{{"Referer", "https://app.example.com/checkout"}, {"Sec-Fetch-Dest", "iframe"}})
+
+	engine := &FlowEngine{indexer: idx}
+	related := engine.findRelatedEntries(seed, applyTraceOptionsDefaults(nil))
+
+	var ids []string
+	for _, docID := range related.ToArray() {
+		ids = append(ids, idx.GetMeta(docID).EntryID)
+	}
+	// The widget shares the seed's referring page but was not loaded by the
+	// seed, so SameHostOnly keeps it out.
+	assert.ElementsMatch(t, []string{"dashboard", "checkout", "payment"}, ids)
+}
+
 // BuildEdgesTestSuite uses testify/suite for complex edge building tests
 type BuildEdgesTestSuite struct {
 	suite.Suite
@@ -487,6 +558,74 @@ func (s *BuildEdgesTestSuite) TestEarliestCookieSetterWins() {
 	s.Equal(1, cookieOriginCount)
 }
 
+func (s *BuildEdgesTestSuite) TestRedirectEdges() {
+	entries := []*indexer.EntryMeta{
+		{EntryID: "login", TsMs: 1000, URL: "https://app.example.com/login", Status: 302, Location: "https://sso.example.net/authorize?client_id=app"},
+		{EntryID: "other", TsMs: 1100, URL: "https://app.example.com/favicon.ico", Status: 200},
+		{EntryID: "authorize", TsMs: 1200, URL: "https://sso.example.net/authorize?client_id=app", Status: 303, Location: "/done#top"},
+		{EntryID: "done", TsMs: 1300, URL: "https://SSO.example.net:443/done", Status: 200},
+	}
+
+	edges := s.engine.buildEdges(entries, nil)
+
+	s.assertEdgeExists(edges, "login", "authorize", types.EdgeReasonRedirect)
+	s.assertEdgeExists(edges, "authorize", "done", types.EdgeReasonRedirect)
+	for _, edge := range edges {
+		if edge.Reason == types.EdgeReasonRedirect {
+			s.NotEqual("other", edge.To)
+		}
+	}
+}
+
+func (s *BuildEdgesTestSuite) TestRedirectMatchesNextRequestOnly() {
+	entries := []*indexer.EntryMeta{
+		{EntryID: "earlier", TsMs: 500, URL: "https://example.com/home", Status: 200},
+		{EntryID: "redirect", TsMs: 1000, URL: "https://example.com/old", Status: 301, Location: "/home"},
+		{EntryID: "followed", TsMs: 1100, URL: "https://example.com/home", Status: 200},
+		{EntryID: "reload", TsMs: 5000, URL: "https://example.com/home", Status: 200},
+	}
+
+	edges := s.engine.buildEdges(entries, nil)
+
+	var targets []string
+	for _, edge := range edges {
+		if edge.Reason == types.EdgeReasonRedirect {
+			targets = append(targets, edge.To)
+		}
+	}
+	s.Equal([]string{"followed"}, targets)
+}
+
+func (s *BuildEdgesTestSuite) TestInitiatorPageAndRefererEdges() {
+	entries := []*indexer.EntryMeta{
+		{EntryID: "page", TsMs: 1000, URL: "https://shop.example.com/cart", RespContentType: "text/html", FetchDest: "document"},
+		{EntryID: "script", TsMs: 1100, URL: "https://cdn.example.net/app.js", Referer: "https://shop.example.com/cart", FetchDest: "script"},
+		{EntryID: "api", TsMs: 1200, URL: "https://api.example.com/cart", Referer: "https://shop.example.com/cart", RespContentType: "application/json"},
+		{EntryID: "checkout", TsMs: 2000, URL: "https://pay.example.org/checkout", Referer: "https://shop.example.com/cart", RespContentType: "text/html", FetchDest: "document"},
+	}
+
+	edges := s.engine.buildEdges(entries, nil)
+
+	s.assertEdgeExists(edges, "page", "script", types.EdgeReasonInitiatorPage)
+	s.assertEdgeExists(edges, "page", "api", types.EdgeReasonInitiatorPage)
+	s.assertEdgeExists(edges, "page", "checkout", types.EdgeReasonReferer)
+}
+
+func (s *BuildEdgesTestSuite) TestOriginOnlyRefererMatchesLatestPage() {
+	entries := []*indexer.EntryMeta{
+		{EntryID: "page1", TsMs: 1000, URL: "https://shop.example.com/", RespContentType: "text/html"},
+		{EntryID: "page2", TsMs: 2000, URL: "https://shop.example.com/product/1", RespContentType: "text/html"},
+		{EntryID: "img", TsMs: 2100, URL: "https://cdn.example.net/1.png", Referer: "https://shop.example.com/", FetchDest: "image"},
+		{EntryID: "cors", TsMs: 2200, URL: "https://api.example.com/stock", Origin: "https://shop.example.com", FetchDest: "empty"},
+	}
+
+	edges := s.engine.buildEdges(entries, nil)
+
+	// An exact Referer match wins over the latest page on the origin
+	s.assertEdgeExists(edges, "page1", "img", types.EdgeReasonInitiatorPage)
+	s.assertEdgeExists(edges, "page2", "cors", types.EdgeReasonInitiatorPage)
+}
+
 // Helper assertion
 func (s *BuildEdgesTestSuite) assertEdgeExists(edges []types.FlowEdge, from, to, reason string) {
 	for _, edge := range edges {
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/ja4"
+	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 // FromSessionEntry creates EntryMeta from a powhttp SessionEntry.
@@ -47,6 +49,7 @@ func FromSessionEntry(entry *client.SessionEntry) *EntryMeta {
 	if entry.TLS.JA4 != nil {
 		meta.JA4 = entry.TLS.JA4.Hashed
 	}
+	meta.JA4H, _ = ja4.JA4H(meta.Method, entry.HTTPVersion, entry.Request.Headers)
 
 	// HTTP/2 info
 	if entry.HTTP2 != nil {
@@ -74,6 +77,14 @@ func FromSessionEntry(entry *client.SessionEntry) *EntryMeta {
 		meta.SetCookies = extractSetCookies(entry.Response.Headers)
 	}
 
+	// Navigation fields for flow tracing
+	meta.Referer = entry.Request.Headers.Get("referer")
+	meta.Origin = entry.Request.Headers.Get("origin")
+	meta.FetchDest = strings.ToLower(entry.Request.Headers.Get("sec-fetch-dest"))
+	if entry.Response != nil {
+		meta.Location = entry.Response.Headers.Get("location")
+	}
+
 	// Body sizes and content type
 	meta.ReqBodyBytes = computeBodySize(entry.Request.Body)
 	if entry.Response != nil {
@@ -81,9 +92,47 @@ func FromSessionEntry(entry *client.SessionEntry) *EntryMeta {
 		meta.RespContentType = normalizeContentType(entry.Response.Headers.Get("content-type"))
 	}
 
+	meta.DurationMs = entryDuration(entry.Timings)
+	meta.Timings = timingPhases(entry.Timings)
+
 	return meta
 }
 
+// entryDuration sums the recorded timing phases. SSL is excluded because it
+// is part of connect. Returns -1 if no phase was recorded.
+func entryDuration(t client.Timings) int64 {
+	total := int64(-1)
+	for _, v := range []*int64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v == nil || *v < 0 {
+			continue
+		}
+		if total < 0 {
+			total = 0
+		}
+		total += *v
+	}
+	return total
+}
+
+// timingPhases copies the recorded phases, using -1 for missing ones.
+func timingPhases(t client.Timings) types.TimingPhases {
+	ms := func(v *int64) int64 {
+		if v == nil || *v < 0 {
+			return -1
+		}
+		return *v
+	}
+	return types.TimingPhases{
+		Blocked: ms(t.Blocked),
+		DNS:     ms(t.DNS),
+		Connect: ms(t.Connect),
+		SSL:     ms(t.SSL),
+		Send:    ms(t.Send),
+		Wait:    ms(t.Wait),
+		Receive: ms(t.Receive),
+	}
+}
+
 // extractHost parses host from URL.
 func extractHost(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 func strPtr(s string) *string { return &s }
@@ -381,6 +382,31 @@ func TestNormalizeContentType(t *testing.T) {
 	}
 }
 
+func TestEntryDuration(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name     string
+		timings  client.Timings
+		expected int64
+	}{
+		{"no timings", client.Timings{StartedAt: 1000}, -1},
+		{"all phases", client.Timings{Blocked: ms(1), DNS: ms(2), Connect: ms(30), SSL: ms(20), Send: ms(1), Wait: ms(100), Receive: ms(5)}, 139},
+		{"reused connection", client.Timings{Blocked: ms(-1), DNS: ms(-1), Connect: ms(-1), Send: ms(0), Wait: ms(40), Receive: ms(2)}, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, entryDuration(tt.timings))
+		})
+	}
+}
+
+func TestTimingPhases(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	got := timingPhases(client.Timings{DNS: ms(-1), Connect: ms(30), SSL: ms(20), Send: ms(0), Wait: ms(100)})
+	assert.Equal(t, types.TimingPhases{Blocked: -1, DNS: -1, Connect: 30, SSL: 20, Send: 0, Wait: 100, Receive: -1}, got)
+}
+
 func TestToSummary(t *testing.T) {
 	meta := &EntryMeta{
 		EntryID:     "e1",
@@ -1,7 +1,7 @@
 package indexer
 
 import (
-	"encoding/base64"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +18,17 @@ type sessionState struct {
 	lastEntryIDsLen int
 	lastTailEntryID string
 	lastSyncAt      time.Time
+
+	// Snapshot debouncing (only used when snapshots are enabled)
+	snapshotAt     time.Time // last snapshot save or load
+	unsavedEntries int       // entries indexed since snapshotAt
+	unsavedIDs     []string  // session entry list for the pending save, nil when clean
+}
+
+// tlsServerPrint holds the server-side fingerprints of a TLS connection.
+type tlsServerPrint struct {
+	JA4S string
+	JA4X []string
 }
 
 // Indexer maintains in-memory indexes over HTTP entries using Roaring bitmaps.
@@ -42,22 +53,32 @@ type Indexer struct {
 	idxH2Connection  map[string]*roaring.Bitmap
 	idxJA3           map[string]*roaring.Bitmap
 	idxJA4           map[string]*roaring.Bitmap
+	idxJA4H          map[string]*roaring.Bitmap
+	idxJA4S          map[string]*roaring.Bitmap
+	idxJA4X          map[string]*roaring.Bitmap
 	idxToken         map[string]*roaring.Bitmap
 	idxHeaderToken   map[string]*roaring.Bitmap
 	idxBodyToken     map[string]*roaring.Bitmap
+	idxSession       map[string]*roaring.Bitmap // session ID -> its docs
 
 	// Per-session refresh state
 	sessions map[string]*sessionState
 
+	// Server fingerprints by TLS connection ID, read once per connection
+	tlsServer map[string]tlsServerPrint
+
 	// Dependencies
-	client *client.Client
-	cache  *cache.EntryCache
-	config *config.Config
+	client    client.DataSource
+	cache     *cache.EntryCache
+	config    *config.Config
+	snapshots SnapshotStore // nil when snapshots are disabled
 }
 
 // New creates a new Indexer instance.
-func New(c *client.Client, cache *cache.EntryCache, cfg *config.Config) *Indexer {
-	return &Indexer{
+// When cfg.IndexSnapshotDir is set, sessions are persisted there and restored
+// on their first refresh.
+func New(c client.DataSource, cache *cache.EntryCache, cfg *config.Config) *Indexer {
+	idx := &Indexer{
 		idToDoc:          make(map[string]uint32),
 		docToMeta:        make([]*EntryMeta, 0, 1024),
 		idxHost:          make(map[string]*roaring.Bitmap),
@@ -72,19 +93,45 @@ func New(c *client.Client, cache *cache.EntryCache, cfg *config.Config) *Indexer
 		idxH2Connection:  make(map[string]*roaring.Bitmap),
 		idxJA3:           make(map[string]*roaring.Bitmap),
 		idxJA4:           make(map[string]*roaring.Bitmap),
+		idxJA4H:          make(map[string]*roaring.Bitmap),
+		idxJA4S:          make(map[string]*roaring.Bitmap),
+		idxJA4X:          make(map[string]*roaring.Bitmap),
 		idxToken:         make(map[string]*roaring.Bitmap),
 		idxHeaderToken:   make(map[string]*roaring.Bitmap),
 		idxBodyToken:     make(map[string]*roaring.Bitmap),
+		idxSession:       make(map[string]*roaring.Bitmap),
 		sessions:         make(map[string]*sessionState),
+		tlsServer:        make(map[string]tlsServerPrint),
 		client:           c,
 		cache:            cache,
 		config:           cfg,
 	}
+
+	if cfg != nil && cfg.IndexSnapshotDir != "" {
+		store, err := NewFileSnapshotStore(cfg.IndexSnapshotDir)
+		if err != nil {
+			slog.Warn("index snapshots disabled",
+				slog.String("dir", cfg.IndexSnapshotDir),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			idx.snapshots = store
+		}
+	}
+
+	return idx
 }
 
-// Index adds or updates an entry in the index.
+// Index adds an entry that belongs to no session to the index.
 // Returns the assigned document ID.
 func (idx *Indexer) Index(entry *client.SessionEntry) uint32 {
+	return idx.IndexSession("", entry)
+}
+
+// IndexSession adds an entry of a session to the index. Entries already
+// indexed keep their document ID and session.
+// Returns the assigned document ID.
+func (idx *Indexer) IndexSession(sessionID string, entry *client.SessionEntry) uint32 {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
@@ -100,6 +147,8 @@ func (idx *Indexer) Index(entry *client.SessionEntry) uint32 {
 	// Convert to metadata
 	meta := FromSessionEntry(entry)
 	meta.DocID = docID
+	meta.SessionID = sessionID
+	idx.addToBitmap(idx.idxSession, sessionID, docID)
 
 	// Store mappings
 	idx.idToDoc[entry.ID] = docID
@@ -166,6 +215,23 @@ func (idx *Indexer) Index(entry *client.SessionEntry) uint32 {
 		idx.addToBitmap(idx.idxJA4, meta.JA4, docID)
 	}
 
+	// Index by JA4H
+	if meta.JA4H != "" {
+		idx.addToBitmap(idx.idxJA4H, meta.JA4H, docID)
+	}
+
+	// Index by the connection's JA4S and JA4X, when its TLS events were read
+	if server, ok := idx.tlsServer[meta.TLSConnectionID]; ok {
+		meta.JA4S = server.JA4S
+		meta.JA4X = server.JA4X
+	}
+	if meta.JA4S != "" {
+		idx.addToBitmap(idx.idxJA4S, meta.JA4S, docID)
+	}
+	for _, fp := range meta.JA4X {
+		idx.addToBitmap(idx.idxJA4X, fp, docID)
+	}
+
 	// Index URL tokens
 	tokens := TokenizeURL(meta.URL)
 	for _, token := range tokens {
@@ -191,7 +257,7 @@ func (idx *Indexer) Index(entry *client.SessionEntry) uint32 {
 	return docID
 }
 
-// indexBodyTokens decodes and tokenizes request and response bodies.
+// indexBodyTokens decodes, decompresses, and tokenizes request and response bodies.
 func (idx *Indexer) indexBodyTokens(entry *client.SessionEntry, meta *EntryMeta, docID uint32) {
 	maxBytes := 65536
 	if idx.config.IndexBodyMaxBytes > 0 {
@@ -207,7 +273,7 @@ func (idx *Indexer) indexBodyTokens(entry *client.SessionEntry, meta *EntryMeta,
 				break
 			}
 		}
-		if bodyBytes, err := base64.StdEncoding.DecodeString(*entry.Request.Body); err == nil {
+		if bodyBytes, err := entry.Request.DecodedBody(); err == nil {
 			for _, token := range TokenizeBody(reqContentType, bodyBytes, maxBytes) {
 				idx.addToBitmap(idx.idxBodyToken, token, docID)
 			}
@@ -216,7 +282,7 @@ func (idx *Indexer) indexBodyTokens(entry *client.SessionEntry, meta *EntryMeta,
 
 	// Tokenize response body
 	if entry.Response != nil && entry.Response.Body != nil && *entry.Response.Body != "" {
-		if bodyBytes, err := base64.StdEncoding.DecodeString(*entry.Response.Body); err == nil {
+		if bodyBytes, err := entry.Response.DecodedBody(); err == nil {
 			for _, token := range TokenizeBody(meta.RespContentType, bodyBytes, maxBytes) {
 				idx.addToBitmap(idx.idxBodyToken, token, docID)
 			}
@@ -262,6 +328,20 @@ func (idx *Indexer) AllDocIDs() *roaring.Bitmap {
 	return bm
 }
 
+// SessionDocIDs returns a bitmap of the documents indexed for the given sessions.
+func (idx *Indexer) SessionDocIDs(sessionIDs []string) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	bm := roaring.New()
+	for _, id := range sessionIDs {
+		if docs, ok := idx.idxSession[id]; ok {
+			bm.Or(docs)
+		}
+	}
+	return bm
+}
+
 // DocCount returns the number of indexed documents.
 func (idx *Indexer) DocCount() int {
 	idx.mu.RLock()
@@ -377,6 +457,28 @@ func (idx *Indexer) GetBitmapForJA4(ja4 string) *roaring.Bitmap {
 	return idx.idxJA4[ja4]
 }
 
+// GetBitmapForJA4H returns the bitmap for a specific JA4H fingerprint.
+func (idx *Indexer) GetBitmapForJA4H(ja4h string) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.idxJA4H[ja4h]
+}
+
+// GetBitmapForJA4S returns the bitmap for a specific JA4S fingerprint.
+func (idx *Indexer) GetBitmapForJA4S(ja4s string) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.idxJA4S[ja4s]
+}
+
+// GetBitmapForJA4X returns the bitmap for a specific JA4X fingerprint,
+// matching entries whose server chain includes that certificate.
+func (idx *Indexer) GetBitmapForJA4X(ja4x string) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.idxJA4X[ja4x]
+}
+
 // GetBitmapForToken returns the bitmap for a specific URL token.
 func (idx *Indexer) GetBitmapForToken(token string) *roaring.Bitmap {
 	idx.mu.RLock()
@@ -398,6 +500,121 @@ func (idx *Indexer) GetBitmapForBodyToken(token string) *roaring.Bitmap {
 	return idx.idxBodyToken[token]
 }
 
+// Field names a keyed index for pattern matching.
+type Field int
+
+// Fields accepted by MatchBitmap and MatchIntBitmap.
+const (
+	FieldHost Field = iota
+	FieldMethod
+	FieldProcessName
+	FieldHTTPVersion
+	FieldHeaderName
+	FieldTLSConnection
+	FieldJA3
+	FieldJA4
+	FieldJA4H
+	FieldJA4S
+	FieldJA4X
+	FieldPID    // int-keyed
+	FieldStatus // int-keyed
+)
+
+// MatchBitmap returns the union of the bitmaps in a string-keyed index whose
+// key satisfies match, or nil if none does.
+func (idx *Indexer) MatchBitmap(field Field, match func(key string) bool) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var index map[string]*roaring.Bitmap
+	switch field {
+	case FieldHost:
+		index = idx.idxHost
+	case FieldMethod:
+		index = idx.idxMethod
+	case FieldProcessName:
+		index = idx.idxProcessName
+	case FieldHTTPVersion:
+		index = idx.idxHTTPVersion
+	case FieldHeaderName:
+		index = idx.idxHeaderName
+	case FieldTLSConnection:
+		index = idx.idxTLSConnection
+	case FieldJA3:
+		index = idx.idxJA3
+	case FieldJA4:
+		index = idx.idxJA4
+	case FieldJA4H:
+		index = idx.idxJA4H
+	case FieldJA4S:
+		index = idx.idxJA4S
+	case FieldJA4X:
+		index = idx.idxJA4X
+	}
+
+	result := roaring.New()
+	for key, bm := range index {
+		if match(key) {
+			result.Or(bm)
+		}
+	}
+	if result.IsEmpty() {
+		return nil
+	}
+	return result
+}
+
+// MatchIntBitmap returns the union of the bitmaps in an int-keyed index
+// (FieldPID or FieldStatus) whose key satisfies match, or nil if none does.
+func (idx *Indexer) MatchIntBitmap(field Field, match func(key int) bool) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var index map[int]*roaring.Bitmap
+	switch field {
+	case FieldPID:
+		index = idx.idxPID
+	case FieldStatus:
+		index = idx.idxStatus
+	}
+
+	result := roaring.New()
+	for key, bm := range index {
+		if match(key) {
+			result.Or(bm)
+		}
+	}
+	if result.IsEmpty() {
+		return nil
+	}
+	return result
+}
+
+// MatchHeaderValueBitmap returns the union of the bitmaps for header
+// name:value pairs that satisfy match, or nil if none does. Names are
+// lowercase.
+func (idx *Indexer) MatchHeaderValueBitmap(match func(name, value string) bool) *roaring.Bitmap {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	result := roaring.New()
+	for key, bm := range idx.idxHeaderValue {
+		// Pseudo-headers start with ':', so split after the first byte
+		sep := strings.IndexByte(key[min(1, len(key)):], ':')
+		if sep < 0 {
+			continue
+		}
+		sep += min(1, len(key))
+		if match(key[:sep], key[sep+1:]) {
+			result.Or(bm)
+		}
+	}
+	if result.IsEmpty() {
+		return nil
+	}
+	return result
+}
+
 // BodyIndexEnabled returns whether body indexing is enabled.
 func (idx *Indexer) BodyIndexEnabled() bool {
 	return idx.config != nil && idx.config.IndexBody
@@ -464,8 +681,8 @@ func (idx *Indexer) getSessionStateCopy(sessionID string) *sessionState {
 	}
 }
 
-// Client returns the underlying powhttp client.
-func (idx *Indexer) Client() *client.Client {
+// Client returns the underlying data source.
+func (idx *Indexer) Client() client.DataSource {
 	return idx.client
 }
 
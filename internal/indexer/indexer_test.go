@@ -1,7 +1,13 @@
 package indexer
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,6 +105,20 @@ func TestAllDocIDs(t *testing.T) {
 	assert.False(t, bm.Contains(3))
 }
 
+func TestSessionDocIDs(t *testing.T) {
+	idx := newTestIndexer(nil)
+	idx.IndexSession("s1", makeEntry("e1", "https://a.com/", "GET", 200))
+	idx.IndexSession("s2", makeEntry("e2", "https://b.com/", "GET", 200))
+	idx.IndexSession("s1", makeEntry("e3", "https://c.com/", "GET", 200))
+	idx.IndexSession("s2", makeEntry("e1", "https://a.com/", "GET", 200)) // already indexed from s1
+
+	assert.Equal(t, []uint32{0, 2}, idx.SessionDocIDs([]string{"s1"}).ToArray())
+	assert.Equal(t, []uint32{0, 1, 2}, idx.SessionDocIDs([]string{"s1", "s2"}).ToArray())
+	assert.True(t, idx.SessionDocIDs([]string{"missing"}).IsEmpty())
+	assert.Equal(t, "s2", idx.GetMetaByEntryID("e2").SessionID)
+	assert.Equal(t, "s1", idx.GetMetaByEntryID("e1").SessionID)
+}
+
 func TestBitmapIndexes_Host(t *testing.T) {
 	idx := newTestIndexer(nil)
 	idx.Index(makeEntry("e1", "https://example.com/a", "GET", 200))
@@ -276,6 +296,63 @@ func TestBitmapIndexes_TLS(t *testing.T) {
 	assert.Nil(t, idx.GetBitmapForJA4("nope"))
 }
 
+func TestBitmapIndexes_JA4H(t *testing.T) {
+	idx := newTestIndexer(nil)
+
+	e := makeEntry("e1", "https://a.com/", "GET", 200)
+	e.Request.Headers = client.Headers{{":method", "GET"}, {"accept", "*/*"}, {"cookie", "a=1"}}
+	idx.Index(e)
+
+	meta := idx.GetMetaByEntryID("e1")
+	require.NotNil(t, meta)
+	assert.Equal(t, "ge20cn010000", meta.JA4H[:12])
+	assert.NotNil(t, idx.GetBitmapForJA4H(meta.JA4H))
+	assert.NotNil(t, idx.MatchBitmap(FieldJA4H, func(k string) bool { return strings.HasPrefix(k, "ge20") }))
+}
+
+func TestRefresh_IndexesTLSServerPrints(t *testing.T) {
+	var serverHello client.TLSEvent
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"side": "server",
+		"msg": {"type": "handshake", "content": {"type": "server_hello", "content": {
+			"version": {"value": 771, "name": "TLS 1.2"},
+			"cipher_suite": {"value": 4865, "name": "TLS_AES_128_GCM_SHA256"},
+			"extensions": [
+				{"value": 43, "name": "supported_versions", "selected_version": {"value": 772, "name": "TLS 1.3"}},
+				{"value": 51, "name": "key_share", "group": {"value": 29, "name": "x25519"}}
+			]
+		}}}
+	}`), &serverHello))
+
+	entries := make([]*client.SessionEntry, 3)
+	for i := range entries {
+		entries[i] = makeEntry(fmt.Sprintf("e%d", i), "https://a.com/", "GET", 200)
+		entries[i].TLS.ConnectionID = strPtr("tls-1")
+	}
+	entries[2].TLS.ConnectionID = strPtr("tls-unknown")
+	src := &fakeSource{
+		sessionID: "s1",
+		entries:   entries[:2],
+		tls:       map[string][]client.TLSEvent{"tls-1": {serverHello}},
+	}
+	cfg := snapshotConfig("")
+	idx := newSnapshotIndexer(t, src, cfg)
+	ctx := context.Background()
+
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	bm := idx.GetBitmapForJA4S("t130200_1301_a56c5b993250")
+	require.NotNil(t, bm)
+	assert.Equal(t, uint64(2), bm.GetCardinality())
+	assert.Equal(t, int32(1), src.tlsFetches.Load())
+
+	// A known connection is not fetched again; an unreadable one is tried once
+	src.entries = entries
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(2), src.tlsFetches.Load())
+	assert.Empty(t, idx.GetMetaByEntryID("e2").JA4S)
+}
+
 func TestBitmapIndexes_H2Connection(t *testing.T) {
 	idx := newTestIndexer(nil)
 
@@ -365,6 +442,26 @@ func TestBitmapIndexes_BodyTokens_RequestBody(t *testing.T) {
 	assert.NotNil(t, idx.GetBitmapForBodyToken("create"))
 }
 
+func TestBitmapIndexes_BodyTokens_GzipBody(t *testing.T) {
+	idx := newTestIndexer(&config.Config{IndexBody: true, IndexBodyMaxBytes: 65536})
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(`{"product":"widget"}`))
+	w.Close()
+	body := base64.StdEncoding.EncodeToString(gz.Bytes())
+	e := makeEntry("e1", "https://a.com/", "GET", 200)
+	e.Response = &client.Response{
+		StatusCode: intPtr(200),
+		Headers:    client.Headers{{"Content-Type", "application/json"}, {"Content-Encoding", "gzip"}},
+		Body:       &body,
+	}
+	idx.Index(e)
+
+	assert.NotNil(t, idx.GetBitmapForBodyToken("product"))
+	assert.NotNil(t, idx.GetBitmapForBodyToken("widget"))
+}
+
 func TestBitmapIndexes_HeaderValue(t *testing.T) {
 	idx := newTestIndexer(nil)
 
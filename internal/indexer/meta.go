@@ -16,6 +16,7 @@ type HeaderValue struct {
 type EntryMeta struct {
 	DocID            uint32
 	EntryID          string
+	SessionID        string // Session the entry was indexed from
 	TsMs             int64
 	Method           string
 	URL              string
@@ -32,6 +33,11 @@ type EntryMeta struct {
 	TLSConnectionID string
 	JA3             string
 	JA4             string
+	JA4S            string   // Of the connection's ServerHello
+	JA4X            []string // Of the server's certificate chain
+
+	// JA4H of the request headers
+	JA4H string
 
 	// HTTP/2 pointers
 	H2ConnectionID string
@@ -42,11 +48,22 @@ type EntryMeta struct {
 	RespBodyBytes   int
 	RespContentType string
 
+	// DurationMs is the total time of the exchange (blocked, dns, connect,
+	// send, wait, and receive phases), or -1 if no timings were recorded.
+	DurationMs int64
+	Timings    types.TimingPhases // Per-phase breakdown; -1 for unrecorded phases
+
 	// Auth fields for flow tracing
 	AuthHeader string            // Full Authorization header value (Bearer, Basic, etc.)
 	Cookies    map[string]string // Session cookie name -> value (filtered to session-related)
 	APIKeys    map[string]string // Auth header name -> value (x-api-key, etc.)
 	SetCookies map[string]string // Set-Cookie name -> value (from response, session-related only)
+
+	// Navigation fields for flow tracing
+	Location  string // Response Location header, unresolved
+	Referer   string // Request Referer header
+	Origin    string // Request Origin header
+	FetchDest string // Request Sec-Fetch-Dest header (document, script, empty, ...)
 }
 
 // ToSummary converts EntryMeta to EntrySummary for tool responses.
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/ja4"
 )
 
 // refreshStrategy indicates how to handle a session refresh.
@@ -31,7 +32,12 @@ func (idx *Indexer) RefreshSession(ctx context.Context, sessionID string) error
 	refreshCtx, cancel := context.WithTimeout(ctx, idx.config.RefreshTimeout)
 	defer cancel()
 
-	_, err, _ := refreshGroup.Do(sessionID, func() (any, error) {
+	sessionID, err := idx.resolveSessionID(refreshCtx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	_, err, _ = refreshGroup.Do(sessionID, func() (any, error) {
 		return nil, idx.doRefresh(refreshCtx, sessionID)
 	})
 	return err
@@ -39,6 +45,10 @@ func (idx *Indexer) RefreshSession(ctx context.Context, sessionID string) error
 
 // RefreshIfStale checks freshness threshold and refreshes if needed.
 func (idx *Indexer) RefreshIfStale(ctx context.Context, sessionID string) error {
+	sessionID, err := idx.resolveSessionID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
 	state := idx.getSessionStateCopy(sessionID)
 
 	// Always refresh if never synced
@@ -54,6 +64,82 @@ func (idx *Indexer) RefreshIfStale(ctx context.Context, sessionID string) error
 	return nil
 }
 
+// AllSessions is the session selector that stands for every session the data
+// source lists.
+const AllSessions = "all"
+
+// ActiveSession is the alias powhttp resolves to the session open in its UI.
+// Entries are always tagged with the concrete session ID behind it.
+const ActiveSession = "active"
+
+// resolveSessionID returns the session ID ActiveSession currently stands for,
+// or sessionID unchanged.
+func (idx *Indexer) resolveSessionID(ctx context.Context, sessionID string) (string, error) {
+	if sessionID != ActiveSession {
+		return sessionID, nil
+	}
+	session, err := idx.client.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("resolving active session: %w", err)
+	}
+	if session.ID == "" {
+		return sessionID, nil
+	}
+	return session.ID, nil
+}
+
+// ResolveSessions returns the sessions a request covers: sessionIDs when any
+// are given, with AllSessions expanded to every listed session, or else
+// sessionID alone. ActiveSession is replaced by the session it stands for
+// and duplicates are dropped.
+func (idx *Indexer) ResolveSessions(ctx context.Context, sessionID string, sessionIDs []string) ([]string, error) {
+	if len(sessionIDs) == 0 {
+		resolved, err := idx.resolveSessionID(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return []string{resolved}, nil
+	}
+
+	var resolved []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			resolved = append(resolved, id)
+		}
+	}
+	for _, id := range sessionIDs {
+		if id != AllSessions {
+			resolved, err := idx.resolveSessionID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			add(resolved)
+			continue
+		}
+		sessions, err := idx.client.ListSessions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing sessions: %w", err)
+		}
+		for _, session := range sessions {
+			add(session.ID)
+		}
+	}
+	return resolved, nil
+}
+
+// RefreshSessionsIfStale refreshes each stale session in turn, stopping at
+// the first failure.
+func (idx *Indexer) RefreshSessionsIfStale(ctx context.Context, sessionIDs []string) error {
+	for _, id := range sessionIDs {
+		if err := idx.RefreshIfStale(ctx, id); err != nil {
+			return fmt.Errorf("session %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
 // StartBackgroundRefresh starts a goroutine that periodically refreshes all sessions.
 func (idx *Indexer) StartBackgroundRefresh(ctx context.Context) {
 	slog.Info("starting background refresh for all sessions",
@@ -106,9 +192,20 @@ func (idx *Indexer) doRefresh(ctx context.Context, sessionID string) error {
 		return fmt.Errorf("fetching session: %w", err)
 	}
 
+	// Key state, entries, and snapshots by the session's own ID so an alias
+	// such as "active" never restores or tags another capture's index
+	if session.ID != "" {
+		sessionID = session.ID
+	}
+
 	currentEntryIDs := session.EntryIDs
 	state := idx.getSessionStateCopy(sessionID)
 
+	// On first sync, restore a saved snapshot so only the delta is fetched
+	if state == nil && idx.loadSnapshot(sessionID) {
+		state = idx.getSessionStateCopy(sessionID)
+	}
+
 	// Determine refresh strategy
 	strategy := idx.detectRefreshStrategy(currentEntryIDs, state)
 	strategyName := "append_only"
@@ -137,6 +234,7 @@ func (idx *Indexer) doRefresh(ctx context.Context, sessionID string) error {
 	if len(entriesToFetch) == 0 {
 		// Update sync time even if nothing to fetch
 		idx.updateSessionState(sessionID, currentEntryIDs)
+		idx.maybeSaveSnapshot(sessionID, currentEntryIDs, 0)
 		slog.Debug("refresh completed with no new entries",
 			slog.String("session_id", sessionID),
 			slog.String("strategy", strategyName),
@@ -151,11 +249,14 @@ func (idx *Indexer) doRefresh(ctx context.Context, sessionID string) error {
 		return fmt.Errorf("fetching entries: %w", err)
 	}
 
+	// Read JA4S and JA4X from the TLS events of connections not seen before
+	idx.fetchTLSServerPrints(ctx, entries)
+
 	// Index all fetched entries
 	indexed := 0
 	for _, entry := range entries {
 		if entry != nil {
-			idx.Index(entry)
+			idx.IndexSession(sessionID, entry)
 			indexed++
 		}
 	}
@@ -163,6 +264,8 @@ func (idx *Indexer) doRefresh(ctx context.Context, sessionID string) error {
 	// Update session state
 	idx.updateSessionState(sessionID, currentEntryIDs)
 
+	idx.maybeSaveSnapshot(sessionID, currentEntryIDs, indexed)
+
 	slog.Info("refresh completed",
 		slog.String("session_id", sessionID),
 		slog.String("strategy", strategyName),
@@ -243,6 +346,231 @@ func (idx *Indexer) fetchEntriesConcurrently(ctx context.Context, sessionID stri
 	return entries, nil
 }
 
+// fetchTLSServerPrints reads the TLS events of the entries' connections that
+// have not been read yet and records their JA4S and JA4X for IndexSession.
+// Connections whose events cannot be read are recorded without fingerprints
+// so they are not fetched again.
+func (idx *Indexer) fetchTLSServerPrints(ctx context.Context, entries []*client.SessionEntry) {
+	idx.mu.RLock()
+	var connIDs []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry == nil || entry.TLS.ConnectionID == nil || *entry.TLS.ConnectionID == "" {
+			continue
+		}
+		connID := *entry.TLS.ConnectionID
+		if _, done := idx.tlsServer[connID]; done || seen[connID] {
+			continue
+		}
+		seen[connID] = true
+		connIDs = append(connIDs, connID)
+	}
+	idx.mu.RUnlock()
+	if len(connIDs) == 0 {
+		return
+	}
+
+	prints := make([]*tlsServerPrint, len(connIDs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(idx.config.FetchWorkers)
+	for i, connID := range connIDs {
+		g.Go(func() error {
+			events, err := idx.client.GetTLSConnection(gctx, connID)
+			if err != nil {
+				slog.Debug("failed to fetch TLS connection",
+					slog.String("connection_id", connID),
+					slog.String("error", err.Error()),
+				)
+				// Retry on a later refresh if the refresh itself was cut short
+				if gctx.Err() == nil {
+					prints[i] = &tlsServerPrint{}
+				}
+				return nil
+			}
+			prints[i] = serverPrints(events)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for i, connID := range connIDs {
+		if prints[i] != nil {
+			idx.tlsServer[connID] = *prints[i]
+		}
+	}
+}
+
+// serverPrints computes the JA4S of a connection's ServerHello and the JA4X
+// of the server's certificate chain.
+func serverPrints(events []client.TLSEvent) *tlsServerPrint {
+	prints := &tlsServerPrint{}
+	for _, event := range events {
+		if event.Msg.Type != client.TLSMsgHandshake || event.Msg.Handshake == nil {
+			continue
+		}
+		switch hs := event.Msg.Handshake; hs.Type {
+		case client.TLSHandshakeServerHello:
+			if hs.ServerHello == nil {
+				continue
+			}
+			if hello, err := ja4.ParseServerHello(hs.ServerHello); err == nil {
+				prints.JA4S, _ = ja4.JA4S(hello)
+			}
+		case client.TLSHandshakeCertificate:
+			if hs.Certificate != nil && event.Side == client.TLSSideServer {
+				prints.JA4X = ja4.ChainJA4X(hs.Certificate)
+			}
+		}
+	}
+	return prints
+}
+
+// loadSnapshot restores a session from the snapshot store, if one is configured
+// and holds a usable snapshot. Failures are logged and fall back to a normal sync.
+func (idx *Indexer) loadSnapshot(sessionID string) bool {
+	idx.mu.RLock()
+	store := idx.snapshots
+	idx.mu.RUnlock()
+	if store == nil {
+		return false
+	}
+
+	start := time.Now()
+	snap, err := store.Load(sessionID)
+	if err != nil {
+		slog.Warn("failed to load index snapshot",
+			slog.String("session_id", sessionID),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	if snap == nil {
+		return false
+	}
+
+	applied, err := idx.applySnapshot(snap)
+	if err != nil {
+		slog.Warn("failed to apply index snapshot",
+			slog.String("session_id", sessionID),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	if !applied {
+		slog.Debug("index snapshot skipped",
+			slog.String("session_id", sessionID),
+			slog.Int("version", snap.Version),
+		)
+		return false
+	}
+
+	slog.Info("index snapshot loaded",
+		slog.String("session_id", sessionID),
+		slog.Int("entries", len(snap.Metas)),
+		slog.Int("cached_entries", len(snap.Entries)),
+		slog.Time("saved_at", snap.SavedAt),
+		slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+	)
+	return true
+}
+
+// maybeSaveSnapshot records newly indexed entries and persists the session
+// when a save is due: the first save after startup, once
+// INDEX_SNAPSHOT_MIN_ENTRIES entries are pending, or once
+// INDEX_SNAPSHOT_INTERVAL_MS has passed since the last save. Building a
+// snapshot walks the whole session, so small refreshes are batched; pending
+// changes are written by a later refresh or by FlushSnapshots.
+func (idx *Indexer) maybeSaveSnapshot(sessionID string, entryIDs []string, indexed int) {
+	idx.mu.Lock()
+	if idx.snapshots == nil {
+		idx.mu.Unlock()
+		return
+	}
+	state := idx.getSessionState(sessionID)
+	state.unsavedEntries += indexed
+	if state.unsavedEntries == 0 {
+		idx.mu.Unlock()
+		return
+	}
+	state.unsavedIDs = entryIDs
+
+	minEntries := idx.config.IndexSnapshotMinEntries
+	due := state.snapshotAt.IsZero() ||
+		time.Since(state.snapshotAt) >= idx.config.IndexSnapshotInterval ||
+		(minEntries > 0 && state.unsavedEntries >= minEntries)
+	idx.mu.Unlock()
+
+	if due {
+		idx.saveSnapshot(sessionID, entryIDs)
+	}
+}
+
+// FlushSnapshots saves every session with entries indexed since its last
+// snapshot. Call it on shutdown so batched changes are not lost.
+func (idx *Indexer) FlushSnapshots() {
+	idx.mu.RLock()
+	pending := make(map[string][]string)
+	if idx.snapshots != nil {
+		for sessionID, state := range idx.sessions {
+			if state.unsavedIDs != nil {
+				pending[sessionID] = state.unsavedIDs
+			}
+		}
+	}
+	idx.mu.RUnlock()
+
+	for sessionID, entryIDs := range pending {
+		idx.saveSnapshot(sessionID, entryIDs)
+	}
+}
+
+// saveSnapshot persists a session to the snapshot store, if one is configured.
+func (idx *Indexer) saveSnapshot(sessionID string, entryIDs []string) {
+	idx.mu.RLock()
+	store := idx.snapshots
+	idx.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	snap, err := idx.buildSnapshot(sessionID, entryIDs)
+	if err == nil {
+		err = store.Save(snap)
+	}
+	if err != nil {
+		slog.Warn("failed to save index snapshot",
+			slog.String("session_id", sessionID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	idx.mu.Lock()
+	state := idx.getSessionState(sessionID)
+	state.snapshotAt = snap.SavedAt
+	state.unsavedEntries = 0
+	state.unsavedIDs = nil
+	idx.mu.Unlock()
+}
+
+// OldestSyncTime returns the earliest last sync time among sessions, the
+// zero time if any was never synced.
+func (idx *Indexer) OldestSyncTime(sessionIDs []string) time.Time {
+	var oldest time.Time
+	for i, id := range sessionIDs {
+		synced := idx.LastSyncTime(id)
+		if synced.IsZero() {
+			return synced
+		}
+		if i == 0 || synced.Before(oldest) {
+			oldest = synced
+		}
+	}
+	return oldest
+}
+
 // LastSyncTime returns the last sync time for a session.
 func (idx *Indexer) LastSyncTime(sessionID string) time.Time {
 	state := idx.getSessionStateCopy(sessionID)
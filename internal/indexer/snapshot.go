@@ -0,0 +1,333 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RoaringBitmap/roaring/v2"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout or the meaning of
+// indexed fields changes; snapshots with another version are ignored.
+const snapshotVersion = 6
+
+// Snapshot is the persisted index state for one session.
+// Doc IDs are local to the snapshot (0..len(Metas)-1, in session order) and
+// are rebased onto the indexer's doc ID space when loaded.
+type Snapshot struct {
+	Version   int
+	SessionID string
+	SavedAt   time.Time
+
+	// Session refresh state at save time
+	EntryIDsLen int
+	TailEntryID string
+	SyncedAt    time.Time
+
+	// BodyIndexed records whether body tokens were indexed when saved.
+	BodyIndexed bool
+
+	Metas []*EntryMeta
+
+	// Serialized bitmaps keyed by index name, then by index key.
+	// Int-keyed indexes (pid, status) use the decimal key.
+	Bitmaps map[string]map[string][]byte
+
+	// Entries optionally holds full entries (with bodies) to warm the entry cache.
+	Entries []*client.SessionEntry
+}
+
+// SnapshotStore persists per-session index snapshots.
+type SnapshotStore interface {
+	// Load returns the snapshot for a session, or (nil, nil) if none exists.
+	Load(sessionID string) (*Snapshot, error)
+	// Save replaces the snapshot for a session.
+	Save(snap *Snapshot) error
+}
+
+// FileSnapshotStore stores one gob-encoded snapshot file per session in a directory.
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore creates a store rooted at dir, creating it if needed.
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating snapshot dir: %w", err)
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+// path returns the snapshot file for a session. Session IDs are hashed so any
+// ID is a safe file name.
+func (s *FileSnapshotStore) path(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16])+".snap")
+}
+
+// Load reads a session snapshot.
+func (s *FileSnapshotStore) Load(sessionID string) (*Snapshot, error) {
+	f, err := os.Open(s.path(sessionID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snap Snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	if snap.SessionID != sessionID {
+		return nil, nil
+	}
+	return &snap, nil
+}
+
+// Save writes a session snapshot atomically (temp file + rename).
+func (s *FileSnapshotStore) Save(snap *Snapshot) error {
+	tmp, err := os.CreateTemp(s.dir, "snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(snap.SessionID))
+}
+
+// SetSnapshotStore enables snapshot persistence. Pass nil to disable it.
+func (idx *Indexer) SetSnapshotStore(store SnapshotStore) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.snapshots = store
+}
+
+// stringIndexes returns the string-keyed bitmap indexes by snapshot name.
+// Caller must hold idx.mu.
+func (idx *Indexer) stringIndexes() map[string]map[string]*roaring.Bitmap {
+	return map[string]map[string]*roaring.Bitmap{
+		"host":           idx.idxHost,
+		"method":         idx.idxMethod,
+		"process_name":   idx.idxProcessName,
+		"http_version":   idx.idxHTTPVersion,
+		"header_name":    idx.idxHeaderName,
+		"header_value":   idx.idxHeaderValue,
+		"tls_connection": idx.idxTLSConnection,
+		"h2_connection":  idx.idxH2Connection,
+		"ja3":            idx.idxJA3,
+		"ja4":            idx.idxJA4,
+		"ja4h":           idx.idxJA4H,
+		"ja4s":           idx.idxJA4S,
+		"ja4x":           idx.idxJA4X,
+		"token":          idx.idxToken,
+		"header_token":   idx.idxHeaderToken,
+		"body_token":     idx.idxBodyToken,
+	}
+}
+
+// intIndexes returns the int-keyed bitmap indexes by snapshot name.
+// Caller must hold idx.mu.
+func (idx *Indexer) intIndexes() map[string]map[int]*roaring.Bitmap {
+	return map[string]map[int]*roaring.Bitmap{
+		"pid":    idx.idxPID,
+		"status": idx.idxStatus,
+	}
+}
+
+// buildSnapshot captures the indexed entries of a session. entryIDs is the
+// session's current entry list; entries that were never indexed (e.g. beyond
+// BOOTSTRAP_TAIL_LIMIT) are left out.
+func (idx *Indexer) buildSnapshot(sessionID string, entryIDs []string) (*Snapshot, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	state := idx.sessions[sessionID]
+	if state == nil {
+		return nil, fmt.Errorf("session %q has not been synced", sessionID)
+	}
+
+	snap := &Snapshot{
+		Version:     snapshotVersion,
+		SessionID:   sessionID,
+		SavedAt:     time.Now(),
+		EntryIDsLen: state.lastEntryIDsLen,
+		TailEntryID: state.lastTailEntryID,
+		SyncedAt:    state.lastSyncAt,
+		BodyIndexed: idx.BodyIndexEnabled(),
+		Bitmaps:     make(map[string]map[string][]byte),
+	}
+
+	// Map global doc IDs to local ones in session order
+	local := make(map[uint32]uint32)
+	members := roaring.New()
+	for _, id := range entryIDs {
+		docID, ok := idx.idToDoc[id]
+		if !ok {
+			continue
+		}
+		if _, dup := local[docID]; dup {
+			continue
+		}
+		meta := *idx.docToMeta[docID]
+		meta.DocID = uint32(len(snap.Metas))
+		local[docID] = meta.DocID
+		members.Add(docID)
+		snap.Metas = append(snap.Metas, &meta)
+	}
+
+	rebase := func(bm *roaring.Bitmap) ([]byte, error) {
+		shared := roaring.And(bm, members)
+		if shared.IsEmpty() {
+			return nil, nil
+		}
+		out := roaring.New()
+		it := shared.Iterator()
+		for it.HasNext() {
+			out.Add(local[it.Next()])
+		}
+		return out.ToBytes()
+	}
+
+	for name, index := range idx.stringIndexes() {
+		keys := make(map[string][]byte)
+		for key, bm := range index {
+			data, err := rebase(bm)
+			if err != nil {
+				return nil, err
+			}
+			if data != nil {
+				keys[key] = data
+			}
+		}
+		snap.Bitmaps[name] = keys
+	}
+	for name, index := range idx.intIndexes() {
+		keys := make(map[string][]byte)
+		for key, bm := range index {
+			data, err := rebase(bm)
+			if err != nil {
+				return nil, err
+			}
+			if data != nil {
+				keys[fmt.Sprint(key)] = data
+			}
+		}
+		snap.Bitmaps[name] = keys
+	}
+
+	// Keep the most recent cached entries, up to the cache capacity
+	if idx.config.IndexSnapshotEntries && idx.cache != nil {
+		limit := idx.config.EntryCacheMaxItems
+		for i := len(entryIDs) - 1; i >= 0 && len(snap.Entries) < limit; i-- {
+			if entry, ok := idx.cache.Get(entryIDs[i]); ok {
+				snap.Entries = append(snap.Entries, entry)
+			}
+		}
+	}
+
+	return snap, nil
+}
+
+// applySnapshot merges a snapshot into the index and restores the session
+// state. It returns false without changing anything if the snapshot is stale
+// for this configuration or overlaps entries that are already indexed.
+func (idx *Indexer) applySnapshot(snap *Snapshot) (bool, error) {
+	if snap.Version != snapshotVersion || (idx.BodyIndexEnabled() && !snap.BodyIndexed) {
+		return false, nil
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if state := idx.sessions[snap.SessionID]; state != nil && !state.lastSyncAt.IsZero() {
+		return false, nil
+	}
+	for _, meta := range snap.Metas {
+		if _, exists := idx.idToDoc[meta.EntryID]; exists {
+			return false, nil
+		}
+	}
+
+	// Decode every bitmap before touching the index so a corrupt snapshot
+	// leaves it unchanged.
+	base := idx.nextDocID
+	decoded := make(map[string]map[string]*roaring.Bitmap, len(snap.Bitmaps))
+	for name, keys := range snap.Bitmaps {
+		m := make(map[string]*roaring.Bitmap, len(keys))
+		for key, data := range keys {
+			bm := roaring.New()
+			if err := bm.UnmarshalBinary(data); err != nil {
+				return false, fmt.Errorf("decoding %s bitmap: %w", name, err)
+			}
+			m[key] = roaring.AddOffset(bm, base)
+		}
+		decoded[name] = m
+	}
+
+	sessionDocs := roaring.New()
+	for _, meta := range snap.Metas {
+		meta.DocID += base
+		meta.SessionID = snap.SessionID
+		idx.idToDoc[meta.EntryID] = meta.DocID
+		idx.docToMeta = append(idx.docToMeta, meta)
+		sessionDocs.Add(meta.DocID)
+	}
+	idx.nextDocID += uint32(len(snap.Metas))
+	mergeBitmap(idx.idxSession, snap.SessionID, sessionDocs)
+
+	for name, index := range idx.stringIndexes() {
+		for key, bm := range decoded[name] {
+			mergeBitmap(index, key, bm)
+		}
+	}
+	for name, index := range idx.intIndexes() {
+		for key, bm := range decoded[name] {
+			var k int
+			if _, err := fmt.Sscan(key, &k); err == nil {
+				mergeBitmap(index, k, bm)
+			}
+		}
+	}
+
+	// Entries are stored newest first; put them oldest first so the newest
+	// stay most recently used.
+	if idx.cache != nil {
+		for i := len(snap.Entries) - 1; i >= 0; i-- {
+			idx.cache.Put(snap.Entries[i].ID, snap.Entries[i])
+		}
+	}
+
+	state := idx.getSessionState(snap.SessionID)
+	state.lastEntryIDsLen = snap.EntryIDsLen
+	state.lastTailEntryID = snap.TailEntryID
+	state.lastSyncAt = snap.SyncedAt
+	state.snapshotAt = time.Now()
+	return true, nil
+}
+
+// mergeBitmap ORs bm into index[key].
+func mergeBitmap[K comparable](index map[K]*roaring.Bitmap, key K, bm *roaring.Bitmap) {
+	if existing, ok := index[key]; ok {
+		existing.Or(bm)
+		return
+	}
+	index[key] = bm
+}
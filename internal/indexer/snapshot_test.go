@@ -0,0 +1,339 @@
+package indexer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/cache"
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// fakeSource serves a single session from memory and counts entry and TLS
+// connection fetches.
+type fakeSource struct {
+	sessionID  string
+	entries    []*client.SessionEntry
+	tls        map[string][]client.TLSEvent // TLS events by connection ID
+	fetches    atomic.Int32
+	tlsFetches atomic.Int32
+}
+
+func (f *fakeSource) ListSessions(ctx context.Context) ([]client.Session, error) {
+	s, _ := f.GetSession(ctx, f.sessionID)
+	return []client.Session{*s}, nil
+}
+
+func (f *fakeSource) GetSession(ctx context.Context, sessionID string) (*client.Session, error) {
+	ids := make([]string, len(f.entries))
+	for i, e := range f.entries {
+		ids[i] = e.ID
+	}
+	return &client.Session{ID: f.sessionID, EntryIDs: ids}, nil
+}
+
+func (f *fakeSource) GetEntry(ctx context.Context, sessionID, entryID string) (*client.SessionEntry, error) {
+	f.fetches.Add(1)
+	for _, e := range f.entries {
+		if e.ID == entryID {
+			return e, nil
+		}
+	}
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) GetSessionBookmarks(ctx context.Context, sessionID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (f *fakeSource) ListEntries(ctx context.Context, sessionID string, opts *client.ListEntriesOptions) ([]client.SessionEntry, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) ListHTTP2StreamIDs(ctx context.Context, connectionID string) ([]int, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) GetTLSConnection(ctx context.Context, connectionID string) ([]client.TLSEvent, error) {
+	f.tlsFetches.Add(1)
+	if events, ok := f.tls[connectionID]; ok {
+		return events, nil
+	}
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) GetHTTP2Stream(ctx context.Context, connectionID string, streamID int) ([]json.RawMessage, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) GetWebSocketMessages(ctx context.Context, sessionID, entryID string) ([]client.WebSocketMessage, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func snapshotConfig(dir string) *config.Config {
+	return &config.Config{
+		RefreshTimeout:       5 * time.Second,
+		FetchWorkers:         4,
+		BootstrapTailLimit:   1000,
+		EntryCacheMaxItems:   16,
+		IndexBody:            true,
+		IndexBodyMaxBytes:    1024,
+		IndexSnapshotDir:     dir,
+		IndexSnapshotEntries: true,
+	}
+}
+
+func newSnapshotIndexer(t *testing.T, src client.DataSource, cfg *config.Config) *Indexer {
+	t.Helper()
+	c, err := cache.NewEntryCache(cfg.EntryCacheMaxItems)
+	require.NoError(t, err)
+	return New(src, c, cfg)
+}
+
+func makeSnapshotEntries(n int) []*client.SessionEntry {
+	body := base64.StdEncoding.EncodeToString([]byte(`{"name":"widget"}`))
+	out := make([]*client.SessionEntry, n)
+	for i := range out {
+		e := makeEntry(fmt.Sprintf("e%d", i), fmt.Sprintf("https://api%d.example.com/items/%d", i%2, i), "GET", 200+i%2)
+		e.Response.Body = &body
+		e.Response.Headers = client.Headers{{"content-type", "application/json"}}
+		out[i] = e
+	}
+	return out
+}
+
+func TestSnapshot_RestartFetchesOnlyDelta(t *testing.T) {
+	dir := t.TempDir()
+	cfg := snapshotConfig(dir)
+	ctx := context.Background()
+
+	src := &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(5)}
+	first := newSnapshotIndexer(t, src, cfg)
+	require.NoError(t, first.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(5), src.fetches.Load())
+
+	// Restart with two new entries appended
+	src = &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(7)}
+	second := newSnapshotIndexer(t, src, cfg)
+	require.NoError(t, second.RefreshSession(ctx, "s1"))
+
+	assert.Equal(t, int32(2), src.fetches.Load())
+	assert.Equal(t, 7, second.DocCount())
+	assert.Equal(t, first.GetBitmapForHost("api0.example.com").ToArray()[:3],
+		second.GetBitmapForHost("api0.example.com").ToArray()[:3])
+	assert.Equal(t, uint64(4), second.GetBitmapForHost("api0.example.com").GetCardinality())
+	assert.Equal(t, uint64(3), second.GetBitmapForStatus(201).GetCardinality())
+	assert.Equal(t, uint64(7), second.GetBitmapForBodyToken("widget").GetCardinality())
+
+	meta := second.GetMetaByEntryID("e4")
+	require.NotNil(t, meta)
+	assert.Equal(t, uint32(4), meta.DocID)
+	assert.Equal(t, "/items/4", meta.Path)
+
+	_, cached := second.cache.Get("e0")
+	assert.True(t, cached)
+}
+
+func TestSnapshot_RebasesOntoExistingDocs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := snapshotConfig(dir)
+	ctx := context.Background()
+
+	src := &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(3)}
+	require.NoError(t, newSnapshotIndexer(t, src, cfg).RefreshSession(ctx, "s1"))
+
+	idx := newSnapshotIndexer(t, src, cfg)
+	idx.Index(makeEntry("other", "https://other.example.com/", "POST", 500))
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+
+	assert.Equal(t, 4, idx.DocCount())
+	assert.Equal(t, []uint32{1, 3}, idx.GetBitmapForHost("api0.example.com").ToArray())
+	assert.Equal(t, []uint32{0}, idx.GetBitmapForMethod("POST").ToArray())
+	assert.Equal(t, "e2", idx.GetMeta(3).EntryID)
+	assert.Equal(t, "s1", idx.GetMeta(3).SessionID)
+	assert.Equal(t, []uint32{1, 2, 3}, idx.SessionDocIDs([]string{"s1"}).ToArray())
+}
+
+func TestSnapshot_KeyedByResolvedSession(t *testing.T) {
+	dir := t.TempDir()
+	cfg := snapshotConfig(dir)
+	ctx := context.Background()
+
+	src := &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(3)}
+	require.NoError(t, newSnapshotIndexer(t, src, cfg).RefreshSession(ctx, "active"))
+
+	store, err := NewFileSnapshotStore(dir)
+	require.NoError(t, err)
+	snap, err := store.Load("s1")
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	snap, err = store.Load("active")
+	require.NoError(t, err)
+	assert.Nil(t, snap)
+
+	// "active" now points at another capture; the s1 snapshot must not be restored
+	other := makeSnapshotEntries(2)
+	for i, e := range other {
+		e.ID = fmt.Sprintf("other%d", i)
+	}
+	src = &fakeSource{sessionID: "s2", entries: other}
+	idx := newSnapshotIndexer(t, src, cfg)
+	require.NoError(t, idx.RefreshSession(ctx, "active"))
+
+	assert.Equal(t, int32(2), src.fetches.Load())
+	assert.Equal(t, 2, idx.DocCount())
+	assert.Nil(t, idx.GetMetaByEntryID("e0"))
+	assert.Equal(t, []uint32{0, 1}, idx.SessionDocIDs([]string{"s2"}).ToArray())
+	assert.True(t, idx.SessionDocIDs([]string{"active"}).IsEmpty())
+}
+
+func TestResolveSessions(t *testing.T) {
+	ctx := context.Background()
+	idx := newSnapshotIndexer(t, &fakeSource{sessionID: "s1"}, snapshotConfig(t.TempDir()))
+
+	got, err := idx.ResolveSessions(ctx, "s9", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s9"}, got)
+
+	got, err = idx.ResolveSessions(ctx, "", []string{"s2", AllSessions, "s2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s2", "s1"}, got)
+
+	got, err = idx.ResolveSessions(ctx, ActiveSession, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, got)
+
+	got, err = idx.ResolveSessions(ctx, "", []string{ActiveSession, "s1", "s2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1", "s2"}, got)
+}
+
+func TestRefresh_ActiveAndConcreteID(t *testing.T) {
+	cfg := snapshotConfig(t.TempDir())
+	cfg.FreshnessThreshold = time.Hour
+	ctx := context.Background()
+
+	src := &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(3)}
+	idx := newSnapshotIndexer(t, src, cfg)
+	require.NoError(t, idx.RefreshIfStale(ctx, ActiveSession))
+	require.NoError(t, idx.RefreshIfStale(ctx, "s1"))
+	require.NoError(t, idx.RefreshIfStale(ctx, ActiveSession))
+
+	// Both names share one index and one sync
+	assert.Equal(t, int32(3), src.fetches.Load())
+	assert.Equal(t, []uint32{0, 1, 2}, idx.SessionDocIDs([]string{"s1"}).ToArray())
+	assert.True(t, idx.SessionDocIDs([]string{ActiveSession}).IsEmpty())
+	assert.Equal(t, "s1", idx.GetMetaByEntryID("e0").SessionID)
+	assert.False(t, idx.LastSyncTime("s1").IsZero())
+}
+
+func TestSnapshot_Skipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(idx *Indexer, snap *Snapshot)
+	}{
+		{"version mismatch", func(idx *Indexer, snap *Snapshot) { snap.Version++ }},
+		{"body index missing", func(idx *Indexer, snap *Snapshot) { snap.BodyIndexed = false }},
+		{"overlapping entries", func(idx *Indexer, snap *Snapshot) {
+			idx.Index(makeEntry("e0", "https://a.com/", "GET", 200))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := snapshotConfig(dir)
+			src := &fakeSource{sessionID: "s1", entries: makeSnapshotEntries(2)}
+			require.NoError(t, newSnapshotIndexer(t, src, cfg).RefreshSession(context.Background(), "s1"))
+
+			store, err := NewFileSnapshotStore(dir)
+			require.NoError(t, err)
+			snap, err := store.Load("s1")
+			require.NoError(t, err)
+			require.NotNil(t, snap)
+
+			idx := newSnapshotIndexer(t, src, cfg)
+			tt.mutate(idx, snap)
+			before := idx.DocCount()
+
+			applied, err := idx.applySnapshot(snap)
+			require.NoError(t, err)
+			assert.False(t, applied)
+			assert.Equal(t, before, idx.DocCount())
+			assert.Nil(t, idx.getSessionStateCopy("s1"))
+		})
+	}
+}
+
+func TestFileSnapshotStore_Missing(t *testing.T) {
+	store, err := NewFileSnapshotStore(t.TempDir())
+	require.NoError(t, err)
+
+	snap, err := store.Load("nope")
+	assert.NoError(t, err)
+	assert.Nil(t, snap)
+}
+
+// countingStore counts saves to the wrapped store.
+type countingStore struct {
+	SnapshotStore
+	saves atomic.Int32
+}
+
+func (s *countingStore) Save(snap *Snapshot) error {
+	s.saves.Add(1)
+	return s.SnapshotStore.Save(snap)
+}
+
+func TestSnapshot_SmallRefreshesAreBatched(t *testing.T) {
+	dir := t.TempDir()
+	cfg := snapshotConfig(dir)
+	cfg.IndexSnapshotInterval = time.Hour
+	cfg.IndexSnapshotMinEntries = 3
+	ctx := context.Background()
+
+	fileStore, err := NewFileSnapshotStore(dir)
+	require.NoError(t, err)
+	store := &countingStore{SnapshotStore: fileStore}
+
+	all := makeSnapshotEntries(10)
+	src := &fakeSource{sessionID: "s1", entries: all[:5]}
+	idx := newSnapshotIndexer(t, src, cfg)
+	idx.SetSnapshotStore(store)
+
+	// The initial sync is saved right away
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(1), store.saves.Load())
+
+	// One new entry per refresh stays pending until the threshold
+	for n := 6; n <= 7; n++ {
+		src.entries = all[:n]
+		require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	}
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(1), store.saves.Load())
+
+	src.entries = all[:8]
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(2), store.saves.Load())
+
+	// Pending entries are written on flush, and only once
+	src.entries = all[:9]
+	require.NoError(t, idx.RefreshSession(ctx, "s1"))
+	assert.Equal(t, int32(2), store.saves.Load())
+	idx.FlushSnapshots()
+	idx.FlushSnapshots()
+	assert.Equal(t, int32(3), store.saves.Load())
+
+	snap, err := fileStore.Load("s1")
+	require.NoError(t, err)
+	assert.Len(t, snap.Metas, 9)
+}
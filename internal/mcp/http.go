@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HTTP endpoint paths served by HTTPHandler.
+const (
+	StreamablePath = "/mcp" // streamable HTTP transport
+	SSEPath        = "/sse" // legacy HTTP+SSE transport (fallback for older clients)
+)
+
+// shutdownTimeout bounds how long RunHTTP waits for in-flight requests on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// HTTPOptions configures the HTTP transport.
+type HTTPOptions struct {
+	// AllowedOrigins lists browser origins permitted to connect (e.g. "https://app.example.com").
+	// Requests without an Origin header are always accepted. When empty, only
+	// localhost origins are accepted. "*" accepts any origin.
+	AllowedOrigins []string
+
+	// AllowedHosts lists Host header values (hostname, optionally with port)
+	// the server answers to. When empty, only loopback hosts are accepted,
+	// which blocks DNS rebinding against a localhost server. "*" accepts any host.
+	AllowedHosts []string
+
+	// BearerToken, when set, must be presented as "Authorization: Bearer <token>".
+	BearerToken string
+}
+
+// HTTPHandler returns an http.Handler serving the streamable HTTP transport
+// at StreamablePath and the SSE fallback at SSEPath.
+// All connected clients share this server and its warm index.
+func (s *Server) HTTPHandler(opts HTTPOptions) http.Handler {
+	getServer := func(*http.Request) *sdkmcp.Server { return s.mcpServer }
+
+	mux := http.NewServeMux()
+	mux.Handle(StreamablePath, sdkmcp.NewStreamableHTTPHandler(getServer, &sdkmcp.StreamableHTTPOptions{
+		Logger: slog.Default(),
+	}))
+	mux.Handle(SSEPath, sdkmcp.NewSSEHandler(getServer, nil))
+
+	return checkHost(opts.AllowedHosts, requireBearer(opts.BearerToken, checkOrigin(opts.AllowedOrigins, mux)))
+}
+
+// RunHTTP serves the HTTP transport on addr until the context is cancelled.
+//
+// Binding a non-loopback address requires a bearer token. In that case the
+// Host allowlist defaults to any host, since remote clients reach the server
+// by names it cannot know and the token already blocks rebinding attacks.
+func (s *Server) RunHTTP(ctx context.Context, addr string, opts HTTPOptions) error {
+	if !isLoopbackAddr(addr) {
+		if opts.BearerToken == "" {
+			return fmt.Errorf("refusing to serve on non-loopback address %q without a bearer token (set MCP_HTTP_BEARER_TOKEN or bind 127.0.0.1)", addr)
+		}
+		if len(opts.AllowedHosts) == 0 {
+			opts.AllowedHosts = []string{"*"}
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.HTTPHandler(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return ctx.Err()
+	}
+}
+
+// checkOrigin rejects browser requests from origins outside the allowlist,
+// guarding against DNS rebinding from pages the user happens to visit.
+func checkOrigin(allowed []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && !originAllowed(origin, allowed) {
+			slog.Warn("rejected request from disallowed origin", "origin", origin, "path", r.URL.Path)
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkHost rejects requests whose Host header is outside the allowlist.
+func checkHost(allowed []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hostAllowed(r.Host, allowed) {
+			slog.Warn("rejected request for disallowed host", "host", r.Host, "path", r.URL.Path)
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hostAllowed reports whether a Host header value matches the allowlist.
+// An empty allowlist permits loopback hosts only. Allowlist entries without
+// a port match any port.
+func hostAllowed(host string, allowed []string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	hostname = strings.Trim(hostname, "[]")
+
+	if len(allowed) == 0 {
+		return isLoopbackHost(hostname)
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, host) || strings.EqualFold(a, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackHost reports whether hostname is localhost or a loopback IP.
+func isLoopbackHost(hostname string) bool {
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLoopbackAddr reports whether a listen address binds loopback only.
+// An empty host (":8765") binds all interfaces.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	return host != "" && isLoopbackHost(host)
+}
+
+// originAllowed reports whether origin matches the allowlist.
+// An empty allowlist permits loopback origins only.
+func originAllowed(origin string, allowed []string) bool {
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return isLoopbackHost(u.Hostname())
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireBearer enforces a static bearer token when one is configured.
+func requireBearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="powhttp-mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/internal/mcp/tools"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"localhost default", "http://localhost:3000", nil, true},
+		{"loopback ipv4 default", "http://127.0.0.1:8080", nil, true},
+		{"loopback ipv6 default", "http://[::1]:8080", nil, true},
+		{"remote rejected by default", "https://evil.example.com", nil, false},
+		{"explicit match", "https://app.example.com", []string{"https://app.example.com"}, true},
+		{"trailing slash in allowlist", "https://app.example.com", []string{"https://app.example.com/"}, true},
+		{"explicit mismatch", "https://evil.example.com", []string{"https://app.example.com"}, false},
+		{"localhost not implied by allowlist", "http://localhost:3000", []string{"https://app.example.com"}, false},
+		{"wildcard", "https://anything.example.com", []string{"*"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, originAllowed(tt.origin, tt.allowed))
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	h := checkOrigin(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"no origin header", "", http.StatusOK},
+		{"localhost origin", "http://localhost:5173", http.StatusOK},
+		{"foreign origin", "https://evil.example.com", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, StreamablePath, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestRequireBearer(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"no token configured", "", "", http.StatusOK},
+		{"valid token", "s3cret", "Bearer s3cret", http.StatusOK},
+		{"missing header", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "s3cret", "Basic s3cret", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, StreamablePath, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			requireBearer(tt.token, ok).ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		allowed []string
+		want    bool
+	}{
+		{"localhost default", "localhost:8765", nil, true},
+		{"loopback ipv4 default", "127.0.0.1:8765", nil, true},
+		{"loopback ipv6 default", "[::1]:8765", nil, true},
+		{"no port default", "localhost", nil, true},
+		{"rebinding name rejected by default", "attacker.example.com:8765", nil, false},
+		{"hostname entry matches any port", "mcp.internal:8765", []string{"mcp.internal"}, true},
+		{"host:port entry", "mcp.internal:8765", []string{"mcp.internal:8765"}, true},
+		{"host:port entry wrong port", "mcp.internal:9000", []string{"mcp.internal:8765"}, false},
+		{"loopback not implied by allowlist", "localhost:8765", []string{"mcp.internal"}, false},
+		{"wildcard", "anything:1", []string{"*"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hostAllowed(tt.host, tt.allowed))
+		})
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	assert.True(t, isLoopbackAddr("127.0.0.1:8765"))
+	assert.True(t, isLoopbackAddr("localhost:8765"))
+	assert.True(t, isLoopbackAddr("[::1]:8765"))
+	assert.False(t, isLoopbackAddr(":8765"))
+	assert.False(t, isLoopbackAddr("0.0.0.0:8765"))
+	assert.False(t, isLoopbackAddr("10.0.0.5:8765"))
+}
+
+func TestRunHTTP_RefusesPublicAddrWithoutToken(t *testing.T) {
+	s, err := NewServer(&tools.Deps{Config: &config.Config{}})
+	require.NoError(t, err)
+
+	err = s.RunHTTP(t.Context(), ":0", HTTPOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bearer token")
+}
+
+func TestHTTPHandler_Initialize(t *testing.T) {
+	s, err := NewServer(&tools.Deps{Config: &config.Config{}})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(s.HTTPHandler(HTTPOptions{BearerToken: "s3cret"}))
+	defer ts.Close()
+
+	const initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+	post := func(host string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+StreamablePath, strings.NewReader(initialize))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Authorization", "Bearer s3cret")
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post("")
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Mcp-Session-Id"))
+	assert.Contains(t, string(body), `"protocolVersion"`)
+	assert.Contains(t, string(body), `"powhttp-mcp"`)
+
+	resp = post("attacker.example.com")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -155,7 +155,7 @@ func HandleBuildAPIMap(cfg *Config) func(ctx context.Context, req *sdkmcp.GetPro
 		sb.WriteString("Use a layered approach to map authentication:\n")
 		sb.WriteString("1. **`describe_endpoint` -> `auth_signals`**: Fastest - shows cookies, bearer tokens, and custom auth headers per endpoint cluster\n")
 		sb.WriteString("2. **`search_entries` -> `header_contains`**: Find specific auth patterns across all traffic (e.g., `\"bearer\"`, `\"x-api-key\"`)\n")
-		sb.WriteString("3. **`trace_flow` -> `edge_type_summary`**: Map auth propagation - `auth_chain` (token reuse), `session_cookie_origin` (Set-Cookie -> Cookie), `same_auth` (shared Authorization), `same_api_key` (shared API key)\n\n")
+		sb.WriteString("3. **`trace_flow` -> `edge_type_summary`**: Map auth propagation - `auth_chain` (token reuse), `session_cookie_origin` (Set-Cookie -> Cookie), `same_auth` (shared Authorization), `same_api_key` (shared API key), `redirect` (OAuth/SSO hops across hosts)\n\n")
 
 		sb.WriteString("## Tips\n\n")
 		sb.WriteString("- Start broad to see overall API surface, then narrow focus\n")
@@ -115,7 +115,7 @@ func HandleCompareBrowserProgram(cfg *Config) func(ctx context.Context, req *sdk
 		sb.WriteString("## Detection Vectors (Priority Order)\n\n")
 		sb.WriteString("1. **[CRITICAL]** TLS Fingerprint (JA3/JA4) - Blocks 80%+ of bots\n")
 		sb.WriteString("2. **[HIGH]** HTTP/2 pseudo-header order - Common in modern sites\n")
-		sb.WriteString("3. **[HIGH]** HTTP/2 SETTINGS frame order - Often overlooked\n")
+		sb.WriteString("3. **[HIGH]** HTTP/2 SETTINGS, WINDOW_UPDATE, and PRIORITY frames (Akamai fingerprint) - Often overlooked\n")
 		sb.WriteString("4. **[MEDIUM]** Header presence/order - Detectable pattern\n")
 		sb.WriteString("5. **[LOW]** Header value differences - Usually less critical\n\n")
 
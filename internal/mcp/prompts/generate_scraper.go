@@ -79,6 +79,7 @@ func HandleGenerateScraper(cfg *Config) func(ctx context.Context, req *sdkmcp.Ge
 		sb.WriteString("   - `powhttp_search_entries(filters={header_contains: \"authorization\"})` - Find Bearer/Basic auth across all traffic\n")
 		sb.WriteString("   - `powhttp_search_entries(filters={header_contains: \"x-api-key\"})` - Find API key auth\n")
 		sb.WriteString("   - `powhttp_trace_flow(seed_entry_id=\"...\")` - Check `edge_type_summary` for `auth_chain`, `session_cookie_origin`, `same_auth` edges to map auth flow\n")
+		sb.WriteString("   - `powhttp_trace_flow(seed_entry_id=\"...\", options={value_propagation: true})` - `value_propagation` edges show which response field (`from_path`) feeds which request header, cookie, or param (`to_path`), e.g. CSRF tokens and IDs the scraper must carry forward\n")
 		sb.WriteString("2. **Analyze Structure**:\n")
 		sb.WriteString("   Pick the relevant `cluster_id` and run: `powhttp_describe_endpoint(cluster_id=\"...\")`\n")
 		sb.WriteString("3. **Get Fingerprint Data**:\n")
@@ -142,7 +143,8 @@ func HandleGenerateScraper(cfg *Config) func(ctx context.Context, req *sdkmcp.Ge
 		sb.WriteString("#### C. Header Logic (Crucial)\n")
 		sb.WriteString("You MUST use `http.HeaderOrderKey` and `http.PHeaderOrderKey` from `fhttp`.\n")
 		sb.WriteString("1. Copy the headers **exactly** as they appear in `powhttp_get_entry`.\n")
-		sb.WriteString("2. Populate `HeaderOrderKey` and `PHeaderOrderKey` with the exact order found in the captured traffic.\n\n")
+		sb.WriteString("2. Populate `HeaderOrderKey` and `PHeaderOrderKey` with the exact order found in the captured traffic.\n")
+		sb.WriteString("3. `powhttp_generate_code(entry_id=\"...\", language=\"go-tls-client\")` renders this block from the capture; start from its output instead of copying by hand.\n\n")
 
 		sb.WriteString("⚠️ **IMPORTANT: Do NOT manually set these headers**:\n")
 		sb.WriteString("- `Cookie` / `Set-Cookie` - Handled automatically by `tls_client.WithCookieJar(jar)`\n")
@@ -25,6 +25,9 @@ import (
 //   powhttp://graphql/{session}/{operation}/response-schema
 //   powhttp://graphql/{session}/{operation}/field-stats
 //   powhttp://graphql/{session}/{operation}/errors
+//   powhttp://websocket/{session}/{entry}
+//   powhttp://har/{export_id}
+//   powhttp://openapi/{export_id}
 
 // registerResources registers resource templates and handlers.
 func (s *Server) registerResources() {
@@ -142,6 +145,39 @@ func (s *Server) registerResources() {
 			Priority: 0.5,
 		},
 	}, s.handleResourceGraphQLErrors)
+
+	s.mcpServer.AddResourceTemplate(&sdkmcp.ResourceTemplate{
+		URITemplate: "powhttp://websocket/{session}/{entry}",
+		Name:        "WebSocket Messages",
+		Description: "Every decoded frame on a WebSocket connection. High context cost - websocket_messages tool already pages and filters frames. Only fetch for a complete transcript.",
+		MIMEType:    tools.MimeJSON,
+		Annotations: &sdkmcp.Annotations{
+			Audience: []sdkmcp.Role{"assistant"},
+			Priority: 0.3,
+		},
+	}, s.handleResourceWebSocket)
+
+	s.mcpServer.AddResourceTemplate(&sdkmcp.ResourceTemplate{
+		URITemplate: "powhttp://har/{export_id}",
+		Name:        "HAR Export",
+		Description: "HAR 1.2 document built by export_har. High context cost - prefer export_har with output_path to write it to disk.",
+		MIMEType:    tools.MimeJSON,
+		Annotations: &sdkmcp.Annotations{
+			Audience: []sdkmcp.Role{"assistant"},
+			Priority: 0.2,
+		},
+	}, s.handleResourceHAR)
+
+	s.mcpServer.AddResourceTemplate(&sdkmcp.ResourceTemplate{
+		URITemplate: "powhttp://openapi/{export_id}",
+		Name:        "OpenAPI Export",
+		Description: "OpenAPI 3.1 document built by export_openapi. High context cost - prefer export_openapi with output_path to write it to disk.",
+		MIMEType:    tools.MimeJSON,
+		Annotations: &sdkmcp.Annotations{
+			Audience: []sdkmcp.Role{"assistant"},
+			Priority: 0.2,
+		},
+	}, s.handleResourceOpenAPI)
 }
 
 // Resource handlers
@@ -263,6 +299,54 @@ func (s *Server) handleResourceFlow(ctx context.Context, req *sdkmcp.ReadResourc
 	return toResourceResult(req.Params.URI, graph)
 }
 
+func (s *Server) handleResourceWebSocket(ctx context.Context, req *sdkmcp.ReadResourceRequest) (*sdkmcp.ReadResourceResult, error) {
+	params, err := parseResourceURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.deps.Client.GetWebSocketMessages(ctx, params["session"], params["entry"])
+	if err != nil {
+		return nil, tools.WrapPowHTTPError(err)
+	}
+
+	content := map[string]any{
+		"session_id": params["session"],
+		"entry_id":   params["entry"],
+		"messages":   tools.WebSocketTranscript(messages, s.deps.Config.ResourceMaxBodyBytes),
+	}
+
+	return toResourceResult(req.Params.URI, content)
+}
+
+func (s *Server) handleResourceHAR(ctx context.Context, req *sdkmcp.ReadResourceRequest) (*sdkmcp.ReadResourceResult, error) {
+	params, err := parseResourceURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.deps.HARExports.Get(params["export_id"])
+	if !ok {
+		return nil, sdkmcp.ResourceNotFoundError(req.Params.URI)
+	}
+
+	return toResourceResult(req.Params.URI, doc)
+}
+
+func (s *Server) handleResourceOpenAPI(ctx context.Context, req *sdkmcp.ReadResourceRequest) (*sdkmcp.ReadResourceResult, error) {
+	params, err := parseResourceURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.deps.OpenAPIExports.Get(params["export_id"])
+	if !ok {
+		return nil, sdkmcp.ResourceNotFoundError(req.Params.URI)
+	}
+
+	return toResourceResult(req.Params.URI, doc)
+}
+
 // Helper functions
 
 // parseResourceURI extracts parameters from a powhttp:// URI.
@@ -333,6 +417,25 @@ func parseResourceURI(uri string) (map[string]string, error) {
 		params["operation"] = parts[2]
 		params["aspect"] = parts[3]
 
+	case "websocket":
+		if len(parts) < 3 {
+			return nil, tools.ErrInvalidInput("websocket URI requires session and entry ID")
+		}
+		params["session"] = parts[1]
+		params["entry"] = parts[2]
+
+	case "har":
+		if len(parts) < 2 {
+			return nil, tools.ErrInvalidInput("har URI requires export ID")
+		}
+		params["export_id"] = parts[1]
+
+	case "openapi":
+		if len(parts) < 2 {
+			return nil, tools.ErrInvalidInput("openapi URI requires export ID")
+		}
+		params["export_id"] = parts[1]
+
 	default:
 		return nil, tools.ErrInvalidInput(fmt.Sprintf("unknown resource type: %s", resourceType))
 	}
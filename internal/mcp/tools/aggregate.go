@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/search"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// AggregateInput is the input for powhttp_aggregate.
+type AggregateInput struct {
+	SessionID  string                `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	SessionIDs []string              `json:"session_ids,omitempty" jsonschema:"Aggregate across these sessions instead of session_id; [\"all\"] for every session. Group by session to compare them."`
+	Query      string                `json:"query,omitempty" jsonschema:"Search query selecting entries (same language as search_entries, e.g. 'host:*.example.com AND NOT method:OPTIONS')"`
+	Filters    *SearchEntriesFilters `json:"filters,omitempty" jsonschema:"Structured filters selecting entries (same as search_entries)"`
+	GroupBy    []string              `json:"group_by,omitempty" jsonschema:"Group keys, combined when several: host, method, status, status_class, process, pid, ja3, ja4, ja4h, ja4s, content_type, http_version, cluster (extract_endpoints cluster ID), session, or header:<name> for a header value. Omit for totals only."`
+	BucketMs   int64                 `json:"bucket_ms,omitempty" jsonschema:"Time bucket width in ms for a histogram of entry start times (e.g. 60000 for per-minute). Omit to skip histograms."`
+	SortBy     string                `json:"sort_by,omitempty" jsonschema:"Group order, descending: count (default), errors, error_rate, bytes, p50, p90, p99"`
+	Limit      int                   `json:"limit,omitempty" jsonschema:"Max groups returned (default: 20, max: 500)"`
+}
+
+// AggregateOutput is the output for powhttp_aggregate.
+type AggregateOutput struct {
+	Groups     []types.AggregateGroup `json:"groups,omitzero"`
+	Total      types.AggregateGroup   `json:"total"`
+	GroupCount int                    `json:"group_count,omitempty"`
+	SyncedAtMs int64                  `json:"synced_at_ms"`
+	Hint       string                 `json:"hint,omitempty"`
+}
+
+// ToolAggregate groups matched entries and reports per-group statistics.
+func ToolAggregate(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input AggregateInput) (*sdkmcp.CallToolResult, AggregateOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input AggregateInput) (*sdkmcp.CallToolResult, AggregateOutput, error) {
+		sessionID, sessionIDs, err := d.ResolveSessionScope(ctx, input.SessionID, input.SessionIDs)
+		if err != nil {
+			return nil, AggregateOutput{}, err
+		}
+
+		resp, err := d.Search.Aggregate(ctx, &types.AggregateRequest{
+			SessionID:  sessionID,
+			SessionIDs: sessionIDs,
+			Query:      input.Query,
+			Filters:    input.Filters.toSearchFilters(),
+			GroupBy:    input.GroupBy,
+			BucketMs:   input.BucketMs,
+			SortBy:     input.SortBy,
+			Limit:      input.Limit,
+		})
+		if err != nil {
+			if errors.Is(err, search.ErrInvalidAggregation) {
+				return nil, AggregateOutput{}, ErrInvalidInput(err.Error())
+			}
+			return nil, AggregateOutput{}, wrapSearchError(err)
+		}
+
+		var hint string
+		switch {
+		case resp.Total.Count == 0:
+			hint = "No entries matched. Check session_id and loosen query or filters."
+		case len(resp.Groups) < resp.GroupCount:
+			hint = fmt.Sprintf("Showing top %d of %d groups by %s. Raise limit or narrow the query to see the rest.",
+				len(resp.Groups), resp.GroupCount, sortLabel(input.SortBy))
+		case len(resp.Groups) > 0:
+			top := resp.Groups[0]
+			hint = fmt.Sprintf("Top group %s: %d entries, %.0f%% errors. Use search_entries with the same query plus the group key to list its entries.",
+				formatGroupKey(top.Key, input.GroupBy), top.Count, top.ErrorRate*100)
+		}
+
+		return nil, AggregateOutput{
+			Groups:     resp.Groups,
+			Total:      resp.Total,
+			GroupCount: resp.GroupCount,
+			SyncedAtMs: resp.SyncedAtMs,
+			Hint:       hint,
+		}, nil
+	}
+}
+
+func sortLabel(sortBy string) string {
+	if sortBy == "" {
+		return "count"
+	}
+	return sortBy
+}
+
+// formatGroupKey renders a group key as "name=value" pairs in group_by order.
+func formatGroupKey(key map[string]string, groupBy []string) string {
+	parts := make([]string, 0, len(groupBy))
+	for _, name := range groupBy {
+		name = strings.ToLower(strings.TrimSpace(name))
+		parts = append(parts, name+"="+key[name])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func TestCheckOutputSchema_Aggregate(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[AggregateOutput]("powhttp_aggregate")
+	})
+}
+
+func aggregateTestDeps(t *testing.T) *Deps {
+	t.Helper()
+	ms := func(v int64) *int64 { return &v }
+	timed := func(e *client.SessionEntry, startedAt, waitMs int64) *client.SessionEntry {
+		e.Timings = client.Timings{StartedAt: startedAt, Send: ms(0), Wait: ms(waitMs), Receive: ms(0)}
+		return e
+	}
+	return newTestDeps(t, newFakeSource("s1",
+		timed(testEntry("e1", "GET", "https://api.example.com/users/1", 200, "", `{"id":1}`), 1_000, 10),
+		timed(testEntry("e2", "GET", "https://api.example.com/users/2", 500, "", `{"error":"x"}`), 2_000, 30),
+		timed(testEntry("e3", "POST", "https://api.example.com/users", 429, `{"n":"a"}`, ""), 61_000, 20),
+		timed(testEntry("e4", "GET", "https://cdn.example.net/app.js", 200, "", "console.log(1)"), 125_000, 5),
+		timed(testEntry("e5", "OPTIONS", "https://api.example.com/users", 204, "", ""), 126_000, 1),
+	))
+}
+
+func TestToolAggregate_GroupByHost(t *testing.T) {
+	d := aggregateTestDeps(t)
+
+	_, out, err := ToolAggregate(d)(context.Background(), nil, AggregateInput{
+		SessionID: "s1",
+		Query:     "NOT method:OPTIONS",
+		GroupBy:   []string{"host"},
+		SortBy:    "errors",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, out.Total.Count)
+	assert.Equal(t, 2, out.GroupCount)
+	require.Len(t, out.Groups, 2)
+
+	api := out.Groups[0]
+	assert.Equal(t, map[string]string{"host": "api.example.com"}, api.Key)
+	assert.Equal(t, 3, api.Count)
+	assert.Equal(t, 2, api.Errors)
+	assert.InDelta(t, 2.0/3.0, api.ErrorRate, 1e-9)
+	assert.Equal(t, int64(len(`{"id":1}`)+len(`{"error":"x"}`)), api.RespBytes)
+	assert.Equal(t, int64(len(`{"n":"a"}`)), api.ReqBytes)
+	require.NotNil(t, api.Latency)
+	assert.Equal(t, 3, api.Latency.Samples)
+	assert.Equal(t, int64(20), api.Latency.P50)
+	assert.Equal(t, int64(30), api.Latency.P99)
+	assert.Equal(t, []string{"e1", "e2", "e3"}, api.ExampleEntryIDs)
+
+	assert.Equal(t, map[string]string{"host": "cdn.example.net"}, out.Groups[1].Key)
+	assert.Contains(t, out.Hint, "host=api.example.com")
+}
+
+func TestToolAggregate_CompositeKeysAndBuckets(t *testing.T) {
+	d := aggregateTestDeps(t)
+
+	_, out, err := ToolAggregate(d)(context.Background(), nil, AggregateInput{
+		SessionID: "s1",
+		GroupBy:   []string{"method", "status_class"},
+		BucketMs:  60_000,
+		Limit:     2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, out.GroupCount)
+	require.Len(t, out.Groups, 2)
+	assert.Equal(t, map[string]string{"method": "GET", "status_class": "2xx"}, out.Groups[0].Key)
+	assert.Equal(t, 2, out.Groups[0].Count)
+	assert.Contains(t, out.Hint, "Showing top 2 of 4")
+
+	// Group histograms list only non-empty buckets; the total covers the whole range
+	assert.Len(t, out.Groups[0].Buckets, 2)
+	require.Len(t, out.Total.Buckets, 3)
+	assert.Equal(t, int64(0), out.Total.Buckets[0].StartMs)
+	assert.Equal(t, 2, out.Total.Buckets[0].Count)
+	assert.Equal(t, 1, out.Total.Buckets[0].Errors)
+	assert.Equal(t, 1, out.Total.Buckets[1].Count)
+	assert.Equal(t, 2, out.Total.Buckets[2].Count)
+}
+
+func TestToolAggregate_HeaderAndClusterKeys(t *testing.T) {
+	d := aggregateTestDeps(t)
+
+	_, out, err := ToolAggregate(d)(context.Background(), nil, AggregateInput{
+		SessionID: "s1",
+		Query:     "path:/users/*",
+		GroupBy:   []string{"cluster", "header:Content-Type"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, out.Groups, 1)
+	assert.Equal(t, 2, out.Groups[0].Count)
+	assert.Len(t, out.Groups[0].Key["cluster"], 12)
+	assert.Equal(t, "application/json", out.Groups[0].Key["header:content-type"])
+}
+
+func TestToolAggregate_BySession(t *testing.T) {
+	d := multiSessionDeps(t)
+
+	_, out, err := ToolAggregate(d)(context.Background(), nil, AggregateInput{
+		SessionIDs: []string{"all"},
+		GroupBy:    []string{"session"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, out.Total.Count)
+	require.Len(t, out.Groups, 3)
+	assert.Equal(t, map[string]string{"session": "run1"}, out.Groups[0].Key)
+	assert.Equal(t, 2, out.Groups[0].Count)
+}
+
+func TestToolAggregate_InvalidInput(t *testing.T) {
+	d := aggregateTestDeps(t)
+
+	inputs := []AggregateInput{
+		{SessionID: "s1", GroupBy: []string{"color"}},
+		{SessionID: "s1", SortBy: "slowest"},
+		{SessionID: "s1", BucketMs: 1},
+		{SessionID: "s1", Query: "status:>"},
+	}
+	for _, in := range inputs {
+		_, _, err := ToolAggregate(d)(context.Background(), nil, in)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v", in)
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code, "%+v", in)
+	}
+}
@@ -13,6 +13,7 @@ import (
 // ExtractEndpointsInput is the input for powhttp_extract_endpoints.
 type ExtractEndpointsInput struct {
 	SessionID string                    `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	SessionIDs []string                 `json:"session_ids,omitempty" jsonschema:"Cluster entries of these sessions together instead of session_id; [\"all\"] for every session. Clusters then list their session_ids."`
 	Scope     *ExtractEndpointsScope    `json:"scope,omitempty" jsonschema:"Pre-clustering filters (narrows input entries)"`
 	Filters   *ExtractEndpointsFilters  `json:"filters,omitempty" jsonschema:"Post-clustering filters (narrows output clusters)"`
 	Options   *ExtractEndpointsOptions  `json:"options,omitempty" jsonschema:"Clustering options"`
@@ -84,7 +85,7 @@ func ToolExtractEndpoints(d *Deps) func(ctx context.Context, req *sdkmcp.CallToo
 			}
 		}
 
-		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		sessionID, sessionIDs, err := d.ResolveSessionScope(ctx, input.SessionID, input.SessionIDs)
 		if err != nil {
 			return nil, ExtractEndpointsOutput{}, err
 		}
@@ -95,9 +96,10 @@ func ToolExtractEndpoints(d *Deps) func(ctx context.Context, req *sdkmcp.CallToo
 		}
 
 		extractReq := &types.ExtractRequest{
-			SessionID: sessionID,
-			Limit:     limit,
-			Offset:    input.Offset,
+			SessionID:  sessionID,
+			SessionIDs: sessionIDs,
+			Limit:      limit,
+			Offset:     input.Offset,
 		}
 
 		if input.Scope != nil {
@@ -168,7 +170,7 @@ func ToolExtractEndpoints(d *Deps) func(ctx context.Context, req *sdkmcp.CallToo
 			// Try all example entries — any single valid GraphQL body confirms the cluster.
 			isGQL := false
 			for _, eid := range c.ExampleEntryIDs {
-				if _, ok := parseGraphQLEntry(ctx, d, sessionID, eid); ok {
+				if _, ok := parseGraphQLEntry(ctx, d, d.SessionOf(eid, sessionID), eid); ok {
 					isGQL = true
 					break
 				}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/pkg/certchain"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/ja4"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// InspectCertificatesInput is the input for powhttp_inspect_certificates.
+type InspectCertificatesInput struct {
+	SessionID    string `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryID      string `json:"entry_id,omitempty" jsonschema:"Entry whose TLS connection to inspect; its URL host is checked against the leaf and its capture time against validity"`
+	ConnectionID string `json:"connection_id,omitempty" jsonschema:"TLS connection ID to inspect instead of an entry; the SNI host is checked against the leaf"`
+	Hostname     string `json:"hostname,omitempty" jsonschema:"Host name to check the leaf against (default: the entry's URL host or the SNI)"`
+}
+
+// InspectCertificatesOutput is the output for powhttp_inspect_certificates.
+type InspectCertificatesOutput struct {
+	ConnectionID string                  `json:"connection_id"`
+	Chain        *types.CertificateChain `json:"chain,omitempty"`
+	Hint         string                  `json:"hint,omitempty"`
+}
+
+// ToolInspectCertificates decodes the server certificate chain of a TLS
+// connection and flags expired, self-signed, misordered, and intercepted
+// chains and host name mismatches.
+func ToolInspectCertificates(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input InspectCertificatesInput) (*sdkmcp.CallToolResult, InspectCertificatesOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input InspectCertificatesInput) (*sdkmcp.CallToolResult, InspectCertificatesOutput, error) {
+		if input.EntryID == "" && input.ConnectionID == "" {
+			return nil, InspectCertificatesOutput{}, ErrInvalidInput("entry_id or connection_id is required")
+		}
+
+		connID := input.ConnectionID
+		hostname := input.Hostname
+		at := time.Now()
+		if input.EntryID != "" {
+			sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+			if err != nil {
+				return nil, InspectCertificatesOutput{}, err
+			}
+			entry, err := d.FetchEntry(ctx, sessionID, input.EntryID)
+			if err != nil {
+				return nil, InspectCertificatesOutput{}, WrapPowHTTPError(err)
+			}
+			if entry.TLS.ConnectionID == nil || *entry.TLS.ConnectionID == "" {
+				return nil, InspectCertificatesOutput{}, ErrInvalidInput("entry " + input.EntryID + " was not sent over TLS")
+			}
+			if connID == "" {
+				connID = *entry.TLS.ConnectionID
+			}
+			if u, err := url.Parse(entry.URL); err == nil && hostname == "" {
+				hostname = u.Hostname()
+			}
+			if entry.Timings.StartedAt > 0 {
+				at = time.UnixMilli(entry.Timings.StartedAt)
+			}
+		}
+
+		events, err := d.Client.GetTLSConnection(ctx, connID)
+		if err != nil {
+			return nil, InspectCertificatesOutput{}, WrapPowHTTPError(err)
+		}
+
+		out := InspectCertificatesOutput{ConnectionID: connID}
+		var certificate *client.TLSCertificate
+		for _, event := range events {
+			if event.Msg.Type != client.TLSMsgHandshake || event.Msg.Handshake == nil {
+				continue
+			}
+			hs := event.Msg.Handshake
+			switch {
+			case hs.Type == client.TLSHandshakeClientHello && hs.ClientHello != nil && hostname == "":
+				if hello, err := ja4.ParseClientHello(hs.ClientHello); err == nil {
+					hostname = hello.ServerName
+				}
+			case hs.Type == client.TLSHandshakeCertificate && hs.Certificate != nil && event.Side == client.TLSSideServer && certificate == nil:
+				certificate = hs.Certificate
+			}
+		}
+		if certificate == nil || len(certificate.CertificateList) == 0 {
+			out.Hint = "No server certificate was recorded for this connection, as happens when a TLS session is resumed. Inspect an entry on a connection with a full handshake."
+			return nil, out, nil
+		}
+
+		chain := certchain.Inspect(certificate, hostname, at)
+		out.Chain = &chain
+		return nil, out, nil
+	}
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/certchain"
+)
+
+func TestCheckOutputSchema_InspectCertificates(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[InspectCertificatesOutput]("powhttp_inspect_certificates")
+	})
+}
+
+// selfSignedCertHex issues a self-signed certificate for dnsName.
+func selfSignedCertHex(t *testing.T, dnsName string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{dnsName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return hex.EncodeToString(der)
+}
+
+// certificateEvent is a server Certificate handshake event.
+func certificateEvent(certHex string) string {
+	return fmt.Sprintf(`{"side":"server","msg":{"type":"handshake","content":{"type":"certificate","content":{"certificate_request_context":"","certificate_list":[{"cert_data":%q,"extensions":""}]}}}}`, certHex)
+}
+
+func TestToolInspectCertificates(t *testing.T) {
+	src := newFakeSource("s1", tlsEntry("e1", "t1"), tlsEntry("e2", "t2"), testEntry("plain", "GET", "http://api.example.com/", 200, "", ""))
+	src.addTLSConnection(t, "t1",
+		`{"side":"client","msg":{"type":"handshake","content":{"type":"client_hello","content":{
+			"version":{"value":771,"name":"TLS 1.2"},"cipher_suites":[],
+			"extensions":[{"value":0,"name":"server_name","server_name":"sni.example.com"}]}}}}`,
+		certificateEvent(selfSignedCertHex(t, "other.example.com")),
+	)
+	src.addTLSConnection(t, "t2", `{"side":"client","msg":{"type":"handshake","content":{"type":"client_hello","content":{"version":{"value":771,"name":"TLS 1.2"},"cipher_suites":[]}}}}`)
+	d := newTestDeps(t, src)
+	tool := ToolInspectCertificates(d)
+
+	t.Run("entry", func(t *testing.T) {
+		_, out, err := tool(context.Background(), nil, InspectCertificatesInput{EntryID: "e1"})
+		require.NoError(t, err)
+		assert.Equal(t, "t1", out.ConnectionID)
+		require.NotNil(t, out.Chain)
+		assert.Equal(t, "api.example.com", out.Chain.Hostname)
+		require.Len(t, out.Chain.Certificates, 1)
+		assert.Equal(t, []string{"other.example.com"}, out.Chain.Certificates[0].DNSNames)
+		var codes []string
+		for _, issue := range out.Chain.Issues {
+			codes = append(codes, issue.Code)
+		}
+		assert.Equal(t, []string{certchain.IssueSelfSigned, certchain.IssueHostnameMismatch}, codes)
+	})
+
+	t.Run("connection uses SNI", func(t *testing.T) {
+		_, out, err := tool(context.Background(), nil, InspectCertificatesInput{ConnectionID: "t1"})
+		require.NoError(t, err)
+		require.NotNil(t, out.Chain)
+		assert.Equal(t, "sni.example.com", out.Chain.Hostname)
+	})
+
+	t.Run("no certificate recorded", func(t *testing.T) {
+		_, out, err := tool(context.Background(), nil, InspectCertificatesInput{EntryID: "e2"})
+		require.NoError(t, err)
+		assert.Nil(t, out.Chain)
+		assert.Contains(t, out.Hint, "resumed")
+	})
+
+	for _, input := range []InspectCertificatesInput{{}, {EntryID: "plain"}} {
+		_, _, err := tool(context.Background(), nil, input)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v: %v", input, err)
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code, "%+v", input)
+	}
+}
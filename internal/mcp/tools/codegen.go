@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/replay"
+	"github.com/usestring/powhttp-mcp/pkg/codegen"
+)
+
+// GenerateCodeInput is the input for powhttp_generate_code.
+type GenerateCodeInput struct {
+	SessionID  string   `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryID    string   `json:"entry_id,omitempty" jsonschema:"Entry to render (one of entry_id or cluster_id is required)"`
+	ClusterID  string   `json:"cluster_id,omitempty" jsonschema:"Cluster from powhttp_extract_endpoints; its first example entry is rendered"`
+	Languages  []string `json:"languages,omitempty" jsonschema:"curl, go, go-tls-client, python-requests, python-httpx, typescript (default: all)"`
+	TLSProfile string   `json:"tls_profile,omitempty" jsonschema:"tls-client profile for go-tls-client (default: Chrome_133_PSK)"`
+}
+
+// GenerateCodeOutput is the output for powhttp_generate_code.
+type GenerateCodeOutput struct {
+	EntryID     string          `json:"entry_id"`
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"http_version,omitempty"`
+	Snippets    []*codegen.Code `json:"snippets,omitzero"`
+}
+
+// ToolGenerateCode renders a captured request as runnable client code.
+func ToolGenerateCode(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input GenerateCodeInput) (*sdkmcp.CallToolResult, GenerateCodeOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input GenerateCodeInput) (*sdkmcp.CallToolResult, GenerateCodeOutput, error) {
+		if (input.EntryID == "") == (input.ClusterID == "") {
+			return nil, GenerateCodeOutput{}, ErrInvalidInput("exactly one of entry_id or cluster_id is required")
+		}
+		languages := input.Languages
+		if len(languages) == 0 {
+			languages = codegen.Languages
+		}
+		for _, lang := range languages {
+			if !slices.Contains(codegen.Languages, lang) {
+				return nil, GenerateCodeOutput{}, ErrInvalidInput(fmt.Sprintf("invalid language %q: must be one of %s", lang, strings.Join(codegen.Languages, ", ")))
+			}
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, GenerateCodeOutput{}, err
+		}
+
+		entryID := input.EntryID
+		if input.ClusterID != "" {
+			stored, ok := d.ClusterStore.GetCluster(input.ClusterID)
+			if !ok {
+				return nil, GenerateCodeOutput{}, ErrNotFound("cluster", input.ClusterID)
+			}
+			switch {
+			case len(stored.Cluster.ExampleEntryIDs) > 0:
+				entryID = stored.Cluster.ExampleEntryIDs[0]
+			case len(stored.EntryIDs) > 0:
+				entryID = stored.EntryIDs[0]
+			default:
+				return nil, GenerateCodeOutput{}, ErrInvalidInput(fmt.Sprintf("cluster %s has no entries", input.ClusterID))
+			}
+		}
+
+		entry, err := d.FetchEntry(ctx, sessionID, entryID)
+		if err != nil {
+			return nil, GenerateCodeOutput{}, WrapPowHTTPError(err)
+		}
+		r, err := replay.FromEntry(entry)
+		if err != nil {
+			return nil, GenerateCodeOutput{}, ErrInvalidInput(err.Error())
+		}
+
+		var pseudo []string
+		for _, h := range entry.Request.Headers {
+			if len(h) >= 2 && strings.HasPrefix(h[0], ":") {
+				pseudo = append(pseudo, h[0])
+			}
+		}
+		cr := &codegen.Request{
+			Method:            r.Method,
+			URL:               r.URL,
+			HTTPVersion:       r.HTTPVersion,
+			Headers:           r.Headers,
+			PseudoHeaderOrder: pseudo,
+			Body:              r.Body,
+		}
+
+		output := GenerateCodeOutput{
+			EntryID:     entry.ID,
+			Method:      r.Method,
+			URL:         r.URL,
+			HTTPVersion: r.HTTPVersion,
+		}
+		for _, lang := range languages {
+			code, err := codegen.Generate(lang, cr, codegen.Options{TLSProfile: input.TLSProfile})
+			if err != nil {
+				return nil, GenerateCodeOutput{}, ErrInvalidInput(err.Error())
+			}
+			output.Snippets = append(output.Snippets, code)
+		}
+
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/codegen"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestCheckOutputSchema_GenerateCode(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[GenerateCodeOutput]("powhttp_generate_code")
+	})
+}
+
+func codegenTestDeps(t *testing.T) *Deps {
+	t.Helper()
+	e1 := testEntry("e1", "POST", "https://api.example.com/orders", 201, `{"sku":"x"}`, `{"id":8}`)
+	e1.HTTPVersion = "h2"
+	e1.Request.Headers = client.Headers{
+		{":method", "POST"},
+		{":authority", "api.example.com"},
+		{":scheme", "https"},
+		{":path", "/orders"},
+		{"content-type", "application/json"},
+		{"content-length", "11"},
+		{"authorization", "Bearer tok"},
+		{"user-agent", "test"},
+	}
+	d := newTestDeps(t, newFakeSource("s1",
+		e1,
+		testEntry("e2", "GET", "https://api.example.com/orders/7", 200, "", `{"id":7}`),
+	))
+	d.ClusterStore.StoreExtraction(&types.ExtractResponse{
+		Clusters:  []types.Cluster{{ID: "c1", Host: "api.example.com", Method: "GET", PathTemplate: "/orders/{id}", ExampleEntryIDs: []string{"e2"}}},
+		ScopeHash: "scope1",
+	}, map[string][]string{"c1": {"e2"}})
+	return d
+}
+
+func TestToolGenerateCode(t *testing.T) {
+	d := codegenTestDeps(t)
+
+	_, out, err := ToolGenerateCode(d)(context.Background(), nil, GenerateCodeInput{SessionID: "s1", EntryID: "e1"})
+	require.NoError(t, err)
+	assert.Equal(t, "e1", out.EntryID)
+	assert.Equal(t, "h2", out.HTTPVersion)
+	require.Len(t, out.Snippets, len(codegen.Languages))
+
+	var tlsClient string
+	for _, s := range out.Snippets {
+		assert.NotContains(t, s.Code, "content-length", s.Language)
+		if s.Language == codegen.GoTLSClient {
+			tlsClient = s.Code
+		}
+	}
+	assert.Contains(t, tlsClient, `http.HeaderOrderKey:  {"content-type", "authorization", "user-agent"}`)
+	assert.Contains(t, tlsClient, `http.PHeaderOrderKey: {":method", ":authority", ":scheme", ":path"}`)
+	assert.Contains(t, tlsClient, `strings.NewReader("{\"sku\":\"x\"}")`)
+}
+
+func TestToolGenerateCode_Cluster(t *testing.T) {
+	d := codegenTestDeps(t)
+
+	_, out, err := ToolGenerateCode(d)(context.Background(), nil, GenerateCodeInput{
+		SessionID: "s1",
+		ClusterID: "c1",
+		Languages: []string{codegen.Curl},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "e2", out.EntryID)
+	require.Len(t, out.Snippets, 1)
+	assert.Contains(t, out.Snippets[0].Code, "curl --http1.1 'https://api.example.com/orders/7'")
+}
+
+func TestToolGenerateCode_InvalidInput(t *testing.T) {
+	d := codegenTestDeps(t)
+
+	for _, input := range []GenerateCodeInput{
+		{SessionID: "s1"},
+		{SessionID: "s1", EntryID: "e1", ClusterID: "c1"},
+		{SessionID: "s1", EntryID: "e1", Languages: []string{"ruby"}},
+		{SessionID: "s1", EntryID: "e1", TLSProfile: "bad profile"},
+	} {
+		_, _, err := ToolGenerateCode(d)(context.Background(), nil, input)
+		require.Error(t, err, "%+v", input)
+	}
+
+	_, _, err := ToolGenerateCode(d)(context.Background(), nil, GenerateCodeInput{SessionID: "s1", ClusterID: "missing"})
+	var coded *CodedError
+	require.ErrorAs(t, err, &coded)
+	assert.Equal(t, ErrCodeNotFound, coded.Code)
+}
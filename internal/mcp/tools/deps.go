@@ -10,11 +10,13 @@ import (
 
 	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
 	"github.com/usestring/powhttp-mcp/internal/compare"
 	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/internal/entryfetch"
 	"github.com/usestring/powhttp-mcp/internal/flow"
 	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/replay"
 	"github.com/usestring/powhttp-mcp/internal/search"
 	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/textquery"
@@ -22,7 +24,7 @@ import (
 
 // Deps contains all dependencies needed by tool handlers.
 type Deps struct {
-	Client       *client.Client
+	Client       client.DataSource
 	Indexer      *indexer.Indexer
 	Cache        *cache.EntryCache
 	Config       *config.Config
@@ -31,9 +33,12 @@ type Deps struct {
 	Diff         *compare.DiffEngine
 	Cluster      *catalog.ClusterEngine
 	Describe     *catalog.DescribeEngine
+	CatalogDiff  *catalog.CatalogDiffEngine
 	ClusterStore *catalog.ClusterStore
 	Flow         *flow.FlowEngine
 	TextQuery    *textquery.Engine
+	Replay       *replay.Sender // nil when POWHTTP_PROXY_URL is invalid
+	Clients      *clientdb.DB   // Reference fingerprints of known clients
 
 	// GraphQLParseCache caches parsed GraphQL request bodies by entry ID,
 	// avoiding redundant fetch+decode+parse across tool calls (e.g.,
@@ -43,6 +48,14 @@ type Deps struct {
 	// GraphQLAnalysisCache caches full analysis results by "session:operation_name".
 	// Populated by ToolInspectGraphQLOperation, read by GraphQL resource handlers.
 	GraphQLAnalysisCache sync.Map // "session:operation" → *GraphQLAnalysis
+
+	// HARExports holds HAR documents built by ToolExportHAR by export ID,
+	// served by the powhttp://har/{export_id} resource.
+	HARExports *cache.ExportCache // exportID → *har.HAR
+
+	// OpenAPIExports holds documents built by ToolExportOpenAPI by export ID,
+	// served by the powhttp://openapi/{export_id} resource.
+	OpenAPIExports *cache.ExportCache // exportID → *openapi.Document
 }
 
 // FetchEntry retrieves an entry by ID, checking the cache first.
@@ -51,20 +64,22 @@ func (d *Deps) FetchEntry(ctx context.Context, sessionID, entryID string) (*clie
 	return entryfetch.FetchEntry(ctx, d.Client, d.Cache, sessionID, entryID)
 }
 
-// DecodeBody extracts and base64-decodes the body for a given target
-// ("request" or "response"). Returns the decoded bytes and the content-type
-// header value. Does not filter by content type.
+// DecodeBody extracts the body for a given target ("request" or
+// "response"), base64-decodes it, and removes its Content-Encoding. Returns
+// the decoded bytes and the content-type header value. Does not filter by
+// content type.
 func (d *Deps) DecodeBody(entry *client.SessionEntry, target string) ([]byte, string, error) {
 	return entryfetch.DecodeBody(entry, target)
 }
 
 // ResolveSessionID resolves the session ID to use for a request.
-// If sessionID is non-empty, it is returned as-is.
-// If sessionID is empty, it attempts to use "active". If that fails,
-// it falls back to listing sessions: if exactly one exists, its ID is
-// returned; if multiple exist, an error listing them is returned.
+// If sessionID is a concrete ID, it is returned as-is.
+// If sessionID is empty or "active", it resolves "active" to the ID of the
+// session it currently stands for. If that fails, it falls back to listing
+// sessions: if exactly one exists, its ID is returned; if multiple exist,
+// an error listing them is returned.
 func (d *Deps) ResolveSessionID(ctx context.Context, sessionID string) (string, error) {
-	if sessionID != "" {
+	if sessionID != "" && sessionID != indexer.ActiveSession {
 		return sessionID, nil
 	}
 
@@ -105,3 +120,27 @@ func (d *Deps) ResolveSessionID(ctx context.Context, sessionID string) (string,
 		))
 	}
 }
+
+// ResolveSessionScope resolves the sessions of a tool that can span several.
+// When sessionIDs is set it is returned as is ("all" is expanded by the
+// engines) and sessionID must be empty; otherwise sessionID is resolved as by
+// ResolveSessionID.
+func (d *Deps) ResolveSessionScope(ctx context.Context, sessionID string, sessionIDs []string) (string, []string, error) {
+	if len(sessionIDs) == 0 {
+		resolved, err := d.ResolveSessionID(ctx, sessionID)
+		return resolved, nil, err
+	}
+	if sessionID != "" {
+		return "", nil, ErrInvalidInput("set either session_id or session_ids, not both")
+	}
+	return "", sessionIDs, nil
+}
+
+// SessionOf returns the session an indexed entry belongs to, or fallback if
+// the entry is not indexed.
+func (d *Deps) SessionOf(entryID, fallback string) string {
+	if meta := d.Indexer.GetMetaByEntryID(entryID); meta != nil && meta.SessionID != "" {
+		return meta.SessionID
+	}
+	return fallback
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/cache"
+	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
+	"github.com/usestring/powhttp-mcp/internal/compare"
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/internal/flow"
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/search"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// fakeSource serves sessions from memory for tool-level tests.
+type fakeSource struct {
+	mu       sync.Mutex
+	sessions map[string][]*client.SessionEntry    // session ID → entries
+	order    []string                             // first is "active"
+	h2       map[string]map[int][]json.RawMessage // connection ID → stream ID → frames
+	tls      map[string][]client.TLSEvent         // connection ID → TLS events
+}
+
+func newFakeSource(sessionID string, entries ...*client.SessionEntry) *fakeSource {
+	f := &fakeSource{sessions: make(map[string][]*client.SessionEntry)}
+	f.addSession(sessionID, entries...)
+	return f
+}
+
+func (f *fakeSource) addSession(sessionID string, entries ...*client.SessionEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[sessionID] = entries
+	f.order = append(f.order, sessionID)
+}
+
+// appendEntry records a new entry into an existing session, as powhttp does
+// for live traffic.
+func (f *fakeSource) appendEntry(sessionID string, entry *client.SessionEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[sessionID] = append(f.sessions[sessionID], entry)
+}
+
+// addTLSConnection records the TLS events of a connection, given as JSON.
+func (f *fakeSource) addTLSConnection(t *testing.T, connectionID string, events ...string) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tls == nil {
+		f.tls = make(map[string][]client.TLSEvent)
+	}
+	for _, raw := range events {
+		var event client.TLSEvent
+		require.NoError(t, json.Unmarshal([]byte(raw), &event))
+		f.tls[connectionID] = append(f.tls[connectionID], event)
+	}
+}
+
+// addHTTP2Stream records the frames of an HTTP/2 stream.
+func (f *fakeSource) addHTTP2Stream(connectionID string, streamID int, frames ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.h2 == nil {
+		f.h2 = make(map[string]map[int][]json.RawMessage)
+	}
+	if f.h2[connectionID] == nil {
+		f.h2[connectionID] = make(map[int][]json.RawMessage)
+	}
+	for _, frame := range frames {
+		f.h2[connectionID][streamID] = append(f.h2[connectionID][streamID], json.RawMessage(frame))
+	}
+}
+
+func (f *fakeSource) resolve(sessionID string) string {
+	if sessionID == "active" && len(f.order) > 0 {
+		return f.order[0]
+	}
+	return sessionID
+}
+
+func (f *fakeSource) ListSessions(ctx context.Context) ([]client.Session, error) {
+	f.mu.Lock()
+	order := slices.Clone(f.order)
+	f.mu.Unlock()
+	out := make([]client.Session, 0, len(order))
+	for _, id := range order {
+		s, _ := f.GetSession(ctx, id)
+		out = append(out, *s)
+	}
+	return out, nil
+}
+
+func (f *fakeSource) GetSession(ctx context.Context, sessionID string) (*client.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sessionID = f.resolve(sessionID)
+	entries, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404, Message: "session not found"}
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return &client.Session{ID: sessionID, EntryIDs: ids}, nil
+}
+
+func (f *fakeSource) GetEntry(ctx context.Context, sessionID, entryID string) (*client.SessionEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.sessions[f.resolve(sessionID)] {
+		if e.ID == entryID {
+			return e, nil
+		}
+	}
+	return nil, &client.APIError{StatusCode: 404, Message: "entry not found"}
+}
+
+func (f *fakeSource) GetSessionBookmarks(ctx context.Context, sessionID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (f *fakeSource) ListEntries(ctx context.Context, sessionID string, opts *client.ListEntriesOptions) ([]client.SessionEntry, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+func (f *fakeSource) ListHTTP2StreamIDs(ctx context.Context, connectionID string) ([]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	streams, ok := f.h2[connectionID]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404}
+	}
+	ids := make([]int, 0, len(streams))
+	for id := range streams {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (f *fakeSource) GetTLSConnection(ctx context.Context, connectionID string) ([]client.TLSEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	events, ok := f.tls[connectionID]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404}
+	}
+	return events, nil
+}
+
+func (f *fakeSource) GetHTTP2Stream(ctx context.Context, connectionID string, streamID int) ([]json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	frames, ok := f.h2[connectionID][streamID]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404}
+	}
+	return frames, nil
+}
+
+func (f *fakeSource) GetWebSocketMessages(ctx context.Context, sessionID, entryID string) ([]client.WebSocketMessage, error) {
+	return nil, &client.APIError{StatusCode: 404}
+}
+
+// testConfig returns a config with the defaults tool handlers rely on.
+func testConfig() *config.Config {
+	return &config.Config{
+		RefreshTimeout:       5 * time.Second,
+		FetchWorkers:         4,
+		BootstrapTailLimit:   1000,
+		EntryCacheMaxItems:   64,
+		ToolMaxBytesDefault:  1 << 20,
+		DefaultSearchLimit:   config.DefaultSearchLimitValue,
+		DefaultClusterLimit:  config.DefaultClusterLimitValue,
+		DefaultQueryLimit:    config.DefaultQueryLimitValue,
+		MaxSearchResults:     config.MaxSearchResultsValue,
+		MaxQueryEntries:      config.MaxQueryEntriesValue,
+		MaxInferEntries:      config.MaxInferEntriesValue,
+		ExportCacheMaxItems:  4,
+		CompactMaxArrayItems: 3,
+		CompactMaxStringLen:  500,
+	}
+}
+
+// newTestDeps wires the engines used by tool handlers over src.
+func newTestDeps(t *testing.T, src client.DataSource) *Deps {
+	t.Helper()
+	cfg := testConfig()
+	entryCache, err := cache.NewEntryCache(cfg.EntryCacheMaxItems)
+	require.NoError(t, err)
+	exports, err := cache.NewExportCache(cfg.ExportCacheMaxItems)
+	require.NoError(t, err)
+	specs, err := cache.NewExportCache(cfg.ExportCacheMaxItems)
+	require.NoError(t, err)
+
+	clients, err := clientdb.New("")
+	require.NoError(t, err)
+
+	idx := indexer.New(src, entryCache, cfg)
+	store := catalog.NewClusterStore()
+	fp := compare.NewFingerprintEngine(src, entryCache, cfg)
+	return &Deps{
+		Client:         src,
+		Indexer:        idx,
+		Cache:          entryCache,
+		Config:         cfg,
+		Search:         search.New(idx, entryCache, cfg),
+		Fingerprint:    fp,
+		Diff:           compare.NewDiffEngine(fp),
+		Cluster:        catalog.NewClusterEngine(idx, cfg, store),
+		Describe:       catalog.NewDescribeEngine(idx, src, entryCache, cfg, store),
+		CatalogDiff:    catalog.NewCatalogDiffEngine(idx, src, entryCache, cfg),
+		ClusterStore:   store,
+		Flow:           flow.NewFlowEngine(idx, src, entryCache, cfg),
+		Clients:        clients,
+		HARExports:     exports,
+		OpenAPIExports: specs,
+	}
+}
+
+func testStrPtr(s string) *string { return &s }
+func testIntPtr(i int) *int       { return &i }
+
+// testEntry builds a captured entry with optional JSON bodies.
+func testEntry(id, method, rawURL string, status int, reqBody, respBody string) *client.SessionEntry {
+	e := &client.SessionEntry{
+		ID:          id,
+		URL:         rawURL,
+		HTTPVersion: "http/1.1",
+		Request: client.Request{
+			Method:  testStrPtr(method),
+			Headers: client.Headers{{"user-agent", "test"}},
+		},
+		Response: &client.Response{
+			StatusCode: testIntPtr(status),
+			Headers:    client.Headers{{"content-type", "application/json"}},
+		},
+		Timings: client.Timings{StartedAt: time.Now().UnixMilli()},
+	}
+	if reqBody != "" {
+		b := base64.StdEncoding.EncodeToString([]byte(reqBody))
+		e.Request.Body = &b
+		e.Request.Headers = append(e.Request.Headers, []string{"content-type", "application/json"})
+	}
+	if respBody != "" {
+		b := base64.StdEncoding.EncodeToString([]byte(respBody))
+		e.Response.Body = &b
+	}
+	return e
+}
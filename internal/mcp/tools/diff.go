@@ -2,19 +2,39 @@ package tools
 
 import (
 	"context"
+	"fmt"
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
+const (
+	defaultGroupMaxEntries = 50
+	maxGroupMaxEntries     = 200
+)
+
 // DiffEntriesInput is the input for powhttp_diff_entries.
 type DiffEntriesInput struct {
 	SessionID        string       `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
-	BaselineEntryID  string       `json:"baseline_entry_id" jsonschema:"required,Baseline (browser) entry ID"`
-	CandidateEntryID string       `json:"candidate_entry_id" jsonschema:"required,Candidate (program) entry ID"`
+	BaselineEntryID  string       `json:"baseline_entry_id,omitempty" jsonschema:"Baseline (browser) entry ID. Required unless comparing groups"`
+	CandidateEntryID string       `json:"candidate_entry_id,omitempty" jsonschema:"Candidate (program) entry ID. Required unless comparing groups"`
 	Options          *DiffOptions `json:"options,omitempty" jsonschema:"Diff options"`
 	MaxBytes         int          `json:"max_bytes,omitempty" jsonschema:"Max body bytes for comparison"`
+
+	// Group mode: compare N entries against N entries
+	BaselineQuery    string                `json:"baseline_query,omitempty" jsonschema:"Group mode: query selecting the baseline entries (search_entries syntax, e.g. 'process:chrome host:api.example.com')"`
+	CandidateQuery   string                `json:"candidate_query,omitempty" jsonschema:"Group mode: query selecting the candidate entries"`
+	BaselineFilters  *SearchEntriesFilters `json:"baseline_filters,omitempty" jsonschema:"Group mode: structured filters selecting the baseline entries"`
+	CandidateFilters *SearchEntriesFilters `json:"candidate_filters,omitempty" jsonschema:"Group mode: structured filters selecting the candidate entries"`
+	MaxEntries       int                   `json:"max_entries,omitempty" jsonschema:"Group mode: max entries fingerprinted per group (default: 50, max: 200)"`
+	MinScore         float64               `json:"min_score,omitempty" jsonschema:"Group mode: min separation score (0-1) for a feature to be reported (default: 0.5)"`
+	Limit            int                   `json:"limit,omitempty" jsonschema:"Group mode: max separators returned (default: 25)"`
+}
+
+// groupMode reports whether the input selects groups rather than two entries.
+func (in *DiffEntriesInput) groupMode() bool {
+	return in.BaselineQuery != "" || in.CandidateQuery != "" || in.BaselineFilters != nil || in.CandidateFilters != nil
 }
 
 // DiffOptions controls diff behavior.
@@ -25,18 +45,42 @@ type DiffOptions struct {
 	CompareHTTP2        bool     `json:"compare_http2,omitempty" jsonschema:"Compare HTTP/2 metadata (default: true)"`
 	IgnoreHeaders       []string `json:"ignore_headers,omitempty" jsonschema:"Headers to ignore"`
 	IgnoreQueryKeys     []string `json:"ignore_query_keys,omitempty" jsonschema:"Query keys to ignore"`
+	CompareCookies      bool     `json:"compare_cookies,omitempty" jsonschema:"Compare cookie names and order inside Cookie headers, ignoring values (default: false)"`
+}
+
+// toDiffOptions converts tool options to engine options. A nil receiver yields
+// nil so the engine applies its defaults.
+func (o *DiffOptions) toDiffOptions(maxBytes int) *types.DiffOptions {
+	if o == nil {
+		return nil
+	}
+	return &types.DiffOptions{
+		CompareHeaderOrder:  o.CompareHeaderOrder,
+		CompareHeaderValues: o.CompareHeaderValues,
+		CompareTLS:          o.CompareTLS,
+		CompareHTTP2:        o.CompareHTTP2,
+		IgnoreHeaders:       o.IgnoreHeaders,
+		IgnoreQueryKeys:     o.IgnoreQueryKeys,
+		CompareCookies:      o.CompareCookies,
+		MaxBytes:            maxBytes,
+	}
 }
 
 // DiffEntriesOutput is the output for powhttp_diff_entries.
 type DiffEntriesOutput struct {
-	Diff     *types.DiffResult  `json:"diff"`
-	Severity string             `json:"severity,omitempty"`
-	Resource *types.ResourceRef `json:"resource,omitempty"`
+	Diff     *types.DiffResult      `json:"diff,omitempty"`
+	Group    *types.GroupDiffResult `json:"group,omitempty"`
+	Severity string                 `json:"severity,omitempty"`
+	Resource *types.ResourceRef     `json:"resource,omitempty"`
+	Hint     string                 `json:"hint,omitempty"`
 }
 
-// ToolDiffEntries compares two entries.
+// ToolDiffEntries compares two entries, or two groups of entries.
 func ToolDiffEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input DiffEntriesInput) (*sdkmcp.CallToolResult, DiffEntriesOutput, error) {
 	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input DiffEntriesInput) (*sdkmcp.CallToolResult, DiffEntriesOutput, error) {
+		if input.groupMode() {
+			return diffGroups(ctx, d, input)
+		}
 		if input.BaselineEntryID == "" {
 			return nil, DiffEntriesOutput{}, ErrInvalidInput("baseline_entry_id is required")
 		}
@@ -55,17 +99,7 @@ func ToolDiffEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 			SessionID:        sessionID,
 		}
 
-		if input.Options != nil {
-			diffReq.Options = &types.DiffOptions{
-				CompareHeaderOrder:  input.Options.CompareHeaderOrder,
-				CompareHeaderValues: input.Options.CompareHeaderValues,
-				CompareTLS:          input.Options.CompareTLS,
-				CompareHTTP2:        input.Options.CompareHTTP2,
-				IgnoreHeaders:       input.Options.IgnoreHeaders,
-				IgnoreQueryKeys:     input.Options.IgnoreQueryKeys,
-				MaxBytes:            input.MaxBytes,
-			}
-		}
+		diffReq.Options = input.Options.toDiffOptions(input.MaxBytes)
 
 		result, err := d.Diff.Diff(ctx, diffReq)
 		if err != nil {
@@ -84,9 +118,104 @@ func ToolDiffEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 	}
 }
 
+// diffGroups ranks the features that separate two groups of entries.
+func diffGroups(ctx context.Context, d *Deps, input DiffEntriesInput) (*sdkmcp.CallToolResult, DiffEntriesOutput, error) {
+	if input.BaselineEntryID != "" || input.CandidateEntryID != "" {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("use either baseline_entry_id/candidate_entry_id or group selectors (baseline_query/baseline_filters, candidate_query/candidate_filters), not both")
+	}
+	if input.BaselineQuery == "" && input.BaselineFilters == nil {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("group mode requires baseline_query or baseline_filters")
+	}
+	if input.CandidateQuery == "" && input.CandidateFilters == nil {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("group mode requires candidate_query or candidate_filters")
+	}
+	if input.MinScore < 0 || input.MinScore > 1 {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("min_score must be between 0 and 1")
+	}
+	maxEntries := input.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultGroupMaxEntries
+	}
+	if maxEntries > maxGroupMaxEntries {
+		maxEntries = maxGroupMaxEntries
+	}
+
+	sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+	if err != nil {
+		return nil, DiffEntriesOutput{}, err
+	}
+
+	baseline, err := matchGroup(ctx, d, sessionID, input.BaselineQuery, input.BaselineFilters, maxEntries)
+	if err != nil {
+		return nil, DiffEntriesOutput{}, err
+	}
+	if len(baseline) == 0 {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("baseline group matched no entries")
+	}
+	candidate, err := matchGroup(ctx, d, sessionID, input.CandidateQuery, input.CandidateFilters, maxEntries)
+	if err != nil {
+		return nil, DiffEntriesOutput{}, err
+	}
+	if len(candidate) == 0 {
+		return nil, DiffEntriesOutput{}, ErrInvalidInput("candidate group matched no entries")
+	}
+
+	groupReq := &types.GroupDiffRequest{
+		SessionID:         sessionID,
+		BaselineEntryIDs:  baseline,
+		CandidateEntryIDs: candidate,
+		MinScore:          input.MinScore,
+		Limit:             input.Limit,
+	}
+	if input.Options != nil {
+		groupReq.IgnoreHeaders = input.Options.IgnoreHeaders
+	}
+
+	result, err := d.Diff.DiffGroups(ctx, groupReq)
+	if err != nil {
+		return nil, DiffEntriesOutput{}, WrapPowHTTPError(err)
+	}
+
+	var hint string
+	if len(result.Separators) == 0 {
+		hint = "No feature consistently separates the groups. Lower min_score, or check that each group selects the intended client."
+	} else {
+		top := result.Separators[0]
+		name := top.Kind
+		if top.Name != "" {
+			name += " " + top.Name
+		}
+		hint = fmt.Sprintf("%d features separate the groups; strongest is %s (score %.2f). Use diff_entries with one entry from each group to see a full comparison.",
+			result.SeparatorCount, name, top.Score)
+	}
+
+	return nil, DiffEntriesOutput{Group: result, Hint: hint}, nil
+}
+
+// matchGroup returns the IDs of up to limit entries matched by a group selector.
+func matchGroup(ctx context.Context, d *Deps, sessionID, query string, filters *SearchEntriesFilters, limit int) ([]string, error) {
+	metas, err := d.Search.MatchEntries(ctx, &types.SearchRequest{
+		SessionID: sessionID,
+		Query:     query,
+		Filters:   filters.toSearchFilters(),
+	})
+	if err != nil {
+		return nil, wrapSearchError(err)
+	}
+	ids := make([]string, 0, min(len(metas), limit))
+	for _, meta := range metas {
+		if len(ids) == limit {
+			break
+		}
+		ids = append(ids, meta.EntryID)
+	}
+	return ids, nil
+}
+
 // computeDiffSeverity computes severity from diff result.
-// "high": JA4 TLS fingerprint mismatch or protocol mismatch or many missing headers.
-// "medium": Header order significantly different or a few missing/extra headers.
+// "high": JA4 TLS or Akamai HTTP/2 fingerprint mismatch, protocol mismatch, or many missing headers.
+// "medium": Header order significantly different, a few missing/extra headers, HTTP/1.x header casing or
+// repeats, cookie differences, or ClientHello differences.
 // "low": Only noisy diffs.
 // "none": No meaningful differences.
 func computeDiffSeverity(result *types.DiffResult) string {
@@ -104,6 +233,11 @@ func computeDiffSeverity(result *types.DiffResult) string {
 		return "high"
 	}
 
+	// High: Akamai HTTP/2 fingerprint mismatch
+	if imp.HTTP2 != nil && imp.HTTP2.AkamaiDifferent {
+		return "high"
+	}
+
 	// High: many missing headers (3+)
 	missingCount := len(imp.HeadersMissing) + len(imp.HeadersExtra)
 	if missingCount >= 3 {
@@ -120,6 +254,16 @@ func computeDiffSeverity(result *types.DiffResult) string {
 		return "medium"
 	}
 
+	// Medium: HTTP/1.x header casing and repeats, and cookies
+	if len(imp.HeaderCasing) > 0 || imp.DuplicateHeaders != nil || imp.Cookies != nil {
+		return "medium"
+	}
+
+	// Medium: ClientHello differences JA4 does not see, such as extension order
+	if imp.TLS != nil && imp.TLS.ClientHello != nil {
+		return "medium"
+	}
+
 	// Low: only noisy diffs
 	noisy := result.NoisyDiffs
 	if len(noisy.IgnoredHeaders) > 0 || len(noisy.QueryKeyDiffs) > 0 {
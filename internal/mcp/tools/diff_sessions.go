@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const defaultDiffSessionsLimit = 50
+
+// DiffSessionsInput is the input for powhttp_diff_sessions.
+type DiffSessionsInput struct {
+	Before  DiffSessionsSide         `json:"before" jsonschema:"required,Earlier capture: a session, optionally narrowed to a time window"`
+	After   DiffSessionsSide         `json:"after" jsonschema:"required,Later capture: a session, optionally narrowed to a time window"`
+	Scope   *DiffSessionsScope       `json:"scope,omitempty" jsonschema:"Pre-clustering filters applied to both captures"`
+	Filters *ExtractEndpointsFilters `json:"filters,omitempty" jsonschema:"Post-clustering filters applied to both captures (same as extract_endpoints). Default: category api"`
+	Samples int                      `json:"samples,omitempty" jsonschema:"Entries sampled per endpoint and capture for body schema comparison (default: 10, max: 50)"`
+	Limit   int                      `json:"limit,omitempty" jsonschema:"Max endpoints listed in each of added, removed, and changed (default: 50)"`
+}
+
+// DiffSessionsSide selects the entries of one capture.
+type DiffSessionsSide struct {
+	SessionID string `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	SinceMs   int64  `json:"since_ms,omitempty" jsonschema:"Unix timestamp (ms) lower bound"`
+	UntilMs   int64  `json:"until_ms,omitempty" jsonschema:"Unix timestamp (ms) upper bound"`
+}
+
+// DiffSessionsScope defines pre-clustering filters for both captures.
+type DiffSessionsScope struct {
+	Host        string `json:"host,omitempty" jsonschema:"Filter by host. Prefix with '*.' to include subdomains."`
+	Method      string `json:"method,omitempty" jsonschema:"Filter by HTTP method (e.g., GET, POST)"`
+	ProcessName string `json:"process_name,omitempty" jsonschema:"Filter by process name"`
+	PID         int    `json:"pid,omitempty" jsonschema:"Filter by process ID"`
+}
+
+// DiffSessionsOutput is the output for powhttp_diff_sessions.
+type DiffSessionsOutput struct {
+	Diff         *types.CatalogDiff `json:"diff"`
+	AddedCount   int                `json:"added_count"`
+	RemovedCount int                `json:"removed_count"`
+	ChangedCount int                `json:"changed_count"`
+	Hint         string             `json:"hint,omitempty"`
+}
+
+// ToolDiffSessions compares the endpoint catalogs of two sessions or time windows.
+func ToolDiffSessions(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input DiffSessionsInput) (*sdkmcp.CallToolResult, DiffSessionsOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input DiffSessionsInput) (*sdkmcp.CallToolResult, DiffSessionsOutput, error) {
+		if input.Filters != nil && input.Filters.Category != "" && !validCategories[input.Filters.Category] {
+			return nil, DiffSessionsOutput{}, ErrInvalidInput(
+				fmt.Sprintf("invalid category %q, must be one of: api, page, asset, data, other", input.Filters.Category))
+		}
+		if err := input.Before.validate("before"); err != nil {
+			return nil, DiffSessionsOutput{}, err
+		}
+		if err := input.After.validate("after"); err != nil {
+			return nil, DiffSessionsOutput{}, err
+		}
+
+		before, err := resolveDiffSide(ctx, d, input.Before)
+		if err != nil {
+			return nil, DiffSessionsOutput{}, err
+		}
+		after, err := resolveDiffSide(ctx, d, input.After)
+		if err != nil {
+			return nil, DiffSessionsOutput{}, err
+		}
+		if before == after {
+			return nil, DiffSessionsOutput{}, ErrInvalidInput("before and after select the same entries; set different session_ids or time windows")
+		}
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = defaultDiffSessionsLimit
+		}
+
+		diffReq := &types.CatalogDiffRequest{
+			Before:  before,
+			After:   after,
+			Filters: &types.ClusterFilters{Category: types.CategoryAPI},
+			Samples: input.Samples,
+		}
+		if input.Scope != nil {
+			diffReq.Scope = &types.ClusterScope{
+				Host:        input.Scope.Host,
+				Method:      input.Scope.Method,
+				ProcessName: input.Scope.ProcessName,
+				PID:         input.Scope.PID,
+			}
+		}
+		if input.Filters != nil {
+			diffReq.Filters = &types.ClusterFilters{
+				Category: types.EndpointCategory(input.Filters.Category),
+				MinCount: input.Filters.MinCount,
+			}
+		}
+
+		diff, err := d.CatalogDiff.Diff(ctx, diffReq)
+		if err != nil {
+			return nil, DiffSessionsOutput{}, WrapPowHTTPError(err)
+		}
+
+		output := DiffSessionsOutput{
+			Diff:         diff,
+			AddedCount:   len(diff.Added),
+			RemovedCount: len(diff.Removed),
+			ChangedCount: len(diff.Changed),
+		}
+		truncated := len(diff.Added) > limit || len(diff.Removed) > limit || len(diff.Changed) > limit
+		diff.Added = diff.Added[:min(len(diff.Added), limit)]
+		diff.Removed = diff.Removed[:min(len(diff.Removed), limit)]
+		diff.Changed = diff.Changed[:min(len(diff.Changed), limit)]
+
+		switch {
+		case diff.BeforeEndpoints == 0 && diff.AfterEndpoints == 0:
+			output.Hint = "No endpoints in either capture. Check the session IDs and time windows, or loosen filters (the default is category api)."
+		case output.AddedCount+output.RemovedCount+output.ChangedCount == 0:
+			output.Hint = fmt.Sprintf("No API changes detected across %d endpoints.", diff.Unchanged)
+		default:
+			output.Hint = fmt.Sprintf("%d added, %d removed, %d changed, %d unchanged.",
+				output.AddedCount, output.RemovedCount, output.ChangedCount, diff.Unchanged)
+			if truncated {
+				output.Hint += fmt.Sprintf(" Lists are capped at %d; raise limit or narrow scope.host to see more.", limit)
+			}
+			output.Hint += " Use powhttp_search_entries with a session_id and path: query to inspect the entries behind an endpoint."
+		}
+
+		return nil, output, nil
+	}
+}
+
+// validate checks that the time window of a capture is not inverted.
+func (s DiffSessionsSide) validate(name string) error {
+	if s.SinceMs > 0 && s.UntilMs > 0 && s.SinceMs > s.UntilMs {
+		return ErrInvalidInput(fmt.Sprintf("%s.since_ms must not be after %s.until_ms", name, name))
+	}
+	return nil
+}
+
+// resolveDiffSide resolves the session of one capture.
+func resolveDiffSide(ctx context.Context, d *Deps, side DiffSessionsSide) (types.CatalogSide, error) {
+	sessionID, err := d.ResolveSessionID(ctx, side.SessionID)
+	if err != nil {
+		return types.CatalogSide{}, err
+	}
+	return types.CatalogSide{SessionID: sessionID, SinceMs: side.SinceMs, UntilMs: side.UntilMs}, nil
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestCheckOutputSchema_DiffSessions(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[DiffSessionsOutput]("powhttp_diff_sessions")
+	})
+}
+
+// deployDeps serves captures of an API before ("v1") and after ("v2") a deploy.
+func deployDeps(t *testing.T) *Deps {
+	t.Helper()
+	user := testEntry("v2-user", "GET", "https://api.example.com/users/3?fields=name", 200, "", `{"id":"u3","name":"c","email":"c@example.com"}`)
+	user.Request.Headers = append(user.Request.Headers, []string{"Authorization", "Bearer tok"})
+
+	src := newFakeSource("v1",
+		testEntry("v1-user1", "GET", "https://api.example.com/users/1", 200, "", `{"id":1,"name":"a"}`),
+		testEntry("v1-user2", "GET", "https://api.example.com/users/2", 200, "", `{"id":2,"name":"b"}`),
+		testEntry("v1-login", "POST", "https://api.example.com/login", 200, `{"user":"a"}`, `{"ok":true}`),
+		testEntry("v1-legacy", "GET", "https://api.example.com/legacy/status", 200, "", `{"up":true}`),
+	)
+	src.addSession("v2",
+		user,
+		testEntry("v2-login", "POST", "https://api.example.com/login", 401, `{"user":"a"}`, `{"ok":true}`),
+		testEntry("v2-feed", "GET", "https://api.example.com/feed", 200, "", `{"items":[]}`),
+	)
+	return newTestDeps(t, src)
+}
+
+func TestToolDiffSessions(t *testing.T) {
+	d := deployDeps(t)
+
+	_, out, err := ToolDiffSessions(d)(context.Background(), nil, DiffSessionsInput{
+		Before: DiffSessionsSide{SessionID: "v1"},
+		After:  DiffSessionsSide{SessionID: "v2"},
+	})
+	require.NoError(t, err)
+
+	diff := out.Diff
+	assert.Equal(t, 3, diff.BeforeEndpoints)
+	assert.Equal(t, 3, diff.AfterEndpoints)
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "/feed", diff.Added[0].PathTemplate)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "/legacy/status", diff.Removed[0].PathTemplate)
+	assert.Equal(t, 0, diff.Unchanged)
+
+	changes := make(map[string]types.EndpointChange)
+	for _, c := range diff.Changed {
+		changes[c.Method+" "+c.PathTemplate] = c
+	}
+	require.Len(t, changes, 2)
+
+	users := changes["GET /users/{id}"]
+	assert.Equal(t, 2, users.BeforeCount)
+	assert.Equal(t, 1, users.AfterCount)
+	assert.Equal(t, []string{"fields"}, users.QueryKeysAdded)
+	require.NotNil(t, users.Auth)
+	assert.False(t, users.Auth.Before.BearerPresent)
+	assert.True(t, users.Auth.After.BearerPresent)
+	assert.Nil(t, users.Status)
+	assert.Equal(t, []types.SchemaChange{
+		{Path: "email", Change: "added", After: "string"},
+		{Path: "id", Change: "type_changed", Before: "integer", After: "string"},
+	}, users.ResponseSchema)
+
+	login := changes["POST /login"]
+	require.NotNil(t, login.Status)
+	assert.Equal(t, map[string]int{"2xx": 1}, login.Status.Before)
+	assert.Equal(t, map[string]int{"4xx": 1}, login.Status.After)
+	assert.Empty(t, login.RequestSchema)
+
+	assert.Contains(t, out.Hint, "1 added, 1 removed, 2 changed")
+}
+
+func TestToolDiffSessions_Limit(t *testing.T) {
+	d := deployDeps(t)
+
+	_, out, err := ToolDiffSessions(d)(context.Background(), nil, DiffSessionsInput{
+		Before: DiffSessionsSide{SessionID: "v1"},
+		After:  DiffSessionsSide{SessionID: "v2"},
+		Limit:  1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.ChangedCount)
+	assert.Len(t, out.Diff.Changed, 1)
+	assert.Contains(t, out.Hint, "capped at 1")
+}
+
+func TestToolDiffSessions_InvalidInput(t *testing.T) {
+	d := deployDeps(t)
+
+	inputs := []DiffSessionsInput{
+		{Before: DiffSessionsSide{SessionID: "v1"}, After: DiffSessionsSide{SessionID: "v1"}},
+		{Before: DiffSessionsSide{SessionID: "v1", SinceMs: 20, UntilMs: 10}, After: DiffSessionsSide{SessionID: "v2"}},
+		{Before: DiffSessionsSide{SessionID: "v1"}, After: DiffSessionsSide{SessionID: "v2"}, Filters: &ExtractEndpointsFilters{Category: "bogus"}},
+	}
+	for _, input := range inputs {
+		_, _, err := ToolDiffSessions(d)(context.Background(), nil, input)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v", input)
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code, "%+v", input)
+	}
+}
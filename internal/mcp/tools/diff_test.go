@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestCheckOutputSchema_DiffEntries(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[DiffEntriesOutput]("powhttp_diff_entries")
+	})
+}
+
+// clientEntry builds a request sent by a process with the given JA4 and
+// headers.
+func clientEntry(id, process, ja4 string, headers ...[]string) *client.SessionEntry {
+	e := testEntry(id, "GET", "https://api.example.com/items", 200, "", `{"items":[]}`)
+	e.Request.Headers = headers
+	e.Process = &client.ProcessInfo{PID: 100, Name: testStrPtr(process)}
+	e.TLS = client.TLSInfo{JA4: &client.JA4Fingerprint{Hashed: ja4}}
+	return e
+}
+
+// browserVsScraperDeps captures the same API called by a browser and by a
+// scraper that sends the same headers in a different order, without client hints.
+func browserVsScraperDeps(t *testing.T) *Deps {
+	t.Helper()
+	var entries []*client.SessionEntry
+	for i := range 4 {
+		entries = append(entries, clientEntry(fmt.Sprintf("chrome-%d", i), "chrome", "t13d1516h2_8daaf6152771_02713d6af862",
+			[]string{"sec-ch-ua", `"Chromium";v="130"`},
+			[]string{"user-agent", "Mozilla/5.0"},
+			[]string{"accept", "*/*"},
+			[]string{"x-request-id", fmt.Sprintf("req-%d", i)},
+		))
+		entries = append(entries, clientEntry(fmt.Sprintf("scraper-%d", i), "my-scraper", "t13d0912h2_f91f431d341e_dc02b4d2d1a0",
+			[]string{"accept", "*/*"},
+			[]string{"user-agent", "Mozilla/5.0"},
+			[]string{"x-request-id", fmt.Sprintf("req-s%d", i)},
+		))
+	}
+	return newTestDeps(t, newFakeSource("s1", entries...))
+}
+
+func TestToolDiffEntries_Groups(t *testing.T) {
+	d := browserVsScraperDeps(t)
+
+	_, out, err := ToolDiffEntries(d)(context.Background(), nil, DiffEntriesInput{
+		BaselineQuery:    "process:chrome",
+		CandidateFilters: &SearchEntriesFilters{ProcessName: "my-scraper"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, out.Diff)
+	require.NotNil(t, out.Group)
+
+	group := out.Group
+	assert.Equal(t, 4, group.BaselineEntries)
+	assert.Equal(t, 4, group.CandidateEntries)
+
+	kinds := make([]string, 0, len(group.Separators))
+	for _, s := range group.Separators {
+		assert.Equal(t, 1.0, s.Score)
+		kinds = append(kinds, s.Kind+" "+s.Name)
+	}
+	assert.Equal(t, []string{
+		"ja4 ",
+		"header_presence sec-ch-ua",
+		"header_order accept vs user-agent",
+	}, kinds)
+	assert.Equal(t, group.SeparatorCount, len(group.Separators))
+	assert.Contains(t, out.Hint, "strongest is ja4 (score 1.00)")
+}
+
+func TestToolDiffEntries_GroupLimit(t *testing.T) {
+	d := browserVsScraperDeps(t)
+
+	_, out, err := ToolDiffEntries(d)(context.Background(), nil, DiffEntriesInput{
+		BaselineQuery:  "process:chrome",
+		CandidateQuery: "process:my-scraper",
+		Limit:          1,
+		MaxEntries:     2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.Group.BaselineEntries)
+	assert.Equal(t, 3, out.Group.SeparatorCount)
+	require.Len(t, out.Group.Separators, 1)
+	assert.Equal(t, types.SeparatorJA4, out.Group.Separators[0].Kind)
+}
+
+func TestToolDiffEntries_GroupInvalidInput(t *testing.T) {
+	d := browserVsScraperDeps(t)
+
+	inputs := []DiffEntriesInput{
+		{BaselineQuery: "process:chrome"},
+		{CandidateQuery: "process:chrome"},
+		{BaselineQuery: "process:chrome", CandidateQuery: "process:my-scraper", BaselineEntryID: "chrome-0"},
+		{BaselineQuery: "process:chrome", CandidateQuery: "process:nobody"},
+		{BaselineQuery: "process:chrome", CandidateQuery: "process:my-scraper", MinScore: 2},
+		{BaselineQuery: "bogus:(", CandidateQuery: "process:my-scraper"},
+	}
+	for _, input := range inputs {
+		_, _, err := ToolDiffEntries(d)(context.Background(), nil, input)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v: %v", input, err)
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code, "%+v", input)
+	}
+}
+
+func TestToolDiffEntries_HTTP1Casing(t *testing.T) {
+	d := newTestDeps(t, newFakeSource("s1",
+		clientEntry("curl", "curl", "",
+			[]string{"Host", "api.example.com"},
+			[]string{"User-Agent", "curl/8.4.0"},
+			[]string{"Cookie", "session=1; theme=dark"},
+		),
+		clientEntry("go", "go", "",
+			[]string{"Host", "api.example.com"},
+			[]string{"user-agent", "curl/8.4.0"},
+			[]string{"Cookie", "theme=dark"},
+			[]string{"Cookie", "session=1"},
+		),
+	))
+
+	_, out, err := ToolDiffEntries(d)(context.Background(), nil, DiffEntriesInput{
+		BaselineEntryID:  "curl",
+		CandidateEntryID: "go",
+		Options:          &DiffOptions{CompareHeaderValues: true, CompareCookies: true},
+	})
+	require.NoError(t, err)
+	imp := out.Diff.ImportantDiffs
+	assert.Equal(t, []types.HeaderCasingDiff{{Name: "user-agent", Baseline: "User-Agent", Candidate: "user-agent"}}, imp.HeaderCasing)
+	assert.Equal(t, &types.DuplicateHeadersDiff{Candidate: []string{"cookie"}}, imp.DuplicateHeaders)
+	require.NotNil(t, imp.Cookies)
+	assert.True(t, imp.Cookies.Reordered)
+	assert.Equal(t, 2, imp.Cookies.CandidateHeaders)
+	assert.Equal(t, "medium", out.Severity)
+}
@@ -1,7 +1,6 @@
 package tools
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"unicode/utf8"
@@ -56,14 +55,14 @@ type BodyTransformOptions struct {
 	IncludeHeaders bool                  // If false, headers are omitted from output
 }
 
-// TransformBody decodes a base64 body for display.
+// TransformBody decodes a base64 body for display, removing any Content-Encoding.
 // Returns the transformed body content as a string (text content, JSON schema, or placeholder).
-func TransformBody(encoded *string, contentType string, opts BodyTransformOptions) string {
+func TransformBody(encoded *string, contentType, contentEncoding string, opts BodyTransformOptions) string {
 	if encoded == nil || *encoded == "" {
 		return ""
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(*encoded)
+	decoded, err := client.DecodeContent(encoded, contentEncoding)
 	if err != nil {
 		return fmt.Sprintf("[decode error: %s]", err.Error())
 	}
@@ -148,7 +147,7 @@ func ToDisplayEntry(entry *client.SessionEntry, opts BodyTransformOptions) *Disp
 			Path:        entry.Request.Path,
 			HTTPVersion: entry.Request.HTTPVersion,
 			Headers:     reqHeaders,
-			Body:        TransformBody(entry.Request.Body, reqContentType, opts),
+			Body:        TransformBody(entry.Request.Body, reqContentType, client.ContentEncoding(entry.Request.Headers), opts),
 		},
 		IsWebSocket: entry.IsWebSocket,
 		TLS:         entry.TLS,
@@ -170,7 +169,7 @@ func ToDisplayEntry(entry *client.SessionEntry, opts BodyTransformOptions) *Disp
 			StatusCode:  entry.Response.StatusCode,
 			StatusText:  entry.Response.StatusText,
 			Headers:     respHeaders,
-			Body:        TransformBody(entry.Response.Body, respContentType, opts),
+			Body:        TransformBody(entry.Response.Body, respContentType, client.ContentEncoding(entry.Response.Headers), opts),
 		}
 	}
 
@@ -39,6 +39,11 @@ type AvailableData struct {
 	RespBodyBytes   int    `json:"resp_body_bytes,omitempty"`
 	RespContentType string `json:"resp_content_type,omitempty"`
 	ReqBodyBytes    int    `json:"req_body_bytes,omitempty"`
+
+	// Content-Encoding of the captured bodies (e.g. "gzip", "br"). Bodies
+	// shown are decompressed; byte counts are as captured.
+	RespContentEncoding string `json:"resp_content_encoding,omitempty"`
+	ReqContentEncoding  string `json:"req_content_encoding,omitempty"`
 }
 
 // Truncation describes what was truncated.
@@ -196,6 +201,10 @@ func ToolGetEntry(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest
 			availableData.RespContentType = summary.Sizes.RespContentType
 			availableData.ReqBodyBytes = summary.Sizes.ReqBodyBytes
 		}
+		availableData.ReqContentEncoding = client.ContentEncoding(entry.Request.Headers)
+		if entry.Response != nil {
+			availableData.RespContentEncoding = client.ContentEncoding(entry.Response.Headers)
+		}
 
 		output := GetEntryOutput{
 			Summary:       summary,
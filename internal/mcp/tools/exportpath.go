@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveExportPath maps a tool's output_path onto the configured export
+// directory. Relative paths are resolved inside EXPORT_DIR; absolute paths
+// must already point inside it. Paths escaping the directory (via ".." or a
+// symlinked parent) are rejected, and an existing file is only replaced when
+// overwrite is set.
+func resolveExportPath(exportDir, outputPath string, overwrite bool) (string, error) {
+	if exportDir == "" {
+		return "", ErrInvalidInput("writing exports to disk is disabled; set EXPORT_DIR to allow output_path, or read the resource URI instead")
+	}
+
+	root, err := filepath.Abs(exportDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving EXPORT_DIR: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	path := outputPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	if !within(root, path) {
+		return "", ErrInvalidInput(fmt.Sprintf("output_path %q is outside EXPORT_DIR %s", outputPath, root))
+	}
+
+	// Resolve symlinks in the existing part of the parent directory so a link
+	// inside the export directory cannot point the write elsewhere.
+	if parent, err := evalExistingPrefix(filepath.Dir(path)); err == nil && !within(root, parent) {
+		return "", ErrInvalidInput(fmt.Sprintf("output_path %q resolves outside EXPORT_DIR %s", outputPath, root))
+	}
+
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return "", ErrInvalidInput(fmt.Sprintf("output_path %q is a directory", outputPath))
+	case err == nil && info.Mode()&fs.ModeSymlink != 0:
+		return "", ErrInvalidInput(fmt.Sprintf("output_path %q is a symlink", outputPath))
+	case err == nil && !overwrite:
+		return "", ErrInvalidInput(fmt.Sprintf("output_path %q already exists; set overwrite=true to replace it", outputPath))
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return "", fmt.Errorf("checking output_path: %w", err)
+	}
+	return path, nil
+}
+
+// within reports whether path is root or inside it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// evalExistingPrefix resolves symlinks in the longest existing prefix of dir,
+// re-appending the components that do not exist yet.
+func evalExistingPrefix(dir string) (string, error) {
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(dir))
+		dir = parent
+	}
+}
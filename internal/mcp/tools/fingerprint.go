@@ -5,6 +5,7 @@ import (
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
@@ -19,9 +20,10 @@ type FingerprintInput struct {
 
 // FingerprintOutput is the output for powhttp_fingerprint.
 type FingerprintOutput struct {
-	Fingerprint *types.Fingerprint `json:"fingerprint"`
-	Resource    *types.ResourceRef `json:"resource,omitempty"`
-	Truncated   bool               `json:"truncated,omitempty"`
+	Fingerprint   *types.Fingerprint  `json:"fingerprint"`
+	ClientMatches []types.ClientMatch `json:"client_matches,omitzero"` // Known clients the fingerprint resembles, best first
+	Resource      *types.ResourceRef  `json:"resource,omitempty"`
+	Truncated     bool                `json:"truncated,omitempty"`
 }
 
 // ToolFingerprint generates a fingerprint for an entry.
@@ -60,8 +62,10 @@ func ToolFingerprint(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 			return nil, FingerprintOutput{}, WrapPowHTTPError(err)
 		}
 
-		return nil, FingerprintOutput{
-			Fingerprint: fp,
-		}, nil
+		out := FingerprintOutput{Fingerprint: fp}
+		if d.Clients != nil {
+			out.ClientMatches = d.Clients.Identify(clientdb.Signals(fp), fingerprintMinScore, fingerprintMatchLimit)
+		}
+		return nil, out, nil
 	}
 }
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func TestCheckOutputSchema_Fingerprint(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[FingerprintOutput]("powhttp_fingerprint")
+	})
+}
+
+// h2Entry builds an HTTP/2 request on the given connection.
+func h2Entry(id, connID string, pseudoHeaders ...string) *client.SessionEntry {
+	e := testEntry(id, "GET", "https://api.example.com/items", 200, "", `{"items":[]}`)
+	e.HTTPVersion = "h2"
+	e.HTTP2 = &client.HTTP2Info{ConnectionID: connID, StreamID: 1}
+	var headers client.Headers
+	for _, name := range pseudoHeaders {
+		headers = append(headers, []string{name, "x"})
+	}
+	e.Request.Headers = append(headers, e.Request.Headers...)
+	return e
+}
+
+// browserVsGoH2Deps captures a Chrome-like and a Go-like HTTP/2 client.
+func browserVsGoH2Deps(t *testing.T) *Deps {
+	t.Helper()
+	src := newFakeSource("s1",
+		h2Entry("chrome", "c1", ":method", ":authority", ":scheme", ":path"),
+		h2Entry("go", "c2", ":method", ":path", ":authority", ":scheme"),
+	)
+	src.addHTTP2Stream("c1", 0,
+		`{"side":"client","type":"SETTINGS","content":{"settings":[{"id":1,"value":65536},{"id":2,"value":0},{"id":4,"value":6291456},{"id":6,"value":262144}]}}`,
+		`{"side":"server","type":"SETTINGS","content":{"settings":[{"id":3,"value":100}]}}`,
+		`{"side":"client","type":"WINDOW_UPDATE","content":{"increment":15663105}}`,
+	)
+	src.addHTTP2Stream("c1", 1, `{"side":"client","type":"HEADERS"}`, `{"side":"server","type":"DATA"}`)
+	src.addHTTP2Stream("c2", 0,
+		`{"side":"client","type":"SETTINGS","content":{"settings":[{"id":2,"value":0},{"id":4,"value":4194304},{"id":6,"value":10485760}]}}`,
+		`{"side":"client","type":"WINDOW_UPDATE","content":{"increment":1073741824}}`,
+	)
+	src.addHTTP2Stream("c2", 1, `{"side":"client","type":"PRIORITY","content":{"exclusive":false,"dependency":0,"weight":16}}`)
+	return newTestDeps(t, src)
+}
+
+func TestToolFingerprint_Akamai(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+
+	_, out, err := ToolFingerprint(d)(context.Background(), nil, FingerprintInput{EntryID: "chrome"})
+	require.NoError(t, err)
+	h2 := out.Fingerprint.HTTP2Summary
+	require.NotNil(t, h2)
+	assert.Equal(t, "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p", h2.Akamai)
+	assert.Len(t, h2.Settings, 4)
+	assert.Equal(t, "INITIAL_WINDOW_SIZE", h2.Settings[2].Name)
+	assert.Equal(t, map[string]int{"HEADERS": 1, "DATA": 1}, h2.FrameCounts)
+
+	_, out, err = ToolFingerprint(d)(context.Background(), nil, FingerprintInput{EntryID: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, "2:0;4:4194304;6:10485760|1073741824|1:0:0:16|m,p,a,s", out.Fingerprint.HTTP2Summary.Akamai)
+}
+
+func TestToolDiffEntries_Akamai(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+
+	_, out, err := ToolDiffEntries(d)(context.Background(), nil, DiffEntriesInput{
+		BaselineEntryID:  "chrome",
+		CandidateEntryID: "go",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "high", out.Severity)
+
+	h2 := out.Diff.ImportantDiffs.HTTP2
+	require.NotNil(t, h2)
+	assert.True(t, h2.AkamaiDifferent)
+	assert.True(t, h2.WindowUpdateDiff)
+	assert.True(t, h2.PrioritiesDiff)
+	assert.Equal(t, "1:0:0:16", h2.CandidatePriorities)
+	assert.True(t, h2.PseudoHeaderOrderDiff)
+	names := make([]string, 0, len(h2.SettingsDiff))
+	for _, s := range h2.SettingsDiff {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"HEADER_TABLE_SIZE", "INITIAL_WINDOW_SIZE", "MAX_HEADER_LIST_SIZE"}, names)
+}
+
+// tlsEntry builds a request on the given TLS connection, without the JA3/JA4
+// that powhttp would record, so they have to be computed from the events.
+func tlsEntry(id, connID string) *client.SessionEntry {
+	e := testEntry(id, "GET", "https://api.example.com/items", 200, "", `{"items":[]}`)
+	e.TLS = client.TLSInfo{ConnectionID: &connID}
+	return e
+}
+
+func TestToolFingerprint_JA4(t *testing.T) {
+	e1 := tlsEntry("e1", "t1")
+	connect, ssl := int64(30), int64(20)
+	e1.Timings.Connect, e1.Timings.SSL = &connect, &ssl
+	src := newFakeSource("s1", e1, tlsEntry("e2", "t2"))
+	src.addTLSConnection(t, "t1",
+		`{"side":"client","msg":{"type":"handshake","content":{"type":"client_hello","content":{
+			"version":{"value":771,"name":"TLS 1.2"},
+			"cipher_suites":[{"value":2570,"name":"GREASE"},{"value":4865,"name":"TLS_AES_128_GCM_SHA256"},{"value":49199,"name":"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}],
+			"extensions":[
+				{"value":6682,"name":"GREASE"},
+				{"value":0,"name":"server_name","server_name":"api.example.com"},
+				{"value":43,"name":"supported_versions","versions":[{"value":772,"name":"TLS 1.3"},{"value":771,"name":"TLS 1.2"}]},
+				{"value":16,"name":"application_layer_protocol_negotiation","protocols":["h2"]}
+			]}}}}`,
+		`{"side":"server","msg":{"type":"handshake","content":{"type":"server_hello","content":{
+			"version":{"value":771,"name":"TLS 1.2"},
+			"cipher_suite":{"value":4865,"name":"TLS_AES_128_GCM_SHA256"},
+			"extensions":[{"value":43,"name":"supported_versions","selected_version":{"value":772,"name":"TLS 1.3"}},{"value":51,"name":"key_share"}]
+			}}}}`,
+	)
+	d := newTestDeps(t, src)
+
+	_, out, err := ToolFingerprint(d)(context.Background(), nil, FingerprintInput{EntryID: "e1"})
+	require.NoError(t, err)
+
+	fp := out.Fingerprint
+	assert.NotEmpty(t, fp.JA4H)
+	assert.Equal(t, "5000", fp.JA4L)
+	tls := fp.TLSSummary
+	require.NotNil(t, tls)
+	assert.Equal(t, "t13d0203h2", tls.JA4[:10])
+	assert.Equal(t, "t130200_1301_a56c5b993250", tls.JA4S)
+	require.NotNil(t, tls.ClientHello)
+	assert.Equal(t, []string{"GREASE", "TLS_AES_128_GCM_SHA256 (0x1301)", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 (0xc02f)"}, tls.ClientHello.CipherSuites)
+	assert.Equal(t, []string{"h2"}, tls.ClientHello.ALPN)
+
+	// The server's JA4S is also a search filter
+	_, search, err := ToolSearchEntries(d)(context.Background(), nil, SearchEntriesInput{
+		Filters: &SearchEntriesFilters{JA4S: tls.JA4S},
+	})
+	require.NoError(t, err)
+	require.Len(t, search.Results, 1)
+	assert.Equal(t, "e1", search.Results[0].Summary.EntryID)
+}
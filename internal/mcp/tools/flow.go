@@ -22,7 +22,9 @@ type TraceFlowInput struct {
 type TraceFlowOptions struct {
 	TimeWindowMs int64 `json:"time_window_ms,omitempty" jsonschema:"Time window (ms, default: 120000)"`
 	SamePIDOnly  bool  `json:"same_pid_only,omitempty" jsonschema:"Same PID only (default: true)"`
-	SameHostOnly bool  `json:"same_host_only,omitempty" jsonschema:"Same host only (default: true)"`
+	SameHostOnly bool  `json:"same_host_only,omitempty" jsonschema:"Same host only (default: true); the seed's redirect chain and the requests it led to are still followed across hosts"`
+
+	ValuePropagation bool `json:"value_propagation,omitempty" jsonschema:"Add value_propagation edges linking response values (IDs, CSRF tokens, JWTs, signed URLs) to later requests that send them, with from_path/to_path (default: false; fetches bodies)"`
 }
 
 // TraceFlowOutput is the output for powhttp_trace_flow.
@@ -54,9 +56,10 @@ func ToolTraceFlow(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolReques
 
 		if input.Options != nil {
 			traceReq.Options = &types.TraceOptions{
-				TimeWindowMs: input.Options.TimeWindowMs,
-				SamePIDOnly:  input.Options.SamePIDOnly,
-				SameHostOnly: input.Options.SameHostOnly,
+				TimeWindowMs:     input.Options.TimeWindowMs,
+				SamePIDOnly:      input.Options.SamePIDOnly,
+				SameHostOnly:     input.Options.SameHostOnly,
+				ValuePropagation: input.Options.ValuePropagation,
 			}
 		}
 
@@ -82,6 +85,9 @@ func ToolTraceFlow(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolReques
 			hint = "Single isolated request. Try same_pid_only=false or same_host_only=false to find related traffic."
 		} else {
 			hint = fmt.Sprintf("Found %d related requests. Use get_entry to inspect individual nodes.", len(graph.Nodes))
+			if input.Options == nil || !input.Options.ValuePropagation {
+				hint += " Set options.value_propagation=true to see which response values feed later requests."
+			}
 		}
 
 		return nil, TraceFlowOutput{
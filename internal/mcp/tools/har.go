@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/har"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// defaultHARMaxEntries is the export size when max_entries is not set.
+const defaultHARMaxEntries = 500
+
+// ExportHARInput is the input for powhttp_export_har.
+type ExportHARInput struct {
+	SessionID    string                `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryIDs     []string              `json:"entry_ids,omitempty" jsonschema:"Entry IDs to export"`
+	ClusterID    string                `json:"cluster_id,omitempty" jsonschema:"Cluster ID (from extract_endpoints) to export all entries in the cluster"`
+	Query        string                `json:"query,omitempty" jsonschema:"Free text search selecting entries to export (same as search_entries)"`
+	Filters      *SearchEntriesFilters `json:"filters,omitempty" jsonschema:"Search filters selecting entries to export (same as search_entries)"`
+	MaxEntries   int                   `json:"max_entries,omitempty" jsonschema:"Max entries to export (default: 500)"`
+	OutputPath   string                `json:"output_path,omitempty" jsonschema:"File path inside EXPORT_DIR to write the HAR to (relative paths resolve against EXPORT_DIR). If omitted, the HAR is only available as a resource."`
+	Overwrite    bool                  `json:"overwrite,omitempty" jsonschema:"Replace output_path if it already exists (default: false)"`
+	OmitBodies   bool                  `json:"omit_bodies,omitempty" jsonschema:"Leave request/response bodies out of the export (default: false)"`
+	MaxBodyBytes int                   `json:"max_body_bytes,omitempty" jsonschema:"Omit bodies larger than this many bytes (default: TOOL_MAX_BYTES_DEFAULT)"`
+}
+
+// ExportHAROutput is the output for powhttp_export_har.
+type ExportHAROutput struct {
+	ExportID       string             `json:"export_id"`
+	EntryCount     int                `json:"entry_count"`
+	EntriesSkipped int                `json:"entries_skipped,omitempty"`
+	Path           string             `json:"path,omitempty"`
+	Bytes          int                `json:"bytes"`
+	Resource       *types.ResourceRef `json:"resource,omitempty"`
+	Hint           string             `json:"hint,omitempty"`
+}
+
+// ToolExportHAR exports selected entries as a HAR 1.2 document.
+func ToolExportHAR(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input ExportHARInput) (*sdkmcp.CallToolResult, ExportHAROutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input ExportHARInput) (*sdkmcp.CallToolResult, ExportHAROutput, error) {
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, ExportHAROutput{}, err
+		}
+
+		maxEntries := input.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = defaultHARMaxEntries
+		}
+		if cap := d.Config.MaxQueryEntries; maxEntries > cap {
+			slog.Warn("export_har max_entries capped", "requested", maxEntries, "cap", cap)
+			maxEntries = cap
+		}
+
+		entryIDs, err := selectEntryIDs(ctx, d, sessionID, input.EntryIDs, input.ClusterID, input.Query, input.Filters, maxEntries)
+		if err != nil {
+			return nil, ExportHAROutput{}, err
+		}
+		if len(entryIDs) == 0 {
+			return nil, ExportHAROutput{}, ErrInvalidInput("no entries matched the selection")
+		}
+
+		entries := make([]*client.SessionEntry, 0, len(entryIDs))
+		skipped := 0
+		for _, id := range entryIDs {
+			entry, err := d.FetchEntry(ctx, sessionID, id)
+			if err != nil {
+				skipped++
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) == 0 {
+			return nil, ExportHAROutput{}, ErrInvalidInput("none of the selected entries could be fetched")
+		}
+
+		maxBodyBytes := input.MaxBodyBytes
+		if maxBodyBytes <= 0 {
+			maxBodyBytes = d.Config.ToolMaxBytesDefault
+		}
+		opts := har.Options{
+			OmitBodies:   input.OmitBodies,
+			MaxBodyBytes: maxBodyBytes,
+		}
+		doc := har.FromEntries(entries, opts)
+
+		exportID := computeHARExportID(sessionID, entryIDs, opts)
+		d.HARExports.Put(exportID, doc)
+
+		output := ExportHAROutput{
+			ExportID:       exportID,
+			EntryCount:     len(doc.Log.Entries),
+			EntriesSkipped: skipped,
+			Resource: &types.ResourceRef{
+				URI:  "powhttp://har/" + exportID,
+				MIME: MimeJSON,
+				Hint: "Fetch for the complete HAR document",
+			},
+		}
+
+		if input.OutputPath != "" {
+			path, err := resolveExportPath(d.Config.ExportDir, input.OutputPath, input.Overwrite)
+			if err != nil {
+				return nil, ExportHAROutput{}, err
+			}
+			n, err := har.WriteFile(path, doc)
+			if err != nil {
+				return nil, ExportHAROutput{}, err
+			}
+			output.Path = path
+			output.Bytes = n
+			output.Hint = fmt.Sprintf("Wrote %d entries to %s. Open it in browser devtools, Charles, Fiddler, or any HAR viewer.", output.EntryCount, path)
+		} else {
+			var buf bytes.Buffer
+			if err := har.Write(&buf, doc); err != nil {
+				return nil, ExportHAROutput{}, fmt.Errorf("encoding HAR: %w", err)
+			}
+			output.Bytes = buf.Len()
+			output.Hint = "Read the resource URI for the HAR contents."
+			if d.Config.ExportDir != "" {
+				output.Hint = "Set output_path to write the HAR to disk, or read the resource URI for its contents."
+			}
+		}
+
+		return nil, output, nil
+	}
+}
+
+// computeHARExportID derives a stable export ID from the session, selected
+// entries, and body options, so exports with different options don't collide.
+func computeHARExportID(sessionID string, entryIDs []string, opts har.Options) string {
+	key := fmt.Sprintf("%s\n%s\n%t\n%d", sessionID, strings.Join(entryIDs, ","), opts.OmitBodies, opts.MaxBodyBytes)
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])[:12]
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/har"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestComputeHARExportID(t *testing.T) {
+	opts := har.Options{MaxBodyBytes: 100}
+	a := computeHARExportID("s1", []string{"e1", "e2"}, opts)
+	assert.Len(t, a, 12)
+	assert.Equal(t, a, computeHARExportID("s1", []string{"e1", "e2"}, opts))
+	assert.NotEqual(t, a, computeHARExportID("s1", []string{"e2", "e1"}, opts))
+	assert.NotEqual(t, a, computeHARExportID("s2", []string{"e1", "e2"}, opts))
+	assert.NotEqual(t, a, computeHARExportID("s1", []string{"e1", "e2"}, har.Options{MaxBodyBytes: 100, OmitBodies: true}))
+	assert.NotEqual(t, a, computeHARExportID("s1", []string{"e1", "e2"}, har.Options{MaxBodyBytes: 200}))
+}
+
+func TestCheckOutputSchema_ExportHAR(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[ExportHAROutput]("powhttp_export_har")
+	})
+}
+
+func harTestDeps(t *testing.T) *Deps {
+	t.Helper()
+	d := newTestDeps(t, newFakeSource("s1",
+		testEntry("e1", "GET", "https://api.example.com/users/1", 200, "", `{"id":1}`),
+		testEntry("e2", "GET", "https://api.example.com/orders/7", 200, "", `{"id":7}`),
+		testEntry("e3", "POST", "https://api.example.com/orders", 201, `{"sku":"x"}`, `{"id":8}`),
+		testEntry("e4", "GET", "https://cdn.example.net/app.js", 200, "", ""),
+	))
+	d.ClusterStore.StoreExtraction(&types.ExtractResponse{
+		Clusters:  []types.Cluster{{ID: "c1", Host: "api.example.com", Method: "GET", PathTemplate: "/orders/{id}"}},
+		ScopeHash: "scope1",
+	}, map[string][]string{"c1": {"e2", "e3"}})
+	return d
+}
+
+func TestSelectEntryIDs(t *testing.T) {
+	d := harTestDeps(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		entryIDs  []string
+		clusterID string
+		query     string
+		filters   *SearchEntriesFilters
+		limit     int
+		want      []string
+	}{
+		{"entry_ids win over everything", []string{"e4", "e1"}, "c1", "orders", nil, 10, []string{"e4", "e1"}},
+		{"cluster_id wins over query", nil, "c1", "users", nil, 10, []string{"e2", "e3"}},
+		{"filters select by host", nil, "", "", &SearchEntriesFilters{Host: "cdn.example.net"}, 10, []string{"e4"}},
+		{"limit caps entry_ids", []string{"e1", "e2", "e3"}, "", "", nil, 2, []string{"e1", "e2"}},
+		{"limit caps cluster", nil, "c1", "", nil, 1, []string{"e2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectEntryIDs(ctx, d, "s1", tt.entryIDs, tt.clusterID, tt.query, tt.filters, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("query matches url tokens", func(t *testing.T) {
+		got, err := selectEntryIDs(ctx, d, "s1", nil, "", "orders", nil, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"e2", "e3"}, got)
+	})
+
+	t.Run("query language", func(t *testing.T) {
+		got, err := selectEntryIDs(ctx, d, "s1", nil, "", "status:2xx AND NOT host:cdn.* AND -method:POST", nil, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"e1", "e2"}, got)
+	})
+
+	t.Run("malformed query", func(t *testing.T) {
+		_, err := selectEntryIDs(ctx, d, "s1", nil, "", "(orders", nil, 10)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded))
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code)
+	})
+
+	t.Run("no selector", func(t *testing.T) {
+		_, err := selectEntryIDs(ctx, d, "s1", nil, "", "", nil, 10)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded))
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code)
+	})
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		_, err := selectEntryIDs(ctx, d, "s1", nil, "missing", "", nil, 10)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded))
+		assert.Equal(t, ErrCodeNotFound, coded.Code)
+	})
+}
+
+func TestToolExportHAR_SkipsMissingEntries(t *testing.T) {
+	d := harTestDeps(t)
+
+	_, out, err := ToolExportHAR(d)(context.Background(), nil, ExportHARInput{
+		SessionID: "s1",
+		EntryIDs:  []string{"e1", "gone", "e3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.EntryCount)
+	assert.Equal(t, 1, out.EntriesSkipped)
+	assert.Empty(t, out.Path)
+
+	doc, ok := d.HARExports.Get(out.ExportID)
+	require.True(t, ok)
+	assert.Len(t, doc.(*har.HAR).Log.Entries, 2)
+
+	_, _, err = ToolExportHAR(d)(context.Background(), nil, ExportHARInput{
+		SessionID: "s1",
+		EntryIDs:  []string{"gone"},
+	})
+	assert.Error(t, err)
+}
+
+func TestToolExportHAR_OutputPath(t *testing.T) {
+	d := harTestDeps(t)
+	input := ExportHARInput{SessionID: "s1", EntryIDs: []string{"e1"}, OutputPath: "out/capture.har"}
+	run := func(in ExportHARInput) (ExportHAROutput, error) {
+		_, out, err := ToolExportHAR(d)(context.Background(), nil, in)
+		return out, err
+	}
+
+	_, err := run(input)
+	require.Error(t, err, "writes are disabled without EXPORT_DIR")
+
+	dir := t.TempDir()
+	d.Config.ExportDir = dir
+
+	out, err := run(input)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "out", "capture.har"), out.Path)
+	assert.FileExists(t, out.Path)
+
+	_, err = run(input)
+	require.Error(t, err, "existing file is kept without overwrite")
+
+	input.Overwrite = true
+	_, err = run(input)
+	require.NoError(t, err)
+
+	for _, bad := range []string{"../escape.har", filepath.Join(filepath.Dir(dir), "escape.har")} {
+		_, err = run(ExportHARInput{SessionID: "s1", EntryIDs: []string{"e1"}, OutputPath: bad})
+		require.Error(t, err, bad)
+	}
+	_, statErr := os.Stat(filepath.Join(filepath.Dir(dir), "escape.har"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestResolveExportPath_SymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+
+	_, err := resolveExportPath(root, "link/x.har", false)
+	assert.Error(t, err)
+
+	path, err := resolveExportPath(root, "sub/x.har", false)
+	require.NoError(t, err)
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(resolvedRoot, "sub", "x.har"), path)
+}
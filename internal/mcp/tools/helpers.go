@@ -2,6 +2,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
@@ -71,3 +72,42 @@ func BuildEntrySummaryFromEntry(entry *client.SessionEntry) *types.EntrySummary
 
 	return summary
 }
+
+// selectEntryIDs resolves the entry selectors shared by multi-entry tools.
+// Explicit entry IDs win, then cluster_id, then a search over query/filters.
+// At least one selector must be set. The result is capped at limit.
+func selectEntryIDs(ctx context.Context, d *Deps, sessionID string, entryIDs []string, clusterID, query string, filters *SearchEntriesFilters, limit int) ([]string, error) {
+	var ids []string
+	switch {
+	case len(entryIDs) > 0:
+		ids = entryIDs
+	case clusterID != "":
+		stored, ok := d.ClusterStore.GetCluster(clusterID)
+		if !ok {
+			return nil, ErrNotFound("cluster", clusterID)
+		}
+		ids = stored.EntryIDs
+	case query != "" || filters != nil:
+		resp, err := d.Search.Search(ctx, &types.SearchRequest{
+			SessionID: sessionID,
+			Query:     query,
+			Filters:   filters.toSearchFilters(),
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, wrapSearchError(err)
+		}
+		for _, r := range resp.Results {
+			if r.Summary != nil {
+				ids = append(ids, r.Summary.EntryID)
+			}
+		}
+	default:
+		return nil, ErrInvalidInput("one of entry_ids, cluster_id, query, or filters is required")
+	}
+
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	defaultIdentifyLimit    = 3
+	maxIdentifyLimit        = 20
+	defaultIdentifyMinScore = 0.3
+
+	// fingerprintMatchLimit and fingerprintMinScore bound the matches
+	// powhttp_fingerprint adds to its output.
+	fingerprintMatchLimit = 3
+	fingerprintMinScore   = 0.5
+)
+
+// IdentifyClientInput is the input for powhttp_identify_client.
+type IdentifyClientInput struct {
+	SessionID    string              `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryID      string              `json:"entry_id,omitempty" jsonschema:"Entry ID to identify. Required unless list_profiles is set"`
+	Limit        int                 `json:"limit,omitempty" jsonschema:"Max matches returned (default: 3, max: 20)"`
+	MinScore     float64             `json:"min_score,omitempty" jsonschema:"Min match score (0-1) (default: 0.3)"`
+	SaveProfile  *ClientProfileInput `json:"save_profile,omitempty" jsonschema:"Save the entry's fingerprints as a reference profile, so later requests can be identified as this client"`
+	ListProfiles bool                `json:"list_profiles,omitempty" jsonschema:"List the reference profiles instead of identifying an entry"`
+}
+
+// ClientProfileInput names a profile saved from an entry.
+type ClientProfileInput struct {
+	ID       string `json:"id,omitempty" jsonschema:"Profile ID (default: derived from name). Saving again with the same ID replaces the profile"`
+	Name     string `json:"name" jsonschema:"required,Client name, e.g. 'Our Android app 5.2'"`
+	Family   string `json:"family,omitempty" jsonschema:"browser, library, tool, or app"`
+	Platform string `json:"platform,omitempty" jsonschema:"Platform, e.g. 'Android 14'"`
+	Notes    string `json:"notes,omitempty" jsonschema:"Free-form notes"`
+}
+
+// IdentifyClientOutput is the output for powhttp_identify_client.
+type IdentifyClientOutput struct {
+	Signals  *types.ClientSignals  `json:"signals,omitempty"`
+	Matches  []types.ClientMatch   `json:"matches,omitzero"`
+	Warning  string                `json:"warning,omitempty"`
+	Saved    *types.ClientProfile  `json:"saved,omitempty"`
+	Profiles []types.ClientProfile `json:"profiles,omitzero"`
+	Hint     string                `json:"hint,omitempty"`
+}
+
+// ToolIdentifyClient matches an entry's fingerprints against reference
+// profiles of known browsers and HTTP libraries.
+func ToolIdentifyClient(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input IdentifyClientInput) (*sdkmcp.CallToolResult, IdentifyClientOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input IdentifyClientInput) (*sdkmcp.CallToolResult, IdentifyClientOutput, error) {
+		if d.Clients == nil {
+			return nil, IdentifyClientOutput{}, ErrInvalidInput("client identification is not available: no client profiles are loaded")
+		}
+		if input.ListProfiles {
+			return nil, IdentifyClientOutput{Profiles: d.Clients.Profiles()}, nil
+		}
+		if input.EntryID == "" {
+			return nil, IdentifyClientOutput{}, ErrInvalidInput("entry_id is required")
+		}
+		if input.MinScore < 0 || input.MinScore > 1 {
+			return nil, IdentifyClientOutput{}, ErrInvalidInput("min_score must be between 0 and 1")
+		}
+		if input.SaveProfile != nil && input.SaveProfile.Name == "" {
+			return nil, IdentifyClientOutput{}, ErrInvalidInput("save_profile.name is required")
+		}
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = defaultIdentifyLimit
+		}
+		limit = min(limit, maxIdentifyLimit)
+		minScore := input.MinScore
+		if minScore == 0 {
+			minScore = defaultIdentifyMinScore
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, IdentifyClientOutput{}, err
+		}
+		fp, err := d.Fingerprint.Generate(ctx, sessionID, input.EntryID, nil)
+		if err != nil {
+			return nil, IdentifyClientOutput{}, WrapPowHTTPError(err)
+		}
+		signals := clientdb.Signals(fp)
+
+		var out IdentifyClientOutput
+		out.Signals = &signals
+
+		if in := input.SaveProfile; in != nil {
+			profile := clientdb.ProfileFromSignals(signals)
+			profile.ID = in.ID
+			profile.Name = in.Name
+			profile.Family = in.Family
+			profile.Platform = in.Platform
+			profile.Notes = in.Notes
+			saved, err := d.Clients.Add(profile)
+			if errors.Is(err, clientdb.ErrInvalidProfile) {
+				return nil, IdentifyClientOutput{}, ErrInvalidInput(err.Error())
+			}
+			if err != nil {
+				return nil, IdentifyClientOutput{}, err
+			}
+			out.Saved = &saved
+			if !d.Clients.Persistent() {
+				out.Hint = "Profile kept in memory until the server restarts. Set CLIENT_PROFILES_FILE to save profiles to disk."
+			}
+		}
+
+		out.Matches = d.Clients.Identify(signals, minScore, limit)
+		out.Warning = userAgentWarning(out.Matches)
+		if len(out.Matches) == 0 && out.Hint == "" {
+			out.Hint = "No profile matched. Lower min_score, or save this client with save_profile to recognize it later."
+		}
+		return nil, out, nil
+	}
+}
+
+// userAgentWarning flags a best match whose TLS or HTTP/2 fingerprint
+// matches but whose User-Agent does not, the mark of a spoofed User-Agent.
+func userAgentWarning(matches []types.ClientMatch) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	best := matches[0]
+	if !slices.Contains(best.Mismatched, clientdb.SignalUserAgent) {
+		return ""
+	}
+	for _, signal := range []string{clientdb.SignalJA4, clientdb.SignalJA3, clientdb.SignalAkamai} {
+		if slices.Contains(best.Matched, signal) {
+			return fmt.Sprintf("The %s fingerprint matches %s but the User-Agent does not; the User-Agent is likely spoofed.", signal, best.Name)
+		}
+	}
+	return ""
+}
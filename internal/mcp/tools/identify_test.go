@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestCheckOutputSchema_IdentifyClient(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[IdentifyClientOutput]("powhttp_identify_client")
+	})
+}
+
+func TestToolIdentifyClient_Builtin(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+
+	_, out, err := ToolIdentifyClient(d)(context.Background(), nil, IdentifyClientInput{EntryID: "chrome"})
+	require.NoError(t, err)
+	require.NotNil(t, out.Signals)
+	assert.Equal(t, "1:65536;2:0;4:6291456;6:262144|15663105|0|m,a,s,p", out.Signals.Akamai)
+	require.NotEmpty(t, out.Matches)
+	best := out.Matches[0]
+	assert.Equal(t, "chrome", best.ProfileID)
+	assert.Contains(t, best.Matched, clientdb.SignalAkamai)
+	assert.Contains(t, best.Mismatched, clientdb.SignalUserAgent)
+	assert.Contains(t, out.Warning, "spoofed")
+}
+
+func TestToolIdentifyClient_SaveProfile(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+	tool := ToolIdentifyClient(d)
+
+	_, out, err := tool(context.Background(), nil, IdentifyClientInput{
+		EntryID:     "go",
+		SaveProfile: &ClientProfileInput{Name: "Inventory Sync", Family: "app"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, out.Saved)
+	assert.Equal(t, "inventory-sync", out.Saved.ID)
+	assert.Equal(t, types.ClientProfileUser, out.Saved.Source)
+	assert.Contains(t, out.Hint, "CLIENT_PROFILES_FILE")
+	require.NotEmpty(t, out.Matches)
+	assert.Equal(t, "inventory-sync", out.Matches[0].ProfileID)
+	assert.Equal(t, 1.0, out.Matches[0].Score)
+
+	_, out, err = tool(context.Background(), nil, IdentifyClientInput{ListProfiles: true})
+	require.NoError(t, err)
+	assert.Equal(t, "inventory-sync", out.Profiles[len(out.Profiles)-1].ID)
+	assert.Nil(t, out.Signals)
+}
+
+func TestToolIdentifyClient_InvalidInput(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+
+	tests := []struct {
+		name  string
+		input IdentifyClientInput
+	}{
+		{"missing entry_id", IdentifyClientInput{}},
+		{"min_score out of range", IdentifyClientInput{EntryID: "chrome", MinScore: 1.5}},
+		{"save without name", IdentifyClientInput{EntryID: "chrome", SaveProfile: &ClientProfileInput{ID: "x"}}},
+		{"save with builtin id", IdentifyClientInput{EntryID: "chrome", SaveProfile: &ClientProfileInput{ID: "chrome", Name: "Chrome"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ToolIdentifyClient(d)(context.Background(), nil, tt.input)
+			var coded *CodedError
+			require.ErrorAs(t, err, &coded)
+			assert.Equal(t, ErrCodeInvalidInput, coded.Code)
+		})
+	}
+}
+
+func TestToolFingerprint_ClientMatches(t *testing.T) {
+	d := browserVsGoH2Deps(t)
+
+	_, out, err := ToolFingerprint(d)(context.Background(), nil, FingerprintInput{EntryID: "chrome"})
+	require.NoError(t, err)
+	require.NotEmpty(t, out.ClientMatches)
+	assert.Equal(t, "chrome", out.ClientMatches[0].ProfileID)
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/usestring/powhttp-mcp/pkg/contenttype"
 	"github.com/usestring/powhttp-mcp/pkg/shape"
+	"github.com/usestring/powhttp-mcp/pkg/typegen"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
@@ -19,6 +22,8 @@ type InferSchemaInput struct {
 	ClusterID  string   `json:"cluster_id,omitempty" jsonschema:"Cluster ID (from extract_endpoints) to analyze all entries in the cluster. Either cluster_id or entry_ids is required."`
 	Target     string   `json:"target,omitempty" jsonschema:"Which body to analyze: response (default), request, or both"`
 	MaxEntries int      `json:"max_entries,omitempty" jsonschema:"Max HTTP entries to inspect (default: 20, max: 100)"`
+	Format     string   `json:"format,omitempty" jsonschema:"Also emit type definitions for JSON/YAML bodies: go, typescript, zod, or pydantic (default: json_schema, schema only)"`
+	TypeName   string   `json:"type_name,omitempty" jsonschema:"Name of the generated top-level type (default: derived from the cluster, e.g. GetUsersResponse)"`
 }
 
 // ToolInferSchema infers a merged schema from multiple HTTP entry bodies.
@@ -45,15 +50,24 @@ func ToolInferSchema(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 		if target != "request" && target != "response" && target != "both" {
 			return nil, types.InferSchemaOutput{}, ErrInvalidInput("target must be 'request', 'response', or 'both'")
 		}
+		format := input.Format
+		if format == "json_schema" {
+			format = ""
+		}
+		if format != "" && !slices.Contains(typegen.Formats, format) {
+			return nil, types.InferSchemaOutput{}, ErrInvalidInput(fmt.Sprintf("format must be json_schema, %s", strings.Join(typegen.Formats, ", ")))
+		}
 
 		// Collect entry IDs
 		var entryIDs []string
+		var cluster *types.Cluster
 		if input.ClusterID != "" {
 			stored, ok := d.ClusterStore.GetCluster(input.ClusterID)
 			if !ok {
 				return nil, types.InferSchemaOutput{}, ErrNotFound("cluster", input.ClusterID)
 			}
 			entryIDs = stored.EntryIDs
+			cluster = stored.Cluster
 		} else {
 			entryIDs = input.EntryIDs
 		}
@@ -134,6 +148,24 @@ func ToolInferSchema(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 			hint = "Use powhttp_query_body(entry_ids=..., expression=...) to extract specific field values based on this schema."
 		}
 
+		var typesOut *types.InferSchemaTypes
+		if format != "" {
+			if result.Schema == nil {
+				hint = fmt.Sprintf("format %s applies to JSON and YAML bodies only; these bodies are %s. ", format, result.ContentCategory) + hint
+			} else {
+				rootName := input.TypeName
+				if rootName == "" {
+					rootName = defaultTypeName(cluster, target)
+				}
+				rootName = typegen.TypeName(rootName)
+				code, err := typegen.Generate(format, result.Schema, result.FieldStats, typegen.Options{RootName: rootName})
+				if err != nil {
+					return nil, types.InferSchemaOutput{}, fmt.Errorf("generating %s types: %w", format, err)
+				}
+				typesOut = &types.InferSchemaTypes{Format: format, RootName: rootName, Code: code}
+			}
+		}
+
 		output := types.InferSchemaOutput{
 			Shape: shapeAny,
 			Summary: types.InferSchemaSummary{
@@ -142,9 +174,32 @@ func ToolInferSchema(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequ
 				EntriesSkipped:   entriesSkipped,
 				ContentCategory:  result.ContentCategory,
 			},
-			Hint: hint,
+			Types: typesOut,
+			Hint:  hint,
 		}
 
 		return nil, output, nil
 	}
 }
+
+// defaultTypeName names generated types after the cluster's method and static
+// path segments (GET /users/{id} -> GetUsersResponse), or after the target.
+func defaultTypeName(cluster *types.Cluster, target string) string {
+	suffix := "Response"
+	switch target {
+	case "request":
+		suffix = "Request"
+	case "both":
+		suffix = "Body"
+	}
+	if cluster == nil {
+		return suffix
+	}
+	parts := []string{strings.ToLower(cluster.Method)}
+	for _, seg := range strings.Split(cluster.PathTemplate, "/") {
+		if seg != "" && !strings.HasPrefix(seg, "{") {
+			parts = append(parts, seg)
+		}
+	}
+	return strings.Join(parts, "_") + "_" + suffix
+}
@@ -1,9 +1,14 @@
 package tools
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 func TestInferSchemaInput_Validation(t *testing.T) {
@@ -33,3 +38,52 @@ func TestInferSchemaInput_Defaults(t *testing.T) {
 	assert.Empty(t, input.Target)
 	assert.Equal(t, 0, input.MaxEntries)
 }
+
+func TestToolInferSchema_Format(t *testing.T) {
+	d := newTestDeps(t, newFakeSource("s1",
+		testEntry("e1", "GET", "https://api.example.com/users/1", 200, "", `{"id":1,"name":"a","email":null}`),
+		testEntry("e2", "GET", "https://api.example.com/users/2", 200, "", `{"id":2,"name":"b","email":"b@example.com"}`),
+	))
+	d.ClusterStore.StoreExtraction(&types.ExtractResponse{
+		Clusters:  []types.Cluster{{ID: "c1", Host: "api.example.com", Method: "GET", PathTemplate: "/users/{id}"}},
+		ScopeHash: "scope1",
+	}, map[string][]string{"c1": {"e1", "e2"}})
+
+	_, out, err := ToolInferSchema(d)(context.Background(), nil, InferSchemaInput{SessionID: "s1", ClusterID: "c1", Format: "typescript"})
+	require.NoError(t, err)
+	require.NotNil(t, out.Types)
+	assert.Equal(t, "GetUsersResponse", out.Types.RootName)
+	assert.Contains(t, out.Types.Code, "export interface GetUsersResponse {")
+	assert.Contains(t, out.Types.Code, "email?: string | null;")
+	assert.NotNil(t, out.Shape, "the schema is still returned")
+
+	_, out, err = ToolInferSchema(d)(context.Background(), nil, InferSchemaInput{SessionID: "s1", EntryIDs: []string{"e1"}, Format: "go", TypeName: "user"})
+	require.NoError(t, err)
+	require.NotNil(t, out.Types)
+	assert.Contains(t, out.Types.Code, "type User struct {")
+
+	_, out, err = ToolInferSchema(d)(context.Background(), nil, InferSchemaInput{SessionID: "s1", EntryIDs: []string{"e1"}, Format: "json_schema"})
+	require.NoError(t, err)
+	assert.Nil(t, out.Types)
+
+	_, _, err = ToolInferSchema(d)(context.Background(), nil, InferSchemaInput{SessionID: "s1", EntryIDs: []string{"e1"}, Format: "rust"})
+	require.Error(t, err)
+}
+
+func TestToolInferSchema_FormatNonJSON(t *testing.T) {
+	e := testEntry("e1", "GET", "https://example.com/", 200, "", "<html><body><p>hi</p></body></html>")
+	e.Response.Headers = client.Headers{{"content-type", "text/html"}}
+	d := newTestDeps(t, newFakeSource("s1", e))
+
+	_, out, err := ToolInferSchema(d)(context.Background(), nil, InferSchemaInput{SessionID: "s1", EntryIDs: []string{"e1"}, Format: "zod"})
+	require.NoError(t, err)
+	assert.Nil(t, out.Types)
+	assert.Contains(t, out.Hint, "JSON and YAML bodies only")
+}
+
+func TestDefaultTypeName(t *testing.T) {
+	c := &types.Cluster{Method: "POST", PathTemplate: "/v1/orders/{id}/items"}
+	assert.Equal(t, "post_v1_orders_items_Request", defaultTypeName(c, "request"))
+	assert.Equal(t, "Response", defaultTypeName(nil, "response"))
+	assert.Equal(t, "Body", defaultTypeName(nil, "both"))
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/query"
+	"github.com/usestring/powhttp-mcp/internal/replay"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/shape"
+)
+
+const (
+	defaultMinimizeRequests = 40
+	maxMinimizeRequests     = 200
+)
+
+// MinimizeRequestInput is the input for powhttp_minimize_request.
+type MinimizeRequestInput struct {
+	SessionID       string   `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryID         string   `json:"entry_id" jsonschema:"required,Entry ID of the request to minimize"`
+	Kinds           []string `json:"kinds,omitempty" jsonschema:"Parts to try removing: header, cookie, query, json (default: all)"`
+	MatchJQ         string   `json:"match_jq,omitempty" jsonschema:"jq assertion on the response body; a variant matches when it yields at least one value and none is null or false. Replaces the schema check."`
+	SkipSchemaCheck bool     `json:"skip_schema_check,omitempty" jsonschema:"Match on status code alone, without comparing response shape"`
+	MaxRequests     int      `json:"max_requests,omitempty" jsonschema:"Maximum requests to send, including the baseline (default: 40, max: 200)"`
+}
+
+// MinimizeRequestOutput is the output for powhttp_minimize_request.
+type MinimizeRequestOutput struct {
+	OriginalStatus int             `json:"original_status"`
+	Match          string          `json:"match"`
+	RequestsSent   int             `json:"requests_sent"`
+	Complete       bool            `json:"complete"`
+	Parts          []MinimizePart  `json:"parts,omitzero"`
+	RequiredCount  int             `json:"required_count"`
+	RemovableCount int             `json:"removable_count"`
+	Minimal        *MinimalRequest `json:"minimal,omitempty"`
+	Hint           string          `json:"hint,omitempty"`
+}
+
+// MinimizePart is one row of the required/removable table.
+type MinimizePart struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	Required      bool   `json:"required"`
+	StatusWithout int    `json:"status_without,omitempty"` // Status when only this part was dropped from the minimal request
+}
+
+// MinimalRequest is the smallest request that still matched.
+type MinimalRequest struct {
+	Method  string         `json:"method"`
+	URL     string         `json:"url"`
+	Headers client.Headers `json:"headers,omitzero"`
+	Body    string         `json:"body,omitempty"`
+}
+
+// responseOracle decides whether a replayed response is equivalent to the baseline.
+type responseOracle struct {
+	status    int
+	jq        string
+	signature []string // Baseline shape signature; nil when shape is not compared
+	engine    *query.Engine
+	shape     *shape.Engine
+}
+
+// matches reports whether resp has the baseline status and passes the jq
+// assertion or keeps every field of the baseline shape.
+func (o *responseOracle) matches(resp *replay.Response) bool {
+	if resp.StatusCode != o.status {
+		return false
+	}
+	if o.jq == "" && o.signature == nil {
+		return true
+	}
+	body, err := resp.DecodedBody()
+	if err != nil {
+		return false
+	}
+	if o.jq != "" {
+		return jqHolds(o.engine, body, o.jq)
+	}
+	sig, ok := shapeSignature(o.shape, body, resp.Headers.Get("content-type"))
+	if !ok {
+		return false
+	}
+	for _, s := range o.signature {
+		if _, found := slices.BinarySearch(sig, s); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// jqHolds reports whether expr yields at least one value and none is null or false.
+func jqHolds(engine *query.Engine, body []byte, expr string) bool {
+	res, err := engine.Query(body, expr, false, 0)
+	if err != nil || len(res.Values) == 0 || len(res.Errors) > 0 {
+		return false
+	}
+	for _, v := range res.Values {
+		if v == nil || v == false {
+			return false
+		}
+	}
+	return true
+}
+
+// shapeSignature returns the sorted "path:type" pairs of a JSON or YAML body,
+// or just its content category for other formats.
+func shapeSignature(engine *shape.Engine, body []byte, contentType string) ([]string, bool) {
+	if len(body) == 0 {
+		return []string{"empty"}, true
+	}
+	res, err := engine.Analyze([][]byte{body}, contentType)
+	if err != nil || res.Skipped {
+		return nil, false
+	}
+	sig := []string{"category:" + res.ContentCategory}
+	for _, fs := range res.FieldStats {
+		sig = append(sig, fs.Path+":"+fs.Type)
+	}
+	slices.Sort(sig)
+	return sig, true
+}
+
+// ToolMinimizeRequest finds the smallest set of headers, cookies, query keys,
+// and JSON body fields the server still accepts.
+func ToolMinimizeRequest(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input MinimizeRequestInput) (*sdkmcp.CallToolResult, MinimizeRequestOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input MinimizeRequestInput) (*sdkmcp.CallToolResult, MinimizeRequestOutput, error) {
+		if input.EntryID == "" {
+			return nil, MinimizeRequestOutput{}, ErrInvalidInput("entry_id is required")
+		}
+		for _, k := range input.Kinds {
+			if !slices.Contains([]string{replay.PartHeader, replay.PartCookie, replay.PartQuery, replay.PartJSON}, k) {
+				return nil, MinimizeRequestOutput{}, ErrInvalidInput(fmt.Sprintf("invalid kind %q: must be header, cookie, query, or json", k))
+			}
+		}
+		budget := input.MaxRequests
+		if budget <= 0 {
+			budget = defaultMinimizeRequests
+		}
+		if budget > maxMinimizeRequests {
+			budget = maxMinimizeRequests
+		}
+		qe := query.NewEngine()
+		if input.MatchJQ != "" {
+			if err := qe.ValidateExpression(input.MatchJQ); err != nil {
+				return nil, MinimizeRequestOutput{}, ErrInvalidInput(fmt.Sprintf("invalid match_jq: %v", err))
+			}
+		}
+		if d.Replay == nil {
+			return nil, MinimizeRequestOutput{}, ErrInvalidInput("replay is not available: POWHTTP_PROXY_URL is not a valid proxy URL")
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, err
+		}
+		entry, err := d.FetchEntry(ctx, sessionID, input.EntryID)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, WrapPowHTTPError(err)
+		}
+		if entry.Response == nil || entry.Response.StatusCode == nil {
+			return nil, MinimizeRequestOutput{}, ErrInvalidInput("entry has no response to match against")
+		}
+		baseline, err := replay.FromEntry(entry)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, ErrInvalidInput(err.Error())
+		}
+
+		output := MinimizeRequestOutput{OriginalStatus: *entry.Response.StatusCode}
+		oracle := &responseOracle{status: output.OriginalStatus, jq: input.MatchJQ, engine: qe, shape: shape.NewEngine()}
+
+		// The unmodified request must match before anything is removed; its
+		// response is the reference shape for every variant.
+		resp, err := d.Replay.Send(ctx, baseline)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, fmt.Errorf("replaying via %s: %w", d.Config.PowHTTPProxyURL, err)
+		}
+		output.RequestsSent = 1
+		switch {
+		case input.MatchJQ != "":
+			output.Match = "status+jq"
+		case input.SkipSchemaCheck:
+			output.Match = "status"
+		default:
+			output.Match = "status+shape"
+			body, err := resp.DecodedBody()
+			if err == nil {
+				oracle.signature, _ = shapeSignature(oracle.shape, body, resp.Headers.Get("content-type"))
+			}
+			if oracle.signature == nil {
+				output.Match = "status"
+				output.Hint = "The response shape could not be analyzed, so variants are matched on status code alone. "
+			}
+		}
+		if !oracle.matches(resp) {
+			output.Hint = fmt.Sprintf("The unmodified replay returned %d and did not match the original (%d) under %q; the server may reject replays (expired tokens, nonces) or match_jq may not hold. Try powhttp_replay_entry to inspect it.", resp.StatusCode, output.OriginalStatus, output.Match)
+			return nil, output, nil
+		}
+
+		parts := replay.Parts(baseline, input.Kinds...)
+		test := func(ctx context.Context, kept []replay.Part) (bool, int, error) {
+			if output.RequestsSent >= budget {
+				return false, 0, replay.ErrBudgetExhausted
+			}
+			variant, err := replay.BuildKept(entry, parts, kept)
+			if err != nil {
+				return false, 0, err
+			}
+			resp, err := d.Replay.Send(ctx, variant)
+			if err != nil {
+				return false, 0, fmt.Errorf("replaying via %s: %w", d.Config.PowHTTPProxyURL, err)
+			}
+			output.RequestsSent++
+			return oracle.matches(resp), resp.StatusCode, nil
+		}
+
+		result, err := replay.Minimize(ctx, parts, test)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, err
+		}
+		output.Complete = result.Complete
+
+		for _, p := range parts {
+			required := !slices.Contains(result.Removable, p)
+			output.Parts = append(output.Parts, MinimizePart{
+				Kind:          p.Kind,
+				Name:          p.Name,
+				Required:      required,
+				StatusWithout: result.StatusOf[p],
+			})
+		}
+		output.RequiredCount = len(result.Required)
+		output.RemovableCount = len(result.Removable)
+
+		minimal, err := replay.BuildKept(entry, parts, result.Required)
+		if err != nil {
+			return nil, MinimizeRequestOutput{}, err
+		}
+		output.Minimal = &MinimalRequest{
+			Method:  minimal.Method,
+			URL:     minimal.URL,
+			Headers: minimal.Headers,
+			Body:    string(minimal.Body),
+		}
+
+		if !output.Complete {
+			output.Hint += fmt.Sprintf("Stopped after %d requests; some parts marked required may be removable. Raise max_requests to continue.", output.RequestsSent)
+		} else {
+			output.Hint += "Every required part was confirmed by dropping it alone. Use powhttp_replay_entry to try value changes on the required parts."
+		}
+		output.Hint = strings.TrimSpace(output.Hint)
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckOutputSchema_MinimizeRequest(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[MinimizeRequestOutput]("powhttp_minimize_request")
+	})
+}
+
+func minimizeTestDeps(t *testing.T) (*Deps, *recordingProxy) {
+	t.Helper()
+	d, proxy := replayTestDeps(t)
+	d.Config.ReplayCaptureWait = 0
+	// The server needs the token and the sku field; everything else is optional.
+	proxy.respond = func(r *http.Request) int {
+		if r.Header.Get("Authorization") == "" {
+			return http.StatusUnauthorized
+		}
+		proxy.mu.Lock()
+		body := proxy.bodies[len(proxy.bodies)-1]
+		proxy.mu.Unlock()
+		if !strings.Contains(body, `"sku"`) {
+			return http.StatusUnprocessableEntity
+		}
+		return http.StatusOK
+	}
+	return d, proxy
+}
+
+func TestToolMinimizeRequest(t *testing.T) {
+	d, proxy := minimizeTestDeps(t)
+
+	_, out, err := ToolMinimizeRequest(d)(context.Background(), nil, MinimizeRequestInput{SessionID: "s1", EntryID: "e1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, out.OriginalStatus)
+	assert.Equal(t, "status+shape", out.Match)
+	assert.True(t, out.Complete)
+	assert.Equal(t, len(proxy.seen), out.RequestsSent)
+	assert.Equal(t, 2, out.RequiredCount)
+
+	required := map[string]int{}
+	for _, p := range out.Parts {
+		if p.Required {
+			required[p.Kind+" "+p.Name] = p.StatusWithout
+		}
+	}
+	assert.Equal(t, map[string]int{"header authorization": 401, "json sku": 422}, required)
+
+	require.NotNil(t, out.Minimal)
+	assert.Equal(t, "POST", out.Minimal.Method)
+	assert.Equal(t, "http://api.example.com/orders", out.Minimal.URL)
+	assert.Equal(t, [][]string{{"authorization", "Bearer tok"}}, [][]string(out.Minimal.Headers))
+	assert.JSONEq(t, `{"sku":"x"}`, out.Minimal.Body)
+}
+
+func TestToolMinimizeRequest_JQAndKinds(t *testing.T) {
+	d, _ := minimizeTestDeps(t)
+
+	_, out, err := ToolMinimizeRequest(d)(context.Background(), nil, MinimizeRequestInput{
+		SessionID: "s1",
+		EntryID:   "e1",
+		Kinds:     []string{"query"},
+		MatchJQ:   ".ok",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "status+jq", out.Match)
+	assert.Len(t, out.Parts, 2)
+	assert.Zero(t, out.RequiredCount)
+	assert.Equal(t, "http://api.example.com/orders", out.Minimal.URL)
+
+	_, out, err = ToolMinimizeRequest(d)(context.Background(), nil, MinimizeRequestInput{SessionID: "s1", EntryID: "e1", MatchJQ: ".missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.RequestsSent)
+	assert.Nil(t, out.Minimal)
+	assert.Contains(t, out.Hint, "did not match")
+}
+
+func TestToolMinimizeRequest_Budget(t *testing.T) {
+	d, proxy := minimizeTestDeps(t)
+
+	_, out, err := ToolMinimizeRequest(d)(context.Background(), nil, MinimizeRequestInput{SessionID: "s1", EntryID: "e1", MaxRequests: 3})
+	require.NoError(t, err)
+	assert.False(t, out.Complete)
+	assert.Equal(t, 3, out.RequestsSent)
+	assert.Len(t, proxy.seen, 3)
+	assert.Contains(t, out.Hint, "max_requests")
+}
+
+func TestToolMinimizeRequest_InvalidInput(t *testing.T) {
+	d, proxy := minimizeTestDeps(t)
+
+	for _, input := range []MinimizeRequestInput{
+		{SessionID: "s1"},
+		{SessionID: "s1", EntryID: "e1", Kinds: []string{"body"}},
+		{SessionID: "s1", EntryID: "e1", MatchJQ: ".["},
+	} {
+		_, _, err := ToolMinimizeRequest(d)(context.Background(), nil, input)
+		require.Error(t, err, "%+v", input)
+	}
+	assert.Empty(t, proxy.seen)
+
+	d.Replay = nil
+	_, _, err := ToolMinimizeRequest(d)(context.Background(), nil, MinimizeRequestInput{SessionID: "s1", EntryID: "e1"})
+	require.Error(t, err)
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/openapi"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// OpenAPI export defaults.
+const (
+	defaultOpenAPIMaxEndpoints = 200
+	defaultOpenAPISamples      = 20
+)
+
+// ExportOpenAPIInput is the input for powhttp_export_openapi.
+type ExportOpenAPIInput struct {
+	SessionID          string                   `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	ClusterIDs         []string                 `json:"cluster_ids,omitempty" jsonschema:"Cluster IDs (from extract_endpoints) to include. If omitted, endpoints are extracted using scope and filters."`
+	Scope              *ExtractEndpointsScope   `json:"scope,omitempty" jsonschema:"Pre-clustering filters (same as extract_endpoints)"`
+	Filters            *ExtractEndpointsFilters `json:"filters,omitempty" jsonschema:"Post-clustering filters (same as extract_endpoints). Default: category api"`
+	MaxEndpoints       int                      `json:"max_endpoints,omitempty" jsonschema:"Max endpoint clusters to include (default: 200)"`
+	SamplesPerEndpoint int                      `json:"samples_per_endpoint,omitempty" jsonschema:"Entries sampled per endpoint for parameters and schemas (default: 20)"`
+	Title              string                   `json:"title,omitempty" jsonschema:"info.title (default: '<host> API')"`
+	Version            string                   `json:"version,omitempty" jsonschema:"info.version (default: 1.0.0)"`
+	Format             string                   `json:"format,omitempty" jsonschema:"Output file format: json or yaml (default: json)"`
+	OutputPath         string                   `json:"output_path,omitempty" jsonschema:"File path inside EXPORT_DIR to write the spec to (relative paths resolve against EXPORT_DIR). If omitted, the spec is only available as a resource."`
+	Overwrite          bool                     `json:"overwrite,omitempty" jsonschema:"Replace output_path if it already exists (default: false)"`
+}
+
+// ExportOpenAPIOutput is the output for powhttp_export_openapi.
+type ExportOpenAPIOutput struct {
+	ExportID         string             `json:"export_id"`
+	EndpointCount    int                `json:"endpoint_count"`
+	EndpointsSkipped int                `json:"endpoints_skipped,omitempty"`
+	PathCount        int                `json:"path_count"`
+	Servers          []string           `json:"servers,omitzero"`
+	SecuritySchemes  []string           `json:"security_schemes,omitzero"`
+	Path             string             `json:"path,omitempty"`
+	Bytes            int                `json:"bytes"`
+	Resource         *types.ResourceRef `json:"resource,omitempty"`
+	Hint             string             `json:"hint,omitempty"`
+}
+
+// ToolExportOpenAPI builds an OpenAPI 3.1 document from endpoint clusters.
+func ToolExportOpenAPI(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input ExportOpenAPIInput) (*sdkmcp.CallToolResult, ExportOpenAPIOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input ExportOpenAPIInput) (*sdkmcp.CallToolResult, ExportOpenAPIOutput, error) {
+		format := strings.ToLower(input.Format)
+		if format != "" && format != openapi.FormatJSON && format != openapi.FormatYAML {
+			return nil, ExportOpenAPIOutput{}, ErrInvalidInput(fmt.Sprintf("invalid format %q, must be json or yaml", input.Format))
+		}
+		if input.Filters != nil && input.Filters.Category != "" && !validCategories[input.Filters.Category] {
+			return nil, ExportOpenAPIOutput{}, ErrInvalidInput(
+				fmt.Sprintf("invalid category %q, must be one of: api, page, asset, data, other", input.Filters.Category))
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, ExportOpenAPIOutput{}, err
+		}
+
+		maxEndpoints := input.MaxEndpoints
+		if maxEndpoints <= 0 {
+			maxEndpoints = defaultOpenAPIMaxEndpoints
+		}
+		samples := input.SamplesPerEndpoint
+		if samples <= 0 {
+			samples = defaultOpenAPISamples
+		}
+
+		clusterIDs := input.ClusterIDs
+		if len(clusterIDs) == 0 {
+			clusterIDs, err = extractOpenAPIClusters(ctx, d, sessionID, input, maxEndpoints)
+			if err != nil {
+				return nil, ExportOpenAPIOutput{}, err
+			}
+			if len(clusterIDs) == 0 {
+				return nil, ExportOpenAPIOutput{}, ErrInvalidInput("no endpoints matched the scope and filters")
+			}
+		} else if len(clusterIDs) > maxEndpoints {
+			clusterIDs = clusterIDs[:maxEndpoints]
+		}
+
+		endpoints := make([]openapi.Endpoint, 0, len(clusterIDs))
+		skipped := 0
+		for _, id := range clusterIDs {
+			ep, err := buildOpenAPIEndpoint(ctx, d, sessionID, id, samples)
+			if err != nil {
+				// Explicitly requested clusters must all resolve
+				if len(input.ClusterIDs) > 0 {
+					return nil, ExportOpenAPIOutput{}, err
+				}
+				slog.Debug("export_openapi skipped endpoint", "cluster_id", id, "error", err)
+				skipped++
+				continue
+			}
+			endpoints = append(endpoints, ep)
+		}
+		if len(endpoints) == 0 {
+			return nil, ExportOpenAPIOutput{}, ErrInvalidInput("none of the selected endpoints had fetchable entries")
+		}
+
+		doc := openapi.Build(endpoints, openapi.Options{
+			Title:   input.Title,
+			Version: input.Version,
+		})
+
+		exportID := computeOpenAPIExportID(sessionID, clusterIDs, samples, input.Title, input.Version)
+		d.OpenAPIExports.Put(exportID, doc)
+
+		output := ExportOpenAPIOutput{
+			ExportID:         exportID,
+			EndpointCount:    len(endpoints),
+			EndpointsSkipped: skipped,
+			PathCount:        len(doc.Paths),
+			Resource: &types.ResourceRef{
+				URI:  "powhttp://openapi/" + exportID,
+				MIME: MimeJSON,
+				Hint: "Fetch for the complete OpenAPI document (JSON)",
+			},
+		}
+		for _, s := range doc.Servers {
+			output.Servers = append(output.Servers, s.URL)
+		}
+		if doc.Components != nil {
+			for name := range doc.Components.SecuritySchemes {
+				output.SecuritySchemes = append(output.SecuritySchemes, name)
+			}
+			sort.Strings(output.SecuritySchemes)
+		}
+
+		if input.OutputPath != "" {
+			path, err := resolveExportPath(d.Config.ExportDir, input.OutputPath, input.Overwrite)
+			if err != nil {
+				return nil, ExportOpenAPIOutput{}, err
+			}
+			n, err := openapi.WriteFile(path, doc, format)
+			if err != nil {
+				return nil, ExportOpenAPIOutput{}, err
+			}
+			output.Path = path
+			output.Bytes = n
+			output.Hint = fmt.Sprintf("Wrote %d paths to %s. Load it in Swagger UI, Redoc, or a client generator.", output.PathCount, path)
+		} else {
+			data, err := openapi.Marshal(doc, format)
+			if err != nil {
+				return nil, ExportOpenAPIOutput{}, fmt.Errorf("encoding OpenAPI document: %w", err)
+			}
+			output.Bytes = len(data)
+			output.Hint = "Read the resource URI for the OpenAPI document."
+			if d.Config.ExportDir != "" {
+				output.Hint = "Set output_path to write the spec to disk, or read the resource URI for its contents."
+			}
+		}
+
+		return nil, output, nil
+	}
+}
+
+// extractOpenAPIClusters runs endpoint extraction for the input scope and
+// returns the cluster IDs, most requested first. Filters default to API endpoints.
+func extractOpenAPIClusters(ctx context.Context, d *Deps, sessionID string, input ExportOpenAPIInput, maxEndpoints int) ([]string, error) {
+	extractReq := &types.ExtractRequest{
+		SessionID: sessionID,
+		Limit:     maxEndpoints,
+		Filters:   &types.ClusterFilters{Category: types.CategoryAPI},
+		Options:   &types.ClusterOptions{NormalizeIDs: true, StripVolatileQueryKeys: true, MaxClusters: maxEndpoints},
+	}
+	if input.Scope != nil {
+		extractReq.Scope = &types.ClusterScope{
+			Host:         input.Scope.Host,
+			Method:       input.Scope.Method,
+			ProcessName:  input.Scope.ProcessName,
+			PID:          input.Scope.PID,
+			TimeWindowMs: input.Scope.TimeWindowMs,
+			SinceMs:      input.Scope.SinceMs,
+			UntilMs:      input.Scope.UntilMs,
+		}
+	}
+	if input.Filters != nil {
+		extractReq.Filters = &types.ClusterFilters{
+			Category: types.EndpointCategory(input.Filters.Category),
+			MinCount: input.Filters.MinCount,
+		}
+	}
+
+	resp, err := d.Cluster.Extract(ctx, extractReq)
+	if err != nil {
+		return nil, WrapPowHTTPError(err)
+	}
+	ids := make([]string, 0, len(resp.Clusters))
+	for _, c := range resp.Clusters {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// buildOpenAPIEndpoint describes one stored cluster and fetches sample entries for it.
+func buildOpenAPIEndpoint(ctx context.Context, d *Deps, sessionID, clusterID string, samples int) (openapi.Endpoint, error) {
+	stored, ok := d.ClusterStore.GetCluster(clusterID)
+	if !ok {
+		return openapi.Endpoint{}, ErrNotFound("cluster", clusterID)
+	}
+
+	desc, err := d.Describe.Describe(ctx, &types.DescribeRequest{
+		ClusterID:   clusterID,
+		SessionID:   sessionID,
+		MaxExamples: samples,
+	})
+	if err != nil {
+		return openapi.Endpoint{}, WrapPowHTTPError(err)
+	}
+
+	ids := spreadSample(stored.EntryIDs, samples)
+	entries := make([]*client.SessionEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := d.FetchEntry(ctx, sessionID, id)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return openapi.Endpoint{}, ErrInvalidInput(fmt.Sprintf("no entries could be fetched for cluster %s", clusterID))
+	}
+
+	return openapi.Endpoint{
+		ClusterID:      clusterID,
+		Host:           desc.Host,
+		Method:         desc.Method,
+		PathTemplate:   desc.PathTemplate,
+		Count:          desc.Count,
+		Auth:           desc.AuthSignals,
+		SessionCookies: commonSessionCookies(d, entries),
+		Entries:        entries,
+	}, nil
+}
+
+// commonSessionCookies returns the session-like cookie names (as recorded by
+// the indexer) sent with every sampled entry.
+func commonSessionCookies(d *Deps, entries []*client.SessionEntry) []string {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		meta := d.Indexer.GetMetaByEntryID(e.ID)
+		if meta == nil {
+			return nil
+		}
+		for name := range meta.Cookies {
+			counts[name]++
+		}
+	}
+	var names []string
+	for name, n := range counts {
+		if n == len(entries) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// spreadSample picks up to n IDs spread evenly across ids.
+func spreadSample(ids []string, n int) []string {
+	if len(ids) <= n {
+		return ids
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = ids[i*len(ids)/n]
+	}
+	return out
+}
+
+// computeOpenAPIExportID derives a stable export ID from the session, clusters, and document options.
+func computeOpenAPIExportID(sessionID string, clusterIDs []string, samples int, title, version string) string {
+	key := fmt.Sprintf("%s\n%s\n%d\n%s\n%s", sessionID, strings.Join(clusterIDs, ","), samples, title, version)
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])[:12]
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/openapi"
+)
+
+func TestCheckOutputSchema_ExportOpenAPI(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[ExportOpenAPIOutput]("powhttp_export_openapi")
+	})
+}
+
+func openAPITestDeps(t *testing.T) *Deps {
+	t.Helper()
+	withBearer := func(e *client.SessionEntry) *client.SessionEntry {
+		e.Request.Headers = append(e.Request.Headers, []string{"authorization", "Bearer tok"})
+		return e
+	}
+	return newTestDeps(t, newFakeSource("s1",
+		withBearer(testEntry("e1", "GET", "https://api.example.com/users/1", 200, "", `{"id":1,"name":"a"}`)),
+		withBearer(testEntry("e2", "GET", "https://api.example.com/users/2", 200, "", `{"id":2,"name":"b"}`)),
+		withBearer(testEntry("e3", "POST", "https://api.example.com/users", 201, `{"name":"c"}`, `{"id":3}`)),
+	))
+}
+
+func TestToolExportOpenAPI(t *testing.T) {
+	d := openAPITestDeps(t)
+
+	_, out, err := ToolExportOpenAPI(d)(context.Background(), nil, ExportOpenAPIInput{SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, out.EndpointCount)
+	assert.Equal(t, 2, out.PathCount)
+	assert.Equal(t, []string{"https://api.example.com"}, out.Servers)
+	assert.Equal(t, []string{"bearerAuth"}, out.SecuritySchemes)
+	assert.Equal(t, "powhttp://openapi/"+out.ExportID, out.Resource.URI)
+	assert.Empty(t, out.Path)
+
+	cached, ok := d.OpenAPIExports.Get(out.ExportID)
+	require.True(t, ok)
+	doc := cached.(*openapi.Document)
+
+	item := doc.Paths["/users/{id}"]
+	require.NotNil(t, item)
+	require.NotNil(t, item.Get)
+	require.Len(t, item.Get.Parameters, 1)
+	assert.Equal(t, "path", item.Get.Parameters[0].In)
+	assert.Contains(t, item.Get.Responses, "200")
+
+	item = doc.Paths["/users"]
+	require.NotNil(t, item)
+	require.NotNil(t, item.Post)
+	assert.NotNil(t, item.Post.RequestBody)
+	assert.Contains(t, item.Post.Responses, "201")
+}
+
+func TestToolExportOpenAPI_ClusterIDs(t *testing.T) {
+	d := openAPITestDeps(t)
+	ctx := context.Background()
+
+	_, _, err := ToolExportOpenAPI(d)(ctx, nil, ExportOpenAPIInput{SessionID: "s1", ClusterIDs: []string{"missing"}})
+	var coded *CodedError
+	require.True(t, errors.As(err, &coded))
+	assert.Equal(t, ErrCodeNotFound, coded.Code)
+
+	ids, err := extractOpenAPIClusters(ctx, d, "s1", ExportOpenAPIInput{}, 10)
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	_, out, err := ToolExportOpenAPI(d)(ctx, nil, ExportOpenAPIInput{SessionID: "s1", ClusterIDs: ids[:1]})
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.EndpointCount)
+}
+
+func TestToolExportOpenAPI_OutputPath(t *testing.T) {
+	d := openAPITestDeps(t)
+	d.Config.ExportDir = t.TempDir()
+
+	_, out, err := ToolExportOpenAPI(d)(context.Background(), nil, ExportOpenAPIInput{
+		SessionID:  "s1",
+		Format:     "yaml",
+		OutputPath: "spec.yaml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(d.Config.ExportDir, "spec.yaml"), out.Path)
+
+	data, err := os.ReadFile(out.Path)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), out.Bytes)
+
+	var parsed map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &parsed))
+	assert.Equal(t, "3.1.0", parsed["openapi"])
+	assert.Contains(t, parsed["paths"], "/users/{id}")
+}
+
+func TestToolExportOpenAPI_InvalidInput(t *testing.T) {
+	d := openAPITestDeps(t)
+
+	for _, input := range []ExportOpenAPIInput{
+		{SessionID: "s1", Format: "xml"},
+		{SessionID: "s1", Filters: &ExtractEndpointsFilters{Category: "bogus"}},
+		{SessionID: "s1", Scope: &ExtractEndpointsScope{Host: "nothing.example"}},
+	} {
+		_, _, err := ToolExportOpenAPI(d)(context.Background(), nil, input)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v", input)
+		assert.Equal(t, ErrCodeInvalidInput, coded.Code)
+	}
+}
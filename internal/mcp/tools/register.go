@@ -23,11 +23,11 @@ func Register(srv *sdkmcp.Server, d *Deps) {
 	if d.Config.IndexBody {
 		searchDesc += " and body content"
 	}
-	searchDesc += " (tokens ANDed). Use header_contains for substring matching on header fields"
+	searchDesc += " (tokens ANDed). The query also takes field terms (host:, status:, method:, header:, ja4:, path:, ...) combined with AND/OR/NOT and parentheses, with globs and numeric ranges, e.g. host:*.example.com AND (status:>=500 OR status:429) AND NOT method:OPTIONS. Use header_contains for substring matching on header fields"
 	if d.Config.IndexBody {
 		searchDesc += ", body_contains for body text substring matching"
 	}
-	searchDesc += "."
+	searchDesc += ". Set session_ids to a list of sessions or [\"all\"] to search across captures; results then carry their session_id."
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_search_entries",
 		Description: searchDesc,
@@ -54,19 +54,19 @@ func Register(srv *sdkmcp.Server, d *Deps) {
 	// Tool 7: powhttp_fingerprint
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_fingerprint",
-		Description: "Generate HTTP, TLS, and HTTP/2 fingerprints for anti-bot comparison",
+		Description: "Generate HTTP, TLS, and HTTP/2 fingerprints for anti-bot comparison. JA4 (ClientHello), JA4S (ServerHello), JA4H (request headers), and JA4X (server certificates) are computed locally, with raw forms, the JA4L server latency estimated from connect timings, and a readable ClientHello/ServerHello breakdown where GREASE values are normalized. client_matches names the known browsers or libraries the entry resembles (see powhttp_identify_client). The HTTP/2 summary includes the Akamai fingerprint (SETTINGS|WINDOW_UPDATE|PRIORITY|pseudo-header order) and its parts.",
 	}, ToolFingerprint(d))
 
 	// Tool 8: powhttp_diff_entries
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_diff_entries",
-		Description: "Compare two HTTP entries to find anti-bot detection differences",
+		Description: "Compare two HTTP entries to find anti-bot detection differences, including the Akamai HTTP/2 fingerprint field by field (SETTINGS values and order, WINDOW_UPDATE, PRIORITY, pseudo-header order) and the TLS ClientHello field by field (cipher suites, extensions, groups, signature algorithms, ALPN, and key shares added, removed, or reordered, GREASE-aware). For HTTP/1.x entries, also compares header-name casing (e.g. Go's canonical 'User-Agent') and repeated headers; set options.compare_cookies to compare cookie names and order inside Cookie headers. Group mode: instead of entry IDs, select a baseline and a candidate group with baseline_query/candidate_query (search_entries syntax, e.g. 'process:chrome' vs 'process:my-scraper') or baseline_filters/candidate_filters. Returns the features that consistently separate the groups (JA3/JA4, HTTP version, HTTP/2 settings and pseudo-header order, header presence, order, values, and HTTP/1.x casing), ranked by a 0-1 separation score so per-request noise drops out.",
 	}, ToolDiffEntries(d))
 
 	// Tool 9: powhttp_extract_endpoints
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_extract_endpoints",
-		Description: "Group HTTP entries by endpoint pattern into clusters (e.g., /api/users/{id}). Returns clusters with cluster_id, host, method, path_template, count, category (api/page/asset/data/other), and lightweight stats (status_profile, error_rate, avg_resp_bytes, has_auth). Filter with scope.host, scope.method (pre-clustering), or filters.category, filters.min_count (post-clustering) to narrow results. Pass cluster_id to describe_endpoint, infer_schema, or query_body for deeper analysis. For GraphQL APIs (POST /graphql), use powhttp_survey_graphql instead.",
+		Description: "Group HTTP entries by endpoint pattern into clusters (e.g., /api/users/{id}). Returns clusters with cluster_id, host, method, path_template, count, category (api/page/asset/data/other), and lightweight stats (status_profile, error_rate, avg_resp_bytes, has_auth). Filter with scope.host, scope.method (pre-clustering), or filters.category, filters.min_count (post-clustering) to narrow results. Pass cluster_id to describe_endpoint, infer_schema, or query_body for deeper analysis. Set session_ids (or [\"all\"]) to cluster several sessions together; clusters then list the sessions they were seen in. For GraphQL APIs (POST /graphql), use powhttp_survey_graphql instead.",
 	}, ToolExtractEndpoints(d))
 
 	// Tool 10: powhttp_describe_endpoint
@@ -78,7 +78,7 @@ func Register(srv *sdkmcp.Server, d *Deps) {
 	// Tool 11: powhttp_trace_flow
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_trace_flow",
-		Description: "Trace related requests (redirects, dependent calls) around a seed entry",
+		Description: "Trace related requests (redirects, dependent calls, response values reused in later requests) around a seed entry",
 	}, ToolTraceFlow(d))
 
 	// Tool 12: powhttp_validate_schema
@@ -96,7 +96,7 @@ func Register(srv *sdkmcp.Server, d *Deps) {
 	// Tool 14: powhttp_infer_schema
 	AddTool(srv, &sdkmcp.Tool{
 		Name:        "powhttp_infer_schema",
-		Description: "Infer a merged schema from multiple HTTP entry bodies. Returns a shape result keyed by content_category (json, xml, csv, html, form) with format-specific analysis: JSON/YAML get a JSON Schema plus field_stats (frequency, required/optional, formats, enums); other types get structural outlines. Use this tool for deep multi-sample analysis when describe_endpoint's shape overview is insufficient. Requires entry_ids or cluster_id.",
+		Description: "Infer a merged schema from multiple HTTP entry bodies. Returns a shape result keyed by content_category (json, xml, csv, html, form) with format-specific analysis: JSON/YAML get a JSON Schema plus field_stats (frequency, required/optional, formats, enums); other types get structural outlines. Set format to go, typescript, zod, or pydantic to also get type definitions (nullable fields as pointers/null unions, optional fields, enums) named after the cluster or type_name. Use this tool for deep multi-sample analysis when describe_endpoint's shape overview is insufficient. Requires entry_ids or cluster_id.",
 	}, ToolInferSchema(d))
 
 	// Tool 15: powhttp_survey_graphql
@@ -110,4 +110,70 @@ func Register(srv *sdkmcp.Server, d *Deps) {
 		Name:        "powhttp_inspect_graphql_operation",
 		Description: "Inspect a single GraphQL operation: parse the query, infer variable and response schemas, compute field statistics, and collect errors. Merges schema inspection and error analysis into one call. Use the sections parameter ([\"query\", \"variables\", \"response_shape\", \"errors\", \"fragment_warnings\", \"fragment_coverage\", \"response_variants\"]; default: all) to request only what you need. Use `fragment_warnings` to detect missing union/interface fragments (response objects with only `__typename`). Use `fragment_coverage` for a comprehensive matrix of query fragments vs response types. Use `response_variants` when the same operation returns different response shapes depending on variable values. Returns compact inline summaries plus resource URIs (powhttp://graphql/{session}/{operation}/{aspect}) for full data. Requires entry_ids or operation_name. Use survey_graphql first to discover operation names.",
 	}, ToolInspectGraphQLOperation(d))
+
+	// Tool 17: powhttp_websocket_messages
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_websocket_messages",
+		Description: "Page through the frames of a WebSocket entry. Filter by side (client/server), type (text/binary/close/ping/pong), since_ms/until_ms, or a contains substring. Binary and close payloads are decoded (text when printable, base64 otherwise). Set include_shapes=true to infer a schema per JSON message \"type\" field and sender. Find WebSocket entries with powhttp_search_entries (status 101).",
+	}, ToolWebSocketMessages(d))
+
+	// Tool 18: powhttp_export_har
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_export_har",
+		Description: "Export entries as a HAR 1.2 file for browser devtools, proxies, and other HAR tooling. Select entries with entry_ids, cluster_id, or query/filters (same as search_entries). Includes timings, headers, cookies, decoded bodies (binary as base64), and TLS details in _tls custom fields. Set output_path to write it under EXPORT_DIR; the HAR is also served as a resource.",
+	}, ToolExportHAR(d))
+
+	// Tool 19: powhttp_export_openapi
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_export_openapi",
+		Description: "Generate an OpenAPI 3.1 spec from endpoint clusters. Extracts endpoints with scope/filters (default: category api), or takes cluster_ids from extract_endpoints. Includes path parameters from {id}/{uuid}/{hex} template segments, query parameters with observed enums, request bodies, per-status response schemas, and security schemes from auth signals (bearer, API key headers, session cookies). Set output_path to write JSON or YAML under EXPORT_DIR; the spec is also served as a resource.",
+	}, ToolExportOpenAPI(d))
+
+	// Tool 20: powhttp_replay_entry
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_replay_entry",
+		Description: "Re-send a captured entry through the powhttp proxy (POWHTTP_PROXY_URL) and compare the recorded replay with the original. SENDS A LIVE REQUEST to the target server. Optional edits: method, set_headers/remove_headers, set_query/remove_query, set_json/remove_json (dotted paths like 'user.id'). Returns the replay status vs the original, the new entry ID, and a diff_entries-style comparison. Use to test which headers, tokens, or params the server enforces.",
+	}, ToolReplayEntry(d))
+
+	// Tool 21: powhttp_minimize_request
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_minimize_request",
+		Description: "Find the smallest request the server still accepts. Replays a captured entry through the powhttp proxy while removing headers, cookies, query keys, and JSON body fields (delta debugging). SENDS MANY LIVE REQUESTS (max_requests, default 40). A variant matches when its status equals the original's and its response keeps the baseline JSON shape, or a match_jq assertion holds. Returns a required/removable table and the minimal request.",
+	}, ToolMinimizeRequest(d))
+
+	// Tool 22: powhttp_generate_code
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_generate_code",
+		Description: "Render a captured entry (or a cluster's first example) as runnable client code: curl, go (net/http), go-tls-client (bogdanfinn/tls-client with HeaderOrderKey/PHeaderOrderKey), python-requests, python-httpx, typescript (fetch). Output is template-based and deterministic; it keeps captured header order and HTTP version where the library allows and embeds the exact body bytes. Each snippet lists fidelity caveats in notes.",
+	}, ToolGenerateCode(d))
+
+	// Tool 23: powhttp_aggregate
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_aggregate",
+		Description: "Group the entries matched by a query/filters (same as search_entries) and return per-group counts, errors and error rate (status >= 400 or no response), request/response byte totals, latency percentiles (p50/p90/p99 ms), and optional time-bucket histograms. Group by host, method, status, status_class, process, pid, ja3, ja4, ja4h, ja4s, content_type, http_version, cluster, session, or header:<name>; sort by count, errors, error_rate, bytes, or latency. Set session_ids (or [\"all\"]) to aggregate across sessions. Answers questions like 'which hosts fail most' in one call.",
+	}, ToolAggregate(d))
+
+	// Tool 24: powhttp_timing_analysis
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_timing_analysis",
+		Description: "Explain where time goes for entries matched by a query/filters (same as search_entries): duration and TTFB percentiles (p50/p90/p99 ms), a per-phase breakdown (blocked, dns, connect, ssl, send, wait, receive) with each phase's share of total time, per-endpoint-cluster percentiles, the slowest entries, and the setup cost (DNS, TCP, TLS handshake) of each TLS connection with its reuse count. Pass page_entry_id instead to trace a page load's flow and render it as an ASCII or JSON waterfall grouped by navigation (redirects, referers, subresources).",
+	}, ToolTimingAnalysis(d))
+
+	// Tool 25: powhttp_diff_sessions
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_diff_sessions",
+		Description: "Compare the endpoint catalogs (as built by extract_endpoints) of two captures: two sessions, or two time windows (since_ms/until_ms) of one session. Returns endpoints added and removed, and for endpoints seen in both: new/removed query keys, auth signal changes (cookies, bearer, API key headers), status profile shifts, and request/response JSON schema changes (added/removed fields, type changes) inferred from sampled bodies. Defaults to API endpoints; set filters to include pages or assets. Use this after a target redeploys to see what changed in its API.",
+	}, ToolDiffSessions(d))
+
+	// Tool 26: powhttp_identify_client
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_identify_client",
+		Description: "Identify which known client sent an entry by matching its JA4, JA3, Akamai HTTP/2 fingerprint, header order, and User-Agent against a bundled reference database of browsers (Chrome, Firefox, Safari) and HTTP libraries (Go net/http, Python requests, curl, OkHttp). Returns the best matches with a 0-1 score and which signals matched, and warns when the User-Agent contradicts the TLS/HTTP/2 fingerprint. Set save_profile to add the entry's fingerprints as a new reference profile (e.g. your own scraper or a mobile app), or list_profiles to see the database.",
+	}, ToolIdentifyClient(d))
+
+	// Tool 27: powhttp_inspect_certificates
+	AddTool(srv, &sdkmcp.Tool{
+		Name:        "powhttp_inspect_certificates",
+		Description: "Decode the server certificate chain of an entry's TLS connection (or a connection_id): subject, issuer, SANs, validity, key type and size, signature algorithm, OCSP/CRL/issuer URLs, embedded SCTs, SHA-256 and SPKI pin hashes, and JA4X. Flags expired or not-yet-valid certificates, self-signed leaves, misordered chains, chains issued by known interception CAs (debugging proxies, corporate TLS inspection, antivirus), and leaves that do not cover the entry's host. Use it to debug certificate pinning and MITM issues.",
+	}, ToolInspectCertificates(d))
 }
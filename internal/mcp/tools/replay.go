@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RoaringBitmap/roaring/v2"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/replay"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// replayPollInterval is how often the capture session is re-indexed while
+// waiting for powhttp to record a replayed request.
+const replayPollInterval = 250 * time.Millisecond
+
+// ReplayEntryInput is the input for powhttp_replay_entry.
+type ReplayEntryInput struct {
+	SessionID        string            `json:"session_id,omitempty" jsonschema:"Session ID of the original entry (default: active)"`
+	EntryID          string            `json:"entry_id" jsonschema:"required,Entry ID to replay"`
+	Method           string            `json:"method,omitempty" jsonschema:"Replacement HTTP method"`
+	SetHeaders       map[string]string `json:"set_headers,omitempty" jsonschema:"Headers to add or replace (name -> value). Replaced headers keep their position."`
+	RemoveHeaders    []string          `json:"remove_headers,omitempty" jsonschema:"Header names to drop (case-insensitive)"`
+	SetQuery         map[string]string `json:"set_query,omitempty" jsonschema:"Query keys to add or replace (key -> value)"`
+	RemoveQuery      []string          `json:"remove_query,omitempty" jsonschema:"Query keys to drop"`
+	SetJSON          map[string]any    `json:"set_json,omitempty" jsonschema:"JSON body fields to set by dotted path (e.g. 'user.id' or 'items.0.sku' -> value)"`
+	RemoveJSON       []string          `json:"remove_json,omitempty" jsonschema:"JSON body fields to delete by dotted path"`
+	CaptureSessionID string            `json:"capture_session_id,omitempty" jsonschema:"Session powhttp records the replay into (default: active)"`
+	Options          *DiffOptions      `json:"options,omitempty" jsonschema:"Options for the comparison against the original"`
+}
+
+// ReplayEntryOutput is the output for powhttp_replay_entry.
+type ReplayEntryOutput struct {
+	Request          ReplaySentRequest `json:"request"`
+	Mutations        []string          `json:"mutations,omitzero"`
+	Status           int               `json:"status"`
+	OriginalStatus   int               `json:"original_status,omitempty"`
+	StatusChanged    bool              `json:"status_changed"`
+	Protocol         string            `json:"protocol,omitempty"`
+	ContentType      string            `json:"content_type,omitempty"`
+	RespBytes        int               `json:"resp_bytes"`
+	DurationMs       int64             `json:"duration_ms"`
+	ReplayEntryID    string            `json:"replay_entry_id,omitempty"`
+	CaptureSessionID string            `json:"capture_session_id,omitempty"`
+	Diff             *types.DiffResult `json:"diff,omitempty"`
+	Severity         string            `json:"severity,omitempty"`
+	Hint             string            `json:"hint,omitempty"`
+}
+
+// ReplaySentRequest summarizes the request that was sent.
+type ReplaySentRequest struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	HTTPVersion string `json:"http_version,omitempty"`
+	HeaderCount int    `json:"header_count"`
+	BodyBytes   int    `json:"body_bytes"`
+}
+
+// ToolReplayEntry re-sends a captured entry through the powhttp proxy and
+// compares the recorded replay with the original.
+func ToolReplayEntry(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input ReplayEntryInput) (*sdkmcp.CallToolResult, ReplayEntryOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input ReplayEntryInput) (*sdkmcp.CallToolResult, ReplayEntryOutput, error) {
+		if input.EntryID == "" {
+			return nil, ReplayEntryOutput{}, ErrInvalidInput("entry_id is required")
+		}
+		if d.Replay == nil {
+			return nil, ReplayEntryOutput{}, ErrInvalidInput("replay is not available: POWHTTP_PROXY_URL is not a valid proxy URL")
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, ReplayEntryOutput{}, err
+		}
+		captureSessionID, err := d.ResolveSessionID(ctx, input.CaptureSessionID)
+		if err != nil {
+			return nil, ReplayEntryOutput{}, err
+		}
+
+		entry, err := d.FetchEntry(ctx, sessionID, input.EntryID)
+		if err != nil {
+			return nil, ReplayEntryOutput{}, WrapPowHTTPError(err)
+		}
+
+		sent, applied, err := replay.Build(entry, &replay.Mutations{
+			Method:        input.Method,
+			SetHeaders:    input.SetHeaders,
+			RemoveHeaders: input.RemoveHeaders,
+			SetQuery:      input.SetQuery,
+			RemoveQuery:   input.RemoveQuery,
+			SetJSON:       input.SetJSON,
+			RemoveJSON:    input.RemoveJSON,
+		})
+		if err != nil {
+			return nil, ReplayEntryOutput{}, ErrInvalidInput(err.Error())
+		}
+
+		resp, err := d.Replay.Send(ctx, sent)
+		if err != nil {
+			return nil, ReplayEntryOutput{}, fmt.Errorf("replaying via %s: %w", d.Config.PowHTTPProxyURL, err)
+		}
+
+		output := ReplayEntryOutput{
+			Request: ReplaySentRequest{
+				Method:      sent.Method,
+				URL:         sent.URL,
+				HTTPVersion: sent.HTTPVersion,
+				HeaderCount: len(sent.Headers),
+				BodyBytes:   len(sent.Body),
+			},
+			Mutations:        applied,
+			Status:           resp.StatusCode,
+			Protocol:         resp.Protocol,
+			ContentType:      resp.Headers.Get("content-type"),
+			RespBytes:        len(resp.Body),
+			DurationMs:       resp.Duration.Milliseconds(),
+			CaptureSessionID: captureSessionID,
+		}
+		if entry.Response != nil && entry.Response.StatusCode != nil {
+			output.OriginalStatus = *entry.Response.StatusCode
+			output.StatusChanged = output.OriginalStatus != resp.StatusCode
+		}
+
+		replayID := findReplayCapture(ctx, d, captureSessionID, sent, resp.SentAt, entry.ID)
+		if replayID == "" {
+			output.Hint = fmt.Sprintf("The replay was sent but not found in session %s; check that powhttp is recording, or search for it with powhttp_search_entries.", captureSessionID)
+			return nil, output, nil
+		}
+		output.ReplayEntryID = replayID
+
+		diff, err := d.Diff.Diff(ctx, &types.DiffRequest{
+			BaselineEntryID:    entry.ID,
+			CandidateEntryID:   replayID,
+			SessionID:          sessionID,
+			CandidateSessionID: captureSessionID,
+			Options:            input.Options.toDiffOptions(0),
+		})
+		if err != nil {
+			output.Hint = "Diff against the original failed: " + err.Error()
+			return nil, output, nil
+		}
+		output.Diff = diff
+		output.Severity = computeDiffSeverity(diff)
+		output.Hint = "Use powhttp_get_entry on replay_entry_id for the recorded response."
+
+		return nil, output, nil
+	}
+}
+
+// findReplayCapture waits up to Config.ReplayCaptureWait for powhttp to record
+// the replayed request, re-indexing the capture session between checks. It
+// returns the matching entry that started closest to sentAt (allowing one
+// second of clock skew), or "" if none showed up.
+func findReplayCapture(ctx context.Context, d *Deps, sessionID string, sent *replay.Request, sentAt time.Time, originalID string) string {
+	target, err := url.Parse(sent.URL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(target.Host)
+	since := sentAt.Add(-time.Second).UnixMilli()
+	deadline := time.Now().Add(d.Config.ReplayCaptureWait)
+
+	for {
+		if err := d.Indexer.RefreshSession(ctx, sessionID); err == nil {
+			if id := matchReplayCapture(d, host, sent.Method, target.String(), since, sentAt.UnixMilli(), originalID); id != "" {
+				return id
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return ""
+		}
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-time.After(replayPollInterval):
+		}
+	}
+}
+
+// matchReplayCapture scans indexed entries with the replay's host and method.
+func matchReplayCapture(d *Deps, host, method, rawURL string, since, sentAtMs int64, originalID string) string {
+	hostBM := d.Indexer.GetBitmapForHost(host)
+	methodBM := d.Indexer.GetBitmapForMethod(method)
+	if hostBM == nil || methodBM == nil {
+		return ""
+	}
+
+	best := ""
+	var bestDist int64
+	it := roaring.And(hostBM, methodBM).Iterator()
+	for it.HasNext() {
+		meta := d.Indexer.GetMeta(it.Next())
+		if meta == nil || meta.EntryID == originalID || meta.TsMs < since {
+			continue
+		}
+		if u, err := url.Parse(meta.URL); err != nil || u.String() != rawURL {
+			continue
+		}
+		dist := meta.TsMs - sentAtMs
+		if dist < 0 {
+			dist = -dist
+		}
+		if best == "" || dist < bestDist {
+			best, bestDist = meta.EntryID, dist
+		}
+	}
+	return best
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/replay"
+)
+
+func TestCheckOutputSchema_ReplayEntry(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[ReplayEntryOutput]("powhttp_replay_entry")
+	})
+}
+
+// recordingProxy stands in for the powhttp proxy and the upstream server: it
+// answers requests itself and records each one into the fake session.
+type recordingProxy struct {
+	t   *testing.T
+	src *fakeSource
+
+	mu       sync.Mutex
+	seen     []*http.Request
+	bodies   []string
+	nextID   int
+	respond  func(r *http.Request) int
+	recordTo string
+}
+
+func (p *recordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(p.t, err)
+
+	p.mu.Lock()
+	p.seen = append(p.seen, r)
+	p.bodies = append(p.bodies, string(body))
+	p.nextID++
+	id := "replay-" + strconv.Itoa(p.nextID)
+	p.mu.Unlock()
+
+	status := p.respond(r)
+	e := testEntry(id, r.Method, r.URL.String(), status, "", `{"ok":true}`)
+	e.Request.Headers = nil
+	for name, values := range r.Header {
+		for _, v := range values {
+			e.Request.Headers = append(e.Request.Headers, []string{strings.ToLower(name), v})
+		}
+	}
+	if len(body) > 0 {
+		b := base64.StdEncoding.EncodeToString(body)
+		e.Request.Body = &b
+	}
+	p.src.appendEntry(p.recordTo, e)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"ok":true}`))
+}
+
+func replayTestDeps(t *testing.T) (*Deps, *recordingProxy) {
+	t.Helper()
+	original := testEntry("e1", "POST", "http://api.example.com/orders?debug=1&page=2", 200, `{"sku":"x","qty":1}`, `{"id":1}`)
+	original.Request.Headers = append(original.Request.Headers,
+		[]string{"authorization", "Bearer tok"},
+		[]string{"x-client", "web"},
+	)
+	original.Timings.StartedAt = time.Now().Add(-time.Hour).UnixMilli()
+
+	src := newFakeSource("s1", original)
+	d := newTestDeps(t, src)
+
+	proxy := &recordingProxy{t: t, src: src, recordTo: "s1", respond: func(r *http.Request) int {
+		if r.Header.Get("Authorization") == "" {
+			return http.StatusUnauthorized
+		}
+		return http.StatusOK
+	}}
+	srv := httptest.NewServer(proxy)
+	t.Cleanup(srv.Close)
+
+	sender, err := replay.NewSender(srv.URL, 5*time.Second, false)
+	require.NoError(t, err)
+	d.Replay = sender
+	d.Config.PowHTTPProxyURL = srv.URL
+	return d, proxy
+}
+
+func TestToolReplayEntry_Unmodified(t *testing.T) {
+	d, proxy := replayTestDeps(t)
+
+	_, out, err := ToolReplayEntry(d)(context.Background(), nil, ReplayEntryInput{SessionID: "s1", EntryID: "e1"})
+	require.NoError(t, err)
+
+	require.Len(t, proxy.seen, 1)
+	assert.Equal(t, "http://api.example.com/orders?debug=1&page=2", proxy.seen[0].URL.String())
+	assert.Equal(t, "test", proxy.seen[0].Header.Get("User-Agent"))
+	assert.JSONEq(t, `{"sku":"x","qty":1}`, proxy.bodies[0])
+
+	assert.Equal(t, 200, out.Status)
+	assert.False(t, out.StatusChanged)
+	assert.Empty(t, out.Mutations)
+	assert.Equal(t, "replay-1", out.ReplayEntryID)
+	require.NotNil(t, out.Diff)
+	assert.Empty(t, out.Diff.ImportantDiffs.HeadersMissing)
+}
+
+func TestToolReplayEntry_Mutations(t *testing.T) {
+	d, proxy := replayTestDeps(t)
+
+	_, out, err := ToolReplayEntry(d)(context.Background(), nil, ReplayEntryInput{
+		SessionID:     "s1",
+		EntryID:       "e1",
+		RemoveHeaders: []string{"Authorization"},
+		SetHeaders:    map[string]string{"x-client": "cli"},
+		RemoveQuery:   []string{"debug"},
+		SetJSON:       map[string]any{"qty": 2},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, proxy.seen, 1)
+	sent := proxy.seen[0]
+	assert.Equal(t, "http://api.example.com/orders?page=2", sent.URL.String())
+	assert.Empty(t, sent.Header.Get("Authorization"))
+	assert.Equal(t, "cli", sent.Header.Get("X-Client"))
+	assert.JSONEq(t, `{"sku":"x","qty":2}`, proxy.bodies[0])
+
+	assert.Equal(t, 401, out.Status)
+	assert.Equal(t, 200, out.OriginalStatus)
+	assert.True(t, out.StatusChanged)
+	assert.Equal(t, []string{"removed header authorization", "set header x-client", "removed query debug", "set json qty"}, out.Mutations)
+
+	assert.Equal(t, "replay-1", out.ReplayEntryID)
+	require.NotNil(t, out.Diff)
+	assert.Contains(t, out.Diff.ImportantDiffs.HeadersMissing, "authorization")
+	assert.Equal(t, "medium", out.Severity)
+}
+
+func TestToolReplayEntry_NotCaptured(t *testing.T) {
+	d, proxy := replayTestDeps(t)
+	proxy.recordTo = "elsewhere"
+	proxy.src.addSession("elsewhere")
+
+	_, out, err := ToolReplayEntry(d)(context.Background(), nil, ReplayEntryInput{SessionID: "s1", EntryID: "e1", CaptureSessionID: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, 200, out.Status)
+	assert.Empty(t, out.ReplayEntryID)
+	assert.Nil(t, out.Diff)
+	assert.Contains(t, out.Hint, "not found")
+}
+
+func TestToolReplayEntry_InvalidInput(t *testing.T) {
+	d, proxy := replayTestDeps(t)
+
+	for _, input := range []ReplayEntryInput{
+		{SessionID: "s1"},
+		{SessionID: "s1", EntryID: "e1", RemoveHeaders: []string{"x-missing"}},
+		{SessionID: "s1", EntryID: "e1", RemoveJSON: []string{"nope"}},
+		{SessionID: "s1", EntryID: "e1", SetHeaders: map[string]string{"content-length": "1"}},
+	} {
+		_, _, err := ToolReplayEntry(d)(context.Background(), nil, input)
+		require.Error(t, err, "%+v", input)
+	}
+	assert.Empty(t, proxy.seen, "invalid mutations must not send anything")
+
+	d.Replay = nil
+	_, _, err := ToolReplayEntry(d)(context.Background(), nil, ReplayEntryInput{SessionID: "s1", EntryID: "e1"})
+	require.Error(t, err)
+}
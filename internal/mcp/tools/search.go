@@ -2,17 +2,20 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/usestring/powhttp-mcp/internal/search"
 	"github.com/usestring/powhttp-mcp/pkg/types"
 )
 
 // SearchEntriesInput is the input for powhttp_search_entries.
 type SearchEntriesInput struct {
 	SessionID      string                `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
-	Query          string                `json:"query,omitempty" jsonschema:"Free text search across URLs, query params, headers, and body content. Tokens are ANDed: all terms must match somewhere. Use for broad discovery."`
+	SessionIDs     []string              `json:"session_ids,omitempty" jsonschema:"Search across these sessions instead of session_id; [\"all\"] searches every session. Results then carry their session_id."`
+	Query          string                `json:"query,omitempty" jsonschema:"Search query. Plain words are free text across URLs, query params, headers, and body content, ANDed: all must match somewhere. Combine with field terms and AND/OR/NOT, '-' to negate, and parentheses, e.g. 'host:*.example.com AND (status:>=500 OR status:429) AND NOT method:OPTIONS AND header:x-api-key AND ja4:t13d*'. Fields: host, method, status, pid, process, version, header (name or name=value), ja3, ja4, ja4h, ja4s, ja4x, tls, path, url, ct, body, ts, req_bytes, resp_bytes, text. Values with * or ? are globs; numeric fields take >=N, <N, N..M, or 5xx. Quote values with spaces."`
 	Filters        *SearchEntriesFilters `json:"filters,omitempty" jsonschema:"Structured filters"`
 	Limit          int                   `json:"limit,omitempty" jsonschema:"Max results (default: 10, max: 100)"`
 	Offset         int                   `json:"offset,omitempty" jsonschema:"Pagination offset"`
@@ -35,11 +38,43 @@ type SearchEntriesFilters struct {
 	TLSConnectionID string `json:"tls_connection_id,omitempty" jsonschema:"TLS connection ID"`
 	JA3             string `json:"ja3,omitempty" jsonschema:"JA3 fingerprint hash"`
 	JA4             string `json:"ja4,omitempty" jsonschema:"JA4 fingerprint hash"`
+	JA4H            string `json:"ja4h,omitempty" jsonschema:"JA4H fingerprint of the request headers"`
+	JA4S            string `json:"ja4s,omitempty" jsonschema:"JA4S fingerprint of the server's TLS ServerHello"`
+	JA4X            string `json:"ja4x,omitempty" jsonschema:"JA4X fingerprint of a certificate in the server's chain"`
 	SinceMs         int64  `json:"since_ms,omitempty" jsonschema:"Unix timestamp (ms) lower bound"`
 	UntilMs         int64  `json:"until_ms,omitempty" jsonschema:"Unix timestamp (ms) upper bound"`
 	TimeWindowMs    int64  `json:"time_window_ms,omitempty" jsonschema:"Relative time window (ms from now)"`
 }
 
+// toSearchFilters converts tool filters to engine filters. A nil receiver yields nil.
+func (f *SearchEntriesFilters) toSearchFilters() *types.SearchFilters {
+	if f == nil {
+		return nil
+	}
+	return &types.SearchFilters{
+		Host:            f.Host,
+		PathContains:    f.PathContains,
+		URLContains:     f.URLContains,
+		Method:          f.Method,
+		Status:          f.Status,
+		HTTPVersion:     f.HTTPVersion,
+		ProcessName:     f.ProcessName,
+		PID:             f.PID,
+		HeaderName:      f.HeaderName,
+		HeaderContains:  f.HeaderContains,
+		BodyContains:    f.BodyContains,
+		TLSConnectionID: f.TLSConnectionID,
+		JA3:             f.JA3,
+		JA4:             f.JA4,
+		JA4H:            f.JA4H,
+		JA4S:            f.JA4S,
+		JA4X:            f.JA4X,
+		SinceMs:         f.SinceMs,
+		UntilMs:         f.UntilMs,
+		TimeWindowMs:    f.TimeWindowMs,
+	}
+}
+
 // SearchEntriesOutput is the output for powhttp_search_entries.
 type SearchEntriesOutput struct {
 	Results       []types.SearchResult `json:"results,omitzero"`
@@ -52,7 +87,7 @@ type SearchEntriesOutput struct {
 // ToolSearchEntries searches HTTP entries.
 func ToolSearchEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input SearchEntriesInput) (*sdkmcp.CallToolResult, SearchEntriesOutput, error) {
 	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input SearchEntriesInput) (*sdkmcp.CallToolResult, SearchEntriesOutput, error) {
-		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		sessionID, sessionIDs, err := d.ResolveSessionScope(ctx, input.SessionID, input.SessionIDs)
 		if err != nil {
 			return nil, SearchEntriesOutput{}, err
 		}
@@ -63,37 +98,17 @@ func ToolSearchEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRe
 		}
 
 		searchReq := &types.SearchRequest{
-			SessionID: sessionID,
-			Query:     input.Query,
-			Limit:     limit,
-			Offset:    input.Offset,
-		}
-
-		if input.Filters != nil {
-			searchReq.Filters = &types.SearchFilters{
-				Host:            input.Filters.Host,
-				PathContains:    input.Filters.PathContains,
-				URLContains:     input.Filters.URLContains,
-				Method:          input.Filters.Method,
-				Status:          input.Filters.Status,
-				HTTPVersion:     input.Filters.HTTPVersion,
-				ProcessName:     input.Filters.ProcessName,
-				PID:             input.Filters.PID,
-				HeaderName:      input.Filters.HeaderName,
-				HeaderContains:  input.Filters.HeaderContains,
-				BodyContains:    input.Filters.BodyContains,
-				TLSConnectionID: input.Filters.TLSConnectionID,
-				JA3:             input.Filters.JA3,
-				JA4:             input.Filters.JA4,
-				SinceMs:         input.Filters.SinceMs,
-				UntilMs:         input.Filters.UntilMs,
-				TimeWindowMs:    input.Filters.TimeWindowMs,
-			}
+			SessionID:  sessionID,
+			SessionIDs: sessionIDs,
+			Query:      input.Query,
+			Limit:      limit,
+			Offset:     input.Offset,
+			Filters:    input.Filters.toSearchFilters(),
 		}
 
 		resp, err := d.Search.Search(ctx, searchReq)
 		if err != nil {
-			return nil, SearchEntriesOutput{}, WrapPowHTTPError(err)
+			return nil, SearchEntriesOutput{}, wrapSearchError(err)
 		}
 
 		// Thin out results if details not requested - keep only essential fields
@@ -120,7 +135,13 @@ func ToolSearchEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRe
 			nextOffset := input.Offset + len(resp.Results)
 			hint = fmt.Sprintf("Showing %d of ~%d. Add host/path filters to narrow, or use offset=%d for next page.", len(resp.Results), resp.TotalHint, nextOffset)
 		} else if len(resp.Results) == 1 && resp.Results[0].Summary != nil {
-			hint = fmt.Sprintf("Single match. Use get_entry(entry_id=%q) for full details.", resp.Results[0].Summary.EntryID)
+			if summary := resp.Results[0].Summary; summary.SessionID != "" {
+				hint = fmt.Sprintf("Single match. Use get_entry(session_id=%q, entry_id=%q) for full details.", summary.SessionID, summary.EntryID)
+			} else {
+				hint = fmt.Sprintf("Single match. Use get_entry(entry_id=%q) for full details.", summary.EntryID)
+			}
+		} else if len(sessionIDs) > 0 {
+			hint = "Results span sessions: pass each result's session_id to get_entry."
 		} else {
 			hint = "Use get_entry with an entry_id for details, or extract_endpoints to see API patterns."
 		}
@@ -134,3 +155,13 @@ func ToolSearchEntries(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRe
 		}, nil
 	}
 }
+
+// wrapSearchError reports a malformed query as invalid input and wraps other
+// search errors as powhttp errors.
+func wrapSearchError(err error) error {
+	var queryErr *search.QueryError
+	if errors.As(err, &queryErr) {
+		return ErrInvalidInput(queryErr.Error())
+	}
+	return WrapPowHTTPError(err)
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multiSessionDeps serves two test-run sessions that both sent a token, and
+// a third without it.
+func multiSessionDeps(t *testing.T) *Deps {
+	t.Helper()
+	src := newFakeSource("run1",
+		testEntry("r1-login", "POST", "https://api.example.com/login", 200, `{"token":"tok-42"}`, ""),
+		testEntry("r1-me", "GET", "https://api.example.com/users/1", 200, "", `{"id":1}`),
+	)
+	src.addSession("run2",
+		testEntry("r2-login", "POST", "https://api.example.com/login", 401, `{"token":"tok-42"}`, ""),
+	)
+	src.addSession("run3",
+		testEntry("r3-me", "GET", "https://api.example.com/users/2", 200, "", `{"id":2}`),
+	)
+	return newTestDeps(t, src)
+}
+
+func TestToolSearchEntries_AcrossSessions(t *testing.T) {
+	d := multiSessionDeps(t)
+	search := ToolSearchEntries(d)
+
+	_, out, err := search(context.Background(), nil, SearchEntriesInput{
+		SessionIDs: []string{"all"},
+		Query:      "path:/login",
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Results, 2)
+	sessions := map[string]string{}
+	for _, r := range out.Results {
+		sessions[r.Summary.EntryID] = r.Summary.SessionID
+	}
+	assert.Equal(t, map[string]string{"r1-login": "run1", "r2-login": "run2"}, sessions)
+	assert.Contains(t, out.Hint, "session_id")
+
+	// An explicit list limits the search to those sessions
+	_, out, err = search(context.Background(), nil, SearchEntriesInput{
+		SessionIDs: []string{"run2", "run3"},
+		Query:      "method:POST",
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Results, 1)
+	assert.Equal(t, "r2-login", out.Results[0].Summary.EntryID)
+	assert.Contains(t, out.Hint, `session_id="run2"`)
+}
+
+func TestToolSearchEntries_SingleSessionIsScoped(t *testing.T) {
+	d := multiSessionDeps(t)
+	search := ToolSearchEntries(d)
+
+	// Index another session first; its entries must not leak into run1
+	_, _, err := search(context.Background(), nil, SearchEntriesInput{SessionID: "run2"})
+	require.NoError(t, err)
+
+	_, out, err := search(context.Background(), nil, SearchEntriesInput{SessionID: "run1", Query: "path:/login"})
+	require.NoError(t, err)
+	require.Len(t, out.Results, 1)
+	assert.Equal(t, "r1-login", out.Results[0].Summary.EntryID)
+	assert.Empty(t, out.Results[0].Summary.SessionID)
+}
+
+func TestToolSearchEntries_ActiveAndConcreteID(t *testing.T) {
+	// "active" is run1; whichever name indexes it first, both must find its entries
+	for _, order := range [][]string{{"active", "run1"}, {"run1", "active"}} {
+		d := multiSessionDeps(t)
+		search := ToolSearchEntries(d)
+		for _, sessionID := range order {
+			_, out, err := search(context.Background(), nil, SearchEntriesInput{SessionID: sessionID})
+			require.NoError(t, err)
+			assert.Len(t, out.Results, 2, "session_id %q after %v", sessionID, order)
+		}
+
+		_, out, err := search(context.Background(), nil, SearchEntriesInput{
+			SessionIDs: []string{"active", "run2", "run1"},
+			Query:      "path:/login",
+		})
+		require.NoError(t, err)
+		assert.Len(t, out.Results, 2)
+	}
+}
+
+func TestToolSearchEntries_SessionSelectorConflict(t *testing.T) {
+	d := multiSessionDeps(t)
+
+	_, _, err := ToolSearchEntries(d)(context.Background(), nil, SearchEntriesInput{
+		SessionID:  "run1",
+		SessionIDs: []string{"run2"},
+	})
+	var coded *CodedError
+	require.True(t, errors.As(err, &coded))
+	assert.Equal(t, ErrCodeInvalidInput, coded.Code)
+}
+
+func TestToolExtractEndpoints_AcrossSessions(t *testing.T) {
+	d := multiSessionDeps(t)
+
+	_, out, err := ToolExtractEndpoints(d)(context.Background(), nil, ExtractEndpointsInput{
+		SessionIDs: []string{"all"},
+	})
+	require.NoError(t, err)
+
+	byPath := make(map[string][]string)
+	counts := make(map[string]int)
+	for _, c := range out.Clusters {
+		byPath[c.PathTemplate] = c.SessionIDs
+		counts[c.PathTemplate] = c.Count
+	}
+	assert.ElementsMatch(t, []string{"run1", "run2"}, byPath["/login"])
+	assert.ElementsMatch(t, []string{"run1", "run3"}, byPath["/users/{id}"])
+	assert.Equal(t, 2, counts["/users/{id}"])
+}
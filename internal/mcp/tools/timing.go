@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/timing"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	defaultTimingLimit   = 10
+	maxTimingLimit       = 100
+	defaultWaterfallCols = 60
+	maxWaterfallEntries  = 200
+)
+
+// TimingAnalysisInput is the input for powhttp_timing_analysis.
+type TimingAnalysisInput struct {
+	SessionID   string                `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	Query       string                `json:"query,omitempty" jsonschema:"Search query selecting entries (same language as search_entries)"`
+	Filters     *SearchEntriesFilters `json:"filters,omitempty" jsonschema:"Structured filters selecting entries (same as search_entries)"`
+	PageEntryID string                `json:"page_entry_id,omitempty" jsonschema:"Entry ID of a page load (e.g. an HTML document). Traces its flow (redirects, subresources, referers) and analyzes those entries with a waterfall instead of query/filters."`
+	Limit       int                   `json:"limit,omitempty" jsonschema:"Max clusters, slowest entries, and connections listed (default: 10, max: 100)"`
+	Format      string                `json:"format,omitempty" jsonschema:"Waterfall format: ascii (default), json (rows with phases), or both"`
+	Width       int                   `json:"width,omitempty" jsonschema:"ASCII waterfall bar width in characters (default: 60, range: 20-200)"`
+}
+
+// TimingAnalysisOutput is the output for powhttp_timing_analysis.
+type TimingAnalysisOutput struct {
+	Analysis   *types.TimingAnalysis `json:"analysis"`
+	Waterfall  *types.Waterfall      `json:"waterfall,omitempty"`
+	SyncedAtMs int64                 `json:"synced_at_ms"`
+	Hint       string                `json:"hint,omitempty"`
+}
+
+// ToolTimingAnalysis reports latency percentiles, phase breakdowns, and
+// connection setup costs, plus a waterfall when given a page load.
+func ToolTimingAnalysis(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input TimingAnalysisInput) (*sdkmcp.CallToolResult, TimingAnalysisOutput, error) {
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input TimingAnalysisInput) (*sdkmcp.CallToolResult, TimingAnalysisOutput, error) {
+		limit := input.Limit
+		if limit <= 0 {
+			limit = defaultTimingLimit
+		}
+		limit = min(limit, maxTimingLimit)
+		format := input.Format
+		if format == "" {
+			format = "ascii"
+		}
+		if format != "ascii" && format != "json" && format != "both" {
+			return nil, TimingAnalysisOutput{}, ErrInvalidInput("format must be ascii, json, or both")
+		}
+		width := input.Width
+		if width == 0 {
+			width = defaultWaterfallCols
+		}
+		if width < 20 || width > 200 {
+			return nil, TimingAnalysisOutput{}, ErrInvalidInput("width must be between 20 and 200")
+		}
+		if input.PageEntryID != "" && (input.Query != "" || input.Filters != nil) {
+			return nil, TimingAnalysisOutput{}, ErrInvalidInput("page_entry_id selects entries by flow and cannot be combined with query or filters")
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, TimingAnalysisOutput{}, err
+		}
+
+		var metas []*indexer.EntryMeta
+		var waterfall *types.Waterfall
+		if input.PageEntryID != "" {
+			metas, waterfall, err = pageLoad(ctx, d, sessionID, input.PageEntryID)
+		} else {
+			metas, err = d.Search.MatchEntries(ctx, &types.SearchRequest{
+				SessionID: sessionID,
+				Query:     input.Query,
+				Filters:   input.Filters.toSearchFilters(),
+			})
+			if err != nil {
+				err = wrapSearchError(err)
+			}
+		}
+		if err != nil {
+			return nil, TimingAnalysisOutput{}, err
+		}
+
+		analysis := timing.Analyze(metas, limit)
+		if waterfall != nil {
+			if format != "json" {
+				waterfall.ASCII = timing.RenderASCII(waterfall, width)
+			}
+			if format == "ascii" {
+				waterfall.Groups = nil
+			}
+		}
+
+		var hint string
+		switch {
+		case analysis.Entries == 0:
+			hint = "No entries matched. Check session_id and loosen query or filters."
+		case analysis.TimedEntries == 0:
+			hint = "None of the matched entries recorded timings."
+		case len(analysis.Slowest) > 0:
+			top := analysis.Slowest[0]
+			hint = fmt.Sprintf("Slowest: %s %s took %d ms (TTFB %d ms). Use get_entry with entry_id %s for details",
+				top.Method, top.URL, top.DurationMs, top.TTFBMs, top.EntryID)
+			if waterfall == nil {
+				hint += ", or pass a page's entry ID as page_entry_id for a waterfall."
+			} else {
+				hint += "."
+			}
+		}
+
+		return nil, TimingAnalysisOutput{
+			Analysis:   analysis,
+			Waterfall:  waterfall,
+			SyncedAtMs: d.Indexer.LastSyncTime(sessionID).UnixMilli(),
+			Hint:       hint,
+		}, nil
+	}
+}
+
+// pageLoad traces the flow around a page entry and lays its entries out as
+// a waterfall.
+func pageLoad(ctx context.Context, d *Deps, sessionID, pageEntryID string) ([]*indexer.EntryMeta, *types.Waterfall, error) {
+	if err := d.Indexer.RefreshIfStale(ctx, sessionID); err != nil {
+		return nil, nil, WrapPowHTTPError(err)
+	}
+	if d.Indexer.GetMetaByEntryID(pageEntryID) == nil {
+		return nil, nil, ErrNotFound("entry", pageEntryID)
+	}
+
+	graph, err := d.Flow.Trace(ctx, &types.TraceRequest{
+		SessionID:   sessionID,
+		SeedEntryID: pageEntryID,
+		Limit:       maxWaterfallEntries,
+	})
+	if err != nil {
+		return nil, nil, WrapPowHTTPError(err)
+	}
+
+	metas := make([]*indexer.EntryMeta, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if meta := d.Indexer.GetMetaByEntryID(node.EntryID); meta != nil {
+			metas = append(metas, meta)
+		}
+	}
+	return metas, timing.BuildWaterfall(pageEntryID, metas, graph.Edges), nil
+}
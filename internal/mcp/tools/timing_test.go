@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestCheckOutputSchema_TimingAnalysis(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[TimingAnalysisOutput]("powhttp_timing_analysis")
+	})
+}
+
+// timingTestDeps serves a page load: an HTML document, a script it loaded
+// from a CDN, and an unrelated API call on the page's host.
+func timingTestDeps(t *testing.T) *Deps {
+	t.Helper()
+	ms := func(v int64) *int64 { return &v }
+	timed := func(e *client.SessionEntry, startedAt int64, conn string, dns, connect, ssl, wait, receive int64) *client.SessionEntry {
+		e.Timings = client.Timings{StartedAt: startedAt, DNS: ms(dns), Connect: ms(connect), SSL: ms(ssl), Send: ms(0), Wait: ms(wait), Receive: ms(receive)}
+		e.TLS = client.TLSInfo{ConnectionID: &conn}
+		return e
+	}
+
+	page := timed(testEntry("page", "GET", "https://www.example.com/", 200, "", "<html></html>"), 1_000, "c1", 10, 50, 30, 100, 40)
+	page.Response.Headers = client.Headers{{"content-type", "text/html"}}
+	script := timed(testEntry("script", "GET", "https://cdn.example.net/app.js", 200, "", "console.log(1)"), 1_250, "c2", 5, 20, 15, 10, 200)
+	script.Request.Headers = append(script.Request.Headers, []string{"referer", "https://www.example.com/"})
+	api := timed(testEntry("api", "GET", "https://www.example.com/api/me", 200, "", `{"id":1}`), 1_300, "c1", -1, -1, -1, 30, 5)
+
+	return newTestDeps(t, newFakeSource("s1", page, script, api))
+}
+
+func TestToolTimingAnalysis_Query(t *testing.T) {
+	d := timingTestDeps(t)
+
+	_, out, err := ToolTimingAnalysis(d)(context.Background(), nil, TimingAnalysisInput{
+		SessionID: "s1",
+		Query:     "host:www.example.com",
+	})
+	require.NoError(t, err)
+
+	a := out.Analysis
+	assert.Equal(t, 2, a.Entries)
+	assert.Equal(t, 2, a.TimedEntries)
+	require.NotNil(t, a.TTFB)
+	assert.Equal(t, int64(160), a.TTFB.Max)
+	require.Len(t, a.Slowest, 2)
+	assert.Equal(t, "page", a.Slowest[0].EntryID)
+	assert.Equal(t, int64(200), a.Slowest[0].DurationMs)
+
+	// Both entries share c1; the page paid its setup
+	require.Len(t, a.Connections, 1)
+	assert.Equal(t, "page", a.Connections[0].FirstEntryID)
+	assert.Equal(t, 2, a.Connections[0].Entries)
+	assert.Equal(t, int64(60), a.Connections[0].SetupMs)
+
+	assert.Nil(t, out.Waterfall)
+	assert.Contains(t, out.Hint, "page_entry_id")
+}
+
+func TestToolTimingAnalysis_Waterfall(t *testing.T) {
+	d := timingTestDeps(t)
+
+	_, out, err := ToolTimingAnalysis(d)(context.Background(), nil, TimingAnalysisInput{
+		SessionID:   "s1",
+		PageEntryID: "page",
+		Format:      "both",
+	})
+	require.NoError(t, err)
+
+	w := out.Waterfall
+	require.NotNil(t, w)
+	assert.Equal(t, int64(1_000), w.StartMs)
+	require.NotEmpty(t, w.Groups)
+	assert.Equal(t, "page", w.Groups[0].RootEntryID)
+	var ids []string
+	for _, r := range w.Groups[0].Rows {
+		ids = append(ids, r.EntryID)
+	}
+	assert.Equal(t, []string{"page", "script"}, ids)
+	assert.Equal(t, types.EdgeReasonInitiatorPage, w.Groups[0].Rows[1].Via)
+	assert.Contains(t, w.ASCII, "== www.example.com/ (2 entries)")
+	assert.Contains(t, w.ASCII, "cdn.example.net/app.js [script]")
+
+	// The default format keeps only the rendered text
+	_, out, err = ToolTimingAnalysis(d)(context.Background(), nil, TimingAnalysisInput{SessionID: "s1", PageEntryID: "page"})
+	require.NoError(t, err)
+	assert.Empty(t, out.Waterfall.Groups)
+	assert.NotEmpty(t, out.Waterfall.ASCII)
+}
+
+func TestToolTimingAnalysis_Errors(t *testing.T) {
+	d := timingTestDeps(t)
+
+	tests := []struct {
+		input TimingAnalysisInput
+		code  string
+	}{
+		{TimingAnalysisInput{SessionID: "s1", Format: "svg"}, ErrCodeInvalidInput},
+		{TimingAnalysisInput{SessionID: "s1", Width: 5}, ErrCodeInvalidInput},
+		{TimingAnalysisInput{SessionID: "s1", PageEntryID: "page", Query: "status:200"}, ErrCodeInvalidInput},
+		{TimingAnalysisInput{SessionID: "s1", Query: "status:>"}, ErrCodeInvalidInput},
+		{TimingAnalysisInput{SessionID: "s1", PageEntryID: "missing"}, ErrCodeNotFound},
+	}
+	for _, tt := range tests {
+		_, _, err := ToolTimingAnalysis(d)(context.Background(), nil, tt.input)
+		var coded *CodedError
+		require.True(t, errors.As(err, &coded), "%+v", tt.input)
+		assert.Equal(t, tt.code, coded.Code, "%+v", tt.input)
+	}
+}
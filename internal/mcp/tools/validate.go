@@ -2,7 +2,6 @@ package tools
 
 import (
 	"context"
-	"encoding/base64"
 	"sort"
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -204,11 +203,11 @@ func validateEntryBody(validator *schema.Validator, entry *client.SessionEntry,
 	}
 
 	var body *string
-	var contentType string
+	var headers client.Headers
 
 	if target == "request" {
 		body = entry.Request.Body
-		contentType = entry.Request.Headers.Get("content-type")
+		headers = entry.Request.Headers
 	} else {
 		if entry.Response == nil {
 			result.Skipped = true
@@ -216,8 +215,9 @@ func validateEntryBody(validator *schema.Validator, entry *client.SessionEntry,
 			return result
 		}
 		body = entry.Response.Body
-		contentType = entry.Response.Headers.Get("content-type")
+		headers = entry.Response.Headers
 	}
+	contentType := headers.Get("content-type")
 
 	if body == nil || *body == "" {
 		result.Skipped = true
@@ -231,7 +231,7 @@ func validateEntryBody(validator *schema.Validator, entry *client.SessionEntry,
 		return result
 	}
 
-	bodyBytes, err := base64.StdEncoding.DecodeString(*body)
+	bodyBytes, err := client.DecodeContent(body, client.ContentEncoding(headers))
 	if err != nil {
 		result.Skipped = true
 		result.SkipReason = "failed to decode body: " + err.Error()
@@ -0,0 +1,391 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/shape"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+const (
+	// defaultWSPayloadBytes caps the payload returned per message unless overridden.
+	defaultWSPayloadBytes = 2048
+	// maxWSShapeSamples caps the JSON frames analyzed per message-type group.
+	maxWSShapeSamples = 50
+	// wsUntypedGroup is the group key for JSON frames without a string "type" field.
+	wsUntypedGroup = "(untyped)"
+)
+
+// WebSocketMessagesInput is the input for powhttp_websocket_messages.
+type WebSocketMessagesInput struct {
+	SessionID       string `json:"session_id,omitempty" jsonschema:"Session ID (default: active)"`
+	EntryID         string `json:"entry_id" jsonschema:"required,Entry ID of the WebSocket upgrade request"`
+	Side            string `json:"side,omitempty" jsonschema:"Filter by sender: client or server"`
+	Type            string `json:"type,omitempty" jsonschema:"Filter by frame type: text, binary, close, ping, pong, unknown"`
+	SinceMs         int64  `json:"since_ms,omitempty" jsonschema:"Only frames started at or after this Unix timestamp (ms)"`
+	UntilMs         int64  `json:"until_ms,omitempty" jsonschema:"Only frames started at or before this Unix timestamp (ms)"`
+	Contains        string `json:"contains,omitempty" jsonschema:"Case-insensitive substring match on decoded payload text"`
+	Limit           int    `json:"limit,omitempty" jsonschema:"Max messages to return (default: 20)"`
+	Offset          int    `json:"offset,omitempty" jsonschema:"Pagination offset"`
+	MaxPayloadBytes int    `json:"max_payload_bytes,omitempty" jsonschema:"Max payload bytes returned per message (default: 2048)"`
+	IncludeShapes   bool   `json:"include_shapes,omitempty" jsonschema:"Infer schemas for JSON text frames grouped by their \"type\" field (default: false)"`
+}
+
+// WebSocketMessagesOutput is the output for powhttp_websocket_messages.
+type WebSocketMessagesOutput struct {
+	EntryID    string                 `json:"entry_id"`
+	Summary    WebSocketSummary       `json:"summary"`
+	Messages   []WebSocketMessageView `json:"messages,omitzero"`
+	Shapes     []WebSocketShapeGroup  `json:"shapes,omitzero"`
+	TotalHint  int                    `json:"total_hint"`
+	HasMore    bool                   `json:"has_more,omitempty"`
+	NextOffset int                    `json:"next_offset,omitempty"`
+	Resource   *types.ResourceRef     `json:"resource,omitempty"`
+	Hint       string                 `json:"hint,omitempty"`
+}
+
+// WebSocketSummary counts all frames on the connection, before filtering.
+type WebSocketSummary struct {
+	TotalMessages int            `json:"total_messages"`
+	BySide        map[string]int `json:"by_side,omitempty"`
+	ByType        map[string]int `json:"by_type,omitempty"`
+	FirstMs       int64          `json:"first_ms,omitempty"`
+	LastMs        int64          `json:"last_ms,omitempty"`
+}
+
+// WebSocketMessageView is a decoded WebSocket frame.
+type WebSocketMessageView struct {
+	Index        int    `json:"index"`
+	Side         string `json:"side"`
+	Type         string `json:"type"`
+	StartedAt    int64  `json:"started_at,omitempty"`
+	Text         string `json:"text,omitempty"`
+	DataBase64   string `json:"data_base64,omitempty"`
+	CloseCode    int    `json:"close_code,omitempty"`
+	CloseReason  string `json:"close_reason,omitempty"`
+	MessageType  string `json:"message_type,omitempty"`
+	PayloadBytes int    `json:"payload_bytes"`
+	Truncated    bool   `json:"truncated,omitempty"`
+}
+
+// WebSocketShapeGroup is the inferred shape of JSON text frames sharing a "type" value.
+type WebSocketShapeGroup struct {
+	MessageType string `json:"message_type"`
+	Side        string `json:"side"`
+	Count       int    `json:"count"`
+	Shape       any    `json:"shape,omitzero"`
+}
+
+// WebSocketFilter selects frames from a connection.
+type WebSocketFilter struct {
+	Side     string
+	Type     string
+	SinceMs  int64
+	UntilMs  int64
+	Contains string
+}
+
+// DecodedWebSocketMessage is a WebSocket frame with its payload decoded.
+type DecodedWebSocketMessage struct {
+	Index       int
+	Side        string
+	Type        string
+	StartedAt   int64
+	Payload     []byte // decoded text or binary payload (close reason for close frames)
+	CloseCode   int
+	MessageType string // value of the top-level "type" field for JSON text frames
+	IsJSON      bool
+}
+
+// DecodeWebSocketMessages decodes base64 payloads and extracts JSON message types.
+// Frames whose payload fails to decode keep an empty payload rather than being dropped.
+func DecodeWebSocketMessages(msgs []client.WebSocketMessage) []DecodedWebSocketMessage {
+	out := make([]DecodedWebSocketMessage, 0, len(msgs))
+	for i, m := range msgs {
+		dm := DecodedWebSocketMessage{
+			Index: i,
+			Side:  m.Side,
+			Type:  m.Content.Type,
+		}
+		if m.StartedAt != nil {
+			dm.StartedAt = *m.StartedAt
+		}
+
+		switch m.Content.Type {
+		case client.WSMessageText:
+			dm.Payload = []byte(m.Content.Text)
+			dm.MessageType, dm.IsJSON = extractWSMessageType(dm.Payload)
+		case client.WSMessageClose:
+			dm.CloseCode = m.Content.Code
+			dm.Payload, _ = base64.StdEncoding.DecodeString(m.Content.Reason)
+		default:
+			dm.Payload, _ = base64.StdEncoding.DecodeString(m.Content.Data)
+		}
+
+		out = append(out, dm)
+	}
+	return out
+}
+
+// extractWSMessageType returns the top-level "type" field of a JSON object frame.
+// The boolean reports whether the payload is valid JSON.
+func extractWSMessageType(payload []byte) (string, bool) {
+	trimmed := strings.TrimSpace(string(payload))
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return "", false
+	}
+	if !json.Valid([]byte(trimmed)) {
+		return "", false
+	}
+
+	var obj map[string]any
+	if json.Unmarshal([]byte(trimmed), &obj) != nil {
+		return "", true
+	}
+	switch v := obj["type"].(type) {
+	case string:
+		return v, true
+	case float64:
+		return fmt.Sprintf("%g", v), true
+	}
+	return "", true
+}
+
+// FilterWebSocketMessages returns the messages matching every set filter field.
+func FilterWebSocketMessages(msgs []DecodedWebSocketMessage, f WebSocketFilter) []DecodedWebSocketMessage {
+	needle := strings.ToLower(f.Contains)
+	var out []DecodedWebSocketMessage
+	for _, m := range msgs {
+		if f.Side != "" && !strings.EqualFold(m.Side, f.Side) {
+			continue
+		}
+		if f.Type != "" && !strings.EqualFold(m.Type, f.Type) {
+			continue
+		}
+		if f.SinceMs > 0 && m.StartedAt < f.SinceMs {
+			continue
+		}
+		if f.UntilMs > 0 && m.StartedAt > f.UntilMs {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(string(m.Payload)), needle) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// summarizeWebSocketMessages counts frames by side and type.
+func summarizeWebSocketMessages(msgs []DecodedWebSocketMessage) WebSocketSummary {
+	s := WebSocketSummary{
+		TotalMessages: len(msgs),
+		BySide:        make(map[string]int),
+		ByType:        make(map[string]int),
+	}
+	for _, m := range msgs {
+		s.BySide[m.Side]++
+		s.ByType[m.Type]++
+		if m.StartedAt > 0 {
+			if s.FirstMs == 0 || m.StartedAt < s.FirstMs {
+				s.FirstMs = m.StartedAt
+			}
+			if m.StartedAt > s.LastMs {
+				s.LastMs = m.StartedAt
+			}
+		}
+	}
+	return s
+}
+
+// toWebSocketMessageView renders a decoded frame, truncating its payload to maxBytes.
+// Printable UTF-8 payloads are returned as text; anything else as base64.
+func toWebSocketMessageView(m DecodedWebSocketMessage, maxBytes int) WebSocketMessageView {
+	v := WebSocketMessageView{
+		Index:        m.Index,
+		Side:         m.Side,
+		Type:         m.Type,
+		StartedAt:    m.StartedAt,
+		MessageType:  m.MessageType,
+		PayloadBytes: len(m.Payload),
+		CloseCode:    m.CloseCode,
+	}
+
+	payload := m.Payload
+	if maxBytes > 0 && len(payload) > maxBytes {
+		payload = payload[:maxBytes]
+		v.Truncated = true
+	}
+
+	switch {
+	case m.Type == client.WSMessageClose:
+		v.CloseReason = string(payload)
+	case m.Type == client.WSMessageText || (len(payload) > 0 && utf8.Valid(payload) && isPrintable(payload)):
+		v.Text = strings.ToValidUTF8(string(payload), "")
+	case len(payload) > 0:
+		v.DataBase64 = base64.StdEncoding.EncodeToString(payload)
+	}
+	return v
+}
+
+// WebSocketTranscript decodes every frame for resource output.
+func WebSocketTranscript(msgs []client.WebSocketMessage, maxPayloadBytes int) []WebSocketMessageView {
+	decoded := DecodeWebSocketMessages(msgs)
+	views := make([]WebSocketMessageView, 0, len(decoded))
+	for _, m := range decoded {
+		views = append(views, toWebSocketMessageView(m, maxPayloadBytes))
+	}
+	return views
+}
+
+// isPrintable reports whether b contains no control characters other than whitespace.
+func isPrintable(b []byte) bool {
+	for _, r := range string(b) {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// groupWebSocketShapes infers a schema per (side, message type) group of JSON text frames.
+func groupWebSocketShapes(engine *shape.Engine, msgs []DecodedWebSocketMessage) []WebSocketShapeGroup {
+	type groupKey struct{ side, msgType string }
+	bodies := make(map[groupKey][][]byte)
+	counts := make(map[groupKey]int)
+
+	for _, m := range msgs {
+		if !m.IsJSON {
+			continue
+		}
+		msgType := m.MessageType
+		if msgType == "" {
+			msgType = wsUntypedGroup
+		}
+		k := groupKey{side: m.Side, msgType: msgType}
+		counts[k]++
+		if len(bodies[k]) < maxWSShapeSamples {
+			bodies[k] = append(bodies[k], m.Payload)
+		}
+	}
+
+	keys := make([]groupKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		if keys[i].msgType != keys[j].msgType {
+			return keys[i].msgType < keys[j].msgType
+		}
+		return keys[i].side < keys[j].side
+	})
+
+	groups := make([]WebSocketShapeGroup, 0, len(keys))
+	for _, k := range keys {
+		g := WebSocketShapeGroup{
+			MessageType: k.msgType,
+			Side:        k.side,
+			Count:       counts[k],
+		}
+		if result, err := engine.Analyze(bodies[k], MimeJSON); err == nil {
+			if shapeAny, err := types.ToAny(result); err == nil {
+				g.Shape = shapeAny
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// ToolWebSocketMessages pages through and analyzes the frames of a WebSocket entry.
+func ToolWebSocketMessages(d *Deps) func(ctx context.Context, req *sdkmcp.CallToolRequest, input WebSocketMessagesInput) (*sdkmcp.CallToolResult, WebSocketMessagesOutput, error) {
+	shapeEngine := shape.NewEngine()
+
+	return func(ctx context.Context, req *sdkmcp.CallToolRequest, input WebSocketMessagesInput) (*sdkmcp.CallToolResult, WebSocketMessagesOutput, error) {
+		if input.EntryID == "" {
+			return nil, WebSocketMessagesOutput{}, ErrInvalidInput("entry_id is required")
+		}
+		if input.Side != "" && input.Side != client.WSSideClient && input.Side != client.WSSideServer {
+			return nil, WebSocketMessagesOutput{}, ErrInvalidInput("side must be 'client' or 'server'")
+		}
+		if input.SinceMs > 0 && input.UntilMs > 0 && input.SinceMs > input.UntilMs {
+			return nil, WebSocketMessagesOutput{}, ErrInvalidInput("since_ms must not be after until_ms")
+		}
+
+		sessionID, err := d.ResolveSessionID(ctx, input.SessionID)
+		if err != nil {
+			return nil, WebSocketMessagesOutput{}, err
+		}
+
+		raw, err := d.Client.GetWebSocketMessages(ctx, sessionID, input.EntryID)
+		if err != nil {
+			return nil, WebSocketMessagesOutput{}, WrapPowHTTPError(err)
+		}
+
+		all := DecodeWebSocketMessages(raw)
+		filtered := FilterWebSocketMessages(all, WebSocketFilter{
+			Side:     input.Side,
+			Type:     input.Type,
+			SinceMs:  input.SinceMs,
+			UntilMs:  input.UntilMs,
+			Contains: input.Contains,
+		})
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = d.Config.DefaultQueryLimit
+		}
+		offset := max(input.Offset, 0)
+		maxBytes := input.MaxPayloadBytes
+		if maxBytes <= 0 {
+			maxBytes = defaultWSPayloadBytes
+		}
+
+		output := WebSocketMessagesOutput{
+			EntryID:   input.EntryID,
+			Summary:   summarizeWebSocketMessages(all),
+			TotalHint: len(filtered),
+			Resource: &types.ResourceRef{
+				URI:  "powhttp://websocket/" + sessionID + "/" + input.EntryID,
+				MIME: MimeJSON,
+				Hint: "Fetch for every decoded frame on this connection",
+			},
+		}
+
+		if offset < len(filtered) {
+			end := min(offset+limit, len(filtered))
+			for _, m := range filtered[offset:end] {
+				output.Messages = append(output.Messages, toWebSocketMessageView(m, maxBytes))
+			}
+			if end < len(filtered) {
+				output.HasMore = true
+				output.NextOffset = end
+			}
+		}
+
+		if input.IncludeShapes {
+			output.Shapes = groupWebSocketShapes(shapeEngine, filtered)
+		}
+
+		switch {
+		case len(all) == 0:
+			output.Hint = "No WebSocket frames recorded. Check that the entry is a WebSocket upgrade (status 101) and the connection carried traffic."
+		case !input.IncludeShapes:
+			output.Hint = "Set include_shapes=true to infer schemas for JSON frames grouped by their \"type\" field."
+		}
+
+		return nil, output, nil
+	}
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/shape"
+)
+
+func wsMsg(side, typ string, ts int64, content client.WebSocketContent) client.WebSocketMessage {
+	content.Type = typ
+	return client.WebSocketMessage{Side: side, StartedAt: &ts, Content: content}
+}
+
+func testWebSocketMessages() []client.WebSocketMessage {
+	b64 := base64.StdEncoding.EncodeToString
+	return []client.WebSocketMessage{
+		wsMsg(client.WSSideClient, client.WSMessageText, 1000, client.WebSocketContent{Text: `{"type":"subscribe","channel":"ticker"}`}),
+		wsMsg(client.WSSideServer, client.WSMessageText, 1100, client.WebSocketContent{Text: `{"type":"ticker","price":1.5}`}),
+		wsMsg(client.WSSideServer, client.WSMessageText, 1200, client.WebSocketContent{Text: `{"type":"ticker","price":1.6,"volume":3}`}),
+		wsMsg(client.WSSideServer, client.WSMessageBinary, 1300, client.WebSocketContent{Data: b64([]byte{0x00, 0x01, 0xff})}),
+		wsMsg(client.WSSideClient, client.WSMessagePing, 1400, client.WebSocketContent{Data: b64([]byte("hb"))}),
+		wsMsg(client.WSSideServer, client.WSMessageText, 1500, client.WebSocketContent{Text: "plain hello"}),
+		wsMsg(client.WSSideServer, client.WSMessageClose, 1600, client.WebSocketContent{Code: 1000, Reason: b64([]byte("bye"))}),
+	}
+}
+
+func TestDecodeWebSocketMessages(t *testing.T) {
+	decoded := DecodeWebSocketMessages(testWebSocketMessages())
+	require.Len(t, decoded, 7)
+
+	assert.Equal(t, "subscribe", decoded[0].MessageType)
+	assert.True(t, decoded[0].IsJSON)
+	assert.Equal(t, "ticker", decoded[1].MessageType)
+	assert.Equal(t, []byte{0x00, 0x01, 0xff}, decoded[3].Payload)
+	assert.Equal(t, []byte("hb"), decoded[4].Payload)
+	assert.False(t, decoded[5].IsJSON)
+	assert.Equal(t, 1000, decoded[6].CloseCode)
+	assert.Equal(t, []byte("bye"), decoded[6].Payload)
+	assert.Equal(t, int64(1600), decoded[6].StartedAt)
+}
+
+func TestFilterWebSocketMessages(t *testing.T) {
+	decoded := DecodeWebSocketMessages(testWebSocketMessages())
+
+	tests := []struct {
+		name    string
+		filter  WebSocketFilter
+		indexes []int
+	}{
+		{"no filter", WebSocketFilter{}, []int{0, 1, 2, 3, 4, 5, 6}},
+		{"client side", WebSocketFilter{Side: "client"}, []int{0, 4}},
+		{"text type", WebSocketFilter{Type: "text"}, []int{0, 1, 2, 5}},
+		{"time range", WebSocketFilter{SinceMs: 1100, UntilMs: 1300}, []int{1, 2, 3}},
+		{"contains case-insensitive", WebSocketFilter{Contains: "TICKER"}, []int{0, 1, 2}},
+		{"contains close reason", WebSocketFilter{Contains: "bye"}, []int{6}},
+		{"combined", WebSocketFilter{Side: "server", Type: "text", Contains: "volume"}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterWebSocketMessages(decoded, tt.filter)
+			var indexes []int
+			for _, m := range got {
+				indexes = append(indexes, m.Index)
+			}
+			assert.Equal(t, tt.indexes, indexes)
+		})
+	}
+}
+
+func TestToWebSocketMessageView(t *testing.T) {
+	decoded := DecodeWebSocketMessages(testWebSocketMessages())
+
+	binary := toWebSocketMessageView(decoded[3], 0)
+	assert.Empty(t, binary.Text)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x00, 0x01, 0xff}), binary.DataBase64)
+
+	ping := toWebSocketMessageView(decoded[4], 0)
+	assert.Equal(t, "hb", ping.Text)
+
+	closeView := toWebSocketMessageView(decoded[6], 0)
+	assert.Equal(t, 1000, closeView.CloseCode)
+	assert.Equal(t, "bye", closeView.CloseReason)
+
+	truncated := toWebSocketMessageView(decoded[0], 8)
+	assert.True(t, truncated.Truncated)
+	assert.Equal(t, `{"type":`, truncated.Text)
+	assert.Equal(t, len(`{"type":"subscribe","channel":"ticker"}`), truncated.PayloadBytes)
+}
+
+func TestGroupWebSocketShapes(t *testing.T) {
+	decoded := DecodeWebSocketMessages(testWebSocketMessages())
+
+	groups := groupWebSocketShapes(shape.NewEngine(), decoded)
+	require.Len(t, groups, 2)
+
+	assert.Equal(t, "ticker", groups[0].MessageType)
+	assert.Equal(t, client.WSSideServer, groups[0].Side)
+	assert.Equal(t, 2, groups[0].Count)
+	assert.NotNil(t, groups[0].Shape)
+
+	assert.Equal(t, "subscribe", groups[1].MessageType)
+	assert.Equal(t, client.WSSideClient, groups[1].Side)
+	assert.Equal(t, 1, groups[1].Count)
+}
+
+func TestSummarizeWebSocketMessages(t *testing.T) {
+	summary := summarizeWebSocketMessages(DecodeWebSocketMessages(testWebSocketMessages()))
+
+	assert.Equal(t, 7, summary.TotalMessages)
+	assert.Equal(t, 2, summary.BySide["client"])
+	assert.Equal(t, 5, summary.BySide["server"])
+	assert.Equal(t, 4, summary.ByType["text"])
+	assert.Equal(t, int64(1000), summary.FirstMs)
+	assert.Equal(t, int64(1600), summary.LastMs)
+}
+
+func TestCheckOutputSchema_WebSocketMessages(t *testing.T) {
+	assert.NotPanics(t, func() {
+		CheckOutputSchema[WebSocketMessagesOutput]("powhttp_websocket_messages")
+	})
+}
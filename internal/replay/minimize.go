@@ -0,0 +1,348 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// Part kinds a request can be minimized over.
+const (
+	PartHeader = "header"
+	PartCookie = "cookie"
+	PartQuery  = "query"
+	PartJSON   = "json"
+)
+
+// ErrBudgetExhausted is returned by a minimization test once the request budget is spent.
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// Part is one removable piece of a request.
+type Part struct {
+	Kind string // PartHeader, PartCookie, PartQuery, or PartJSON
+	Name string // Lowercase header name, cookie name, query key, or dotted JSON path
+}
+
+func (p Part) String() string { return p.Kind + " " + p.Name }
+
+// Parts lists the removable pieces of r in request order. The Cookie header is
+// split into its cookies, and JSON bodies contribute object field paths (array
+// elements are not descended into). kinds restricts the result when non-empty.
+func Parts(r *Request, kinds ...string) []Part {
+	want := func(kind string) bool { return len(kinds) == 0 || slices.Contains(kinds, kind) }
+	var parts []Part
+	seen := make(map[Part]bool)
+	add := func(p Part) {
+		if !seen[p] {
+			seen[p] = true
+			parts = append(parts, p)
+		}
+	}
+
+	for _, h := range r.Headers {
+		name := strings.ToLower(h[0])
+		if name == "cookie" {
+			if want(PartCookie) {
+				for _, pair := range strings.Split(h[1], ";") {
+					if k, _, _ := strings.Cut(strings.TrimSpace(pair), "="); k != "" {
+						add(Part{PartCookie, k})
+					}
+				}
+			}
+			continue
+		}
+		if want(PartHeader) {
+			add(Part{PartHeader, name})
+		}
+	}
+
+	if want(PartQuery) {
+		if u, err := url.Parse(r.URL); err == nil && u.RawQuery != "" {
+			for _, kv := range strings.Split(u.RawQuery, "&") {
+				k, _, _ := strings.Cut(kv, "=")
+				if unescaped, err := url.QueryUnescape(k); err == nil {
+					k = unescaped
+				}
+				if k != "" {
+					add(Part{PartQuery, k})
+				}
+			}
+		}
+	}
+
+	if want(PartJSON) && len(r.Body) > 0 {
+		var doc any
+		if json.Unmarshal(r.Body, &doc) == nil {
+			walkObjectPaths(doc, "", func(path string) { add(Part{PartJSON, path}) })
+		}
+	}
+
+	return parts
+}
+
+// walkObjectPaths calls fn for every object field path, parents before children.
+func walkObjectPaths(node any, prefix string, fn func(string)) {
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		fn(path)
+		walkObjectPaths(obj[k], path, fn)
+	}
+}
+
+// RemovalMutations returns the mutations that drop every part in removed.
+// JSON paths under a removed parent are skipped, since the parent takes them along.
+func RemovalMutations(removed []Part) *Mutations {
+	m := &Mutations{}
+	jsonRemoved := make(map[string]bool)
+	for _, p := range removed {
+		if p.Kind == PartJSON {
+			jsonRemoved[p.Name] = true
+		}
+	}
+	for _, p := range removed {
+		switch p.Kind {
+		case PartHeader:
+			m.RemoveHeaders = append(m.RemoveHeaders, p.Name)
+		case PartCookie:
+			m.RemoveCookies = append(m.RemoveCookies, p.Name)
+		case PartQuery:
+			m.RemoveQuery = append(m.RemoveQuery, p.Name)
+		case PartJSON:
+			if !hasRemovedAncestor(p.Name, jsonRemoved) {
+				m.RemoveJSON = append(m.RemoveJSON, p.Name)
+			}
+		}
+	}
+	return m
+}
+
+func hasRemovedAncestor(path string, removed map[string]bool) bool {
+	for i := strings.LastIndexByte(path, '.'); i > 0; i = strings.LastIndexByte(path[:i], '.') {
+		if removed[path[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+// Test reports whether a request with only the kept parts still gets an
+// equivalent response, with the observed status code. Returning
+// ErrBudgetExhausted stops minimization early.
+type Test func(ctx context.Context, kept []Part) (ok bool, status int, err error)
+
+// MinimizeResult is the outcome of Minimize.
+type MinimizeResult struct {
+	Required  []Part       // Parts the minimal request keeps (including parents of kept JSON fields)
+	Removable []Part       // Parts the server did not need
+	StatusOf  map[Part]int // Status observed when a required part alone was dropped from the minimal request
+	Tests     int          // Distinct variants tested
+	Complete  bool         // False when the budget ran out before the result was 1-minimal
+}
+
+// Minimize runs delta debugging (ddmin) over parts to find a smallest subset
+// that test still accepts, followed by a pass that drops parts one at a time
+// until no single removal succeeds. Results are cached so no variant is tested twice.
+func Minimize(ctx context.Context, parts []Part, test Test) (*MinimizeResult, error) {
+	index := make(map[Part]int, len(parts))
+	for i, p := range parts {
+		index[p] = i
+	}
+	type outcome struct {
+		ok     bool
+		status int
+	}
+	cache := make(map[string]outcome)
+	res := &MinimizeResult{StatusOf: make(map[Part]int)}
+
+	run := func(kept []Part) (bool, error) {
+		key := subsetKey(kept, index)
+		if o, ok := cache[key]; ok {
+			return o.ok, nil
+		}
+		ok, status, err := test(ctx, kept)
+		if err != nil {
+			return false, err
+		}
+		res.Tests++
+		cache[key] = outcome{ok, status}
+		return ok, nil
+	}
+	finish := func(kept []Part, complete bool) *MinimizeResult {
+		res.Complete = complete
+		res.Removable = Removed(parts, kept)
+		for _, p := range parts {
+			if !slices.Contains(res.Removable, p) {
+				res.Required = append(res.Required, p)
+			}
+		}
+		for _, p := range kept {
+			if o, ok := cache[subsetKey(without(kept, p), index)]; ok {
+				res.StatusOf[p] = o.status
+			}
+		}
+		return res
+	}
+
+	kept := parts
+	n := 2
+	for len(kept) >= 2 {
+		if n > len(kept) {
+			n = len(kept)
+		}
+		chunks := split(kept, n)
+		reduced := false
+		for _, c := range chunks {
+			ok, err := run(c)
+			if err != nil {
+				return stopEarly(finish, kept, err)
+			}
+			if ok {
+				kept, n, reduced = c, 2, true
+				break
+			}
+		}
+		if !reduced && n > 2 {
+			for i := range chunks {
+				comp := complement(chunks, i)
+				ok, err := run(comp)
+				if err != nil {
+					return stopEarly(finish, kept, err)
+				}
+				if ok {
+					kept, n, reduced = comp, max(n-1, 2), true
+					break
+				}
+			}
+		}
+		if !reduced {
+			if n >= len(kept) {
+				break
+			}
+			n = min(2*n, len(kept))
+		}
+	}
+
+	// Make the result 1-minimal: no single remaining part can be dropped.
+	for changed := true; changed; {
+		changed = false
+		for _, p := range kept {
+			candidate := without(kept, p)
+			ok, err := run(candidate)
+			if err != nil {
+				return stopEarly(finish, kept, err)
+			}
+			if ok {
+				kept, changed = candidate, true
+				break
+			}
+		}
+	}
+
+	return finish(kept, true), nil
+}
+
+// stopEarly returns the partial result when the budget ran out, and the error otherwise.
+func stopEarly(finish func([]Part, bool) *MinimizeResult, kept []Part, err error) (*MinimizeResult, error) {
+	if errors.Is(err, ErrBudgetExhausted) {
+		return finish(kept, false), nil
+	}
+	return nil, err
+}
+
+// split divides parts into n contiguous chunks of near-equal size.
+func split(parts []Part, n int) [][]Part {
+	chunks := make([][]Part, 0, n)
+	start := 0
+	for i := range n {
+		end := start + (len(parts)-start)/(n-i)
+		chunks = append(chunks, parts[start:end])
+		start = end
+	}
+	return chunks
+}
+
+func complement(chunks [][]Part, skip int) []Part {
+	var out []Part
+	for i, c := range chunks {
+		if i != skip {
+			out = append(out, c...)
+		}
+	}
+	return out
+}
+
+func without(parts []Part, drop Part) []Part {
+	out := make([]Part, 0, len(parts))
+	for _, p := range parts {
+		if p != drop {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// subsetKey identifies a subset of parts independent of order.
+func subsetKey(parts []Part, index map[Part]int) string {
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		ids[i] = index[p]
+	}
+	sort.Ints(ids)
+	var sb strings.Builder
+	for _, id := range ids {
+		sb.WriteString(strconv.Itoa(id))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+// Removed returns the parts of all that are not in kept. Keeping a JSON path
+// implicitly keeps its parent objects.
+func Removed(all, kept []Part) []Part {
+	keep := make(map[Part]bool, len(kept))
+	for _, p := range kept {
+		keep[p] = true
+		if p.Kind != PartJSON {
+			continue
+		}
+		for i := strings.LastIndexByte(p.Name, '.'); i > 0; i = strings.LastIndexByte(p.Name[:i], '.') {
+			keep[Part{PartJSON, p.Name[:i]}] = true
+		}
+	}
+	var out []Part
+	for _, p := range all {
+		if !keep[p] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// BuildKept rebuilds entry's request keeping only the listed parts of all.
+func BuildKept(entry *client.SessionEntry, all, kept []Part) (*Request, error) {
+	r, _, err := Build(entry, RemovalMutations(Removed(all, kept)))
+	if err != nil {
+		return nil, fmt.Errorf("building variant: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,142 @@
+package replay
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func minimizeEntry() *client.SessionEntry {
+	body := base64.StdEncoding.EncodeToString([]byte(`{"user":{"id":7,"name":"x"},"trace":"t"}`))
+	return &client.SessionEntry{
+		ID:  "e1",
+		URL: "https://api.example.com/v1/me?lang=en&cb=123",
+		Request: client.Request{
+			Method: strPtr("POST"),
+			Headers: client.Headers{
+				{"Authorization", "Bearer tok"},
+				{"accept", "*/*"},
+				{"cookie", "sid=abc; theme=dark"},
+				{"x-trace", "1"},
+			},
+			Body: &body,
+		},
+	}
+}
+
+func TestParts(t *testing.T) {
+	r, err := FromEntry(minimizeEntry())
+	require.NoError(t, err)
+
+	assert.Equal(t, []Part{
+		{PartHeader, "authorization"},
+		{PartHeader, "accept"},
+		{PartCookie, "sid"},
+		{PartCookie, "theme"},
+		{PartHeader, "x-trace"},
+		{PartQuery, "lang"},
+		{PartQuery, "cb"},
+		{PartJSON, "trace"},
+		{PartJSON, "user"},
+		{PartJSON, "user.id"},
+		{PartJSON, "user.name"},
+	}, Parts(r))
+
+	assert.Equal(t, []Part{{PartQuery, "lang"}, {PartQuery, "cb"}}, Parts(r, PartQuery))
+}
+
+func TestRemovalMutations_SkipsChildrenOfRemovedParents(t *testing.T) {
+	m := RemovalMutations([]Part{{PartJSON, "user"}, {PartJSON, "user.id"}, {PartCookie, "sid"}, {PartHeader, "accept"}})
+	assert.Equal(t, []string{"user"}, m.RemoveJSON)
+	assert.Equal(t, []string{"sid"}, m.RemoveCookies)
+	assert.Equal(t, []string{"accept"}, m.RemoveHeaders)
+}
+
+func TestApply_RemoveCookies(t *testing.T) {
+	r, applied, err := Build(minimizeEntry(), &Mutations{RemoveCookies: []string{"theme"}})
+	require.NoError(t, err)
+	assert.Equal(t, "sid=abc", r.Headers.Get("cookie"))
+	assert.Equal(t, []string{"removed cookie theme"}, applied)
+
+	r, _, err = Build(minimizeEntry(), &Mutations{RemoveCookies: []string{"theme", "sid"}})
+	require.NoError(t, err)
+	assert.Empty(t, r.Headers.Get("cookie"), "emptied Cookie header is dropped")
+
+	_, _, err = Build(minimizeEntry(), &Mutations{RemoveCookies: []string{"nope"}})
+	assert.Error(t, err)
+}
+
+func hasUserID(body []byte) bool {
+	var doc struct {
+		User struct {
+			ID *int `json:"id"`
+		} `json:"user"`
+	}
+	return json.Unmarshal(body, &doc) == nil && doc.User.ID != nil
+}
+
+func TestMinimize(t *testing.T) {
+	entry := minimizeEntry()
+	r, err := FromEntry(entry)
+	require.NoError(t, err)
+	parts := Parts(r)
+
+	// The server needs the bearer token, the sid cookie, and user.id.
+	test := func(_ context.Context, kept []Part) (bool, int, error) {
+		v, err := BuildKept(entry, parts, kept)
+		require.NoError(t, err)
+		switch {
+		case v.Headers.Get("authorization") == "":
+			return false, 401, nil
+		case !strings.Contains(v.Headers.Get("cookie"), "sid=abc"):
+			return false, 403, nil
+		case !hasUserID(v.Body):
+			return false, 400, nil
+		}
+		return true, 200, nil
+	}
+
+	res, err := Minimize(context.Background(), parts, test)
+	require.NoError(t, err)
+	assert.True(t, res.Complete)
+	assert.Equal(t, []Part{
+		{PartHeader, "authorization"},
+		{PartCookie, "sid"},
+		{PartJSON, "user"},
+		{PartJSON, "user.id"},
+	}, res.Required)
+	assert.Equal(t, 401, res.StatusOf[Part{PartHeader, "authorization"}])
+	assert.Equal(t, 403, res.StatusOf[Part{PartCookie, "sid"}])
+	assert.Equal(t, 400, res.StatusOf[Part{PartJSON, "user.id"}])
+
+	minimal, err := BuildKept(entry, parts, res.Required)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1/me", minimal.URL)
+	assert.Equal(t, client.Headers{{"Authorization", "Bearer tok"}, {"cookie", "sid=abc"}}, minimal.Headers)
+	assert.JSONEq(t, `{"user":{"id":7}}`, string(minimal.Body))
+}
+
+func TestMinimize_Budget(t *testing.T) {
+	parts := []Part{{PartHeader, "a"}, {PartHeader, "b"}, {PartHeader, "c"}, {PartHeader, "d"}}
+	calls := 0
+	test := func(context.Context, []Part) (bool, int, error) {
+		if calls == 2 {
+			return false, 0, ErrBudgetExhausted
+		}
+		calls++
+		return false, 500, nil
+	}
+
+	res, err := Minimize(context.Background(), parts, test)
+	require.NoError(t, err)
+	assert.False(t, res.Complete)
+	assert.Equal(t, parts, res.Required, "nothing was shown removable")
+	assert.Equal(t, 2, res.Tests)
+}
@@ -0,0 +1,379 @@
+// Package replay rebuilds captured entries as outgoing requests, applies
+// mutations to them, and re-sends them through the powhttp proxy.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// skipHeaders are dropped when rebuilding a request: HTTP/2 pseudo-headers are
+// handled separately, and hop-by-hop or framing headers are set by the transport.
+var skipHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"proxy-connection":  true,
+	"keep-alive":        true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+}
+
+// Request is a captured request rebuilt for sending.
+type Request struct {
+	Method      string
+	URL         string
+	HTTPVersion string         // HTTP version of the original entry (e.g. "h2", "http/1.1")
+	Headers     client.Headers // In capture order, without pseudo or hop-by-hop headers
+	Body        []byte
+}
+
+// Mutations describes edits applied to a rebuilt request.
+type Mutations struct {
+	Method        string            // Replacement method
+	SetHeaders    map[string]string // Headers to add or replace (all values of the name)
+	RemoveHeaders []string          // Header names to drop (case-insensitive)
+	RemoveCookies []string          // Cookie names to drop from the Cookie header
+	SetQuery      map[string]string // Query keys to add or replace
+	RemoveQuery   []string          // Query keys to drop
+	SetJSON       map[string]any    // Dotted JSON body paths to set (e.g. "user.id", "items.0.sku")
+	RemoveJSON    []string          // Dotted JSON body paths to delete
+}
+
+// FromEntry rebuilds the request of a captured entry.
+func FromEntry(entry *client.SessionEntry) (*Request, error) {
+	if entry.Request.Method == nil || *entry.Request.Method == "" {
+		return nil, fmt.Errorf("entry %s has no request method", entry.ID)
+	}
+	if entry.URL == "" {
+		return nil, fmt.Errorf("entry %s has no URL", entry.ID)
+	}
+
+	body, err := client.DecodeBody(entry.Request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding request body: %w", err)
+	}
+
+	headers := make(client.Headers, 0, len(entry.Request.Headers))
+	for _, h := range entry.Request.Headers {
+		if len(h) < 2 || strings.HasPrefix(h[0], ":") || skipHeaders[strings.ToLower(h[0])] {
+			continue
+		}
+		headers = append(headers, []string{h[0], h[1]})
+	}
+
+	return &Request{
+		Method:      *entry.Request.Method,
+		URL:         entry.URL,
+		HTTPVersion: entry.HTTPVersion,
+		Headers:     headers,
+		Body:        body,
+	}, nil
+}
+
+// Build rebuilds the entry's request and applies m. It returns the request and
+// a readable description of each applied change.
+func Build(entry *client.SessionEntry, m *Mutations) (*Request, []string, error) {
+	r, err := FromEntry(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil {
+		return r, nil, nil
+	}
+	applied, err := r.Apply(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, applied, nil
+}
+
+// Apply edits r in place. Removing a header, query key, or JSON path that is
+// not present is an error so that typos do not go unnoticed.
+func (r *Request) Apply(m *Mutations) ([]string, error) {
+	var applied []string
+
+	if m.Method != "" && !strings.EqualFold(m.Method, r.Method) {
+		applied = append(applied, fmt.Sprintf("method %s -> %s", r.Method, strings.ToUpper(m.Method)))
+		r.Method = strings.ToUpper(m.Method)
+	}
+
+	for _, name := range m.RemoveHeaders {
+		before := len(r.Headers)
+		r.Headers = slices.DeleteFunc(r.Headers, func(h []string) bool {
+			return strings.EqualFold(h[0], name)
+		})
+		if len(r.Headers) == before {
+			return nil, fmt.Errorf("header %q is not present in the request", name)
+		}
+		applied = append(applied, "removed header "+strings.ToLower(name))
+	}
+	for _, name := range m.RemoveCookies {
+		if !r.removeCookie(name) {
+			return nil, fmt.Errorf("cookie %q is not present in the request", name)
+		}
+		applied = append(applied, "removed cookie "+name)
+	}
+	for _, name := range sortedKeys(m.SetHeaders) {
+		if strings.HasPrefix(name, ":") || skipHeaders[strings.ToLower(name)] {
+			return nil, fmt.Errorf("header %q is managed by the transport and cannot be set", name)
+		}
+		applied = append(applied, "set header "+strings.ToLower(name))
+		r.setHeader(name, m.SetHeaders[name])
+	}
+
+	if len(m.SetQuery) > 0 || len(m.RemoveQuery) > 0 {
+		changes, err := r.applyQuery(m.SetQuery, m.RemoveQuery)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, changes...)
+	}
+
+	if len(m.SetJSON) > 0 || len(m.RemoveJSON) > 0 {
+		changes, err := r.applyJSON(m.SetJSON, m.RemoveJSON)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, changes...)
+	}
+
+	return applied, nil
+}
+
+// setHeader replaces the first header with name in place, keeping its
+// position, and drops any further values. New headers are appended.
+func (r *Request) setHeader(name, value string) {
+	idx := slices.IndexFunc(r.Headers, func(h []string) bool {
+		return strings.EqualFold(h[0], name)
+	})
+	if idx < 0 {
+		r.Headers = append(r.Headers, []string{name, value})
+		return
+	}
+	r.Headers[idx] = []string{r.Headers[idx][0], value}
+	r.Headers = append(r.Headers[:idx+1], slices.DeleteFunc(r.Headers[idx+1:], func(h []string) bool {
+		return strings.EqualFold(h[0], name)
+	})...)
+}
+
+// removeCookie drops every pair named name from the Cookie header(s). Emptied
+// Cookie headers are removed. It reports whether anything was dropped.
+func (r *Request) removeCookie(name string) bool {
+	found := false
+	kept := r.Headers[:0]
+	for _, h := range r.Headers {
+		if !strings.EqualFold(h[0], "cookie") {
+			kept = append(kept, h)
+			continue
+		}
+		var pairs []string
+		for _, pair := range strings.Split(h[1], ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			if k, _, _ := strings.Cut(pair, "="); k == name {
+				found = true
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+		if len(pairs) > 0 {
+			kept = append(kept, []string{h[0], strings.Join(pairs, "; ")})
+		}
+	}
+	r.Headers = kept
+	return found
+}
+
+// applyQuery edits the URL query. Untouched keys keep their raw encoding and order.
+func (r *Request) applyQuery(set map[string]string, remove []string) ([]string, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+
+	var parts []string
+	if u.RawQuery != "" {
+		parts = strings.Split(u.RawQuery, "&")
+	}
+	keyOf := func(part string) string {
+		k, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(k); err == nil {
+			return unescaped
+		}
+		return k
+	}
+
+	var applied []string
+	for _, key := range remove {
+		before := len(parts)
+		parts = slices.DeleteFunc(parts, func(p string) bool { return keyOf(p) == key })
+		if len(parts) == before {
+			return nil, fmt.Errorf("query key %q is not present in the URL", key)
+		}
+		applied = append(applied, "removed query "+key)
+	}
+	for _, key := range sortedKeys(set) {
+		encoded := url.QueryEscape(key) + "=" + url.QueryEscape(set[key])
+		idx := slices.IndexFunc(parts, func(p string) bool { return keyOf(p) == key })
+		if idx < 0 {
+			parts = append(parts, encoded)
+		} else {
+			parts[idx] = encoded
+			parts = append(parts[:idx+1], slices.DeleteFunc(parts[idx+1:], func(p string) bool { return keyOf(p) == key })...)
+		}
+		applied = append(applied, "set query "+key)
+	}
+
+	u.RawQuery = strings.Join(parts, "&")
+	r.URL = u.String()
+	return applied, nil
+}
+
+// applyJSON edits the JSON request body.
+func (r *Request) applyJSON(set map[string]any, remove []string) ([]string, error) {
+	var doc any
+	if len(r.Body) > 0 {
+		if err := json.Unmarshal(r.Body, &doc); err != nil {
+			return nil, fmt.Errorf("request body is not JSON: %w", err)
+		}
+	} else {
+		doc = map[string]any{}
+	}
+
+	var applied []string
+	for _, path := range remove {
+		var err error
+		if doc, err = DeletePath(doc, path); err != nil {
+			return nil, err
+		}
+		applied = append(applied, "removed json "+path)
+	}
+	for _, path := range sortedKeys(set) {
+		var err error
+		if doc, err = SetPath(doc, path, set[path]); err != nil {
+			return nil, err
+		}
+		applied = append(applied, "set json "+path)
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request body: %w", err)
+	}
+	r.Body = body
+	return applied, nil
+}
+
+// SetPath sets the value at a dotted path ("a.b.0.c"), creating missing object
+// keys. Array indexes must already exist. It returns the updated document.
+func SetPath(doc any, path string, value any) (any, error) {
+	if path == "" {
+		return value, nil
+	}
+	return setPath(doc, strings.Split(path, "."), path, value)
+}
+
+func setPath(node any, segs []string, path string, value any) (any, error) {
+	if len(segs) == 0 {
+		return value, nil
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		child, err := setPath(n[segs[0]], segs[1:], path, value)
+		if err != nil {
+			return nil, err
+		}
+		n[segs[0]] = child
+		return n, nil
+	case []any:
+		i, err := strconv.Atoi(segs[0])
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, fmt.Errorf("json path %q: index %q out of range", path, segs[0])
+		}
+		child, err := setPath(n[i], segs[1:], path, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	case nil:
+		child, err := setPath(map[string]any{}, segs, path, value)
+		if err != nil {
+			return nil, err
+		}
+		return child, nil
+	default:
+		return nil, fmt.Errorf("json path %q: cannot descend into %T at %q", path, node, segs[0])
+	}
+}
+
+// DeletePath removes the value at a dotted path. Array elements are removed
+// and later elements shift down. It returns the updated document.
+func DeletePath(doc any, path string) (any, error) {
+	segs := strings.Split(path, ".")
+	parent := doc
+	for i, seg := range segs[:len(segs)-1] {
+		next, ok := child(parent, seg)
+		if !ok {
+			return nil, fmt.Errorf("json path %q not found at %q", path, strings.Join(segs[:i+1], "."))
+		}
+		parent = next
+	}
+
+	last := segs[len(segs)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		if _, ok := p[last]; !ok {
+			return nil, fmt.Errorf("json path %q not found", path)
+		}
+		delete(p, last)
+		return doc, nil
+	case []any:
+		i, err := strconv.Atoi(last)
+		if err != nil || i < 0 || i >= len(p) {
+			return nil, fmt.Errorf("json path %q not found", path)
+		}
+		trimmed := slices.Delete(p, i, i+1)
+		if len(segs) == 1 {
+			return trimmed, nil
+		}
+		return SetPath(doc, strings.Join(segs[:len(segs)-1], "."), trimmed)
+	default:
+		return nil, fmt.Errorf("json path %q not found", path)
+	}
+}
+
+// child returns the value of an object key or array index.
+func child(node any, seg string) (any, bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[seg]
+		return v, ok
+	case []any:
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, false
+		}
+		return n[i], true
+	}
+	return nil, false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package replay
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func strPtr(s string) *string { return &s }
+
+func makeEntry(body string) *client.SessionEntry {
+	e := &client.SessionEntry{
+		ID:          "e1",
+		URL:         "https://api.example.com/v1/items?b=2&a=1&a=3&q=hello%20world",
+		HTTPVersion: "h2",
+		Request: client.Request{
+			Method: strPtr("POST"),
+			Headers: client.Headers{
+				{":method", "POST"},
+				{":authority", "api.example.com"},
+				{"Host", "api.example.com"},
+				{"User-Agent", "Mozilla/5.0"},
+				{"accept", "*/*"},
+				{"x-token", "one"},
+				{"content-length", "42"},
+				{"x-token", "two"},
+				{"connection", "keep-alive"},
+			},
+		},
+	}
+	if body != "" {
+		e.Request.Body = strPtr(base64.StdEncoding.EncodeToString([]byte(body)))
+	}
+	return e
+}
+
+func TestFromEntry(t *testing.T) {
+	r, err := FromEntry(makeEntry(`{"a":1}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "h2", r.HTTPVersion)
+	assert.Equal(t, client.Headers{
+		{"User-Agent", "Mozilla/5.0"},
+		{"accept", "*/*"},
+		{"x-token", "one"},
+		{"x-token", "two"},
+	}, r.Headers)
+	assert.Equal(t, `{"a":1}`, string(r.Body))
+
+	_, err = FromEntry(&client.SessionEntry{ID: "x", URL: "https://a"})
+	assert.Error(t, err)
+}
+
+func TestApply_Headers(t *testing.T) {
+	r, applied, err := Build(makeEntry(""), &Mutations{
+		Method:        "put",
+		SetHeaders:    map[string]string{"X-Token": "three", "x-new": "v"},
+		RemoveHeaders: []string{"ACCEPT"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "PUT", r.Method)
+	assert.Equal(t, client.Headers{
+		{"User-Agent", "Mozilla/5.0"},
+		{"x-token", "three"},
+		{"x-new", "v"},
+	}, r.Headers, "replaced headers keep their position and original casing")
+	assert.Equal(t, []string{"method POST -> PUT", "removed header accept", "set header x-token", "set header x-new"}, applied)
+
+	_, _, err = Build(makeEntry(""), &Mutations{RemoveHeaders: []string{"x-missing"}})
+	assert.Error(t, err)
+	_, _, err = Build(makeEntry(""), &Mutations{SetHeaders: map[string]string{":path": "/"}})
+	assert.Error(t, err)
+}
+
+func TestApply_Query(t *testing.T) {
+	r, _, err := Build(makeEntry(""), &Mutations{
+		SetQuery:    map[string]string{"b": "x y", "c": "new"},
+		RemoveQuery: []string{"a"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1/items?b=x+y&q=hello%20world&c=new", r.URL)
+
+	_, _, err = Build(makeEntry(""), &Mutations{RemoveQuery: []string{"zzz"}})
+	assert.Error(t, err)
+}
+
+func TestApply_JSON(t *testing.T) {
+	body := `{"user":{"id":1,"tags":["a","b","c"]},"items":[{"sku":"x"}]}`
+
+	r, _, err := Build(makeEntry(body), &Mutations{
+		SetJSON:    map[string]any{"user.id": 2, "items.0.qty": 5, "meta.source": "replay"},
+		RemoveJSON: []string{"user.tags.1"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":{"id":2,"tags":["a","c"]},"items":[{"sku":"x","qty":5}],"meta":{"source":"replay"}}`, string(r.Body))
+
+	for _, m := range []*Mutations{
+		{RemoveJSON: []string{"user.missing"}},
+		{RemoveJSON: []string{"items.3"}},
+		{SetJSON: map[string]any{"items.9.sku": "y"}},
+		{SetJSON: map[string]any{"user.id.deeper": 1}},
+	} {
+		_, _, err := Build(makeEntry(body), m)
+		assert.Error(t, err, "%+v", m)
+	}
+
+	_, _, err = Build(makeEntry("not json"), &Mutations{SetJSON: map[string]any{"a": 1}})
+	assert.Error(t, err)
+}
+
+func TestDeletePath_TopLevelArray(t *testing.T) {
+	doc, err := DeletePath([]any{"a", "b"}, "0")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"b"}, doc)
+}
+
+func TestIsHTTP2(t *testing.T) {
+	assert.True(t, isHTTP2("h2"))
+	assert.True(t, isHTTP2("HTTP/2.0"))
+	assert.False(t, isHTTP2("http/1.1"))
+	assert.False(t, isHTTP2(""))
+}
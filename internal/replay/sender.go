@@ -0,0 +1,150 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// maxResponseBytes caps how much of a replayed response body is kept in memory.
+const maxResponseBytes = 4 << 20
+
+// Response is the result of sending a request.
+type Response struct {
+	StatusCode int
+	Protocol   string // e.g. "HTTP/1.1", "HTTP/2.0"
+	Headers    client.Headers
+	Body       []byte // Raw bytes as received (not decompressed), capped at 4MB
+	Truncated  bool
+	SentAt     time.Time
+	Duration   time.Duration
+}
+
+// Sender sends requests through an HTTP proxy. Redirects are not followed, so
+// every hop shows up as its own captured entry.
+type Sender struct {
+	h1 *http.Client
+	h2 *http.Client
+}
+
+// NewSender creates a Sender that routes every request through proxyURL.
+// insecureTLS skips verification of the certificates the proxy presents,
+// for setups where the powhttp CA is not in the system trust store.
+func NewSender(proxyURL string, timeout time.Duration, insecureTLS bool) (*Sender, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", proxyURL)
+	}
+
+	newClient := func(h2 bool) *http.Client {
+		transport := &http.Transport{
+			Proxy:              http.ProxyURL(proxy),
+			TLSClientConfig:    &tls.Config{InsecureSkipVerify: insecureTLS},
+			DisableCompression: true, // send Accept-Encoding exactly as captured
+			ForceAttemptHTTP2:  h2,
+			MaxIdleConns:       4,
+			IdleConnTimeout:    30 * time.Second,
+		}
+		if !h2 {
+			// A non-nil empty map disables HTTP/2 negotiation.
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		return &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &Sender{h1: newClient(false), h2: newClient(true)}, nil
+}
+
+// Send sends r, using HTTP/2 when the original entry did.
+func (s *Sender) Send(ctx context.Context, r *Request) (*Response, error) {
+	var body io.Reader
+	if len(r.Body) > 0 {
+		body = bytes.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	// Assign directly rather than via Header.Add so HTTP/1.x keeps the
+	// captured header-name casing. net/http always writes User-Agent itself
+	// under its canonical name, so that one key is canonicalized.
+	for _, h := range r.Headers {
+		name := h[0]
+		if strings.EqualFold(name, "user-agent") {
+			name = "User-Agent"
+		}
+		req.Header[name] = append(req.Header[name], h[1])
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		// An empty value stops net/http from sending its Go-http-client default.
+		req.Header["User-Agent"] = []string{""}
+	}
+
+	c := s.h1
+	if isHTTP2(r.HTTPVersion) {
+		c = s.h2
+	}
+
+	start := time.Now()
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	out := &Response{
+		StatusCode: resp.StatusCode,
+		Protocol:   resp.Proto,
+		Body:       data,
+		SentAt:     start,
+		Duration:   time.Since(start),
+	}
+	if len(data) > maxResponseBytes {
+		out.Body = data[:maxResponseBytes]
+		out.Truncated = true
+	}
+	for name, values := range resp.Header {
+		for _, v := range values {
+			out.Headers = append(out.Headers, []string{strings.ToLower(name), v})
+		}
+	}
+	return out, nil
+}
+
+// isHTTP2 reports whether a captured HTTP version string denotes HTTP/2.
+func isHTTP2(version string) bool {
+	v := strings.ToLower(version)
+	return v == "h2" || strings.HasPrefix(v, "http/2")
+}
+
+// DecodedBody returns the response body with any gzip, deflate, or zstd
+// Content-Encoding removed. Other encodings are returned as an error.
+func (r *Response) DecodedBody() ([]byte, error) {
+	data, err := client.Decompress(r.Body, client.ContentEncoding(r.Headers))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseBytes {
+		return nil, fmt.Errorf("decoded body exceeds %d bytes", maxResponseBytes)
+	}
+	return data, nil
+}
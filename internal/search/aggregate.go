@@ -0,0 +1,346 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/timing"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// GroupKeys lists the fixed aggregation keys. "header:<name>" additionally
+// groups by the first value of a request or response header.
+var GroupKeys = []string{
+	"host", "method", "status", "status_class", "process", "pid",
+	"ja3", "ja4", "ja4h", "ja4s", "content_type", "http_version", "cluster", "session",
+}
+
+// SortOrders lists the accepted AggregateRequest.SortBy values.
+var SortOrders = []string{"count", "errors", "error_rate", "bytes", "p50", "p90", "p99"}
+
+// ErrInvalidAggregation is returned for unknown group keys or sort orders and
+// for time buckets too narrow for the matched time range.
+var ErrInvalidAggregation = errors.New("invalid aggregation")
+
+const (
+	defaultAggregateLimit = 20
+	maxAggregateLimit     = 500
+	maxTimeBuckets        = 500
+	aggregateExamples     = 3
+
+	// noValue is the group value of entries without the grouped field.
+	noValue = "(none)"
+)
+
+// Aggregate groups the entries matched by a query and filters, computing
+// counts, error rates, byte totals, latency percentiles, and optional time
+// histograms per group.
+func (s *SearchEngine) Aggregate(ctx context.Context, req *types.AggregateRequest) (*types.AggregateResponse, error) {
+	keyFuncs := make([]func(*indexer.EntryMeta) string, len(req.GroupBy))
+	keyNames := make([]string, len(req.GroupBy))
+	for i, key := range req.GroupBy {
+		keyNames[i] = strings.ToLower(strings.TrimSpace(key))
+		f, ok := groupKeyFunc(keyNames[i])
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown group key %q (supported: %s, header:<name>)",
+				ErrInvalidAggregation, key, strings.Join(GroupKeys, ", "))
+		}
+		keyFuncs[i] = f
+	}
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = "count"
+	}
+	if !slices.Contains(SortOrders, sortBy) {
+		return nil, fmt.Errorf("%w: unknown sort_by %q (supported: %s)",
+			ErrInvalidAggregation, req.SortBy, strings.Join(SortOrders, ", "))
+	}
+	if req.BucketMs < 0 {
+		return nil, fmt.Errorf("%w: bucket_ms must not be negative", ErrInvalidAggregation)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAggregateLimit
+	}
+	limit = min(limit, maxAggregateLimit)
+
+	searchReq := &types.SearchRequest{
+		SessionID:  req.SessionID,
+		SessionIDs: req.SessionIDs,
+		Query:      req.Query,
+		Filters:    req.Filters,
+	}
+	sessions, err := s.refresh(ctx, searchReq)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := s.match(searchReq, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []*indexer.EntryMeta
+	minTs, maxTs := int64(math.MaxInt64), int64(math.MinInt64)
+	iter := matched.bitmap.Iterator()
+	for iter.HasNext() {
+		if meta := s.indexer.GetMeta(iter.Next()); meta != nil {
+			metas = append(metas, meta)
+			minTs, maxTs = min(minTs, meta.TsMs), max(maxTs, meta.TsMs)
+		}
+	}
+	if req.BucketMs > 0 && len(metas) > 0 {
+		if n := maxTs/req.BucketMs - minTs/req.BucketMs + 1; n > maxTimeBuckets {
+			return nil, fmt.Errorf("%w: bucket_ms %d splits the matched %d ms into %d buckets (max %d)",
+				ErrInvalidAggregation, req.BucketMs, maxTs-minTs, n, maxTimeBuckets)
+		}
+	}
+
+	total := newGroupAcc(nil, req.BucketMs)
+	groups := make(map[string]*groupAcc)
+	for _, meta := range metas {
+		total.add(meta)
+		if len(keyFuncs) == 0 {
+			continue
+		}
+		values := make([]string, len(keyFuncs))
+		for i, f := range keyFuncs {
+			if values[i] = f(meta); values[i] == "" {
+				values[i] = noValue
+			}
+		}
+		id := strings.Join(values, "\x00")
+		acc, ok := groups[id]
+		if !ok {
+			key := make(map[string]string, len(keyNames))
+			for i, name := range keyNames {
+				key[name] = values[i]
+			}
+			acc = newGroupAcc(key, req.BucketMs)
+			acc.id = id
+			groups[id] = acc
+		}
+		acc.add(meta)
+	}
+
+	ranked := make([]*groupAcc, 0, len(groups))
+	results := make(map[*groupAcc]types.AggregateGroup, len(groups))
+	for _, acc := range groups {
+		ranked = append(ranked, acc)
+		results[acc] = acc.result()
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		gi, gj := results[ranked[i]], results[ranked[j]]
+		if vi, vj := sortValue(&gi, sortBy), sortValue(&gj, sortBy); vi != vj {
+			return vi > vj
+		}
+		return ranked[i].id < ranked[j].id
+	})
+	out := make([]types.AggregateGroup, 0, min(limit, len(ranked)))
+	for _, acc := range ranked[:min(limit, len(ranked))] {
+		out = append(out, results[acc])
+	}
+
+	totalResult := total.result()
+	if req.BucketMs > 0 && len(metas) > 0 {
+		totalResult.Buckets = fillBuckets(totalResult.Buckets, minTs, maxTs, req.BucketMs)
+	}
+	totalResult.ExampleEntryIDs = nil
+
+	return &types.AggregateResponse{
+		Groups:     out,
+		Total:      totalResult,
+		GroupCount: len(groups),
+		SyncedAtMs: s.indexer.OldestSyncTime(sessions).UnixMilli(),
+	}, nil
+}
+
+// groupKeyFunc returns the function extracting a group key's value from an
+// entry, or false if the key is unknown.
+func groupKeyFunc(key string) (func(*indexer.EntryMeta) string, bool) {
+	if name, ok := strings.CutPrefix(key, "header:"); ok && name != "" {
+		return func(m *indexer.EntryMeta) string {
+			for _, hv := range m.HeaderValues {
+				if hv.Name == name {
+					return hv.Value
+				}
+			}
+			return ""
+		}, true
+	}
+
+	switch key {
+	case "host":
+		return func(m *indexer.EntryMeta) string { return m.Host }, true
+	case "method":
+		return func(m *indexer.EntryMeta) string { return m.Method }, true
+	case "status":
+		return func(m *indexer.EntryMeta) string {
+			if m.Status == 0 {
+				return ""
+			}
+			return strconv.Itoa(m.Status)
+		}, true
+	case "status_class":
+		return func(m *indexer.EntryMeta) string {
+			if m.Status == 0 {
+				return ""
+			}
+			return catalog.StatusBucket(m.Status)
+		}, true
+	case "process":
+		return func(m *indexer.EntryMeta) string { return m.ProcessName }, true
+	case "pid":
+		return func(m *indexer.EntryMeta) string {
+			if m.PID == 0 {
+				return ""
+			}
+			return strconv.Itoa(m.PID)
+		}, true
+	case "ja3":
+		return func(m *indexer.EntryMeta) string { return m.JA3 }, true
+	case "ja4":
+		return func(m *indexer.EntryMeta) string { return m.JA4 }, true
+	case "ja4h":
+		return func(m *indexer.EntryMeta) string { return m.JA4H }, true
+	case "ja4s":
+		return func(m *indexer.EntryMeta) string { return m.JA4S }, true
+	case "content_type":
+		return func(m *indexer.EntryMeta) string { return m.RespContentType }, true
+	case "http_version":
+		return func(m *indexer.EntryMeta) string { return m.HTTPVersion }, true
+	case "cluster":
+		return func(m *indexer.EntryMeta) string { return catalog.ClusterID(m.Host, m.Method, m.Path) }, true
+	case "session":
+		return func(m *indexer.EntryMeta) string { return m.SessionID }, true
+	default:
+		return nil, false
+	}
+}
+
+// groupAcc accumulates the statistics of one group.
+type groupAcc struct {
+	id        string // Joined key values, for stable ordering
+	key       map[string]string
+	bucketMs  int64
+	count     int
+	errors    int
+	reqBytes  int64
+	respBytes int64
+	durations []int64
+	buckets   map[int64]*types.TimeBucket
+	examples  []string
+}
+
+func newGroupAcc(key map[string]string, bucketMs int64) *groupAcc {
+	acc := &groupAcc{key: key, bucketMs: bucketMs}
+	if bucketMs > 0 {
+		acc.buckets = make(map[int64]*types.TimeBucket)
+	}
+	return acc
+}
+
+func (a *groupAcc) add(m *indexer.EntryMeta) {
+	failed := isErrorStatus(m.Status)
+	a.count++
+	if failed {
+		a.errors++
+	}
+	a.reqBytes += int64(m.ReqBodyBytes)
+	a.respBytes += int64(m.RespBodyBytes)
+	if m.DurationMs >= 0 {
+		a.durations = append(a.durations, m.DurationMs)
+	}
+	if a.bucketMs > 0 {
+		start := m.TsMs / a.bucketMs * a.bucketMs
+		b, ok := a.buckets[start]
+		if !ok {
+			b = &types.TimeBucket{StartMs: start}
+			a.buckets[start] = b
+		}
+		b.Count++
+		if failed {
+			b.Errors++
+		}
+	}
+	if len(a.examples) < aggregateExamples {
+		a.examples = append(a.examples, m.EntryID)
+	}
+}
+
+func (a *groupAcc) result() types.AggregateGroup {
+	g := types.AggregateGroup{
+		Key:             a.key,
+		Count:           a.count,
+		Errors:          a.errors,
+		ReqBytes:        a.reqBytes,
+		RespBytes:       a.respBytes,
+		Latency:         timing.Latency(a.durations),
+		ExampleEntryIDs: a.examples,
+	}
+	if a.count > 0 {
+		g.ErrorRate = float64(a.errors) / float64(a.count)
+	}
+	for _, b := range a.buckets {
+		g.Buckets = append(g.Buckets, *b)
+	}
+	sort.Slice(g.Buckets, func(i, j int) bool { return g.Buckets[i].StartMs < g.Buckets[j].StartMs })
+	return g
+}
+
+// isErrorStatus reports whether a status counts as an error: a 4xx or 5xx,
+// or no response at all.
+func isErrorStatus(status int) bool {
+	return status == 0 || status >= 400
+}
+
+// fillBuckets returns buckets covering minTs..maxTs contiguously, with empty
+// buckets where no entry started.
+func fillBuckets(sparse []types.TimeBucket, minTs, maxTs, bucketMs int64) []types.TimeBucket {
+	byStart := make(map[int64]types.TimeBucket, len(sparse))
+	for _, b := range sparse {
+		byStart[b.StartMs] = b
+	}
+	var dense []types.TimeBucket
+	for start := minTs / bucketMs * bucketMs; start <= maxTs; start += bucketMs {
+		b, ok := byStart[start]
+		if !ok {
+			b = types.TimeBucket{StartMs: start}
+		}
+		dense = append(dense, b)
+	}
+	return dense
+}
+
+// sortValue returns the metric groups are ordered by, descending.
+func sortValue(g *types.AggregateGroup, sortBy string) float64 {
+	switch sortBy {
+	case "errors":
+		return float64(g.Errors)
+	case "error_rate":
+		return g.ErrorRate
+	case "bytes":
+		return float64(g.ReqBytes + g.RespBytes)
+	case "p50", "p90", "p99":
+		if g.Latency == nil {
+			return -1
+		}
+		switch sortBy {
+		case "p50":
+			return float64(g.Latency.P50)
+		case "p90":
+			return float64(g.Latency.P90)
+		default:
+			return float64(g.Latency.P99)
+		}
+	default:
+		return float64(g.Count)
+	}
+}
@@ -0,0 +1,31 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func TestFillBuckets(t *testing.T) {
+	sparse := []types.TimeBucket{{StartMs: 1000, Count: 2}, {StartMs: 4000, Count: 1, Errors: 1}}
+	dense := fillBuckets(sparse, 1500, 4200, 1000)
+	assert.Equal(t, []types.TimeBucket{
+		{StartMs: 1000, Count: 2},
+		{StartMs: 2000},
+		{StartMs: 3000},
+		{StartMs: 4000, Count: 1, Errors: 1},
+	}, dense)
+}
+
+func TestGroupKeyFunc(t *testing.T) {
+	for _, key := range append(GroupKeys, "header:x-request-id") {
+		_, ok := groupKeyFunc(key)
+		assert.True(t, ok, key)
+	}
+	for _, key := range []string{"", "color", "header:"} {
+		_, ok := groupKeyFunc(key)
+		assert.False(t, ok, key)
+	}
+}
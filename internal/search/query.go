@@ -0,0 +1,643 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/RoaringBitmap/roaring/v2"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+)
+
+// The query language combines field-scoped terms and free text with boolean
+// operators:
+//
+//	host:*.example.com AND (status:>=500 OR status:429) AND NOT method:OPTIONS
+//
+// Operators are AND, OR, and NOT (uppercase), with a leading '-' as shorthand
+// for NOT and parentheses for grouping. Adjacent terms are ANDed, and NOT binds
+// tighter than AND, which binds tighter than OR. Double quotes keep spaces,
+// parentheses, and operator words inside a value.
+//
+// A term is either free text, matched like the plain query always was (every
+// token must appear in the URL, headers, or an indexed body), or field:value.
+// Values containing * or ? are globs over the whole value. Plain values match
+// exactly for indexed fields and as substrings for path, url, content_type,
+// and header values. Numeric fields take N, >N, >=N, <N, <=N, N..M, N-M, or a
+// class like 5xx; sizes accept k and m suffixes. A field name that is not
+// recognized leaves the whole term as free text.
+
+// QueryError reports a malformed search query.
+type QueryError struct {
+	Pos int // Byte offset in the query
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at offset %d: %s", e.Pos, e.Msg)
+}
+
+// queryFields maps field names and aliases to canonical fields.
+var queryFields = map[string]string{
+	"host":         "host",
+	"method":       "method",
+	"status":       "status",
+	"pid":          "pid",
+	"process":      "process",
+	"process_name": "process",
+	"version":      "version",
+	"http_version": "version",
+	"header":       "header",
+	"ja3":          "ja3",
+	"ja4":          "ja4",
+	"ja4h":         "ja4h",
+	"ja4s":         "ja4s",
+	"ja4x":         "ja4x",
+	"tls":          "tls",
+	"path":         "path",
+	"url":          "url",
+	"body":         "body",
+	"ct":           "content_type",
+	"content_type": "content_type",
+	"ts":           "ts",
+	"req_bytes":    "req_bytes",
+	"resp_bytes":   "resp_bytes",
+	"text":         "text",
+}
+
+// numericFields take range values.
+var numericFields = map[string]bool{
+	"status": true, "pid": true, "ts": true, "req_bytes": true, "resp_bytes": true,
+}
+
+type queryOp int
+
+const (
+	opTerm queryOp = iota
+	opAnd
+	opOr
+	opNot
+)
+
+// queryNode is one node of a parsed query.
+type queryNode struct {
+	op       queryOp
+	children []*queryNode // Operands of AND/OR, the negated node of NOT
+	field    string       // Canonical field of a term; "text" for free text
+	value    string
+	lo, hi   int64 // Inclusive bounds of a numeric term
+}
+
+// parsedQuery is a parsed search query. Evaluation records how many cached
+// bodies a body: term could and could not search.
+type parsedQuery struct {
+	root            *queryNode
+	bodyCacheHits   int
+	bodyCacheMisses int
+}
+
+// parseQuery parses a search query. An empty query yields nil.
+func parseQuery(q string) (*parsedQuery, error) {
+	toks, err := lexQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, nil
+	}
+	p := &queryParser{toks: toks, end: len(q)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		if t.kind == tokRParen {
+			return nil, &QueryError{Pos: t.pos, Msg: "unmatched ')'"}
+		}
+		return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return &parsedQuery{root: root}, nil
+}
+
+// textTokens returns the tokens of free-text terms that are not negated, for
+// scoring and highlights.
+func (q *parsedQuery) textTokens() []string {
+	var tokens []string
+	var walk func(n *queryNode)
+	walk = func(n *queryNode) {
+		switch n.op {
+		case opTerm:
+			if n.field == "text" {
+				tokens = append(tokens, indexer.Tokenize(n.value)...)
+			}
+		case opAnd, opOr:
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+	}
+	walk(q.root)
+	return tokens
+}
+
+// scoringTokens returns the free-text tokens of a query for scoring. A query
+// that does not parse is tokenized as plain text.
+func scoringTokens(query string) []string {
+	q, err := parseQuery(query)
+	if err != nil {
+		return indexer.Tokenize(query)
+	}
+	if q == nil {
+		return nil
+	}
+	return q.textTokens()
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind   tokenKind
+	text   string // Word with quotes removed
+	pos    int
+	quoted bool // Some part of the word was quoted
+	sep    int  // Offset in text of the first ':' before any quote, or -1
+	neg    bool // The word had a leading '-', removed from text
+}
+
+func lexQuery(q string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(q); {
+		switch c := q[i]; {
+		case isQuerySpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, queryToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, queryToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		default:
+			tok := queryToken{kind: tokWord, pos: i, sep: -1}
+			var sb strings.Builder
+			for i < len(q) && !isQuerySpace(q[i]) && q[i] != '(' && q[i] != ')' {
+				if q[i] != '"' {
+					if q[i] == ':' && tok.sep < 0 && !tok.quoted {
+						tok.sep = sb.Len()
+					}
+					sb.WriteByte(q[i])
+					i++
+					continue
+				}
+				open := i
+				tok.quoted = true
+				for i++; ; i++ {
+					if i >= len(q) {
+						return nil, &QueryError{Pos: open, Msg: "unterminated quote"}
+					}
+					if q[i] == '\\' && i+1 < len(q) {
+						i++
+					} else if q[i] == '"' {
+						i++
+						break
+					}
+					sb.WriteByte(q[i])
+				}
+			}
+			tok.text = sb.String()
+			if q[tok.pos] == '-' && len(tok.text) > 1 {
+				tok.text, tok.neg = tok.text[1:], true
+				tok.pos++
+				tok.sep--
+			}
+			toks = append(toks, tok)
+		}
+	}
+	return toks, nil
+}
+
+func isQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// queryParser is a recursive-descent parser over lexed tokens.
+type queryParser struct {
+	toks []queryToken
+	i    int
+	end  int // Query length, the position reported for errors at the end
+}
+
+func (p *queryParser) peek() *queryToken {
+	if p.i >= len(p.toks) {
+		return nil
+	}
+	return &p.toks[p.i]
+}
+
+// isOperator reports whether t is the unquoted operator word name.
+func isOperator(t *queryToken, name string) bool {
+	return t != nil && t.kind == tokWord && !t.quoted && !t.neg && t.text == name
+}
+
+func (p *queryParser) parseOr() (*queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	node := left
+	for isOperator(p.peek(), "OR") {
+		p.i++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if node.op != opOr || node == left {
+			node = &queryNode{op: opOr, children: []*queryNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (p *queryParser) parseAnd() (*queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	node := left
+	for {
+		t := p.peek()
+		if t == nil || t.kind == tokRParen || isOperator(t, "OR") {
+			return node, nil
+		}
+		if isOperator(t, "AND") {
+			p.i++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if node == left {
+			node = &queryNode{op: opAnd, children: []*queryNode{left}}
+		}
+		node.children = append(node.children, right)
+	}
+}
+
+func (p *queryParser) parseUnary() (*queryNode, error) {
+	t := p.peek()
+	switch {
+	case t == nil:
+		return nil, &QueryError{Pos: p.end, Msg: "expected a term at end of query"}
+	case isOperator(t, "NOT"):
+		p.i++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{op: opNot, children: []*queryNode{child}}, nil
+	case isOperator(t, "AND"), isOperator(t, "OR"):
+		return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("expected a term before %s", t.text)}
+	case t.kind == tokRParen:
+		return nil, &QueryError{Pos: t.pos, Msg: "unmatched ')'"}
+	case t.kind == tokLParen:
+		p.i++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != tokRParen {
+			return nil, &QueryError{Pos: t.pos, Msg: "missing ')'"}
+		}
+		p.i++
+		return inner, nil
+	case t.kind == tokWord && !t.quoted && t.text == "-" &&
+		p.i+1 < len(p.toks) && p.toks[p.i+1].kind == tokLParen:
+		// "-(" negates a group
+		p.i++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{op: opNot, children: []*queryNode{child}}, nil
+	}
+	p.i++
+	term, err := newTerm(t)
+	if err != nil || !t.neg {
+		return term, err
+	}
+	return &queryNode{op: opNot, children: []*queryNode{term}}, nil
+}
+
+// newTerm builds a term node from a word, validating field values.
+func newTerm(t *queryToken) (*queryNode, error) {
+	if t.sep > 0 {
+		if field, ok := queryFields[strings.ToLower(t.text[:t.sep])]; ok {
+			value := t.text[t.sep+1:]
+			if value == "" {
+				return nil, &QueryError{Pos: t.pos, Msg: fmt.Sprintf("missing value for %s:", t.text[:t.sep])}
+			}
+			node := &queryNode{op: opTerm, field: field, value: value}
+			if numericFields[field] {
+				lo, hi, ok := parseRange(value)
+				if !ok {
+					return nil, &QueryError{Pos: t.pos + t.sep + 1, Msg: fmt.Sprintf("invalid number or range %q for %s", value, field)}
+				}
+				node.lo, node.hi = lo, hi
+			}
+			return node, nil
+		}
+	}
+	return &queryNode{op: opTerm, field: "text", value: t.text}, nil
+}
+
+// parseRange parses a numeric value into inclusive bounds.
+func parseRange(v string) (lo, hi int64, ok bool) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, found := strings.CutPrefix(v, op); found {
+			n, ok := parseNumber(rest)
+			if !ok {
+				return 0, 0, false
+			}
+			switch op {
+			case ">=":
+				return n, math.MaxInt64, true
+			case "<=":
+				return math.MinInt64, n, true
+			case ">":
+				return n + 1, math.MaxInt64, true
+			case "<":
+				return math.MinInt64, n - 1, true
+			default:
+				return n, n, true
+			}
+		}
+	}
+
+	if a, b, found := strings.Cut(v, ".."); found {
+		lo, hi = math.MinInt64, math.MaxInt64
+		if a != "" {
+			if lo, ok = parseNumber(a); !ok {
+				return 0, 0, false
+			}
+		}
+		if b != "" {
+			if hi, ok = parseNumber(b); !ok {
+				return 0, 0, false
+			}
+		}
+		return lo, hi, a != "" || b != ""
+	}
+
+	// Classes such as 5xx or 40x
+	if digits := strings.TrimRight(strings.ToLower(v), "x"); digits != v && digits != "" {
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		scale := int64(math.Pow10(len(v) - len(digits)))
+		return n * scale, n*scale + scale - 1, true
+	}
+
+	if a, b, found := strings.Cut(v, "-"); found && a != "" {
+		lo, okLo := parseNumber(a)
+		hi, okHi := parseNumber(b)
+		return lo, hi, okLo && okHi
+	}
+
+	n, ok := parseNumber(v)
+	return n, n, ok
+}
+
+// parseNumber parses an integer with an optional k/kb or m/mb (binary) suffix.
+func parseNumber(s string) (int64, bool) {
+	lower := strings.ToLower(s)
+	mult := int64(1)
+	for _, suffix := range []struct {
+		s string
+		m int64
+	}{{"kb", 1 << 10}, {"mb", 1 << 20}, {"k", 1 << 10}, {"m", 1 << 20}} {
+		if rest, found := strings.CutSuffix(lower, suffix.s); found {
+			lower, mult = rest, suffix.m
+			break
+		}
+	}
+	n, err := strconv.ParseInt(lower, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * mult, true
+}
+
+// evalQuery returns the documents in within that match n.
+func (s *SearchEngine) evalQuery(q *parsedQuery, n *queryNode, within *roaring.Bitmap) *roaring.Bitmap {
+	switch n.op {
+	case opAnd:
+		result := within
+		for _, c := range n.children {
+			if result.IsEmpty() {
+				break
+			}
+			result = s.evalQuery(q, c, result)
+		}
+		return result
+	case opOr:
+		result := roaring.New()
+		for _, c := range n.children {
+			result.Or(s.evalQuery(q, c, within))
+		}
+		return result
+	case opNot:
+		return roaring.AndNot(within, s.evalQuery(q, n.children[0], within))
+	default:
+		return s.evalTerm(q, n, within)
+	}
+}
+
+// evalTerm returns the documents in within that match a term.
+func (s *SearchEngine) evalTerm(q *parsedQuery, t *queryNode, within *roaring.Bitmap) *roaring.Bitmap {
+	inRange := func(v int64) bool { return v >= t.lo && v <= t.hi }
+
+	var bm *roaring.Bitmap
+	switch t.field {
+	case "text":
+		return s.textBitmap(t.value, within)
+	case "host":
+		match := valueMatcher(t.value, false)
+		// "*.example.com" also matches the apex, as the host filter does
+		apex, subdomains := strings.CutPrefix(strings.ToLower(t.value), "*.")
+		bm = s.indexer.MatchBitmap(indexer.FieldHost, func(k string) bool {
+			return match(k) || (subdomains && k == apex)
+		})
+	case "method":
+		bm = s.indexer.MatchBitmap(indexer.FieldMethod, valueMatcher(t.value, false))
+	case "process":
+		bm = s.indexer.MatchBitmap(indexer.FieldProcessName, valueMatcher(t.value, false))
+	case "version":
+		bm = s.indexer.MatchBitmap(indexer.FieldHTTPVersion, valueMatcher(t.value, false))
+	case "ja3":
+		bm = s.indexer.MatchBitmap(indexer.FieldJA3, valueMatcher(t.value, false))
+	case "ja4":
+		bm = s.indexer.MatchBitmap(indexer.FieldJA4, valueMatcher(t.value, false))
+	case "ja4h":
+		bm = s.indexer.MatchBitmap(indexer.FieldJA4H, valueMatcher(t.value, false))
+	case "ja4s":
+		bm = s.indexer.MatchBitmap(indexer.FieldJA4S, valueMatcher(t.value, false))
+	case "ja4x":
+		bm = s.indexer.MatchBitmap(indexer.FieldJA4X, valueMatcher(t.value, false))
+	case "tls":
+		bm = s.indexer.MatchBitmap(indexer.FieldTLSConnection, valueMatcher(t.value, false))
+	case "status":
+		bm = s.indexer.MatchIntBitmap(indexer.FieldStatus, func(k int) bool { return inRange(int64(k)) })
+	case "pid":
+		bm = s.indexer.MatchIntBitmap(indexer.FieldPID, func(k int) bool { return inRange(int64(k)) })
+	case "header":
+		name, value, hasValue := strings.Cut(t.value, "=")
+		matchName := valueMatcher(name, false)
+		if !hasValue {
+			bm = s.indexer.MatchBitmap(indexer.FieldHeaderName, matchName)
+			break
+		}
+		matchValue := valueMatcher(value, true)
+		bm = s.indexer.MatchHeaderValueBitmap(func(n, v string) bool { return matchName(n) && matchValue(v) })
+	case "path":
+		match := valueMatcher(t.value, true)
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return match(m.Path) })
+	case "url":
+		match := valueMatcher(t.value, true)
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return match(m.URL) })
+	case "content_type":
+		match := valueMatcher(t.value, true)
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return match(m.RespContentType) })
+	case "ts":
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return inRange(m.TsMs) })
+	case "req_bytes":
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return inRange(int64(m.ReqBodyBytes)) })
+	case "resp_bytes":
+		return s.scanMeta(within, func(m *indexer.EntryMeta) bool { return inRange(int64(m.RespBodyBytes)) })
+	case "body":
+		return s.bodyBitmap(q, t.value, within)
+	}
+
+	if bm == nil {
+		return roaring.New()
+	}
+	return roaring.And(within, bm)
+}
+
+// textBitmap matches free text: every token must appear in the URL, header,
+// or (when indexed) body token index.
+func (s *SearchEngine) textBitmap(text string, within *roaring.Bitmap) *roaring.Bitmap {
+	result := within
+	for _, token := range indexer.Tokenize(text) {
+		union := roaring.New()
+
+		if bm := s.indexer.GetBitmapForToken(token); bm != nil {
+			union.Or(bm)
+		}
+		if bm := s.indexer.GetBitmapForHeaderToken(token); bm != nil {
+			union.Or(bm)
+		}
+		if s.indexer.BodyIndexEnabled() {
+			if bm := s.indexer.GetBitmapForBodyToken(token); bm != nil {
+				union.Or(bm)
+			}
+		}
+
+		result = roaring.And(result, union)
+	}
+	return result
+}
+
+// bodyBitmap matches body text through the body token index when enabled,
+// otherwise by substring over cached bodies.
+func (s *SearchEngine) bodyBitmap(q *parsedQuery, text string, within *roaring.Bitmap) *roaring.Bitmap {
+	if s.indexer.BodyIndexEnabled() {
+		result := within
+		for _, token := range indexer.Tokenize(text) {
+			bm := s.indexer.GetBitmapForBodyToken(token)
+			if bm == nil {
+				return roaring.New()
+			}
+			result = roaring.And(result, bm)
+		}
+		return result
+	}
+
+	needle := strings.ToLower(text)
+	return s.scanMeta(within, func(m *indexer.EntryMeta) bool {
+		if s.cache == nil {
+			q.bodyCacheMisses++
+			return false
+		}
+		entry, ok := s.cache.Get(m.EntryID)
+		if !ok {
+			q.bodyCacheMisses++
+			return false
+		}
+		q.bodyCacheHits++
+		return bodyContainsMatch(entry, needle)
+	})
+}
+
+// scanMeta returns the documents in within whose metadata satisfies match.
+func (s *SearchEngine) scanMeta(within *roaring.Bitmap, match func(*indexer.EntryMeta) bool) *roaring.Bitmap {
+	result := roaring.New()
+	iter := within.Iterator()
+	for iter.HasNext() {
+		docID := iter.Next()
+		if meta := s.indexer.GetMeta(docID); meta != nil && match(meta) {
+			result.Add(docID)
+		}
+	}
+	return result
+}
+
+// valueMatcher returns a case-insensitive matcher for a term value: a glob
+// over the whole string if the value contains * or ?, otherwise an exact or
+// (if substring) substring match.
+func valueMatcher(value string, substring bool) func(string) bool {
+	pattern := strings.ToLower(value)
+	switch {
+	case strings.ContainsAny(pattern, "*?"):
+		return func(s string) bool { return GlobMatch(pattern, strings.ToLower(s)) }
+	case substring:
+		return func(s string) bool { return strings.Contains(strings.ToLower(s), pattern) }
+	default:
+		return func(s string) bool { return strings.EqualFold(s, pattern) }
+	}
+}
+
+// GlobMatch reports whether s matches pattern, where * matches any run of
+// bytes and ? matches one byte.
+func GlobMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,228 @@
+package search
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// queryFixture indexes a small mixed session for query tests.
+func queryFixture(t *testing.T) *testFixture {
+	t.Helper()
+	f := newFixture(false)
+
+	withKey := client.Headers{{"X-Api-Key", "k-123"}, {"Content-Type", "application/json"}}
+
+	e1 := makeEntryWithHeaders("ok", "https://api.example.com/v1/users", "GET", 200, 1000, withKey)
+	e1.TLS = client.TLSInfo{JA4: &client.JA4Fingerprint{Hashed: "t13d1516h2_8daaf6152771_e5627efa2ab1"}}
+	e2 := makeEntryWithHeaders("fail", "https://api.example.com/v1/orders", "POST", 502, 2000, withKey)
+	e2.TLS = client.TLSInfo{JA4: &client.JA4Fingerprint{Hashed: "t13d1516h2_8daaf6152771_e5627efa2ab1"}}
+	e3 := makeEntryWithHeaders("preflight", "https://api.example.com/v1/orders", "OPTIONS", 503, 3000, withKey)
+	e4 := makeEntryWithHeaders("limited", "https://example.com/login", "POST", 429, 4000, withKey)
+	e4.TLS = client.TLSInfo{JA4: &client.JA4Fingerprint{Hashed: "t12d1209h1_c866b44c5a26_7eb3c5a8ab1c"}}
+	e5 := makeEntry("nokey", "https://api.example.com/v1/orders", "GET", 500, 5000)
+	e6 := makeEntry("other", "https://other.org/v1/users", "GET", 500, 6000)
+	e6.Process = &client.ProcessInfo{PID: 4242, Name: strPtr("python3")}
+
+	for _, e := range []*client.SessionEntry{e1, e2, e3, e4, e5, e6} {
+		f.addEntry(e)
+	}
+	return f
+}
+
+// queryEntryIDs runs a query through planFilters and returns the matched entry IDs.
+func queryEntryIDs(t *testing.T, f *testFixture, filters *types.SearchFilters, q string) []string {
+	t.Helper()
+	var ids []string
+	for _, docID := range f.engine.planFilters(nil, filters, mustParseQuery(t, q)).ToArray() {
+		ids = append(ids, f.idx.GetMeta(docID).EntryID)
+	}
+	return ids
+}
+
+func TestQuery_BooleanExample(t *testing.T) {
+	f := queryFixture(t)
+
+	got := queryEntryIDs(t, f, nil,
+		`host:*.example.com AND (status:>=500 OR status:429) AND NOT method:OPTIONS AND header:x-api-key AND ja4:t13d*`)
+	assert.Equal(t, []string{"fail"}, got)
+
+	// Without the fingerprint constraint the 429 on the apex domain matches too
+	got = queryEntryIDs(t, f, nil,
+		`host:*.example.com (status:>=500 OR status:429) -method:OPTIONS header:x-api-key`)
+	assert.Equal(t, []string{"fail", "limited"}, got)
+}
+
+func TestQuery_Fields(t *testing.T) {
+	f := queryFixture(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"status:5xx", []string{"fail", "preflight", "nokey", "other"}},
+		{"status:200..299", []string{"ok"}},
+		{"status:500-502", []string{"fail", "nokey", "other"}},
+		{"status:<300", []string{"ok"}},
+		{"method:post", []string{"fail", "limited"}},
+		{"method:P*", []string{"fail", "limited"}},
+		{"host:example.com", []string{"limited"}},
+		{"host:api.*", []string{"ok", "fail", "preflight", "nokey"}},
+		{"pid:>4000", []string{"other"}},
+		{"process:py*", []string{"other"}},
+		{"header:x-api-key=k-1*", []string{"ok", "fail", "preflight", "limited"}},
+		{"header:x-api-key=nope", nil},
+		{"header:content-type=json", []string{"ok", "fail", "preflight", "limited"}},
+		{"path:/v1/orders", []string{"fail", "preflight", "nokey"}},
+		{"url:https://other.org/*", []string{"other"}},
+		{"ts:2000..4000", []string{"fail", "preflight", "limited"}},
+		{"ja4:t12d*", []string{"limited"}},
+		{"NOT ja4:*", []string{"preflight", "nokey", "other"}},
+		{"ja4h:po*", []string{"fail", "limited"}},
+		{"users OR login", []string{"ok", "limited", "other"}},
+		{`text:"v1 users" -host:other.org`, []string{"ok"}},
+		{"-(status:5xx OR method:GET)", []string{"limited"}},
+		{"api:users", []string{"ok"}}, // unknown field stays free text
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, queryEntryIDs(t, f, nil, tt.query))
+		})
+	}
+}
+
+func TestQuery_CombinesWithFilters(t *testing.T) {
+	f := queryFixture(t)
+
+	got := queryEntryIDs(t, f, &types.SearchFilters{Method: "GET"}, "status:5xx")
+	assert.Equal(t, []string{"nokey", "other"}, got)
+}
+
+func TestQuery_Precedence(t *testing.T) {
+	q := mustParseQuery(t, "a OR b AND NOT c")
+	require.Equal(t, opOr, q.root.op)
+	require.Len(t, q.root.children, 2)
+	assert.Equal(t, "a", q.root.children[0].value)
+
+	and := q.root.children[1]
+	require.Equal(t, opAnd, and.op)
+	require.Len(t, and.children, 2)
+	assert.Equal(t, opNot, and.children[1].op)
+	assert.Equal(t, "c", and.children[1].children[0].value)
+}
+
+func TestQuery_Quoting(t *testing.T) {
+	q := mustParseQuery(t, `"AND" header:"x-note=a (b)"`)
+	require.Equal(t, opAnd, q.root.op)
+	assert.Equal(t, "text", q.root.children[0].field)
+	assert.Equal(t, "AND", q.root.children[0].value)
+	assert.Equal(t, "header", q.root.children[1].field)
+	assert.Equal(t, "x-note=a (b)", q.root.children[1].value)
+}
+
+func TestQuery_Errors(t *testing.T) {
+	tests := []string{
+		"(status:500",
+		"status:500)",
+		"status:abc",
+		"status:",
+		"AND users",
+		"users OR",
+		"NOT",
+		`body:"unterminated`,
+		"()",
+	}
+	for _, q := range tests {
+		t.Run(q, func(t *testing.T) {
+			_, err := parseQuery(q)
+			var qe *QueryError
+			assert.ErrorAs(t, err, &qe)
+		})
+	}
+}
+
+func TestQuery_EmptyIsNil(t *testing.T) {
+	q, err := parseQuery("   ")
+	require.NoError(t, err)
+	assert.Nil(t, q)
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in     string
+		lo, hi int64
+	}{
+		{"404", 404, 404},
+		{">=500", 500, math.MaxInt64},
+		{">499", 500, math.MaxInt64},
+		{"<=299", math.MinInt64, 299},
+		{"<300", math.MinInt64, 299},
+		{"400..499", 400, 499},
+		{"1000..", 1000, math.MaxInt64},
+		{"400-499", 400, 499},
+		{"4xx", 400, 499},
+		{"50x", 500, 509},
+		{">1kb", 1025, math.MaxInt64},
+		{"2m", 2 << 20, 2 << 20},
+	}
+	for _, tt := range tests {
+		lo, hi, ok := parseRange(tt.in)
+		require.True(t, ok, tt.in)
+		assert.Equal(t, tt.lo, lo, tt.in)
+		assert.Equal(t, tt.hi, hi, tt.in)
+	}
+
+	for _, bad := range []string{"", "..", "abc", ">=", "1..x", "xx"} {
+		_, _, ok := parseRange(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestScoringTokens(t *testing.T) {
+	assert.Equal(t, []string{"users", "orders"}, scoringTokens("users status:5xx -admin orders"))
+	assert.Equal(t, []string{"status", "abc", "users"}, scoringTokens("status:abc users"))
+	assert.Nil(t, scoringTokens(""))
+}
+
+func TestGlobMatch(t *testing.T) {
+	assert.True(t, GlobMatch("t13d*", "t13d1516h2_abc"))
+	assert.True(t, GlobMatch("*.example.com", "api.example.com"))
+	assert.False(t, GlobMatch("*.example.com", "example.com"))
+	assert.True(t, GlobMatch("a?c", "abc"))
+	assert.True(t, GlobMatch("*a*b*", "xxaxxbxx"))
+	assert.False(t, GlobMatch("a*b", "acbd"))
+	assert.True(t, GlobMatch("*", ""))
+}
+
+func TestQuery_BodyScanWithoutIndex(t *testing.T) {
+	f := newFixture(false)
+	f.addEntry(makeEntryWithBody("e1", "https://a.com/api", "GET", 200, 1000, "application/json", `{"error":"quota exceeded"}`))
+	f.addEntry(makeEntryWithBody("e2", "https://a.com/api", "GET", 200, 2000, "application/json", `{"error":"none"}`))
+
+	q := mustParseQuery(t, `body:"quota exceeded"`)
+	assert.Equal(t, uint64(1), f.engine.planFilters(nil, nil, q).GetCardinality())
+	assert.Equal(t, 2, q.bodyCacheHits)
+	assert.Equal(t, 0, q.bodyCacheMisses)
+
+	// Entries missing from the cache cannot match and count as misses
+	engine := New(f.idx, newTestCache(), &config.Config{})
+	q = mustParseQuery(t, `body:quota`)
+	assert.Equal(t, uint64(0), engine.planFilters(nil, nil, q).GetCardinality())
+	assert.Equal(t, 2, q.bodyCacheMisses)
+}
+
+func TestQuery_BodyIndex(t *testing.T) {
+	f := newFixture(true)
+	f.addEntry(makeEntryWithBody("e1", "https://a.com/api", "GET", 200, 1000, "application/json", `{"product":"widget"}`))
+	f.addEntry(makeEntryWithBody("e2", "https://a.com/widget", "GET", 200, 2000, "application/json", `{"product":"gadget"}`))
+
+	// Free text also matches URLs; body: only bodies
+	assert.Equal(t, uint64(2), f.engine.planFilters(nil, nil, mustParseQuery(t, "widget")).GetCardinality())
+	assert.Equal(t, uint64(1), f.engine.planFilters(nil, nil, mustParseQuery(t, "body:widget")).GetCardinality())
+}
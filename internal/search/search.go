@@ -3,7 +3,6 @@ package search
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -34,7 +33,8 @@ func New(idx *indexer.Indexer, c *cache.EntryCache, cfg *config.Config) *SearchE
 // Search executes a search with auto-refresh if stale.
 func (s *SearchEngine) Search(ctx context.Context, req *types.SearchRequest) (*types.SearchResponse, error) {
 	// Ensure index is fresh
-	if err := s.indexer.RefreshIfStale(ctx, req.SessionID); err != nil {
+	sessions, err := s.refresh(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 
@@ -50,12 +50,11 @@ func (s *SearchEngine) Search(ctx context.Context, req *types.SearchRequest) (*t
 		capped = true
 	}
 
-	// Plan filters to get candidate bitmap
-	candidates := s.planFilters(req.Filters, req.Query)
-
-	// Apply time filters and post-filters (requires scanning metadata)
-	postFilterResult := s.applyPostFilters(candidates, req.Filters)
-	candidates = postFilterResult.bitmap
+	postFilterResult, err := s.match(req, sessions)
+	if err != nil {
+		return nil, err
+	}
+	candidates := postFilterResult.bitmap
 
 	// Get total count hint before pagination
 	totalHint := int(candidates.GetCardinality())
@@ -80,6 +79,15 @@ func (s *SearchEngine) Search(ctx context.Context, req *types.SearchRequest) (*t
 	}
 	paginated := results[start:end]
 
+	// Label results with their session when the search spans several
+	if len(req.SessionIDs) > 0 {
+		for i := range paginated {
+			if meta := s.indexer.GetMetaByEntryID(paginated[i].Summary.EntryID); meta != nil {
+				paginated[i].Summary.SessionID = meta.SessionID
+			}
+		}
+	}
+
 	// Build search scope
 	var scope *types.SearchScope
 	needsScope := req.Query != "" || (req.Filters != nil && (req.Filters.BodyContains != "" || req.Filters.HeaderContains != ""))
@@ -87,13 +95,11 @@ func (s *SearchEngine) Search(ctx context.Context, req *types.SearchRequest) (*t
 		scope = &types.SearchScope{
 			BodyIndexEnabled: s.indexer.BodyIndexEnabled(),
 		}
-		if req.Filters != nil && req.Filters.BodyContains != "" {
-			total := postFilterResult.bodyCacheHits + postFilterResult.bodyCacheMisses
-			if total > 0 {
-				scope.BodySearchCoverage = fmt.Sprintf("partial (%d/%d entries cached)", postFilterResult.bodyCacheHits, total)
-				if postFilterResult.bodyCacheMisses == 0 {
-					scope.BodySearchCoverage = fmt.Sprintf("full (%d entries searched)", total)
-				}
+		total := postFilterResult.bodyCacheHits + postFilterResult.bodyCacheMisses
+		if total > 0 {
+			scope.BodySearchCoverage = fmt.Sprintf("partial (%d/%d entries cached)", postFilterResult.bodyCacheHits, total)
+			if postFilterResult.bodyCacheMisses == 0 {
+				scope.BodySearchCoverage = fmt.Sprintf("full (%d entries searched)", total)
 			}
 		}
 	}
@@ -101,18 +107,76 @@ func (s *SearchEngine) Search(ctx context.Context, req *types.SearchRequest) (*t
 	return &types.SearchResponse{
 		Results:    paginated,
 		TotalHint:  totalHint,
-		SyncedAtMs: s.indexer.LastSyncTime(req.SessionID).UnixMilli(),
+		SyncedAtMs: s.indexer.OldestSyncTime(sessions).UnixMilli(),
 		Scope:      scope,
 		Capped:     capped,
 	}, nil
 }
 
-// planFilters converts SearchFilters to bitmap operations.
-func (s *SearchEngine) planFilters(filters *types.SearchFilters, query string) *roaring.Bitmap {
-	// Start with all documents
-	result := s.indexer.AllDocIDs()
+// refresh resolves the sessions a request covers and refreshes the stale ones.
+func (s *SearchEngine) refresh(ctx context.Context, req *types.SearchRequest) ([]string, error) {
+	sessions, err := s.indexer.ResolveSessions(ctx, req.SessionID, req.SessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.indexer.RefreshSessionsIfStale(ctx, sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// match returns the documents of sessions matching a request's query and
+// filters.
+func (s *SearchEngine) match(req *types.SearchRequest, sessions []string) (postFilterResult, error) {
+	query, err := parseQuery(req.Query)
+	if err != nil {
+		return postFilterResult{}, err
+	}
+
+	// Plan filters to get candidate bitmap
+	candidates := s.planFilters(s.indexer.SessionDocIDs(sessions), req.Filters, query)
+
+	// Apply time filters and post-filters (requires scanning metadata)
+	result := s.applyPostFilters(candidates, req.Filters)
+	if query != nil {
+		result.bodyCacheHits += query.bodyCacheHits
+		result.bodyCacheMisses += query.bodyCacheMisses
+	}
+	return result, nil
+}
+
+// MatchEntries refreshes the index and returns the metadata of every entry
+// matching a request's query and filters, in index order, unscored and
+// unpaginated.
+func (s *SearchEngine) MatchEntries(ctx context.Context, req *types.SearchRequest) ([]*indexer.EntryMeta, error) {
+	sessions, err := s.refresh(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := s.match(req, sessions)
+	if err != nil {
+		return nil, err
+	}
 
-	if filters == nil && query == "" {
+	metas := make([]*indexer.EntryMeta, 0, matched.bitmap.GetCardinality())
+	iter := matched.bitmap.Iterator()
+	for iter.HasNext() {
+		if meta := s.indexer.GetMeta(iter.Next()); meta != nil {
+			metas = append(metas, meta)
+		}
+	}
+	return metas, nil
+}
+
+// planFilters converts SearchFilters and the parsed query to bitmap
+// operations over scope, or over all documents when scope is nil.
+func (s *SearchEngine) planFilters(scope *roaring.Bitmap, filters *types.SearchFilters, query *parsedQuery) *roaring.Bitmap {
+	result := scope
+	if result == nil {
+		result = s.indexer.AllDocIDs()
+	}
+
+	if filters == nil && query == nil {
 		return result
 	}
 
@@ -197,30 +261,38 @@ func (s *SearchEngine) planFilters(filters *types.SearchFilters, query string) *
 				return roaring.New()
 			}
 		}
-	}
-
-	// Apply free text query: OR across URL, header, body token indexes per token, AND across tokens
-	if query != "" {
-		queryTokens := indexer.Tokenize(query)
-		for _, token := range queryTokens {
-			union := roaring.New()
 
-			if bm := s.indexer.GetBitmapForToken(token); bm != nil {
-				union.Or(bm)
-			}
-			if bm := s.indexer.GetBitmapForHeaderToken(token); bm != nil {
-				union.Or(bm)
+		if filters.JA4H != "" {
+			if bm := s.indexer.GetBitmapForJA4H(filters.JA4H); bm != nil {
+				result = roaring.And(result, bm)
+			} else {
+				return roaring.New()
 			}
-			if s.indexer.BodyIndexEnabled() {
-				if bm := s.indexer.GetBitmapForBodyToken(token); bm != nil {
-					union.Or(bm)
-				}
+		}
+
+		if filters.JA4S != "" {
+			if bm := s.indexer.GetBitmapForJA4S(filters.JA4S); bm != nil {
+				result = roaring.And(result, bm)
+			} else {
+				return roaring.New()
 			}
+		}
 
-			result = roaring.And(result, union)
+		if filters.JA4X != "" {
+			if bm := s.indexer.GetBitmapForJA4X(filters.JA4X); bm != nil {
+				result = roaring.And(result, bm)
+			} else {
+				return roaring.New()
+			}
 		}
 	}
 
+	// Apply the query within the filtered set. Free text is ORed across URL,
+	// header, and body token indexes per token and ANDed across tokens.
+	if query != nil {
+		result = s.evalQuery(query, query.root, result)
+	}
+
 	// PathContains, URLContains, HeaderContains, BodyContains require post-filtering
 
 	return result
@@ -339,7 +411,7 @@ func (s *SearchEngine) applyPostFilters(candidates *roaring.Bitmap, filters *typ
 func bodyContainsMatch(entry *client.SessionEntry, needle string) bool {
 	// Check request body
 	if entry.Request.Body != nil && *entry.Request.Body != "" {
-		if decoded, err := base64.StdEncoding.DecodeString(*entry.Request.Body); err == nil {
+		if decoded, err := entry.Request.DecodedBody(); err == nil {
 			if strings.Contains(strings.ToLower(string(decoded)), needle) {
 				return true
 			}
@@ -348,7 +420,7 @@ func bodyContainsMatch(entry *client.SessionEntry, needle string) bool {
 
 	// Check response body
 	if entry.Response != nil && entry.Response.Body != nil && *entry.Response.Body != "" {
-		if decoded, err := base64.StdEncoding.DecodeString(*entry.Response.Body); err == nil {
+		if decoded, err := entry.Response.DecodedBody(); err == nil {
 			if strings.Contains(strings.ToLower(string(decoded)), needle) {
 				return true
 			}
@@ -362,11 +434,8 @@ func bodyContainsMatch(entry *client.SessionEntry, needle string) bool {
 func (s *SearchEngine) scoreResults(docIDs []uint32, req *types.SearchRequest) []types.SearchResult {
 	results := make([]types.SearchResult, 0, len(docIDs))
 
-	// Parse query tokens for scoring
-	var queryTokens []string
-	if req.Query != "" {
-		queryTokens = indexer.Tokenize(req.Query)
-	}
+	// Free-text query tokens for scoring; field terms only filter
+	queryTokens := scoringTokens(req.Query)
 
 	// Find time range for recency scoring
 	var minTs, maxTs int64
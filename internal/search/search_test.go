@@ -50,6 +50,13 @@ func (f *testFixture) addEntry(e *client.SessionEntry) uint32 {
 	return f.idx.Index(e)
 }
 
+func mustParseQuery(t *testing.T, q string) *parsedQuery {
+	t.Helper()
+	parsed, err := parseQuery(q)
+	require.NoError(t, err)
+	return parsed
+}
+
 func makeEntry(id, url, method string, status int, tsMs int64) *client.SessionEntry {
 	return &client.SessionEntry{
 		ID:          id,
@@ -93,7 +100,7 @@ func TestPlanFilters_NoFilters(t *testing.T) {
 	f.addEntry(makeEntry("e1", "https://a.com/p1", "GET", 200, 1000))
 	f.addEntry(makeEntry("e2", "https://b.com/p2", "POST", 201, 2000))
 
-	result := f.engine.planFilters(nil, "")
+	result := f.engine.planFilters(nil, nil, nil)
 	assert.Equal(t, uint64(2), result.GetCardinality())
 }
 
@@ -103,7 +110,7 @@ func TestPlanFilters_HostFilter(t *testing.T) {
 	f.addEntry(makeEntry("e2", "https://api.example.com/b", "GET", 200, 2000))
 	f.addEntry(makeEntry("e3", "https://other.com/c", "GET", 200, 3000))
 
-	result := f.engine.planFilters(&types.SearchFilters{Host: "api.example.com"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{Host: "api.example.com"}, nil)
 	assert.Equal(t, uint64(2), result.GetCardinality())
 }
 
@@ -113,7 +120,7 @@ func TestPlanFilters_HostFilter_Wildcard(t *testing.T) {
 	f.addEntry(makeEntry("e2", "https://api.example.com/b", "GET", 200, 2000))
 	f.addEntry(makeEntry("e3", "https://other.com/c", "GET", 200, 3000))
 
-	result := f.engine.planFilters(&types.SearchFilters{Host: "*.example.com"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{Host: "*.example.com"}, nil)
 	assert.Equal(t, uint64(2), result.GetCardinality())
 }
 
@@ -121,7 +128,7 @@ func TestPlanFilters_HostFilter_NoMatch(t *testing.T) {
 	f := newFixture(false)
 	f.addEntry(makeEntry("e1", "https://a.com/", "GET", 200, 1000))
 
-	result := f.engine.planFilters(&types.SearchFilters{Host: "nonexistent.com"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{Host: "nonexistent.com"}, nil)
 	assert.Equal(t, uint64(0), result.GetCardinality())
 }
 
@@ -131,7 +138,7 @@ func TestPlanFilters_MethodFilter(t *testing.T) {
 	f.addEntry(makeEntry("e2", "https://a.com/", "POST", 201, 2000))
 	f.addEntry(makeEntry("e3", "https://a.com/", "GET", 200, 3000))
 
-	result := f.engine.planFilters(&types.SearchFilters{Method: "GET"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{Method: "GET"}, nil)
 	assert.Equal(t, uint64(2), result.GetCardinality())
 }
 
@@ -140,7 +147,7 @@ func TestPlanFilters_StatusFilter(t *testing.T) {
 	f.addEntry(makeEntry("e1", "https://a.com/", "GET", 200, 1000))
 	f.addEntry(makeEntry("e2", "https://a.com/", "GET", 404, 2000))
 
-	result := f.engine.planFilters(&types.SearchFilters{Status: 404}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{Status: 404}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -151,10 +158,10 @@ func TestPlanFilters_CombinedFilters(t *testing.T) {
 	f.addEntry(makeEntry("e3", "https://other.com/", "GET", 200, 3000))
 
 	// Host AND Method filter
-	result := f.engine.planFilters(&types.SearchFilters{
+	result := f.engine.planFilters(nil, &types.SearchFilters{
 		Host:   "api.example.com",
 		Method: "GET",
-	}, "")
+	}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -169,7 +176,7 @@ func TestPlanFilters_ProcessNameFilter(t *testing.T) {
 	e2.Process = &client.ProcessInfo{PID: 2, Name: strPtr("python")}
 	f.addEntry(e2)
 
-	result := f.engine.planFilters(&types.SearchFilters{ProcessName: "Chrome"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{ProcessName: "Chrome"}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -184,7 +191,7 @@ func TestPlanFilters_PIDFilter(t *testing.T) {
 	e2.Process = &client.ProcessInfo{PID: 5678}
 	f.addEntry(e2)
 
-	result := f.engine.planFilters(&types.SearchFilters{PID: 1234}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{PID: 1234}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -201,7 +208,7 @@ func TestPlanFilters_HeaderNameFilter(t *testing.T) {
 	})
 	f.addEntry(e2)
 
-	result := f.engine.planFilters(&types.SearchFilters{HeaderName: "authorization"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{HeaderName: "authorization"}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -217,10 +224,16 @@ func TestPlanFilters_TLSFilters(t *testing.T) {
 	f.addEntry(e)
 	f.addEntry(makeEntry("e2", "https://a.com/", "GET", 200, 2000))
 
-	assert.Equal(t, uint64(1), f.engine.planFilters(&types.SearchFilters{TLSConnectionID: "tls-1"}, "").GetCardinality())
-	assert.Equal(t, uint64(1), f.engine.planFilters(&types.SearchFilters{JA3: "j3hash"}, "").GetCardinality())
-	assert.Equal(t, uint64(1), f.engine.planFilters(&types.SearchFilters{JA4: "j4hash"}, "").GetCardinality())
-	assert.Equal(t, uint64(0), f.engine.planFilters(&types.SearchFilters{JA4: "nope"}, "").GetCardinality())
+	assert.Equal(t, uint64(1), f.engine.planFilters(nil, &types.SearchFilters{TLSConnectionID: "tls-1"}, nil).GetCardinality())
+	assert.Equal(t, uint64(1), f.engine.planFilters(nil, &types.SearchFilters{JA3: "j3hash"}, nil).GetCardinality())
+	assert.Equal(t, uint64(1), f.engine.planFilters(nil, &types.SearchFilters{JA4: "j4hash"}, nil).GetCardinality())
+	assert.Equal(t, uint64(0), f.engine.planFilters(nil, &types.SearchFilters{JA4: "nope"}, nil).GetCardinality())
+
+	// JA4H is computed from the request, so both entries share it
+	ja4h := f.idx.GetMetaByEntryID("e1").JA4H
+	assert.Equal(t, uint64(2), f.engine.planFilters(nil, &types.SearchFilters{JA4H: ja4h}, nil).GetCardinality())
+	assert.Equal(t, uint64(0), f.engine.planFilters(nil, &types.SearchFilters{JA4S: "nope"}, nil).GetCardinality())
+	assert.Equal(t, uint64(0), f.engine.planFilters(nil, &types.SearchFilters{JA4X: "nope"}, nil).GetCardinality())
 }
 
 func TestPlanFilters_HTTPVersionFilter(t *testing.T) {
@@ -231,7 +244,7 @@ func TestPlanFilters_HTTPVersionFilter(t *testing.T) {
 	e2.HTTPVersion = "HTTP/1.1"
 	f.addEntry(e2)
 
-	result := f.engine.planFilters(&types.SearchFilters{HTTPVersion: "h2"}, "")
+	result := f.engine.planFilters(nil, &types.SearchFilters{HTTPVersion: "h2"}, nil)
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -241,7 +254,7 @@ func TestPlanFilters_QueryTokenSearch(t *testing.T) {
 	f.addEntry(makeEntry("e2", "https://api.example.com/products", "GET", 200, 2000))
 
 	// "users" matches e1 URL
-	result := f.engine.planFilters(nil, "users")
+	result := f.engine.planFilters(nil, nil, mustParseQuery(t, "users"))
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -251,11 +264,11 @@ func TestPlanFilters_QueryTokenSearch_ANDLogic(t *testing.T) {
 	f.addEntry(makeEntry("e2", "https://api.example.com/products/search", "GET", 200, 2000))
 
 	// "users" AND "search" - only e1 has both in URL
-	result := f.engine.planFilters(nil, "users search")
+	result := f.engine.planFilters(nil, nil, mustParseQuery(t, "users search"))
 	assert.Equal(t, uint64(1), result.GetCardinality())
 
 	// "search" matches both
-	result = f.engine.planFilters(nil, "search")
+	result = f.engine.planFilters(nil, nil, mustParseQuery(t, "search"))
 	assert.Equal(t, uint64(2), result.GetCardinality())
 }
 
@@ -269,7 +282,7 @@ func TestPlanFilters_QueryTokenSearch_CrossIndex(t *testing.T) {
 	f.addEntry(e1)
 
 	// "bearer" should match via header token index
-	result := f.engine.planFilters(nil, "bearer")
+	result := f.engine.planFilters(nil, nil, mustParseQuery(t, "bearer"))
 	assert.Equal(t, uint64(1), result.GetCardinality())
 }
 
@@ -280,11 +293,11 @@ func TestPlanFilters_QueryWithBodyIndex(t *testing.T) {
 	f.addEntry(e1)
 
 	// "widget" only in response body
-	result := f.engine.planFilters(nil, "widget")
+	result := f.engine.planFilters(nil, nil, mustParseQuery(t, "widget"))
 	assert.Equal(t, uint64(1), result.GetCardinality())
 
 	// "nonexistent" nowhere
-	result = f.engine.planFilters(nil, "nonexistent")
+	result = f.engine.planFilters(nil, nil, mustParseQuery(t, "nonexistent"))
 	assert.Equal(t, uint64(0), result.GetCardinality())
 }
 
@@ -775,9 +788,9 @@ func TestSearchEngine_PlanAndScore_Integration(t *testing.T) {
 	f.addEntry(e4)
 
 	// Filter by host + query "users"
-	candidates := f.engine.planFilters(&types.SearchFilters{
+	candidates := f.engine.planFilters(nil, &types.SearchFilters{
 		Host: "api.example.com",
-	}, "users")
+	}, mustParseQuery(t, "users"))
 
 	// Should match e1 and e4 (api.example.com + "users" in URL)
 	assert.Equal(t, uint64(2), candidates.GetCardinality())
@@ -847,9 +860,9 @@ func TestSearchEngine_CombinedIndexAndPostFilter(t *testing.T) {
 	f.addEntry(e3)
 
 	// Step 1: Index filter (host)
-	candidates := f.engine.planFilters(&types.SearchFilters{
+	candidates := f.engine.planFilters(nil, &types.SearchFilters{
 		Host: "api.example.com",
-	}, "")
+	}, nil)
 	assert.Equal(t, uint64(2), candidates.GetCardinality())
 
 	// Step 2: Post-filter (header contains)
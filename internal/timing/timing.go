@@ -0,0 +1,272 @@
+// Package timing analyzes entry timings: latency percentiles, phase
+// breakdowns, connection setup costs, and page load waterfalls.
+package timing
+
+import (
+	"math"
+	"sort"
+
+	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// PhaseNames lists the timing phases in the order they happen.
+var PhaseNames = []string{"blocked", "dns", "connect", "ssl", "send", "wait", "receive"}
+
+// phaseValue returns a named phase of t, or -1 if it was not recorded.
+func phaseValue(t types.TimingPhases, name string) int64 {
+	switch name {
+	case "blocked":
+		return t.Blocked
+	case "dns":
+		return t.DNS
+	case "connect":
+		return t.Connect
+	case "ssl":
+		return t.SSL
+	case "send":
+		return t.Send
+	case "wait":
+		return t.Wait
+	case "receive":
+		return t.Receive
+	default:
+		return -1
+	}
+}
+
+// TTFB returns the time from an entry's start to the first response byte:
+// every recorded phase before receive. Returns -1 if none was recorded.
+func TTFB(t types.TimingPhases) int64 {
+	total := int64(-1)
+	for _, v := range []int64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait} {
+		if v < 0 {
+			continue
+		}
+		total = max(total, 0) + v
+	}
+	return total
+}
+
+// Latency computes nearest-rank percentiles, or nil without samples.
+func Latency(durations []int64) *types.LatencyStats {
+	if len(durations) == 0 {
+		return nil
+	}
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+	return &types.LatencyStats{
+		Samples: len(sorted),
+		Min:     sorted[0],
+		P50:     percentile(sorted, 50),
+		P90:     percentile(sorted, 90),
+		P99:     percentile(sorted, 99),
+		Max:     sorted[len(sorted)-1],
+		Avg:     round1(float64(sum) / float64(len(sorted))),
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// Analyze summarizes the timings of metas. limit caps the clusters, slowest
+// entries, and connections returned; each list is ordered worst first.
+func Analyze(metas []*indexer.EntryMeta, limit int) *types.TimingAnalysis {
+	out := &types.TimingAnalysis{Entries: len(metas)}
+
+	var durations, ttfbs []int64
+	var timed []*indexer.EntryMeta
+	for _, m := range metas {
+		if m.DurationMs < 0 {
+			continue
+		}
+		timed = append(timed, m)
+		durations = append(durations, m.DurationMs)
+		if ttfb := TTFB(m.Timings); ttfb >= 0 {
+			ttfbs = append(ttfbs, ttfb)
+		}
+	}
+	out.TimedEntries = len(timed)
+	out.Duration = Latency(durations)
+	out.TTFB = Latency(ttfbs)
+	out.Phases = phaseStats(timed)
+	out.Clusters, out.ClusterCount = clusterTimings(metas, limit)
+	out.Slowest = slowest(timed, limit)
+	out.Connections, out.ConnectionCount = connectionTimings(metas, limit)
+	return out
+}
+
+// phaseStats computes percentiles per phase and each phase's share of the
+// total recorded time.
+func phaseStats(timed []*indexer.EntryMeta) []types.PhaseStats {
+	var total int64
+	for _, m := range timed {
+		total += m.DurationMs
+	}
+	var out []types.PhaseStats
+	for _, name := range PhaseNames {
+		var values []int64
+		var sum int64
+		for _, m := range timed {
+			if v := phaseValue(m.Timings, name); v >= 0 {
+				values = append(values, v)
+				sum += v
+			}
+		}
+		stats := Latency(values)
+		if stats == nil {
+			continue
+		}
+		ps := types.PhaseStats{
+			Phase:   name,
+			Samples: stats.Samples,
+			P50:     stats.P50,
+			P90:     stats.P90,
+			P99:     stats.P99,
+			Avg:     stats.Avg,
+		}
+		if total > 0 {
+			ps.Share = math.Round(float64(sum)/float64(total)*1000) / 1000
+		}
+		out = append(out, ps)
+	}
+	return out
+}
+
+// clusterTimings groups metas by endpoint cluster, ordered by p90 duration,
+// and returns the first limit clusters and the cluster count.
+func clusterTimings(metas []*indexer.EntryMeta, limit int) ([]types.ClusterTiming, int) {
+	type acc struct {
+		timing           types.ClusterTiming
+		durations, ttfbs []int64
+	}
+	byID := make(map[string]*acc)
+	var order []*acc
+	for _, m := range metas {
+		id := catalog.ClusterID(m.Host, m.Method, m.Path)
+		a, ok := byID[id]
+		if !ok {
+			a = &acc{timing: types.ClusterTiming{
+				ClusterID:    id,
+				Host:         m.Host,
+				Method:       m.Method,
+				PathTemplate: catalog.PathTemplate(m.Path),
+			}}
+			byID[id] = a
+			order = append(order, a)
+		}
+		a.timing.Count++
+		if m.DurationMs >= 0 {
+			a.durations = append(a.durations, m.DurationMs)
+		}
+		if ttfb := TTFB(m.Timings); ttfb >= 0 {
+			a.ttfbs = append(a.ttfbs, ttfb)
+		}
+	}
+
+	out := make([]types.ClusterTiming, len(order))
+	for i, a := range order {
+		a.timing.Duration = Latency(a.durations)
+		a.timing.TTFB = Latency(a.ttfbs)
+		out[i] = a.timing
+	}
+	p90 := func(c *types.ClusterTiming) int64 {
+		if c.Duration == nil {
+			return -1
+		}
+		return c.Duration.P90
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if pi, pj := p90(&out[i]), p90(&out[j]); pi != pj {
+			return pi > pj
+		}
+		return out[i].Count > out[j].Count
+	})
+	return out[:min(limit, len(out))], len(out)
+}
+
+// slowest returns the limit timed entries with the longest durations.
+func slowest(timed []*indexer.EntryMeta, limit int) []types.SlowEntry {
+	sorted := append([]*indexer.EntryMeta(nil), timed...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DurationMs > sorted[j].DurationMs })
+
+	out := make([]types.SlowEntry, 0, min(limit, len(sorted)))
+	for _, m := range sorted[:min(limit, len(sorted))] {
+		out = append(out, types.SlowEntry{
+			EntryID:    m.EntryID,
+			Method:     m.Method,
+			URL:        m.URL,
+			Status:     m.Status,
+			DurationMs: m.DurationMs,
+			TTFBMs:     TTFB(m.Timings),
+			Phases:     m.Timings,
+		})
+	}
+	return out
+}
+
+// connectionTimings reports the setup cost of each TLS connection, ordered
+// by setup time, and returns the first limit connections and the count.
+// The cost is read from the entry that opened the connection: the first one
+// recording a connect phase, or else the first entry on it.
+func connectionTimings(metas []*indexer.EntryMeta, limit int) ([]types.ConnectionTiming, int) {
+	type acc struct {
+		first  *indexer.EntryMeta
+		opener *indexer.EntryMeta
+		count  int
+	}
+	byID := make(map[string]*acc)
+	var order []string
+	for _, m := range metas {
+		if m.TLSConnectionID == "" {
+			continue
+		}
+		a, ok := byID[m.TLSConnectionID]
+		if !ok {
+			a = &acc{first: m}
+			byID[m.TLSConnectionID] = a
+			order = append(order, m.TLSConnectionID)
+		}
+		a.count++
+		if m.TsMs < a.first.TsMs {
+			a.first = m
+		}
+		if m.Timings.Connect >= 0 && (a.opener == nil || m.TsMs < a.opener.TsMs) {
+			a.opener = m
+		}
+	}
+
+	out := make([]types.ConnectionTiming, 0, len(order))
+	for _, id := range order {
+		a := byID[id]
+		opener := a.opener
+		if opener == nil {
+			opener = a.first
+		}
+		out = append(out, types.ConnectionTiming{
+			ConnectionID: id,
+			Host:         opener.Host,
+			FirstEntryID: opener.EntryID,
+			Entries:      a.count,
+			DNSMs:        opener.Timings.DNS,
+			ConnectMs:    opener.Timings.Connect,
+			SSLMs:        opener.Timings.SSL,
+			SetupMs:      max(opener.Timings.DNS, 0) + max(opener.Timings.Connect, 0),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].SetupMs > out[j].SetupMs })
+	return out[:min(limit, len(out))], len(out)
+}
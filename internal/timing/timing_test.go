@@ -0,0 +1,194 @@
+package timing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// unrecorded has every phase missing.
+var unrecorded = types.TimingPhases{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Send: -1, Wait: -1, Receive: -1}
+
+// meta builds an entry whose duration is the sum of its phases.
+func meta(id, rawURL string, tsMs int64, conn string, phases types.TimingPhases) *indexer.EntryMeta {
+	m := &indexer.EntryMeta{EntryID: id, URL: rawURL, Method: "GET", Status: 200, TsMs: tsMs, TLSConnectionID: conn, Timings: phases, DurationMs: -1}
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		rest := rawURL[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			m.Host, m.Path = rest[:j], rest[j:]
+		}
+	}
+	for _, v := range []int64{phases.Blocked, phases.DNS, phases.Connect, phases.Send, phases.Wait, phases.Receive} {
+		if v >= 0 {
+			m.DurationMs = max(m.DurationMs, 0) + v
+		}
+	}
+	return m
+}
+
+func reused(wait, receive int64) types.TimingPhases {
+	return types.TimingPhases{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Send: 0, Wait: wait, Receive: receive}
+}
+
+func fresh(dns, connect, ssl, wait, receive int64) types.TimingPhases {
+	return types.TimingPhases{Blocked: 0, DNS: dns, Connect: connect, SSL: ssl, Send: 0, Wait: wait, Receive: receive}
+}
+
+func TestLatency(t *testing.T) {
+	assert.Nil(t, Latency(nil))
+
+	durations := make([]int64, 0, 100)
+	for i := int64(100); i >= 1; i-- {
+		durations = append(durations, i)
+	}
+	stats := Latency(durations)
+	require.NotNil(t, stats)
+	assert.Equal(t, 100, stats.Samples)
+	assert.Equal(t, int64(1), stats.Min)
+	assert.Equal(t, int64(50), stats.P50)
+	assert.Equal(t, int64(90), stats.P90)
+	assert.Equal(t, int64(99), stats.P99)
+	assert.Equal(t, int64(100), stats.Max)
+	assert.Equal(t, 50.5, stats.Avg)
+	assert.Equal(t, int64(100), durations[0], "input must not be reordered")
+
+	single := Latency([]int64{7})
+	assert.Equal(t, int64(7), single.P50)
+	assert.Equal(t, int64(7), single.P99)
+}
+
+func TestTTFB(t *testing.T) {
+	assert.Equal(t, int64(-1), TTFB(unrecorded))
+	assert.Equal(t, int64(40), TTFB(reused(40, 5)))
+	assert.Equal(t, int64(10+50+100), TTFB(fresh(10, 50, 30, 100, 20)))
+}
+
+func TestAnalyze(t *testing.T) {
+	metas := []*indexer.EntryMeta{
+		meta("e1", "https://api.example.com/users/1", 1000, "c1", fresh(10, 50, 30, 100, 20)),
+		meta("e2", "https://api.example.com/users/2", 1200, "c1", reused(300, 10)),
+		meta("e3", "https://api.example.com/users/3", 1500, "c1", reused(40, 5)),
+		meta("e4", "https://cdn.example.net/app.js", 1100, "c2", fresh(5, 20, 15, 10, 200)),
+		meta("e5", "https://cdn.example.net/logo.png", 1300, "", unrecorded),
+	}
+
+	a := Analyze(metas, 2)
+	assert.Equal(t, 5, a.Entries)
+	assert.Equal(t, 4, a.TimedEntries)
+	require.NotNil(t, a.Duration)
+	assert.Equal(t, int64(310), a.Duration.Max)
+	require.NotNil(t, a.TTFB)
+	assert.Equal(t, int64(300), a.TTFB.Max)
+
+	phases := make(map[string]types.PhaseStats)
+	for _, p := range a.Phases {
+		phases[p.Phase] = p
+	}
+	assert.Equal(t, 2, phases["dns"].Samples)
+	assert.Equal(t, 4, phases["wait"].Samples)
+	assert.InDelta(t, 450.0/770.0, phases["wait"].Share, 0.001)
+
+	// users/{id} has the higher p90; the cdn clusters follow
+	assert.Equal(t, 3, a.ClusterCount)
+	require.Len(t, a.Clusters, 2)
+	assert.Equal(t, "/users/{id}", a.Clusters[0].PathTemplate)
+	assert.Equal(t, 3, a.Clusters[0].Count)
+	assert.Equal(t, int64(310), a.Clusters[0].Duration.P90)
+	assert.Equal(t, "/app.js", a.Clusters[1].PathTemplate)
+
+	require.Len(t, a.Slowest, 2)
+	assert.Equal(t, "e2", a.Slowest[0].EntryID)
+	assert.Equal(t, int64(300), a.Slowest[0].TTFBMs)
+	assert.Equal(t, "e4", a.Slowest[1].EntryID)
+
+	// Setup cost comes from the entry that opened each connection
+	assert.Equal(t, 2, a.ConnectionCount)
+	require.Len(t, a.Connections, 2)
+	assert.Equal(t, types.ConnectionTiming{
+		ConnectionID: "c1", Host: "api.example.com", FirstEntryID: "e1", Entries: 3,
+		DNSMs: 10, ConnectMs: 50, SSLMs: 30, SetupMs: 60,
+	}, a.Connections[0])
+	assert.Equal(t, int64(25), a.Connections[1].SetupMs)
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	a := Analyze(nil, 10)
+	assert.Equal(t, 0, a.Entries)
+	assert.Nil(t, a.Duration)
+	assert.Empty(t, a.Phases)
+	assert.Empty(t, a.Clusters)
+}
+
+func TestBuildWaterfall(t *testing.T) {
+	metas := []*indexer.EntryMeta{
+		meta("page", "https://www.example.com/", 1000, "c1", fresh(10, 40, 20, 50, 100)),
+		meta("login", "https://www.example.com/login", 900, "c1", fresh(5, 0, 0, 20, 5)),
+		meta("css", "https://cdn.example.net/site.css", 1250, "c2", fresh(5, 30, 20, 15, 50)),
+		meta("api", "https://api.example.com/me", 1300, "c3", reused(80, 10)),
+		meta("beacon", "https://stats.example.org/b", 1400, "", unrecorded),
+	}
+	edges := []types.FlowEdge{
+		{From: "login", To: "page", Reason: types.EdgeReasonRedirect},
+		{From: "page", To: "css", Reason: types.EdgeReasonReferer},
+		{From: "page", To: "css", Reason: types.EdgeReasonInitiatorPage},
+		{From: "page", To: "api", Reason: types.EdgeReasonReferer},
+		{From: "api", To: "beacon", Reason: types.EdgeReasonTemporal},
+	}
+
+	w := BuildWaterfall("page", metas, edges)
+	assert.Equal(t, int64(900), w.StartMs)
+	assert.Equal(t, int64(1400-900), w.TotalMs)
+
+	require.Len(t, w.Groups, 2)
+	root := w.Groups[0]
+	assert.Equal(t, "login", root.RootEntryID)
+	var ids []string
+	for _, r := range root.Rows {
+		ids = append(ids, r.EntryID)
+	}
+	assert.Equal(t, []string{"login", "page", "css", "api"}, ids)
+	assert.Equal(t, types.EdgeReasonRedirect, root.Rows[1].Via)
+	assert.Equal(t, types.EdgeReasonInitiatorPage, root.Rows[2].Via, "initiator_page outranks referer")
+	assert.Equal(t, "page", root.Rows[2].ParentID)
+	assert.Equal(t, int64(350), root.Rows[2].OffsetMs)
+
+	// Temporal edges do not make an initiator
+	assert.Empty(t, w.Groups[1].RootEntryID)
+	require.Len(t, w.Groups[1].Rows, 1)
+	assert.Equal(t, "beacon", w.Groups[1].Rows[0].EntryID)
+}
+
+func TestRenderASCII(t *testing.T) {
+	metas := []*indexer.EntryMeta{
+		meta("page", "https://www.example.com/", 0, "c1", fresh(10, 40, 20, 30, 20)),
+		meta("js", "https://www.example.com/app.js", 100, "c1", reused(50, 50)),
+		meta("img", "https://www.example.com/a.png", 150, "", unrecorded),
+	}
+	edges := []types.FlowEdge{{From: "page", To: "js", Reason: types.EdgeReasonInitiatorPage}}
+
+	out := RenderASCII(BuildWaterfall("page", metas, edges), 20)
+	lines := strings.Split(out, "\n")
+	assert.Contains(t, lines[0], "3 entries over 200 ms (1 char = 10 ms)")
+	assert.Contains(t, out, "== www.example.com/ (2 entries)")
+	assert.Contains(t, out, "== no navigation link (1 entries)")
+	assert.Contains(t, out, Legend)
+
+	// page: dns 10 (1 cell), connect 20 + ssl 20, wait 30, receive 20
+	assert.Contains(t, out, "|dccss---==          |   100ms 200 GET www.example.com/ [page]")
+	// js starts at cell 10: wait 50, receive 50
+	assert.Contains(t, out, "|          -----=====|   100ms 200 GET www.example.com/app.js [js]")
+	// img has no timings: a single marker at its start
+	assert.Contains(t, out, "|               ?    |       ? 200 GET www.example.com/a.png [img]")
+}
+
+func TestTruncateLabel(t *testing.T) {
+	assert.Equal(t, "example.com/a", truncateLabel("https://example.com/a"))
+	long := truncateLabel("https://example.com/" + strings.Repeat("x", 100))
+	assert.Len(t, long, maxURLLabel)
+	assert.True(t, strings.HasSuffix(long, "..."))
+}
@@ -0,0 +1,227 @@
+package timing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// navigationPriority ranks the flow edges that make one entry the initiator
+// of another; lower wins when several link the same entry.
+var navigationPriority = map[string]int{
+	types.EdgeReasonRedirect:      0,
+	types.EdgeReasonInitiatorPage: 1,
+	types.EdgeReasonReferer:       2,
+}
+
+// phaseChars maps phases to the characters drawing them in ASCII bars.
+// Connect is drawn without its SSL part, which gets its own character.
+var phaseChars = []struct {
+	name string
+	char byte
+}{
+	{"blocked", '.'}, {"dns", 'd'}, {"connect", 'c'}, {"ssl", 's'},
+	{"send", '>'}, {"wait", '-'}, {"receive", '='},
+}
+
+// Legend explains the characters of ASCII waterfall bars.
+const Legend = "legend: . blocked  d dns  c connect  s ssl  > send  - wait (TTFB)  = receive  ? no timings"
+
+const maxURLLabel = 60
+
+// BuildWaterfall lays out metas on a timeline starting at the earliest entry
+// and groups them by navigation flow: each entry joins the group of the
+// entry that initiated it through a redirect, initiator_page, or referer
+// edge, transitively. Entries with no navigation link share a final group.
+func BuildWaterfall(pageEntryID string, metas []*indexer.EntryMeta, edges []types.FlowEdge) *types.Waterfall {
+	w := &types.Waterfall{PageEntryID: pageEntryID}
+	if len(metas) == 0 {
+		return w
+	}
+
+	sorted := append([]*indexer.EntryMeta(nil), metas...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TsMs < sorted[j].TsMs })
+	w.StartMs = sorted[0].TsMs
+	var end int64
+	for _, m := range sorted {
+		end = max(end, m.TsMs+max(m.DurationMs, 0))
+	}
+	w.TotalMs = end - w.StartMs
+
+	inSet := make(map[string]*indexer.EntryMeta, len(sorted))
+	for _, m := range sorted {
+		inSet[m.EntryID] = m
+	}
+	parents := make(map[string]types.FlowEdge)
+	hasChildren := make(map[string]bool)
+	for _, e := range edges {
+		rank, ok := navigationPriority[e.Reason]
+		if !ok || inSet[e.From] == nil || inSet[e.To] == nil || e.From == e.To {
+			continue
+		}
+		if cur, seen := parents[e.To]; seen && navigationPriority[cur.Reason] <= rank {
+			continue
+		}
+		parents[e.To] = e
+	}
+	for _, e := range parents {
+		hasChildren[e.From] = true
+	}
+
+	// rootOf follows initiator links up to an entry with none, stopping on cycles.
+	rootOf := func(id string) string {
+		seen := map[string]bool{id: true}
+		for {
+			e, ok := parents[id]
+			if !ok || seen[e.From] {
+				return id
+			}
+			id = e.From
+			seen[id] = true
+		}
+	}
+
+	groups := make(map[string]*types.WaterfallGroup)
+	var order []string
+	var unlinked []types.WaterfallRow
+	for _, m := range sorted {
+		row := types.WaterfallRow{
+			EntryID:    m.EntryID,
+			Method:     m.Method,
+			URL:        m.URL,
+			Status:     m.Status,
+			OffsetMs:   m.TsMs - w.StartMs,
+			DurationMs: m.DurationMs,
+			Phases:     m.Timings,
+		}
+		if e, ok := parents[m.EntryID]; ok {
+			row.Via, row.ParentID = e.Reason, e.From
+		}
+
+		root := rootOf(m.EntryID)
+		if root == m.EntryID && !hasChildren[root] && root != pageEntryID {
+			unlinked = append(unlinked, row)
+			continue
+		}
+		g, ok := groups[root]
+		if !ok {
+			g = &types.WaterfallGroup{RootEntryID: root, RootURL: inSet[root].URL}
+			groups[root] = g
+			order = append(order, root)
+		}
+		g.Rows = append(g.Rows, row)
+	}
+
+	for _, root := range order {
+		w.Groups = append(w.Groups, *groups[root])
+	}
+	if len(unlinked) > 0 {
+		w.Groups = append(w.Groups, types.WaterfallGroup{Rows: unlinked})
+	}
+	return w
+}
+
+// RenderASCII draws a waterfall as text: one bar per entry, width characters
+// wide, under a header per group.
+func RenderASCII(w *types.Waterfall, width int) string {
+	var rows int
+	for _, g := range w.Groups {
+		rows += len(g.Rows)
+	}
+	scale := max(float64(w.TotalMs)/float64(width), 1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Waterfall for %s: %d entries over %d ms (1 char = %.0f ms)\n", w.PageEntryID, rows, w.TotalMs, scale)
+	for _, g := range w.Groups {
+		if g.RootEntryID != "" {
+			fmt.Fprintf(&b, "\n== %s (%d entries)\n", truncateLabel(g.RootURL), len(g.Rows))
+		} else {
+			fmt.Fprintf(&b, "\n== no navigation link (%d entries)\n", len(g.Rows))
+		}
+		for _, r := range g.Rows {
+			duration := "?"
+			if r.DurationMs >= 0 {
+				duration = fmt.Sprintf("%dms", r.DurationMs)
+			}
+			fmt.Fprintf(&b, "%8s |%s| %7s %3d %s %s [%s]\n",
+				fmt.Sprintf("+%dms", r.OffsetMs), bar(r, width, scale), duration,
+				r.Status, r.Method, truncateLabel(r.URL), r.EntryID)
+		}
+	}
+	b.WriteString("\n" + Legend + "\n")
+	return b.String()
+}
+
+// bar draws one row: blanks up to the entry's start, then one character per
+// scale milliseconds in the phase active at that time.
+func bar(r types.WaterfallRow, width int, scale float64) string {
+	cells := []byte(strings.Repeat(" ", width))
+	start := min(int(float64(r.OffsetMs)/scale), width-1)
+	if r.DurationMs < 0 {
+		cells[start] = '?'
+		return string(cells)
+	}
+
+	type segment struct {
+		char   byte
+		lo, hi int64 // ms from the entry's start
+	}
+	var segments []segment
+	var cursor int64
+	for _, pc := range phaseChars {
+		v := phaseValue(r.Phases, pc.name)
+		switch pc.name {
+		case "connect":
+			v -= max(r.Phases.SSL, 0)
+		case "ssl":
+			if r.Phases.Connect < 0 {
+				v = -1
+			}
+		}
+		if v <= 0 {
+			continue
+		}
+		segments = append(segments, segment{pc.char, cursor, cursor + v})
+		cursor += v
+	}
+
+	end := min(int(math.Round(float64(r.OffsetMs+cursor)/scale)), width)
+	if end <= start || len(segments) == 0 {
+		// Too short to span a cell: draw the longest phase once
+		char := byte('=')
+		var longest int64
+		for _, s := range segments {
+			if s.hi-s.lo > longest {
+				char, longest = s.char, s.hi-s.lo
+			}
+		}
+		cells[start] = char
+		return string(cells)
+	}
+	for c := start; c < end; c++ {
+		at := (float64(c)+0.5)*scale - float64(r.OffsetMs)
+		cells[c] = segments[len(segments)-1].char
+		for _, s := range segments {
+			if at < float64(s.hi) {
+				cells[c] = s.char
+				break
+			}
+		}
+	}
+	return string(cells)
+}
+
+// truncateLabel shortens a URL to host and path, capped for display.
+func truncateLabel(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	}
+	if len(u) > maxURLLabel {
+		u = u[:maxURLLabel-3] + "..."
+	}
+	return u
+}
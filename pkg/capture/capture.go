@@ -0,0 +1,381 @@
+// Package capture serves recorded traffic from files through
+// client.DataSource, so powhttp-mcp can run without the powhttp app.
+//
+// Two formats are supported:
+//
+//   - HAR 1.2 (.har), including files written by powhttp_export_har and by
+//     browser devtools. Chrome's _webSocketMessages are loaded as WebSocket frames.
+//
+//   - JSONL (.jsonl, .ndjson): one client.SessionEntry per line, as returned by
+//     the Data API. Lines with a "kind" field carry connection-level data:
+//
+//     {"kind":"tls","connectionId":"...","events":[...]}
+//     {"kind":"http2","connectionId":"...","streamId":1,"frames":[...]}
+//     {"kind":"websocket","entryId":"...","messages":[...]}
+//
+// Each file becomes one session named after the file. The first file is the
+// "active" session. Entries without an ID get "<session>-<n>", so files loaded
+// together never share entry IDs. Connection IDs for TLS and HTTP/2 records
+// must be unique across files, since lookups by connection carry no session.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/har"
+)
+
+// maxLineBytes bounds a single JSONL line (entries carry base64 bodies).
+const maxLineBytes = 256 << 20
+
+// Source is a read-only client.DataSource backed by capture files.
+type Source struct {
+	sessions []*session
+	byID     map[string]*session
+}
+
+type session struct {
+	info    client.Session
+	entries map[string]*client.SessionEntry
+	ws      map[string][]client.WebSocketMessage
+	tls     map[string][]client.TLSEvent
+	h2      map[h2Key][]json.RawMessage
+}
+
+type h2Key struct {
+	connectionID string
+	streamID     int
+}
+
+var _ client.DataSource = (*Source)(nil)
+
+// Open loads each capture file as a session.
+func Open(paths ...string) (*Source, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no capture files given")
+	}
+	s := &Source{byID: make(map[string]*session)}
+	for _, p := range paths {
+		if err := s.load(p); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// load reads one file, detecting HAR vs JSONL by extension and then content.
+func (s *Source) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading capture %q: %w", path, err)
+	}
+
+	sess := s.newSession(path)
+	switch ext := strings.ToLower(filepath.Ext(path)); {
+	case ext == ".har":
+		err = s.loadHAR(sess, data)
+	case ext == ".jsonl" || ext == ".ndjson":
+		err = s.loadJSONL(sess, data)
+	case looksLikeHAR(data):
+		err = s.loadHAR(sess, data)
+	default:
+		err = s.loadJSONL(sess, data)
+	}
+	if err != nil {
+		return fmt.Errorf("loading capture %q: %w", path, err)
+	}
+	return nil
+}
+
+// newSession registers a session whose ID is the file's base name, made unique.
+func (s *Source) newSession(path string) *session {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if base == "" || base == "active" {
+		base = "capture"
+	}
+	id := base
+	for n := 2; s.byID[id] != nil; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	sess := &session{
+		info:    client.Session{ID: id, Name: filepath.Base(path), EntryIDs: []string{}},
+		entries: make(map[string]*client.SessionEntry),
+		ws:      make(map[string][]client.WebSocketMessage),
+		tls:     make(map[string][]client.TLSEvent),
+		h2:      make(map[h2Key][]json.RawMessage),
+	}
+	s.sessions = append(s.sessions, sess)
+	s.byID[id] = sess
+	return sess
+}
+
+func (s *Source) loadHAR(sess *session, data []byte) error {
+	doc, err := har.Read(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for i, e := range doc.Log.Entries {
+		entry := har.ToEntry(e, sess.syntheticID(i+1))
+		sess.add(entry)
+		if msgs := har.ToWebSocketMessages(e); len(msgs) > 0 {
+			sess.ws[entry.ID] = msgs
+		}
+	}
+	return nil
+}
+
+// jsonlRecord is the envelope for connection-level JSONL lines.
+type jsonlRecord struct {
+	Kind         string                    `json:"kind"`
+	ConnectionID string                    `json:"connectionId"`
+	StreamID     int                       `json:"streamId"`
+	EntryID      string                    `json:"entryId"`
+	Events       []client.TLSEvent         `json:"events"`
+	Frames       []json.RawMessage         `json:"frames"`
+	Messages     []client.WebSocketMessage `json:"messages"`
+}
+
+func (s *Source) loadJSONL(sess *session, data []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec jsonlRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch rec.Kind {
+		case "", "entry":
+			var entry client.SessionEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if entry.ID == "" {
+				entry.ID = sess.syntheticID(len(sess.info.EntryIDs) + 1)
+			}
+			sess.add(&entry)
+		case "tls":
+			if owner := s.connectionOwner(rec.ConnectionID, sess); owner != nil {
+				return fmt.Errorf("line %d: TLS connection %q is already loaded from %s", lineNo, rec.ConnectionID, owner.info.Name)
+			}
+			sess.tls[rec.ConnectionID] = append(sess.tls[rec.ConnectionID], rec.Events...)
+		case "http2":
+			if owner := s.connectionOwner(rec.ConnectionID, sess); owner != nil {
+				return fmt.Errorf("line %d: HTTP/2 connection %q is already loaded from %s", lineNo, rec.ConnectionID, owner.info.Name)
+			}
+			k := h2Key{connectionID: rec.ConnectionID, streamID: rec.StreamID}
+			sess.h2[k] = append(sess.h2[k], rec.Frames...)
+		case "websocket":
+			sess.ws[rec.EntryID] = append(sess.ws[rec.EntryID], rec.Messages...)
+		default:
+			return fmt.Errorf("line %d: unknown record kind %q", lineNo, rec.Kind)
+		}
+	}
+	return sc.Err()
+}
+
+// connectionOwner returns another session that already holds TLS events or
+// HTTP/2 frames for connectionID, or nil.
+func (s *Source) connectionOwner(connectionID string, self *session) *session {
+	for _, sess := range s.sessions {
+		if sess != self && sess.hasConnection(connectionID) {
+			return sess
+		}
+	}
+	return nil
+}
+
+func (sess *session) hasConnection(connectionID string) bool {
+	if _, ok := sess.tls[connectionID]; ok {
+		return true
+	}
+	for k := range sess.h2 {
+		if k.connectionID == connectionID {
+			return true
+		}
+	}
+	return false
+}
+
+// syntheticID names the n-th entry of a file that carries no entry IDs.
+// The session prefix keeps IDs unique across files, since the index and
+// entry cache are keyed by entry ID alone.
+func (sess *session) syntheticID(n int) string {
+	return fmt.Sprintf("%s-%d", sess.info.ID, n)
+}
+
+// add stores an entry, keeping the first occurrence of a duplicate ID.
+func (sess *session) add(entry *client.SessionEntry) {
+	if _, dup := sess.entries[entry.ID]; dup {
+		return
+	}
+	sess.entries[entry.ID] = entry
+	sess.info.EntryIDs = append(sess.info.EntryIDs, entry.ID)
+}
+
+// looksLikeHAR reports whether data is a JSON object with a top-level "log" key.
+func looksLikeHAR(data []byte) bool {
+	var probe struct {
+		Log json.RawMessage `json:"log"`
+	}
+	return json.Unmarshal(data, &probe) == nil && len(probe.Log) > 0
+}
+
+// ListSessions returns one session per loaded file.
+func (s *Source) ListSessions(ctx context.Context) ([]client.Session, error) {
+	out := make([]client.Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		out = append(out, sess.snapshot())
+	}
+	return out, nil
+}
+
+// GetSession returns a session by ID; "active" is the first loaded file.
+func (s *Source) GetSession(ctx context.Context, sessionID string) (*client.Session, error) {
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	info := sess.snapshot()
+	return &info, nil
+}
+
+// GetSessionBookmarks returns no bookmarks; capture files carry none.
+func (s *Source) GetSessionBookmarks(ctx context.Context, sessionID string) ([]string, error) {
+	if _, err := s.session(sessionID); err != nil {
+		return nil, err
+	}
+	return []string{}, nil
+}
+
+// ListEntries returns copies of a session's entries in file order.
+// Capture files have no selection, bookmark, or highlight state, so any of
+// those filters yields an empty list.
+func (s *Source) ListEntries(ctx context.Context, sessionID string, opts *client.ListEntriesOptions) ([]client.SessionEntry, error) {
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil && (opts.Selected || opts.Bookmarked || len(opts.Highlighted) > 0) {
+		return []client.SessionEntry{}, nil
+	}
+	out := make([]client.SessionEntry, 0, len(sess.info.EntryIDs))
+	for _, id := range sess.info.EntryIDs {
+		out = append(out, *sess.entries[id])
+	}
+	return out, nil
+}
+
+// GetEntry returns a copy of an entry.
+func (s *Source) GetEntry(ctx context.Context, sessionID, entryID string) (*client.SessionEntry, error) {
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := sess.entries[entryID]
+	if !ok {
+		return nil, notFound("entry %q not found in session %q", entryID, sess.info.ID)
+	}
+	cp := *entry
+	return &cp, nil
+}
+
+// GetTLSConnection returns the TLS events recorded for a connection.
+// HAR captures carry no handshake events, so this is only populated from JSONL.
+func (s *Source) GetTLSConnection(ctx context.Context, connectionID string) ([]client.TLSEvent, error) {
+	var events []client.TLSEvent
+	ok := false
+	for _, sess := range s.sessions {
+		if events, ok = sess.tls[connectionID]; ok {
+			break
+		}
+	}
+	if !ok {
+		return nil, notFound("no TLS events recorded for connection %q", connectionID)
+	}
+	return events, nil
+}
+
+// ListHTTP2StreamIDs returns the stream IDs recorded for a connection, in ascending order.
+func (s *Source) ListHTTP2StreamIDs(ctx context.Context, connectionID string) ([]int, error) {
+	var ids []int
+	for _, sess := range s.sessions {
+		for k := range sess.h2 {
+			if k.connectionID == connectionID {
+				ids = append(ids, k.streamID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, notFound("no HTTP/2 frames recorded for connection %q", connectionID)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// GetHTTP2Stream returns the HTTP/2 frames recorded for a stream.
+// HAR captures carry no frames, so this is only populated from JSONL.
+func (s *Source) GetHTTP2Stream(ctx context.Context, connectionID string, streamID int) ([]json.RawMessage, error) {
+	var frames []json.RawMessage
+	ok := false
+	for _, sess := range s.sessions {
+		if frames, ok = sess.h2[h2Key{connectionID: connectionID, streamID: streamID}]; ok {
+			break
+		}
+	}
+	if !ok {
+		return nil, notFound("no HTTP/2 frames recorded for connection %q stream %d", connectionID, streamID)
+	}
+	return frames, nil
+}
+
+// GetWebSocketMessages returns the frames recorded for a WebSocket entry.
+func (s *Source) GetWebSocketMessages(ctx context.Context, sessionID, entryID string) ([]client.WebSocketMessage, error) {
+	sess, err := s.session(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sess.entries[entryID]; !ok {
+		return nil, notFound("entry %q not found in session %q", entryID, sess.info.ID)
+	}
+	return sess.ws[entryID], nil
+}
+
+func (s *Source) session(sessionID string) (*session, error) {
+	if sessionID == "active" && len(s.sessions) > 0 {
+		return s.sessions[0], nil
+	}
+	sess, ok := s.byID[sessionID]
+	if !ok {
+		return nil, notFound("session %q not found", sessionID)
+	}
+	return sess, nil
+}
+
+func (sess *session) snapshot() client.Session {
+	info := sess.info
+	info.EntryIDs = append([]string(nil), sess.info.EntryIDs...)
+	return info
+}
+
+func notFound(format string, args ...any) error {
+	return &client.APIError{StatusCode: 404, Message: fmt.Sprintf(format, args...)}
+}
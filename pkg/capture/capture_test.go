@@ -0,0 +1,268 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/internal/cache"
+	"github.com/usestring/powhttp-mcp/internal/config"
+	"github.com/usestring/powhttp-mcp/internal/entryfetch"
+	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/search"
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/har"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func strPtr(s string) *string { return &s }
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func writeHAR(t *testing.T, dir, name string) string {
+	t.Helper()
+	doc := har.FromEntries([]*client.SessionEntry{
+		{
+			ID:          "a1",
+			URL:         "https://example.com/users",
+			HTTPVersion: "http/1.1",
+			Request:     client.Request{Method: strPtr("GET"), Headers: client.Headers{{"host", "example.com"}}},
+			Timings:     client.Timings{StartedAt: 1000},
+		},
+	}, har.Options{})
+	path := filepath.Join(dir, name)
+	_, err := har.WriteFile(path, doc)
+	require.NoError(t, err)
+	return path
+}
+
+const jsonlCapture = `{"id":"e1","url":"https://api.example.com/x","httpVersion":"h2","request":{"method":"GET","headers":[]},"tls":{"connectionId":"c1"},"http2":{"connectionId":"c1","streamId":1},"timings":{"startedAt":1}}
+
+{"kind":"tls","connectionId":"c1","events":[{"side":"client","msg":{}}]}
+{"kind":"http2","connectionId":"c1","streamId":1,"frames":[{"type":"HEADERS"}]}
+{"kind":"websocket","entryId":"e1","messages":[{"side":"client","content":{"type":"text","text":"hi"}}]}
+{"url":"https://api.example.com/y","request":{"method":"POST","headers":[]},"timings":{"startedAt":2}}
+`
+
+func TestOpen_HARAndJSONL(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(writeHAR(t, dir, "browser.har"), writeFile(t, dir, "recorded.jsonl", jsonlCapture))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	sessions, err := src.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "browser", sessions[0].ID)
+	assert.Equal(t, []string{"a1"}, sessions[0].EntryIDs)
+	assert.Equal(t, "recorded", sessions[1].ID)
+	assert.Equal(t, []string{"e1", "recorded-2"}, sessions[1].EntryIDs)
+
+	active, err := src.GetSession(ctx, "active")
+	require.NoError(t, err)
+	assert.Equal(t, "browser", active.ID)
+
+	entry, err := src.GetEntry(ctx, "browser", "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/users", entry.URL)
+	assert.Equal(t, "GET", *entry.Request.Method)
+
+	events, err := src.GetTLSConnection(ctx, "c1")
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	frames, err := src.GetHTTP2Stream(ctx, "c1", 1)
+	require.NoError(t, err)
+	assert.Len(t, frames, 1)
+
+	msgs, err := src.GetWebSocketMessages(ctx, "recorded", "e1")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "hi", msgs[0].Content.Text)
+}
+
+func TestOpen_SniffsHARWithoutExtension(t *testing.T) {
+	dir := t.TempDir()
+	harPath := writeHAR(t, dir, "export.har")
+	data, err := os.ReadFile(harPath)
+	require.NoError(t, err)
+
+	src, err := Open(writeFile(t, dir, "export.json", string(data)))
+	require.NoError(t, err)
+	_, err = src.GetEntry(context.Background(), "export", "a1")
+	assert.NoError(t, err)
+}
+
+func TestOpen_UniqueSessionIDs(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other")
+	require.NoError(t, os.Mkdir(other, 0o755))
+
+	src, err := Open(writeHAR(t, dir, "traffic.har"), writeHAR(t, other, "traffic.har"))
+	require.NoError(t, err)
+
+	sessions, err := src.ListSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "traffic", sessions[0].ID)
+	assert.Equal(t, "traffic-2", sessions[1].ID)
+}
+
+func TestSource_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(writeHAR(t, dir, "s.har"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"session", func() error { _, err := src.GetSession(ctx, "missing"); return err }},
+		{"entry", func() error { _, err := src.GetEntry(ctx, "s", "missing"); return err }},
+		{"tls", func() error { _, err := src.GetTLSConnection(ctx, "missing"); return err }},
+		{"http2", func() error { _, err := src.GetHTTP2Stream(ctx, "missing", 1); return err }},
+		{"websocket", func() error { _, err := src.GetWebSocketMessages(ctx, "s", "missing"); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *client.APIError
+			require.True(t, errors.As(tt.call(), &apiErr))
+			assert.Equal(t, 404, apiErr.StatusCode)
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{"no files", nil},
+		{"missing file", []string{filepath.Join(dir, "nope.har")}},
+		{"unknown kind", []string{writeFile(t, dir, "bad.jsonl", `{"kind":"quic"}`)}},
+		{"malformed line", []string{writeFile(t, dir, "broken.jsonl", "{")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.paths...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGetEntry_ReturnsCopy(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(writeHAR(t, dir, "s.har"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	entry, err := src.GetEntry(ctx, "s", "a1")
+	require.NoError(t, err)
+	entry.URL = "mutated"
+
+	again, err := src.GetEntry(ctx, "s", "a1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/users", again.URL)
+}
+
+// devtoolsHAR is a browser-style HAR whose entries carry no _id.
+const devtoolsHAR = `{"log":{"version":"1.2","creator":{"name":"devtools","version":"1"},"entries":[
+{"startedDateTime":"2024-01-01T00:00:00Z","time":1,"request":{"method":"GET","url":"https://%s/one","httpVersion":"HTTP/1.1","headers":[],"queryString":[],"cookies":[],"headersSize":-1,"bodySize":0},"response":{"status":200,"statusText":"OK","httpVersion":"HTTP/1.1","headers":[],"cookies":[],"content":{"size":0,"mimeType":"text/plain"},"redirectURL":"","headersSize":-1,"bodySize":0},"cache":{},"timings":{"send":0,"wait":1,"receive":0}},
+{"startedDateTime":"2024-01-01T00:00:01Z","time":1,"request":{"method":"GET","url":"https://%s/two","httpVersion":"HTTP/1.1","headers":[],"queryString":[],"cookies":[],"headersSize":-1,"bodySize":0},"response":{"status":200,"statusText":"OK","httpVersion":"HTTP/1.1","headers":[],"cookies":[],"content":{"size":0,"mimeType":"text/plain"},"redirectURL":"","headersSize":-1,"bodySize":0},"cache":{},"timings":{"send":0,"wait":1,"receive":0}}
+]}}`
+
+func TestOpen_TwoHARsIndexedAndSearchable(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(
+		writeFile(t, dir, "a.har", fmt.Sprintf(devtoolsHAR, "alpha.example.com", "alpha.example.com")),
+		writeFile(t, dir, "b.har", fmt.Sprintf(devtoolsHAR, "beta.example.com", "beta.example.com")),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	sessions, err := src.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, []string{"a-1", "a-2"}, sessions[0].EntryIDs)
+	assert.Equal(t, []string{"b-1", "b-2"}, sessions[1].EntryIDs)
+
+	cfg := &config.Config{
+		RefreshTimeout:     5 * time.Second,
+		FetchWorkers:       2,
+		BootstrapTailLimit: 100,
+		MaxSearchResults:   100,
+	}
+	entryCache, err := cache.NewEntryCache(16)
+	require.NoError(t, err)
+	idx := indexer.New(src, entryCache, cfg)
+	engine := search.New(idx, entryCache, cfg)
+
+	for _, tt := range []struct{ session, host, firstID string }{
+		{"a", "alpha.example.com", "a-1"},
+		{"b", "beta.example.com", "b-1"},
+	} {
+		resp, err := engine.Search(ctx, &types.SearchRequest{
+			SessionID: tt.session,
+			Filters:   &types.SearchFilters{Host: tt.host},
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 2, tt.session)
+
+		entry, err := entryfetch.FetchEntry(ctx, src, entryCache, tt.session, tt.firstID)
+		require.NoError(t, err)
+		assert.Equal(t, "https://"+tt.host+"/one", entry.URL)
+	}
+	assert.Equal(t, 4, idx.DocCount())
+}
+
+func TestOpen_ConflictingConnectionIDs(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFile(t, dir, "first.jsonl", `{"kind":"tls","connectionId":"c1","events":[]}`)
+	second := writeFile(t, dir, "second.jsonl", `{"kind":"http2","connectionId":"c1","streamId":3,"frames":[]}`)
+
+	_, err := Open(first, second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "first.jsonl")
+}
+
+func TestSource_ListMethods(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open(writeFile(t, dir, "recorded.jsonl", jsonlCapture))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	entries, err := src.ListEntries(ctx, "recorded", nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "e1", entries[0].ID)
+
+	bookmarked, err := src.ListEntries(ctx, "recorded", &client.ListEntriesOptions{Bookmarked: true})
+	require.NoError(t, err)
+	assert.Empty(t, bookmarked)
+
+	bookmarks, err := src.GetSessionBookmarks(ctx, "active")
+	require.NoError(t, err)
+	assert.Empty(t, bookmarks)
+
+	streams, err := src.ListHTTP2StreamIDs(ctx, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, streams)
+}
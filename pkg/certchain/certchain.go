@@ -0,0 +1,234 @@
+// Package certchain decodes the certificate chains recorded in TLS handshakes
+// and flags the problems behind pinning failures and TLS interception:
+// expired, self-signed, and misordered certificates, names that do not match
+// the host, and issuers known to intercept TLS.
+package certchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/ja4"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// Issue codes.
+const (
+	IssueInvalid          = "invalid"
+	IssueExpired          = "expired"
+	IssueNotYetValid      = "not_yet_valid"
+	IssueSelfSigned       = "self_signed"
+	IssueBrokenChain      = "broken_chain"
+	IssueHostnameMismatch = "hostname_mismatch"
+	IssueInterceptionCA   = "interception_ca"
+)
+
+// interceptionIssuers are name fragments of the CAs of debugging proxies,
+// corporate TLS inspection appliances, and antivirus web shields. A chain
+// issued by one was re-signed between the client and the server.
+var interceptionIssuers = []string{
+	"powhttp",
+	"mitmproxy",
+	"charles proxy",
+	"fiddler",
+	"do_not_trust",
+	"portswigger",
+	"proxyman",
+	"http toolkit",
+	"zscaler",
+	"netskope",
+	"fortinet",
+	"fortigate",
+	"palo alto networks",
+	"blue coat",
+	"cisco umbrella",
+	"forcepoint",
+	"sophos",
+	"kaspersky",
+	"eset ssl filter",
+	"avast",
+	"bitdefender",
+	"menlo security",
+	"check point",
+}
+
+// Inspect decodes the certificates of a Certificate message, leaf first,
+// and checks them at the given time. The leaf is checked against hostname
+// unless it is empty.
+func Inspect(msg *client.TLSCertificate, hostname string, at time.Time) types.CertificateChain {
+	chain := types.CertificateChain{
+		Hostname:  hostname,
+		CheckedAt: at.UTC().Format(time.RFC3339),
+	}
+
+	parsed := make([]*x509.Certificate, len(msg.CertificateList))
+	for i, entry := range msg.CertificateList {
+		info := types.CertificateInfo{Index: i}
+		cert, der, err := parse(entry.CertData)
+		if err != nil {
+			info.Error = err.Error()
+			chain.Issues = append(chain.Issues, issue(IssueInvalid, i, "certificate %d could not be parsed: %v", i, err))
+		} else {
+			parsed[i] = cert
+			info = describe(i, cert, der)
+		}
+		chain.Certificates = append(chain.Certificates, info)
+	}
+
+	chain.Issues = append(chain.Issues, check(parsed, hostname, at)...)
+	return chain
+}
+
+// parse decodes a hex-encoded DER certificate.
+func parse(certHex string) (*x509.Certificate, []byte, error) {
+	der, err := hex.DecodeString(certHex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, der, nil
+}
+
+// describe lists the fields of a certificate.
+func describe(index int, cert *x509.Certificate, der []byte) types.CertificateInfo {
+	info := types.CertificateInfo{
+		Index:                  index,
+		Subject:                cert.Subject.String(),
+		Issuer:                 cert.Issuer.String(),
+		SerialNumber:           cert.SerialNumber.Text(16),
+		DNSNames:               cert.DNSNames,
+		NotBefore:              cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:               cert.NotAfter.UTC().Format(time.RFC3339),
+		SignatureAlgorithm:     cert.SignatureAlgorithm.String(),
+		IsCA:                   cert.IsCA,
+		SelfSigned:             selfSigned(cert),
+		OCSPServers:            cert.OCSPServer,
+		CRLDistributionPoints:  cert.CRLDistributionPoints,
+		IssuingCertificateURLs: cert.IssuingCertificateURL,
+		SCTs:                   embeddedSCTs(cert),
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	info.KeyType, info.KeyBits = publicKey(cert)
+
+	sum := sha256.Sum256(der)
+	info.SHA256 = hex.EncodeToString(sum[:])
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	info.SPKISHA256 = base64.StdEncoding.EncodeToString(spki[:])
+	if fp, err := ja4.JA4X(der); err == nil {
+		info.JA4X = fp
+	}
+	return info
+}
+
+// publicKey returns the type and size of a certificate's public key.
+func publicKey(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
+	}
+}
+
+// selfSigned reports whether a certificate is signed by its own key. The
+// signature alone is checked, since self-signed leaves are often not CAs.
+func selfSigned(cert *x509.Certificate) bool {
+	return string(cert.RawIssuer) == string(cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// check finds the problems in a chain. Certificates that could not be
+// parsed are nil and skipped.
+func check(chain []*x509.Certificate, hostname string, at time.Time) []types.CertificateIssue {
+	var issues []types.CertificateIssue
+	for i, cert := range chain {
+		if cert == nil {
+			continue
+		}
+		switch {
+		case at.After(cert.NotAfter):
+			issues = append(issues, issue(IssueExpired, i, "certificate %d (%s) expired on %s", i, name(cert), cert.NotAfter.UTC().Format(time.RFC3339)))
+		case at.Before(cert.NotBefore):
+			issues = append(issues, issue(IssueNotYetValid, i, "certificate %d (%s) is not valid before %s", i, name(cert), cert.NotBefore.UTC().Format(time.RFC3339)))
+		}
+		if issuer := interceptionIssuer(cert); issuer != "" {
+			issues = append(issues, issue(IssueInterceptionCA, i, "certificate %d was issued by %s, which intercepts TLS; the chain is not the server's own", i, issuer))
+		}
+		if i+1 < len(chain) && chain[i+1] != nil && cert.CheckSignatureFrom(chain[i+1]) != nil {
+			issues = append(issues, issue(IssueBrokenChain, i, "certificate %d is not signed by certificate %d (%s)", i, i+1, name(chain[i+1])))
+		}
+	}
+
+	if len(chain) == 0 || chain[0] == nil {
+		return issues
+	}
+	leaf := chain[0]
+	if selfSigned(leaf) {
+		issues = append(issues, issue(IssueSelfSigned, 0, "the leaf certificate (%s) is self-signed", name(leaf)))
+	}
+	if hostname != "" {
+		if err := leaf.VerifyHostname(hostname); err != nil {
+			issues = append(issues, issue(IssueHostnameMismatch, 0, "the leaf certificate is not valid for %s: it covers %s", hostname, coveredNames(leaf)))
+		}
+	}
+	return issues
+}
+
+// interceptionIssuer returns the issuer of a certificate if it is a known
+// interception CA, or "".
+func interceptionIssuer(cert *x509.Certificate) string {
+	names := append([]string{cert.Issuer.CommonName}, cert.Issuer.Organization...)
+	for _, n := range names {
+		lower := strings.ToLower(n)
+		for _, fragment := range interceptionIssuers {
+			if strings.Contains(lower, fragment) {
+				return n
+			}
+		}
+	}
+	return ""
+}
+
+// name identifies a certificate by its subject common name, or its whole
+// subject if it has none.
+func name(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// coveredNames lists the names a certificate is valid for.
+func coveredNames(cert *x509.Certificate) string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 {
+		return "no subject alternative names"
+	}
+	return strings.Join(names, ", ")
+}
+
+// issue builds a CertificateIssue.
+func issue(code string, index int, format string, args ...any) types.CertificateIssue {
+	return types.CertificateIssue{Code: code, Certificate: index, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,182 @@
+package certchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+var now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// testCA issues a self-signed CA certificate with organization org.
+func testCA(t *testing.T, org string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: org + " Root CA", Organization: []string{org}},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(5, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// testLeaf issues an RSA leaf for dnsNames, signed by ca, valid until notAfter.
+func testLeaf(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, notAfter time.Time, dnsNames ...string) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x2a),
+		Subject:               pkix.Name{CommonName: dnsNames[0]},
+		NotBefore:             now.AddDate(0, -1, 0),
+		NotAfter:              notAfter,
+		DNSNames:              dnsNames,
+		OCSPServer:            []string{"http://ocsp.example.net"},
+		CRLDistributionPoints: []string{"http://crl.example.net/ca.crl"},
+		ExtraExtensions:       []pkix.Extension{{Id: oidSCTList, Value: sctList(t)}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	return der
+}
+
+// sctList encodes an SCT list extension value with one timestamp, at now,
+// from a log whose ID is 32 bytes of 0x01.
+func sctList(t *testing.T) []byte {
+	t.Helper()
+	sct := []byte{0} // v1
+	for range 32 {
+		sct = append(sct, 1)
+	}
+	ms := uint64(now.UnixMilli())
+	for i := 7; i >= 0; i-- {
+		sct = append(sct, byte(ms>>(8*i)))
+	}
+	sct = append(sct, 0, 0) // No extensions; the signature is not read
+	list := append([]byte{byte(len(sct) >> 8), byte(len(sct))}, sct...)
+	list = append([]byte{byte(len(list) >> 8), byte(len(list))}, list...)
+	value, err := asn1.Marshal(list)
+	require.NoError(t, err)
+	return value
+}
+
+// message builds a Certificate message from DER certificates.
+func message(ders ...[]byte) *client.TLSCertificate {
+	msg := &client.TLSCertificate{}
+	for _, der := range ders {
+		msg.CertificateList = append(msg.CertificateList, client.TLSCertificateEntry{CertData: hex.EncodeToString(der)})
+	}
+	return msg
+}
+
+// codes lists the codes of issues.
+func codes(issues []types.CertificateIssue) []string {
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.Code)
+	}
+	return out
+}
+
+func TestInspect(t *testing.T) {
+	ca, caKey := testCA(t, "Example Trust")
+	leaf := testLeaf(t, ca, caKey, now.AddDate(0, 3, 0), "api.example.com", "*.cdn.example.com")
+
+	chain := Inspect(message(leaf, ca.Raw), "api.example.com", now)
+	assert.Equal(t, "2026-06-01T12:00:00Z", chain.CheckedAt)
+	assert.Empty(t, chain.Issues)
+	require.Len(t, chain.Certificates, 2)
+
+	c := chain.Certificates[0]
+	assert.Equal(t, "CN=api.example.com", c.Subject)
+	assert.Equal(t, "CN=Example Trust Root CA,O=Example Trust", c.Issuer)
+	assert.Equal(t, "2a", c.SerialNumber)
+	assert.Equal(t, []string{"api.example.com", "*.cdn.example.com"}, c.DNSNames)
+	assert.Equal(t, "RSA", c.KeyType)
+	assert.Equal(t, 2048, c.KeyBits)
+	assert.Equal(t, "ECDSA-SHA256", c.SignatureAlgorithm)
+	assert.Equal(t, []string{"http://ocsp.example.net"}, c.OCSPServers)
+	assert.Equal(t, []string{"http://crl.example.net/ca.crl"}, c.CRLDistributionPoints)
+	assert.Equal(t, []types.SignedCertificateTimestamp{{
+		LogID:     "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=",
+		Timestamp: "2026-06-01T12:00:00Z",
+	}}, c.SCTs)
+	assert.Len(t, c.SHA256, 64)
+	assert.Len(t, c.SPKISHA256, 44)
+	assert.NotEmpty(t, c.JA4X)
+	assert.False(t, c.IsCA)
+
+	root := chain.Certificates[1]
+	assert.True(t, root.IsCA)
+	assert.True(t, root.SelfSigned)
+	assert.Equal(t, "ECDSA", root.KeyType)
+	assert.Equal(t, 256, root.KeyBits)
+
+	// A wildcard covers one label
+	chain = Inspect(message(leaf, ca.Raw), "img.cdn.example.com", now)
+	assert.Empty(t, chain.Issues)
+}
+
+func TestInspect_Issues(t *testing.T) {
+	ca, caKey := testCA(t, "Example Trust")
+	other, _ := testCA(t, "Other Trust")
+	expired := testLeaf(t, ca, caKey, now.AddDate(0, 0, -1), "api.example.com")
+
+	tests := []struct {
+		name     string
+		msg      *client.TLSCertificate
+		hostname string
+		want     []string
+	}{
+		{"expired", message(expired, ca.Raw), "api.example.com", []string{IssueExpired}},
+		{"hostname mismatch", message(testLeaf(t, ca, caKey, now.AddDate(1, 0, 0), "api.example.com"), ca.Raw), "www.example.org", []string{IssueHostnameMismatch}},
+		{"no hostname to check", message(testLeaf(t, ca, caKey, now.AddDate(1, 0, 0), "api.example.com")), "", nil},
+		{"self-signed leaf", message(ca.Raw), "api.example.com", []string{IssueSelfSigned, IssueHostnameMismatch}},
+		{"broken chain", message(expired, other.Raw), "api.example.com", []string{IssueExpired, IssueBrokenChain}},
+		{"unparseable", message([]byte("junk"), ca.Raw), "", []string{IssueInvalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := Inspect(tt.msg, tt.hostname, now)
+			assert.Equal(t, tt.want, codes(chain.Issues))
+		})
+	}
+}
+
+func TestInspect_InterceptionCA(t *testing.T) {
+	ca, caKey := testCA(t, "mitmproxy")
+	leaf := testLeaf(t, ca, caKey, now.AddDate(1, 0, 0), "api.example.com")
+
+	chain := Inspect(message(leaf), "api.example.com", now)
+	require.Equal(t, []string{IssueInterceptionCA}, codes(chain.Issues))
+	assert.Contains(t, chain.Issues[0].Message, "mitmproxy")
+}
+
+func TestEmbeddedSCTs_Malformed(t *testing.T) {
+	ext := pkix.Extension{Id: oidSCTList, Value: []byte{0x04, 0x02, 0x00, 0x09}}
+	assert.Nil(t, embeddedSCTs(&x509.Certificate{Extensions: []pkix.Extension{ext}}))
+	assert.Nil(t, embeddedSCTs(&x509.Certificate{}))
+}
@@ -0,0 +1,72 @@
+package certchain
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// oidSCTList is the X.509 extension carrying embedded Certificate
+// Transparency timestamps (RFC 6962, section 3.3).
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// sctHeaderLen is the size of an SCT's version, log ID, and timestamp, the
+// fields read here.
+const sctHeaderLen = 1 + 32 + 8
+
+// embeddedSCTs returns the log ID and time of each timestamp embedded in a
+// certificate. A malformed list yields the timestamps read before the error.
+func embeddedSCTs(cert *x509.Certificate) []types.SignedCertificateTimestamp {
+	var list []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSCTList) {
+			if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+				return nil
+			}
+			break
+		}
+	}
+
+	// The list is a TLS opaque<1..2^16-1> of opaque<1..2^16-1> SCTs
+	list, ok := readVector(list)
+	if !ok {
+		return nil
+	}
+	var scts []types.SignedCertificateTimestamp
+	for len(list) > 0 {
+		var sct []byte
+		sct, list, ok = splitVector(list)
+		if !ok || len(sct) < sctHeaderLen {
+			break
+		}
+		ms := binary.BigEndian.Uint64(sct[33:41])
+		scts = append(scts, types.SignedCertificateTimestamp{
+			LogID:     base64.StdEncoding.EncodeToString(sct[1:33]),
+			Timestamp: time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339),
+		})
+	}
+	return scts
+}
+
+// readVector returns the body of data holding exactly one vector with a
+// 2-byte length prefix.
+func readVector(data []byte) ([]byte, bool) {
+	body, rest, ok := splitVector(data)
+	return body, ok && len(rest) == 0
+}
+
+// splitVector splits a vector with a 2-byte length prefix off data.
+func splitVector(data []byte) (body, rest []byte, ok bool) {
+	if len(data) < 2 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+n {
+		return nil, nil, false
+	}
+	return data[2 : 2+n], data[2+n:], true
+}
@@ -43,9 +43,15 @@
 //
 // # Working with Bodies
 //
-// Request and response bodies are base64-encoded. Use DecodeBody to decode them:
+// Request and response bodies are base64-encoded and kept as sent, so they
+// may still be gzip, deflate, or zstd compressed. DecodedBody decodes and
+// decompresses them:
 //
-//	body, err := client.DecodeBody(entry.Response.Body)
+//	body, err := entry.Response.DecodedBody()
+//
+// DecodeBody only base64-decodes, for when the wire bytes are needed:
+//
+//	raw, err := client.DecodeBody(entry.Response.Body)
 //
 // # Working with Headers
 //
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/usestring/powhttp-mcp/internal/brotli"
+	"github.com/usestring/powhttp-mcp/internal/zstd"
+)
+
+// MaxDecodedBodyBytes caps the size of a decompressed body, guarding against
+// decompression bombs.
+const MaxDecodedBodyBytes = 32 << 20
+
+// ErrBodyTooLarge is returned when a body decompresses to more than
+// MaxDecodedBodyBytes.
+var ErrBodyTooLarge = fmt.Errorf("decompressed body exceeds %d bytes", MaxDecodedBodyBytes)
+
+// ErrUnsupportedEncoding is returned for content codings that cannot be
+// decoded, such as compress.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// Magic numbers that identify compressed payloads.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ContentEncoding returns the normalized Content-Encoding of headers: the
+// lowercase codings in the order they were applied, joined by ", ", with
+// identity dropped. Returns "" for uncompressed bodies.
+func ContentEncoding(h Headers) string {
+	var codings []string
+	for _, v := range h.Values("content-encoding") {
+		for _, c := range strings.Split(v, ",") {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c != "" && c != "identity" {
+				codings = append(codings, c)
+			}
+		}
+	}
+	return strings.Join(codings, ", ")
+}
+
+// Decompress removes the content codings listed in contentEncoding (as
+// returned by ContentEncoding or taken from the header) from data. Codings are
+// undone in reverse order of application. It supports gzip, deflate, zstd,
+// and br; other codings fail with ErrUnsupportedEncoding.
+//
+// Captures and HAR files sometimes hold a body that was already decoded while
+// the header still names the coding, so a payload that is evidently not
+// compressed (no gzip or zstd magic, undecodable deflate, or plain text for
+// br) is returned unchanged.
+func Decompress(data []byte, contentEncoding string) ([]byte, error) {
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		var err error
+		switch coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			if !bytes.HasPrefix(data, gzipMagic) {
+				continue
+			}
+			data, err = decompressWith(data, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) })
+		case "deflate":
+			// RFC 9110 deflate is zlib-wrapped, but some servers send raw deflate.
+			out, zerr := decompressWith(data, func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) })
+			if zerr != nil && !errors.Is(zerr, ErrBodyTooLarge) {
+				out, zerr = decompressWith(data, func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil })
+				if zerr != nil && !errors.Is(zerr, ErrBodyTooLarge) {
+					continue
+				}
+			}
+			data, err = out, zerr
+		case "zstd":
+			if !bytes.HasPrefix(data, zstdMagic) {
+				continue
+			}
+			data, err = decompressWith(data, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r), nil })
+		case "br":
+			// Brotli streams have no magic number.
+			if isPlainText(data) {
+				continue
+			}
+			data, err = decompressWith(data, func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil })
+		default:
+			return nil, fmt.Errorf("%w %q", ErrUnsupportedEncoding, coding)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s body: %w", coding, err)
+		}
+	}
+	return data, nil
+}
+
+// decompressWith reads data through the reader returned by open, stopping
+// at MaxDecodedBodyBytes.
+func decompressWith(data []byte, open func(io.Reader) (io.Reader, error)) ([]byte, error) {
+	r, err := open(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	out, err := io.ReadAll(io.LimitReader(r, MaxDecodedBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > MaxDecodedBodyBytes {
+		return nil, ErrBodyTooLarge
+	}
+	return out, nil
+}
+
+// isPlainText reports whether data is UTF-8 text without control characters
+// other than whitespace, which compressed streams practically never are.
+func isPlainText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeContent base64-decodes a body and removes its content coding.
+// Returns nil if the input is nil.
+func DecodeContent(encoded *string, contentEncoding string) ([]byte, error) {
+	raw, err := DecodeBody(encoded)
+	if err != nil || len(raw) == 0 {
+		return raw, err
+	}
+	return Decompress(raw, contentEncoding)
+}
+
+// DecodedBody returns the request body base64-decoded and decompressed
+// according to its Content-Encoding.
+func (r *Request) DecodedBody() ([]byte, error) {
+	return DecodeContent(r.Body, ContentEncoding(r.Headers))
+}
+
+// DecodedBody returns the response body base64-decoded and decompressed
+// according to its Content-Encoding.
+func (r *Response) DecodedBody() ([]byte, error) {
+	return DecodeContent(r.Body, ContentEncoding(r.Headers))
+}
+
+// EncodeBody base64-encodes a body for storage in a SessionEntry.
+func EncodeBody(body []byte) *string {
+	s := base64.StdEncoding.EncodeToString(body)
+	return &s
+}
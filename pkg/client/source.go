@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// DataSource is the read-only view of captured traffic that powhttp-mcp
+// engines consume. *Client implements it against the live Data API; other
+// implementations (such as pkg/capture) serve traffic from files.
+//
+// Implementations should return an *APIError with StatusCode 404 when a
+// session, entry, or connection does not exist, and accept "active" as a
+// session ID where the Data API does.
+type DataSource interface {
+	ListSessions(ctx context.Context) ([]Session, error)
+	GetSession(ctx context.Context, sessionID string) (*Session, error)
+	GetSessionBookmarks(ctx context.Context, sessionID string) ([]string, error)
+	ListEntries(ctx context.Context, sessionID string, opts *ListEntriesOptions) ([]SessionEntry, error)
+	GetEntry(ctx context.Context, sessionID, entryID string) (*SessionEntry, error)
+	GetTLSConnection(ctx context.Context, connectionID string) ([]TLSEvent, error)
+	ListHTTP2StreamIDs(ctx context.Context, connectionID string) ([]int, error)
+	GetHTTP2Stream(ctx context.Context, connectionID string, streamID int) ([]json.RawMessage, error)
+	GetWebSocketMessages(ctx context.Context, sessionID, entryID string) ([]WebSocketMessage, error)
+}
+
+var _ DataSource = (*Client)(nil)
@@ -365,7 +365,9 @@ type errorResponse struct {
 	Error string `json:"error"`
 }
 
-// DecodeBody decodes a base64-encoded body.
+// DecodeBody decodes a base64-encoded body as captured on the wire; any
+// Content-Encoding is left in place. Use DecodeContent or the DecodedBody
+// methods on Request and Response to also decompress it.
 // Returns nil if the input is nil.
 func DecodeBody(encoded *string) ([]byte, error) {
 	if encoded == nil {
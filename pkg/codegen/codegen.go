@@ -0,0 +1,264 @@
+// Package codegen renders captured HTTP requests as runnable client code.
+//
+// Output is produced from fixed templates, so the same request always yields
+// the same code. Header order, HTTP version, and body bytes are carried over
+// as far as each client library allows; what a library cannot reproduce is
+// reported in Code.Notes.
+package codegen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Supported languages.
+const (
+	Curl           = "curl"
+	Go             = "go"
+	GoTLSClient    = "go-tls-client"
+	PythonRequests = "python-requests"
+	PythonHTTPX    = "python-httpx"
+	TypeScript     = "typescript"
+)
+
+// Languages lists the supported languages in display order.
+var Languages = []string{Curl, Go, GoTLSClient, PythonRequests, PythonHTTPX, TypeScript}
+
+// DefaultTLSProfile is the tls-client profile used when none is given.
+const DefaultTLSProfile = "Chrome_133_PSK"
+
+// profileName matches tls-client profile identifiers such as Chrome_133_PSK.
+var profileName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Request is the request to render.
+type Request struct {
+	Method            string
+	URL               string
+	HTTPVersion       string     // Captured version (e.g. "h2", "http/1.1")
+	Headers           [][]string // Regular headers in capture order, without pseudo or hop-by-hop headers
+	PseudoHeaderOrder []string   // HTTP/2 pseudo-header names in capture order (e.g. ":method", ":authority")
+	Body              []byte
+}
+
+// Options tunes generation.
+type Options struct {
+	TLSProfile string // tls-client profile name from the profiles package (default: DefaultTLSProfile)
+}
+
+// Code is generated source for one language.
+type Code struct {
+	Language string   `json:"language"`
+	Code     string   `json:"code"`
+	Notes    []string `json:"notes,omitzero"` // Fidelity caveats for this language
+}
+
+// Generate renders r as code in lang.
+func Generate(lang string, r *Request, opts Options) (*Code, error) {
+	tmpl, ok := templates[lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+	if r.Method == "" || r.URL == "" {
+		return nil, fmt.Errorf("request needs a method and URL")
+	}
+	if opts.TLSProfile != "" && !profileName.MatchString(opts.TLSProfile) {
+		return nil, fmt.Errorf("invalid tls profile %q", opts.TLSProfile)
+	}
+
+	v := newView(r, opts)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", lang, err)
+	}
+	src := buf.Bytes()
+	if lang == Go || lang == GoTLSClient {
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("formatting %s: %w", lang, err)
+		}
+		src = formatted
+	}
+
+	return &Code{Language: lang, Code: string(src), Notes: notes(lang, v)}, nil
+}
+
+// view is the template data for a request.
+type view struct {
+	Method      string
+	URL         string
+	HTTP2       bool
+	Headers     [][]string
+	Grouped     []headerGroup // Headers merged by case-insensitive name, in first-seen order
+	PseudoOrder []string
+	HasBody     bool
+	Binary      bool
+	Body        string // Text body, or base64 when Binary
+	RawBody     string // Body bytes as-is, for Go string literals
+	Profile     string
+}
+
+// headerGroup holds every value of one header name.
+type headerGroup struct {
+	Name   string // Casing of the first occurrence
+	Values []string
+}
+
+// Joined returns the values as one field value. Cookies are joined with "; "
+// as the Cookie header requires; other headers with ", ".
+func (g headerGroup) Joined() string {
+	if strings.EqualFold(g.Name, "cookie") {
+		return strings.Join(g.Values, "; ")
+	}
+	return strings.Join(g.Values, ", ")
+}
+
+func newView(r *Request, opts Options) *view {
+	v := &view{
+		Method:      strings.ToUpper(r.Method),
+		URL:         r.URL,
+		HTTP2:       isHTTP2(r.HTTPVersion),
+		Headers:     r.Headers,
+		PseudoOrder: r.PseudoHeaderOrder,
+		HasBody:     len(r.Body) > 0,
+		RawBody:     string(r.Body),
+		Profile:     opts.TLSProfile,
+	}
+	if v.Profile == "" {
+		v.Profile = DefaultTLSProfile
+	}
+	if v.HTTP2 && len(v.PseudoOrder) == 0 {
+		v.PseudoOrder = []string{":method", ":authority", ":scheme", ":path"}
+	}
+
+	if isBinary(r.Body) {
+		v.Binary = true
+		v.Body = base64.StdEncoding.EncodeToString(r.Body)
+	} else {
+		v.Body = string(r.Body)
+	}
+
+	for _, h := range r.Headers {
+		idx := slices.IndexFunc(v.Grouped, func(g headerGroup) bool { return strings.EqualFold(g.Name, h[0]) })
+		if idx < 0 {
+			v.Grouped = append(v.Grouped, headerGroup{Name: h[0], Values: []string{h[1]}})
+		} else {
+			v.Grouped[idx].Values = append(v.Grouped[idx].Values, h[1])
+		}
+	}
+	return v
+}
+
+// HeaderOrder returns the lowercase header names in first-seen order.
+func (v *view) HeaderOrder() []string {
+	names := make([]string, len(v.Grouped))
+	for i, g := range v.Grouped {
+		names[i] = strings.ToLower(g.Name)
+	}
+	return names
+}
+
+// HasHeader reports whether the request carries the named header.
+func (v *view) HasHeader(name string) bool {
+	return slices.ContainsFunc(v.Headers, func(h []string) bool { return strings.EqualFold(h[0], name) })
+}
+
+// ImpliedMethod reports whether curl infers the method without -X.
+func (v *view) ImpliedMethod() bool {
+	return (v.Method == "GET" && !v.HasBody) || (v.Method == "POST" && v.HasBody)
+}
+
+func notes(lang string, v *view) []string {
+	var out []string
+	hasDup := len(v.Grouped) < len(v.Headers)
+	switch lang {
+	case Curl:
+		out = append(out, "curl sends Host, User-Agent, and Accept first unless the captured headers override them.")
+	case Go:
+		out = append(out, "net/http writes headers in its own order and cannot match the captured order or TLS fingerprint; use go-tls-client for that.")
+	case GoTLSClient:
+		out = append(out, fmt.Sprintf("The TLS and HTTP/2 fingerprint comes from profiles.%s; compare it with powhttp_fingerprint.", v.Profile))
+	case PythonRequests:
+		if v.HTTP2 {
+			out = append(out, "requests only speaks HTTP/1.1 but the original used HTTP/2; use python-httpx to keep the version.")
+		}
+		if hasDup {
+			out = append(out, "Repeated headers are joined into one value because requests takes a dict.")
+		}
+	case PythonHTTPX:
+		if v.HTTP2 {
+			out = append(out, "HTTP/2 needs the http2 extra: pip install 'httpx[http2]'.")
+		}
+	case TypeScript:
+		out = append(out, "fetch negotiates the HTTP version itself, and browsers refuse to set forbidden headers such as Cookie and User-Agent; run it with Node.js or Deno.")
+	}
+	return out
+}
+
+// isHTTP2 reports whether a captured HTTP version string denotes HTTP/2.
+func isHTTP2(version string) bool {
+	v := strings.ToLower(version)
+	return v == "h2" || strings.HasPrefix(v, "http/2")
+}
+
+// isBinary reports whether body cannot be embedded as a text literal.
+func isBinary(body []byte) bool {
+	if !utf8.Valid(body) {
+		return true
+	}
+	for _, c := range body {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// curlHeader formats a header for curl -H. An empty value needs the "name;"
+// form, since "name:" tells curl to drop the header.
+func curlHeader(name, value string) string {
+	if value == "" {
+		return name + ";"
+	}
+	return name + ": " + value
+}
+
+// jsonQuote returns s as a JSON string, which is also a valid Python and
+// JavaScript string literal.
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+var funcs = template.FuncMap{
+	"sh":         shellQuote,
+	"curlHeader": curlHeader,
+	"goq":        strconv.Quote,
+	"q":          jsonQuote,
+	"lower":      strings.ToLower,
+}
+
+var templates = map[string]*template.Template{
+	Curl:           template.Must(template.New(Curl).Funcs(funcs).Parse(curlTemplate)),
+	Go:             template.Must(template.New(Go).Funcs(funcs).Parse(goTemplate)),
+	GoTLSClient:    template.Must(template.New(GoTLSClient).Funcs(funcs).Parse(goTLSClientTemplate)),
+	PythonRequests: template.Must(template.New(PythonRequests).Funcs(funcs).Parse(pythonRequestsTemplate)),
+	PythonHTTPX:    template.Must(template.New(PythonHTTPX).Funcs(funcs).Parse(pythonHTTPXTemplate)),
+	TypeScript:     template.Must(template.New(TypeScript).Funcs(funcs).Parse(typeScriptTemplate)),
+}
@@ -0,0 +1,157 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRequest() *Request {
+	return &Request{
+		Method:      "POST",
+		URL:         "https://api.example.com/v1/items?q=it's",
+		HTTPVersion: "h2",
+		Headers: [][]string{
+			{"user-agent", "UA"},
+			{"accept-encoding", "gzip"},
+			{"cookie", "a=1"},
+			{"cookie", "b=2"},
+			{"x-empty", ""},
+		},
+		PseudoHeaderOrder: []string{":method", ":authority", ":scheme", ":path"},
+		Body:              []byte(`{"name":"it's \"q\""}`),
+	}
+}
+
+// assertInOrder checks that each needle occurs in code after the previous one.
+func assertInOrder(t *testing.T, code string, needles ...string) {
+	t.Helper()
+	pos := 0
+	for _, n := range needles {
+		i := strings.Index(code[pos:], n)
+		if !assert.GreaterOrEqual(t, i, 0, "%q not found in order in:\n%s", n, code) {
+			return
+		}
+		pos += i + len(n)
+	}
+}
+
+func TestGenerate_Curl(t *testing.T) {
+	c, err := Generate(Curl, testRequest(), Options{})
+	require.NoError(t, err)
+	assertInOrder(t, c.Code,
+		`curl --http2 'https://api.example.com/v1/items?q=it'\''s'`,
+		`-H 'user-agent: UA'`,
+		`-H 'cookie: a=1'`,
+		`-H 'cookie: b=2'`,
+		`-H 'x-empty;'`,
+		`--data-raw '{"name":"it'\''s \"q\""}'`,
+		`--compressed`,
+	)
+	assert.NotContains(t, c.Code, "-X", "POST with a body needs no -X")
+
+	r := testRequest()
+	r.Method = "PUT"
+	c, err = Generate(Curl, r, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, c.Code, "curl --http2 -X PUT 'https://")
+}
+
+func TestGenerate_Go(t *testing.T) {
+	c, err := Generate(Go, testRequest(), Options{})
+	require.NoError(t, err)
+	assertInOrder(t, c.Code,
+		`http.NewRequest("POST", "https://api.example.com/v1/items?q=it's", body)`,
+		`req.Header.Add("user-agent", "UA")`,
+		`req.Header.Add("cookie", "a=1")`,
+		`req.Header.Add("cookie", "b=2")`,
+		`ForceAttemptHTTP2: true`,
+	)
+	assert.Contains(t, c.Code, `strings.NewReader("{\"name\":\"it's \\\"q\\\"\"}")`)
+	assert.NotContains(t, c.Code, "crypto/tls")
+
+	r := testRequest()
+	r.HTTPVersion = "http/1.1"
+	r.Body = nil
+	c, err = Generate(Go, r, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, c.Code, "TLSNextProto")
+	assert.NotContains(t, c.Code, `"strings"`)
+	assert.Contains(t, c.Code, `http.NewRequest("POST", "https://api.example.com/v1/items?q=it's", nil)`)
+}
+
+func TestGenerate_GoTLSClient(t *testing.T) {
+	c, err := Generate(GoTLSClient, testRequest(), Options{TLSProfile: "Firefox_135"})
+	require.NoError(t, err)
+	assert.Contains(t, c.Code, "profiles.Firefox_135")
+	assert.Contains(t, c.Code, `"cookie":             {"a=1", "b=2"}`)
+	assert.Contains(t, c.Code, `http.HeaderOrderKey:  {"user-agent", "accept-encoding", "cookie", "x-empty"}`)
+	assert.Contains(t, c.Code, `http.PHeaderOrderKey: {":method", ":authority", ":scheme", ":path"}`)
+	assert.NotContains(t, c.Code, "WithForceHttp1")
+
+	r := testRequest()
+	r.HTTPVersion = "http/1.1"
+	c, err = Generate(GoTLSClient, r, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, c.Code, "profiles."+DefaultTLSProfile)
+	assert.Contains(t, c.Code, "tls_client.WithForceHttp1()")
+	assert.NotContains(t, c.Code, "PHeaderOrderKey")
+}
+
+func TestGenerate_Python(t *testing.T) {
+	c, err := Generate(PythonRequests, testRequest(), Options{})
+	require.NoError(t, err)
+	assertInOrder(t, c.Code, `"user-agent": "UA"`, `"accept-encoding": "gzip"`, `"cookie": "a=1; b=2"`)
+	assert.Contains(t, c.Code, `data = "{\"name\":\"it's \\\"q\\\"\"}".encode()`)
+	assert.Len(t, c.Notes, 2, "HTTP/2 and repeated-header caveats")
+
+	c, err = Generate(PythonHTTPX, testRequest(), Options{})
+	require.NoError(t, err)
+	assertInOrder(t, c.Code, `("user-agent", "UA")`, `("cookie", "a=1")`, `("cookie", "b=2")`, `http2=True`, `content=data`)
+}
+
+func TestGenerate_TypeScript(t *testing.T) {
+	c, err := Generate(TypeScript, testRequest(), Options{})
+	require.NoError(t, err)
+	assertInOrder(t, c.Code, `const body = "{\"name\":\"it's \\\"q\\\"\"}";`, `method: "POST"`, `["user-agent", "UA"]`, `["cookie", "b=2"]`, `body,`)
+}
+
+func TestGenerate_BinaryBody(t *testing.T) {
+	r := testRequest()
+	r.Body = []byte{0x00, 0x01, 0xff}
+
+	want := map[string]string{
+		Curl:           `printf '%s' 'AAH/' | base64 -d | curl`,
+		Go:             `strings.NewReader("\x00\x01\xff")`,
+		GoTLSClient:    `strings.NewReader("\x00\x01\xff")`,
+		PythonRequests: `data = base64.b64decode("AAH/")`,
+		PythonHTTPX:    `data = base64.b64decode("AAH/")`,
+		TypeScript:     `Uint8Array.from(atob("AAH/"), (c) => c.charCodeAt(0))`,
+	}
+	for _, lang := range Languages {
+		c, err := Generate(lang, r, Options{})
+		require.NoError(t, err, lang)
+		assert.Contains(t, c.Code, want[lang], lang)
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	for _, lang := range Languages {
+		a, err := Generate(lang, testRequest(), Options{})
+		require.NoError(t, err)
+		b, err := Generate(lang, testRequest(), Options{})
+		require.NoError(t, err)
+		assert.Equal(t, a.Code, b.Code, lang)
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	_, err := Generate("ruby", testRequest(), Options{})
+	assert.Error(t, err)
+	_, err = Generate(Go, &Request{URL: "https://a"}, Options{})
+	assert.Error(t, err)
+	_, err = Generate(GoTLSClient, testRequest(), Options{TLSProfile: "x); os.Exit(1"})
+	assert.Error(t, err)
+}
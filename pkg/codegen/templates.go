@@ -0,0 +1,190 @@
+package codegen
+
+const curlTemplate = `{{if .Binary}}printf '%s' {{sh .Body}} | base64 -d | {{end}}curl {{if .HTTP2}}--http2{{else}}--http1.1{{end}}{{if not .ImpliedMethod}} -X {{.Method}}{{end}} {{sh .URL}}
+{{- range .Headers}} \
+  -H {{sh (curlHeader (index . 0) (index . 1))}}
+{{- end}}
+{{- if .HasBody}} \
+  {{if .Binary}}--data-binary @-{{else}}--data-raw {{sh .Body}}{{end}}
+{{- end}}
+{{- if .HasHeader "accept-encoding"}} \
+  --compressed
+{{- end}}
+`
+
+const goTemplate = `package main
+
+import (
+{{- if not .HTTP2}}
+	"crypto/tls"
+{{- end}}
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+{{- if .HasBody}}
+	"strings"
+{{- end}}
+)
+
+func main() {
+{{- if .HasBody}}
+	body := strings.NewReader({{goq .RawBody}})
+{{- end}}
+	req, err := http.NewRequest({{goq .Method}}, {{goq .URL}}, {{if .HasBody}}body{{else}}nil{{end}})
+	if err != nil {
+		log.Fatal(err)
+	}
+{{- range .Headers}}
+	req.Header.Add({{goq (index . 0)}}, {{goq (index . 1)}})
+{{- end}}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+{{- if .HTTP2}}
+			ForceAttemptHTTP2: true,
+{{- else}}
+			// A non-nil empty map disables HTTP/2; the original request used HTTP/1.x.
+			TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+{{- end}}
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(resp.Status)
+	fmt.Println(string(data))
+}
+`
+
+const goTLSClientTemplate = `package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+{{- if .HasBody}}
+	"strings"
+{{- end}}
+
+	http "github.com/bogdanfinn/fhttp"
+	tls_client "github.com/bogdanfinn/tls-client"
+	"github.com/bogdanfinn/tls-client/profiles"
+)
+
+func main() {
+	client, err := tls_client.NewHttpClient(tls_client.NewNoopLogger(),
+		tls_client.WithTimeoutSeconds(30),
+		tls_client.WithClientProfile(profiles.{{.Profile}}),
+		tls_client.WithNotFollowRedirects(),
+{{- if not .HTTP2}}
+		tls_client.WithForceHttp1(),
+{{- end}}
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+{{if .HasBody}}
+	body := strings.NewReader({{goq .RawBody}})
+{{- end}}
+	req, err := http.NewRequest({{goq .Method}}, {{goq .URL}}, {{if .HasBody}}body{{else}}nil{{end}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header = http.Header{
+{{- range .Grouped}}
+		{{goq .Name}}: { {{- range $i, $v := .Values}}{{if $i}}, {{end}}{{goq $v}}{{end -}} },
+{{- end}}
+		http.HeaderOrderKey: { {{- range $i, $n := .HeaderOrder}}{{if $i}}, {{end}}{{goq $n}}{{end -}} },
+{{- if .HTTP2}}
+		http.PHeaderOrderKey: { {{- range $i, $n := .PseudoOrder}}{{if $i}}, {{end}}{{goq $n}}{{end -}} },
+{{- end}}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(resp.Status)
+	fmt.Println(string(data))
+}
+`
+
+const pythonRequestsTemplate = `{{if .Binary}}import base64
+
+{{end}}import requests
+
+url = {{q .URL}}
+headers = {
+{{- range .Grouped}}
+    {{q .Name}}: {{q .Joined}},
+{{- end}}
+}
+{{- if .HasBody}}
+data = {{if .Binary}}base64.b64decode({{q .Body}}){{else}}{{q .Body}}.encode(){{end}}
+{{- end}}
+
+session = requests.Session()
+session.headers.clear()  # send only the captured headers
+response = session.request({{q .Method}}, url, headers=headers{{if .HasBody}}, data=data{{end}}, allow_redirects=False)
+print(response.status_code)
+print(response.text)
+`
+
+const pythonHTTPXTemplate = `{{if .Binary}}import base64
+
+{{end}}import httpx
+
+url = {{q .URL}}
+headers = [
+{{- range .Headers}}
+    ({{q (index . 0)}}, {{q (index . 1)}}),
+{{- end}}
+]
+{{- if .HasBody}}
+data = {{if .Binary}}base64.b64decode({{q .Body}}){{else}}{{q .Body}}.encode(){{end}}
+{{- end}}
+
+with httpx.Client(http2={{if .HTTP2}}True{{else}}False{{end}}, follow_redirects=False) as client:
+    client.headers.clear()  # send only the captured headers
+    response = client.request({{q .Method}}, url, headers=headers{{if .HasBody}}, content=data{{end}})
+print(response.status_code)
+print(response.text)
+`
+
+const typeScriptTemplate = `const url = {{q .URL}};
+{{- if .HasBody}}
+const body = {{if .Binary}}Uint8Array.from(atob({{q .Body}}), (c) => c.charCodeAt(0)){{else}}{{q .Body}}{{end}};
+{{- end}}
+
+const response = await fetch(url, {
+  method: {{q .Method}},
+  headers: [
+{{- range .Headers}}
+    [{{q (index . 0)}}, {{q (index . 1)}}],
+{{- end}}
+  ],
+{{- if .HasBody}}
+  body,
+{{- end}}
+  redirect: "manual",
+});
+console.log(response.status);
+console.log(await response.text());
+`
@@ -0,0 +1,440 @@
+package har
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/contenttype"
+)
+
+// Default creator identity written to the log.
+const (
+	DefaultCreatorName    = "powhttp-mcp"
+	DefaultCreatorVersion = "1.0.0"
+)
+
+// unknownMimeType is used when a body has no Content-Type, matching browser exports.
+const unknownMimeType = "x-unknown"
+
+// Options controls conversion.
+type Options struct {
+	CreatorName    string // default DefaultCreatorName
+	CreatorVersion string // default DefaultCreatorVersion
+	OmitBodies     bool   // leave request/response body text out entirely
+	MaxBodyBytes   int    // bodies larger than this are omitted with a comment; 0 = no limit
+}
+
+// FromEntries builds a HAR document from captured entries, ordered by start time.
+// Nil entries are skipped.
+func FromEntries(entries []*client.SessionEntry, opts Options) *HAR {
+	if opts.CreatorName == "" {
+		opts.CreatorName = DefaultCreatorName
+	}
+	if opts.CreatorVersion == "" {
+		opts.CreatorVersion = DefaultCreatorVersion
+	}
+
+	sorted := make([]*client.SessionEntry, 0, len(entries))
+	for _, e := range entries {
+		if e != nil {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timings.StartedAt < sorted[j].Timings.StartedAt
+	})
+
+	h := &HAR{Log: Log{
+		Version: Version,
+		Creator: Creator{Name: opts.CreatorName, Version: opts.CreatorVersion},
+		Entries: make([]Entry, 0, len(sorted)),
+	}}
+	for _, e := range sorted {
+		h.Log.Entries = append(h.Log.Entries, FromEntry(e, opts))
+	}
+	return h
+}
+
+// FromEntry converts a single captured entry.
+func FromEntry(e *client.SessionEntry, opts Options) Entry {
+	out := Entry{
+		StartedDateTime: time.UnixMilli(e.Timings.StartedAt).UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		Request:         convertRequest(e, opts),
+		Response:        convertResponse(e, opts),
+		Timings:         convertTimings(e.Timings),
+		EntryID:         e.ID,
+		WebSocket:       e.IsWebSocket,
+		TLS:             convertTLS(e.TLS),
+	}
+	out.Time = totalTime(out.Timings)
+
+	if e.RemoteAddr != nil {
+		out.ServerIPAddress = e.RemoteAddr.IP
+	}
+	if e.ClientAddr != nil {
+		out.Client = &RemoteAddr{IP: e.ClientAddr.IP}
+		if e.ClientAddr.Port != nil {
+			out.Client.Port = *e.ClientAddr.Port
+		}
+	}
+	if e.HTTP2 != nil {
+		out.HTTP2 = &HTTP2{ConnectionID: e.HTTP2.ConnectionID, StreamID: e.HTTP2.StreamID}
+	}
+	if e.Process != nil {
+		out.Process = &Process{PID: e.Process.PID}
+		if e.Process.Name != nil {
+			out.Process.Name = *e.Process.Name
+		}
+	}
+
+	switch {
+	case e.TLS.ConnectionID != nil:
+		out.Connection = *e.TLS.ConnectionID
+	case e.HTTP2 != nil:
+		out.Connection = e.HTTP2.ConnectionID
+	}
+
+	return out
+}
+
+// Write encodes h as indented JSON.
+func Write(w io.Writer, h *HAR) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(h)
+}
+
+// WriteFile writes h to path, creating parent directories as needed.
+func WriteFile(path string, h *HAR) (int, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, h); err != nil {
+		return 0, fmt.Errorf("encoding HAR: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("creating directory for %q: %w", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return 0, fmt.Errorf("writing HAR %q: %w", path, err)
+	}
+	return buf.Len(), nil
+}
+
+func convertRequest(e *client.SessionEntry, opts Options) Request {
+	req := Request{
+		URL:         e.URL,
+		HTTPVersion: normalizeHTTPVersion(ptrString(e.Request.HTTPVersion), e.HTTPVersion),
+		Cookies:     requestCookies(e.Request.Headers),
+		Headers:     convertHeaders(e.Request.Headers),
+		QueryString: parseQueryString(e.URL),
+		HeadersSize: -1,
+	}
+	if e.Request.Method != nil {
+		req.Method = *e.Request.Method
+	}
+
+	wire := decodeBase64(e.Request.Body)
+	req.BodySize = len(wire)
+	if len(wire) == 0 {
+		return req
+	}
+	body, decodeErr := decompress(wire, e.Request.Headers)
+
+	ct := e.Request.Headers.Get("content-type")
+	pd := &PostData{MimeType: ct}
+	if pd.MimeType == "" {
+		pd.MimeType = unknownMimeType
+	}
+	switch omitted := omitComment(opts, wire, body); {
+	case omitted != "":
+		pd.Comment = omitted
+	case decodeErr != nil:
+		pd.Text = base64.StdEncoding.EncodeToString(body)
+		pd.Encoding = "base64"
+		pd.Comment = "body left compressed: " + decodeErr.Error()
+	case isBinary(ct, body):
+		pd.Text = base64.StdEncoding.EncodeToString(body)
+		pd.Encoding = "base64"
+	default:
+		pd.Text = string(body)
+		if contenttype.Classify(ct) == contenttype.Form {
+			pd.Params = parsePairs(pd.Text)
+		}
+	}
+	req.PostData = pd
+	return req
+}
+
+func convertResponse(e *client.SessionEntry, opts Options) Response {
+	resp := Response{
+		HTTPVersion: normalizeHTTPVersion("", e.HTTPVersion),
+		Cookies:     []Cookie{},
+		Headers:     []NameValue{},
+		Content:     Content{MimeType: unknownMimeType},
+		HeadersSize: -1,
+	}
+	r := e.Response
+	if r == nil {
+		return resp
+	}
+
+	resp.HTTPVersion = normalizeHTTPVersion(ptrString(r.HTTPVersion), e.HTTPVersion)
+	resp.Headers = convertHeaders(r.Headers)
+	resp.Cookies = responseCookies(r.Headers)
+	resp.RedirectURL = r.Headers.Get("location")
+	if r.StatusCode != nil {
+		resp.Status = *r.StatusCode
+	}
+	resp.StatusText = ptrString(r.StatusText)
+	if resp.StatusText == "" && resp.Status > 0 {
+		resp.StatusText = http.StatusText(resp.Status)
+	}
+
+	ct := r.Headers.Get("content-type")
+	if ct != "" {
+		resp.Content.MimeType = ct
+	}
+
+	wire := decodeBase64(r.Body)
+	resp.BodySize = len(wire)
+	if len(wire) == 0 {
+		return resp
+	}
+	body, decodeErr := decompress(wire, r.Headers)
+	resp.Content.Size = len(body)
+	resp.Content.Compression = len(body) - len(wire)
+
+	switch omitted := omitComment(opts, wire, body); {
+	case omitted != "":
+		resp.Content.Comment = omitted
+	case decodeErr != nil:
+		resp.Content.Text = base64.StdEncoding.EncodeToString(body)
+		resp.Content.Encoding = "base64"
+		resp.Content.Comment = "body left compressed: " + decodeErr.Error()
+	case isBinary(ct, body):
+		resp.Content.Text = base64.StdEncoding.EncodeToString(body)
+		resp.Content.Encoding = "base64"
+	default:
+		resp.Content.Text = string(body)
+	}
+	return resp
+}
+
+// convertTimings maps powhttp phase timings (milliseconds) to HAR timings.
+// Optional phases default to -1; send, wait and receive are required and default to 0.
+func convertTimings(t client.Timings) Timings {
+	optional := func(v *int64) float64 {
+		if v == nil || *v < 0 {
+			return -1
+		}
+		return float64(*v)
+	}
+	required := func(v *int64) float64 {
+		if v == nil || *v < 0 {
+			return 0
+		}
+		return float64(*v)
+	}
+	return Timings{
+		Blocked: optional(t.Blocked),
+		DNS:     optional(t.DNS),
+		Connect: optional(t.Connect),
+		SSL:     optional(t.SSL),
+		Send:    required(t.Send),
+		Wait:    required(t.Wait),
+		Receive: required(t.Receive),
+	}
+}
+
+// totalTime sums the phases that make up Entry.Time. SSL is excluded because
+// HAR counts it as part of connect.
+func totalTime(t Timings) float64 {
+	var total float64
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+func convertTLS(info client.TLSInfo) *TLS {
+	if info.ConnectionID == nil && info.TLSVersion == nil && info.JA3 == nil && info.JA4 == nil {
+		return nil
+	}
+	out := &TLS{ConnectionID: ptrString(info.ConnectionID)}
+	if info.TLSVersion != nil {
+		out.Version = tls.VersionName(uint16(*info.TLSVersion))
+	}
+	if info.CipherSuite != nil {
+		out.CipherSuite = tls.CipherSuiteName(uint16(*info.CipherSuite))
+	}
+	if info.JA3 != nil {
+		out.JA3 = info.JA3.String
+		out.JA3Hash = info.JA3.Hash
+	}
+	if info.JA4 != nil {
+		out.JA4 = info.JA4.Hashed
+		out.JA4Raw = info.JA4.Raw
+	}
+	return out
+}
+
+func convertHeaders(h client.Headers) []NameValue {
+	out := make([]NameValue, 0, len(h))
+	for _, pair := range h {
+		if len(pair) >= 2 {
+			out = append(out, NameValue{Name: pair[0], Value: pair[1]})
+		}
+	}
+	return out
+}
+
+// requestCookies parses Cookie headers leniently, keeping malformed pairs as name-only.
+func requestCookies(h client.Headers) []Cookie {
+	cookies := []Cookie{}
+	for _, v := range h.Values("cookie") {
+		for _, part := range strings.Split(v, ";") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(part, "=")
+			cookies = append(cookies, Cookie{Name: name, Value: value})
+		}
+	}
+	return cookies
+}
+
+// responseCookies parses Set-Cookie headers, skipping values that fail to parse.
+func responseCookies(h client.Headers) []Cookie {
+	cookies := []Cookie{}
+	for _, v := range h.Values("set-cookie") {
+		c, err := http.ParseSetCookie(v)
+		if err != nil {
+			continue
+		}
+		hc := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.UTC().Format(time.RFC3339)
+		}
+		cookies = append(cookies, hc)
+	}
+	return cookies
+}
+
+// parseQueryString extracts query parameters in their original order.
+func parseQueryString(rawURL string) []NameValue {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return []NameValue{}
+	}
+	return parsePairs(u.RawQuery)
+}
+
+// parsePairs splits an application/x-www-form-urlencoded string preserving order.
+// Undecodable components are kept as-is.
+func parsePairs(s string) []NameValue {
+	out := []NameValue{}
+	for _, part := range strings.Split(s, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		out = append(out, NameValue{Name: name, Value: value})
+	}
+	return out
+}
+
+// normalizeHTTPVersion prefers the message-level version and maps ALPN-style
+// identifiers ("h2", "h3") to the HTTP/x.y form browsers write.
+func normalizeHTTPVersion(messageVersion, entryVersion string) string {
+	v := messageVersion
+	if v == "" {
+		v = entryVersion
+	}
+	switch strings.ToLower(v) {
+	case "h2", "http/2", "http/2.0":
+		return "HTTP/2.0"
+	case "h3", "http/3", "http/3.0":
+		return "HTTP/3.0"
+	case "http/1.1", "h1", "1.1":
+		return "HTTP/1.1"
+	case "http/1.0", "1.0":
+		return "HTTP/1.0"
+	}
+	return v
+}
+
+// omitComment returns why a body is left out, or "" to keep it. The size
+// limit applies to the wire length as well as the decoded one, so bodies
+// that could not be decompressed are held to it too.
+func omitComment(opts Options, wire, body []byte) string {
+	size := max(len(wire), len(body))
+	switch {
+	case opts.OmitBodies:
+		return "body omitted"
+	case opts.MaxBodyBytes > 0 && size > opts.MaxBodyBytes:
+		return fmt.Sprintf("body omitted: %d bytes exceeds limit of %d", size, opts.MaxBodyBytes)
+	}
+	return ""
+}
+
+func isBinary(ct string, body []byte) bool {
+	return !utf8.Valid(body) || contenttype.IsBinary(ct, body)
+}
+
+func decodeBase64(s *string) []byte {
+	if s == nil || *s == "" {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(*s)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// decompress removes the Content-Encoding from a body, since HAR content
+// text is the decoded body. On failure the wire bytes are returned with the
+// error.
+func decompress(wire []byte, headers client.Headers) ([]byte, error) {
+	body, err := client.Decompress(wire, client.ContentEncoding(headers))
+	if err != nil {
+		return wire, err
+	}
+	return body, nil
+}
+
+func ptrString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,302 @@
+package har
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+func i64Ptr(i int64) *int64   { return &i }
+
+func b64(s string) *string {
+	v := base64.StdEncoding.EncodeToString([]byte(s))
+	return &v
+}
+
+func makeEntry(id string, startedAt int64) *client.SessionEntry {
+	return &client.SessionEntry{
+		ID:          id,
+		URL:         "https://api.example.com/v1/items?page=2&q=a%20b&page=3",
+		HTTPVersion: "h2",
+		RemoteAddr:  &client.SocketAddress{IP: "93.184.216.34", Port: intPtr(443)},
+		Request: client.Request{
+			Method: strPtr("POST"),
+			Headers: client.Headers{
+				{":authority", "api.example.com"},
+				{"content-type", "application/x-www-form-urlencoded"},
+				{"cookie", "sid=abc; theme=dark"},
+			},
+			Body: b64("name=widget&qty=2"),
+		},
+		Response: &client.Response{
+			StatusCode: intPtr(302),
+			Headers: client.Headers{
+				{"content-type", "application/json"},
+				{"location", "/v1/items/42"},
+				{"set-cookie", "sid=xyz; Path=/; Domain=example.com; HttpOnly; Secure"},
+			},
+			Body: b64(`{"id":42}`),
+		},
+		TLS: client.TLSInfo{
+			ConnectionID: strPtr("tls-1"),
+			TLSVersion:   intPtr(0x0304),
+			CipherSuite:  intPtr(0x1301),
+			JA3:          &client.JA3Fingerprint{String: "771,4865", Hash: "abc123"},
+			JA4:          &client.JA4Fingerprint{Raw: "t13d_raw", Hashed: "t13d_hash"},
+		},
+		HTTP2: &client.HTTP2Info{ConnectionID: "h2-1", StreamID: 3},
+		Timings: client.Timings{
+			StartedAt: startedAt,
+			DNS:       i64Ptr(5),
+			Connect:   i64Ptr(20),
+			SSL:       i64Ptr(12),
+			Send:      i64Ptr(1),
+			Wait:      i64Ptr(40),
+			Receive:   i64Ptr(4),
+		},
+		Process: &client.ProcessInfo{PID: 1234, Name: strPtr("chrome")},
+	}
+}
+
+func TestFromEntry_Request(t *testing.T) {
+	e := FromEntry(makeEntry("e1", 1700000000123), Options{})
+
+	assert.Equal(t, "2023-11-14T22:13:20.123Z", e.StartedDateTime)
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Equal(t, "HTTP/2.0", e.Request.HTTPVersion)
+	assert.Equal(t, -1, e.Request.HeadersSize)
+	assert.Len(t, e.Request.Headers, 3)
+	assert.Equal(t, []NameValue{
+		{Name: "page", Value: "2"},
+		{Name: "q", Value: "a b"},
+		{Name: "page", Value: "3"},
+	}, e.Request.QueryString)
+	assert.Equal(t, []Cookie{{Name: "sid", Value: "abc"}, {Name: "theme", Value: "dark"}}, e.Request.Cookies)
+
+	require.NotNil(t, e.Request.PostData)
+	assert.Equal(t, "application/x-www-form-urlencoded", e.Request.PostData.MimeType)
+	assert.Equal(t, "name=widget&qty=2", e.Request.PostData.Text)
+	assert.Equal(t, []NameValue{{Name: "name", Value: "widget"}, {Name: "qty", Value: "2"}}, e.Request.PostData.Params)
+	assert.Equal(t, len("name=widget&qty=2"), e.Request.BodySize)
+}
+
+func TestFromEntry_Response(t *testing.T) {
+	e := FromEntry(makeEntry("e1", 0), Options{})
+
+	assert.Equal(t, 302, e.Response.Status)
+	assert.Equal(t, "Found", e.Response.StatusText)
+	assert.Equal(t, "/v1/items/42", e.Response.RedirectURL)
+	assert.Equal(t, "application/json", e.Response.Content.MimeType)
+	assert.Equal(t, `{"id":42}`, e.Response.Content.Text)
+	assert.Empty(t, e.Response.Content.Encoding)
+	assert.Equal(t, 9, e.Response.Content.Size)
+
+	require.Len(t, e.Response.Cookies, 1)
+	c := e.Response.Cookies[0]
+	assert.Equal(t, "sid", c.Name)
+	assert.Equal(t, "xyz", c.Value)
+	assert.Equal(t, "/", c.Path)
+	assert.Equal(t, "example.com", c.Domain)
+	assert.True(t, c.HTTPOnly)
+	assert.True(t, c.Secure)
+}
+
+func TestFromEntry_CompressedResponse(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(`{"id":42,"name":"widget","tags":["a","b","c"]}`))
+	w.Close()
+	wire := base64.StdEncoding.EncodeToString(gz.Bytes())
+
+	entry := makeEntry("e1", 0)
+	entry.Response.Headers = append(entry.Response.Headers, []string{"content-encoding", "gzip"})
+	entry.Response.Body = &wire
+	e := FromEntry(entry, Options{})
+
+	assert.Equal(t, `{"id":42,"name":"widget","tags":["a","b","c"]}`, e.Response.Content.Text)
+	assert.Empty(t, e.Response.Content.Encoding)
+	assert.Equal(t, 46, e.Response.Content.Size)
+	assert.Equal(t, gz.Len(), e.Response.BodySize)
+	assert.Equal(t, 46-gz.Len(), e.Response.Content.Compression)
+
+	// Importing the HAR back keeps the decoded text, which still decodes
+	// cleanly under the original Content-Encoding header.
+	back := ToEntry(e, "e1")
+	body, err := back.Response.DecodedBody()
+	require.NoError(t, err)
+	assert.Equal(t, e.Response.Content.Text, string(body))
+}
+
+func TestFromEntry_UndecodableBody(t *testing.T) {
+	entry := makeEntry("e1", 0)
+	entry.Response.Headers = append(entry.Response.Headers, []string{"content-encoding", "br"})
+	entry.Response.Body = b64("\x1b\x0e\x00\xf8")
+	e := FromEntry(entry, Options{})
+
+	assert.Equal(t, "base64", e.Response.Content.Encoding)
+	assert.Equal(t, *entry.Response.Body, e.Response.Content.Text)
+	assert.Contains(t, e.Response.Content.Comment, "body left compressed")
+}
+
+func TestFromEntry_TimingsAndCustomFields(t *testing.T) {
+	e := FromEntry(makeEntry("e1", 0), Options{})
+
+	assert.Equal(t, Timings{Blocked: -1, DNS: 5, Connect: 20, SSL: 12, Send: 1, Wait: 40, Receive: 4}, e.Timings)
+	assert.Equal(t, float64(5+20+1+40+4), e.Time)
+	assert.Equal(t, "93.184.216.34", e.ServerIPAddress)
+	assert.Equal(t, "tls-1", e.Connection)
+	assert.Equal(t, "e1", e.EntryID)
+
+	require.NotNil(t, e.TLS)
+	assert.Equal(t, "TLS 1.3", e.TLS.Version)
+	assert.Equal(t, "TLS_AES_128_GCM_SHA256", e.TLS.CipherSuite)
+	assert.Equal(t, "abc123", e.TLS.JA3Hash)
+	assert.Equal(t, "t13d_hash", e.TLS.JA4)
+
+	require.NotNil(t, e.HTTP2)
+	assert.Equal(t, 3, e.HTTP2.StreamID)
+	require.NotNil(t, e.Process)
+	assert.Equal(t, "chrome", e.Process.Name)
+}
+
+func TestFromEntry_BinaryBody(t *testing.T) {
+	entry := makeEntry("e1", 0)
+	raw := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff})
+	entry.Response.Headers = client.Headers{{"content-type", "image/png"}}
+	entry.Response.Body = b64(raw)
+
+	e := FromEntry(entry, Options{})
+	assert.Equal(t, "base64", e.Response.Content.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(raw)), e.Response.Content.Text)
+	assert.Equal(t, len(raw), e.Response.Content.Size)
+}
+
+func TestFromEntry_BodyLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"omit bodies", Options{OmitBodies: true}},
+		{"over max bytes", Options{MaxBodyBytes: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := FromEntry(makeEntry("e1", 0), tt.opts)
+			assert.Empty(t, e.Response.Content.Text)
+			assert.NotEmpty(t, e.Response.Content.Comment)
+			assert.Equal(t, 9, e.Response.Content.Size)
+			require.NotNil(t, e.Request.PostData)
+			assert.Empty(t, e.Request.PostData.Text)
+		})
+	}
+}
+
+func TestFromEntry_UndecodableBodyLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		comment string
+	}{
+		{"omit bodies", Options{OmitBodies: true}, "body omitted"},
+		{"over max bytes on the wire", Options{MaxBodyBytes: 4}, "body omitted: 6 bytes exceeds limit of 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := makeEntry("e1", 0)
+			entry.Request.Headers = append(entry.Request.Headers, []string{"content-encoding", "x-custom"})
+			entry.Request.Body = b64("\x00\x01\x02\x03\x04\x05")
+			entry.Response.Headers = append(entry.Response.Headers, []string{"content-encoding", "x-custom"})
+			entry.Response.Body = b64("\x00\x01\x02\x03\x04\x05")
+			e := FromEntry(entry, tt.opts)
+
+			assert.Empty(t, e.Response.Content.Text)
+			assert.Empty(t, e.Response.Content.Encoding)
+			assert.Equal(t, tt.comment, e.Response.Content.Comment)
+			require.NotNil(t, e.Request.PostData)
+			assert.Empty(t, e.Request.PostData.Text)
+			assert.Empty(t, e.Request.PostData.Encoding)
+			assert.Equal(t, tt.comment, e.Request.PostData.Comment)
+		})
+	}
+}
+
+func TestFromEntry_NoResponse(t *testing.T) {
+	entry := makeEntry("e1", 0)
+	entry.Response = nil
+	entry.TLS = client.TLSInfo{}
+
+	e := FromEntry(entry, Options{})
+	assert.Equal(t, 0, e.Response.Status)
+	assert.Equal(t, unknownMimeType, e.Response.Content.MimeType)
+	assert.NotNil(t, e.Response.Headers)
+	assert.NotNil(t, e.Response.Cookies)
+	assert.Nil(t, e.TLS)
+	assert.Equal(t, "h2-1", e.Connection)
+}
+
+func TestFromEntries_SortedAndSkipsNil(t *testing.T) {
+	h := FromEntries([]*client.SessionEntry{
+		makeEntry("late", 2000),
+		nil,
+		makeEntry("early", 1000),
+	}, Options{})
+
+	assert.Equal(t, Version, h.Log.Version)
+	assert.Equal(t, DefaultCreatorName, h.Log.Creator.Name)
+	require.Len(t, h.Log.Entries, 2)
+	assert.Equal(t, "early", h.Log.Entries[0].EntryID)
+	assert.Equal(t, "late", h.Log.Entries[1].EntryID)
+}
+
+func TestNormalizeHTTPVersion(t *testing.T) {
+	tests := []struct {
+		message, entry, want string
+	}{
+		{"", "h2", "HTTP/2.0"},
+		{"", "h3", "HTTP/3.0"},
+		{"HTTP/1.1", "h2", "HTTP/1.1"},
+		{"", "http/1.0", "HTTP/1.0"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, normalizeHTTPVersion(tt.message, tt.entry))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	h := FromEntries([]*client.SessionEntry{makeEntry("e1", 0)}, Options{})
+	path := filepath.Join(t.TempDir(), "nested", "out.har")
+
+	n, err := WriteFile(path, h)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	log := decoded["log"].(map[string]any)
+	assert.Equal(t, "1.2", log["version"])
+	entries := log["entries"].([]any)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0], "_tls")
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, h))
+	assert.Equal(t, data, buf.Bytes())
+}
@@ -0,0 +1,252 @@
+package har
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// Read decodes a HAR document.
+func Read(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("decoding HAR: %w", err)
+	}
+	return &h, nil
+}
+
+// ReadFile decodes the HAR document at path.
+func ReadFile(path string) (*HAR, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening HAR %q: %w", path, err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// ToEntry converts a HAR entry back into a SessionEntry. It is the inverse of
+// FromEntry for documents written by this package, and best-effort for HAR
+// files from browsers and other tools. id is used when the entry carries no
+// _entryId.
+func ToEntry(e Entry, id string) *client.SessionEntry {
+	out := &client.SessionEntry{
+		ID:              id,
+		URL:             e.Request.URL,
+		HTTPVersion:     alpnHTTPVersion(e.Request.HTTPVersion),
+		TransactionType: client.TransactionRequest,
+		Request: client.Request{
+			Method:  nonEmpty(e.Request.Method),
+			Headers: toHeaders(e.Request.Headers),
+		},
+		Timings:     toTimings(e),
+		IsWebSocket: e.WebSocket || e.ResourceType == "websocket" || len(e.WebSocketMessages) > 0,
+	}
+	if e.EntryID != "" {
+		out.ID = e.EntryID
+	}
+	if u, err := url.Parse(e.Request.URL); err == nil {
+		out.Request.Path = nonEmpty(u.RequestURI())
+	}
+	out.Request.HTTPVersion = nonEmpty(e.Request.HTTPVersion)
+	if e.Request.PostData != nil {
+		out.Request.Body = encodeBody(e.Request.PostData.Text, e.Request.PostData.Encoding)
+	}
+
+	if e.Response.Status > 0 || len(e.Response.Headers) > 0 {
+		out.Response = &client.Response{
+			HTTPVersion: nonEmpty(e.Response.HTTPVersion),
+			StatusText:  nonEmpty(e.Response.StatusText),
+			Headers:     toHeaders(e.Response.Headers),
+			Body:        encodeBody(e.Response.Content.Text, e.Response.Content.Encoding),
+		}
+		if e.Response.Status > 0 {
+			status := e.Response.Status
+			out.Response.StatusCode = &status
+		}
+	}
+
+	if e.ServerIPAddress != "" {
+		out.RemoteAddr = &client.SocketAddress{IP: strings.Trim(e.ServerIPAddress, "[]")}
+	}
+	if e.Client != nil {
+		out.ClientAddr = &client.SocketAddress{IP: e.Client.IP}
+		if e.Client.Port > 0 {
+			port := e.Client.Port
+			out.ClientAddr.Port = &port
+		}
+	}
+	if e.Process != nil {
+		out.Process = &client.ProcessInfo{PID: e.Process.PID, Name: nonEmpty(e.Process.Name)}
+	}
+
+	if e.HTTP2 != nil {
+		out.HTTP2 = &client.HTTP2Info{ConnectionID: e.HTTP2.ConnectionID, StreamID: e.HTTP2.StreamID}
+	}
+	out.TLS = toTLSInfo(e)
+
+	return out
+}
+
+// ToEntries converts every entry in h. Entries without an _entryId get
+// sequential IDs ("1", "2", ...) in document order.
+func ToEntries(h *HAR) []*client.SessionEntry {
+	out := make([]*client.SessionEntry, 0, len(h.Log.Entries))
+	for i, e := range h.Log.Entries {
+		out = append(out, ToEntry(e, fmt.Sprintf("%d", i+1)))
+	}
+	return out
+}
+
+// ToWebSocketMessages converts the Chrome _webSocketMessages extension.
+// "send" frames are client-side; "receive" frames are server-side.
+func ToWebSocketMessages(e Entry) []client.WebSocketMessage {
+	out := make([]client.WebSocketMessage, 0, len(e.WebSocketMessages))
+	for _, m := range e.WebSocketMessages {
+		msg := client.WebSocketMessage{Side: client.WSSideServer}
+		if m.Type == "send" {
+			msg.Side = client.WSSideClient
+		}
+		if m.Time > 0 {
+			ms := int64(math.Round(m.Time * 1000))
+			msg.StartedAt = &ms
+		}
+
+		switch m.Opcode {
+		case 1:
+			msg.Content = client.WebSocketContent{Type: client.WSMessageText, Text: m.Data}
+		case 2:
+			msg.Content = client.WebSocketContent{Type: client.WSMessageBinary, Data: m.Data}
+		case 8:
+			msg.Content = client.WebSocketContent{Type: client.WSMessageClose, Reason: base64.StdEncoding.EncodeToString([]byte(m.Data))}
+		case 9:
+			msg.Content = client.WebSocketContent{Type: client.WSMessagePing, Data: base64.StdEncoding.EncodeToString([]byte(m.Data))}
+		case 10:
+			msg.Content = client.WebSocketContent{Type: client.WSMessagePong, Data: base64.StdEncoding.EncodeToString([]byte(m.Data))}
+		default:
+			msg.Content = client.WebSocketContent{Type: client.WSMessageUnknown, Data: base64.StdEncoding.EncodeToString([]byte(m.Data))}
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// toTLSInfo restores TLS details from _tls, or treats the HAR connection ID
+// as the TLS connection for https entries from other tools.
+func toTLSInfo(e Entry) client.TLSInfo {
+	var info client.TLSInfo
+	if e.TLS == nil {
+		if e.Connection != "" && strings.HasPrefix(strings.ToLower(e.Request.URL), "https://") {
+			info.ConnectionID = nonEmpty(e.Connection)
+		}
+		return info
+	}
+
+	info.ConnectionID = nonEmpty(e.TLS.ConnectionID)
+	if v, ok := tlsVersionByName[e.TLS.Version]; ok {
+		info.TLSVersion = &v
+	}
+	if id, ok := cipherSuiteByName()[e.TLS.CipherSuite]; ok {
+		info.CipherSuite = &id
+	}
+	if e.TLS.JA3 != "" || e.TLS.JA3Hash != "" {
+		info.JA3 = &client.JA3Fingerprint{String: e.TLS.JA3, Hash: e.TLS.JA3Hash}
+	}
+	if e.TLS.JA4 != "" || e.TLS.JA4Raw != "" {
+		info.JA4 = &client.JA4Fingerprint{Raw: e.TLS.JA4Raw, Hashed: e.TLS.JA4}
+	}
+	return info
+}
+
+var tlsVersionByName = map[string]int{
+	"TLS 1.0": tls.VersionTLS10,
+	"TLS 1.1": tls.VersionTLS11,
+	"TLS 1.2": tls.VersionTLS12,
+	"TLS 1.3": tls.VersionTLS13,
+}
+
+// cipherSuiteByName maps Go cipher suite names to their IDs.
+func cipherSuiteByName() map[string]int {
+	m := make(map[string]int)
+	for _, cs := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		m[cs.Name] = int(cs.ID)
+	}
+	return m
+}
+
+func toTimings(e Entry) client.Timings {
+	t := client.Timings{
+		Blocked: phase(e.Timings.Blocked),
+		DNS:     phase(e.Timings.DNS),
+		Connect: phase(e.Timings.Connect),
+		SSL:     phase(e.Timings.SSL),
+		Send:    phase(e.Timings.Send),
+		Wait:    phase(e.Timings.Wait),
+		Receive: phase(e.Timings.Receive),
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, e.StartedDateTime); err == nil {
+		t.StartedAt = ts.UnixMilli()
+	}
+	return t
+}
+
+// phase converts a HAR timing to milliseconds; -1 (not applicable) becomes nil.
+func phase(v float64) *int64 {
+	if v < 0 {
+		return nil
+	}
+	ms := int64(math.Round(v))
+	return &ms
+}
+
+func toHeaders(nvs []NameValue) client.Headers {
+	h := make(client.Headers, 0, len(nvs))
+	for _, nv := range nvs {
+		h = append(h, []string{nv.Name, nv.Value})
+	}
+	return h
+}
+
+// encodeBody returns the base64 form the Data API uses for bodies.
+func encodeBody(text, encoding string) *string {
+	if text == "" {
+		return nil
+	}
+	if encoding == "base64" {
+		return &text
+	}
+	b := base64.StdEncoding.EncodeToString([]byte(text))
+	return &b
+}
+
+// alpnHTTPVersion maps HAR version strings to the ALPN-style identifiers
+// powhttp uses on entries ("http/1.1", "h2", "h3").
+func alpnHTTPVersion(v string) string {
+	switch strings.ToLower(v) {
+	case "http/2.0", "http/2", "h2":
+		return "h2"
+	case "http/3.0", "http/3", "h3":
+		return "h3"
+	case "http/1.1":
+		return "http/1.1"
+	case "http/1.0":
+		return "http/1.0"
+	}
+	return strings.ToLower(v)
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
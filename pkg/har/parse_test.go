@@ -0,0 +1,102 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func TestToEntry_RoundTrip(t *testing.T) {
+	orig := makeEntry("e1", 1700000000123)
+	got := ToEntry(FromEntry(orig, Options{}), "ignored")
+
+	assert.Equal(t, "e1", got.ID)
+	assert.Equal(t, orig.URL, got.URL)
+	assert.Equal(t, "h2", got.HTTPVersion)
+	assert.Equal(t, "POST", *got.Request.Method)
+	assert.Equal(t, "/v1/items?page=2&q=a%20b&page=3", *got.Request.Path)
+	assert.Equal(t, orig.Request.Headers, got.Request.Headers)
+	assert.Equal(t, *orig.Request.Body, *got.Request.Body)
+
+	require.NotNil(t, got.Response)
+	assert.Equal(t, 302, *got.Response.StatusCode)
+	assert.Equal(t, orig.Response.Headers, got.Response.Headers)
+	assert.Equal(t, *orig.Response.Body, *got.Response.Body)
+
+	assert.Equal(t, orig.Timings.StartedAt, got.Timings.StartedAt)
+	assert.Equal(t, orig.Timings.Wait, got.Timings.Wait)
+	assert.Nil(t, got.Timings.Blocked)
+
+	assert.Equal(t, orig.TLS.ConnectionID, got.TLS.ConnectionID)
+	assert.Equal(t, orig.TLS.TLSVersion, got.TLS.TLSVersion)
+	assert.Equal(t, orig.TLS.CipherSuite, got.TLS.CipherSuite)
+	assert.Equal(t, orig.TLS.JA3, got.TLS.JA3)
+	assert.Equal(t, orig.TLS.JA4, got.TLS.JA4)
+	assert.Equal(t, orig.HTTP2, got.HTTP2)
+	assert.Equal(t, "93.184.216.34", got.RemoteAddr.IP)
+	assert.Equal(t, "chrome", *got.Process.Name)
+}
+
+func TestToEntry_BinaryBodyPassthrough(t *testing.T) {
+	entry := makeEntry("e1", 0)
+	raw := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff})
+	entry.Response.Headers = client.Headers{{"content-type", "image/png"}}
+	entry.Response.Body = b64(raw)
+
+	got := ToEntry(FromEntry(entry, Options{}), "")
+	decoded, err := base64.StdEncoding.DecodeString(*got.Response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, raw, string(decoded))
+}
+
+func TestToEntry_BrowserHAR(t *testing.T) {
+	doc := `{"log":{"version":"1.2","entries":[
+		{"startedDateTime":"2024-01-02T03:04:05.678Z","connection":"812",
+		 "request":{"method":"GET","url":"https://example.com/a","httpVersion":"http/2.0","headers":[]},
+		 "response":{"status":0,"headers":[],"content":{}},"timings":{"blocked":-1,"wait":12.6}},
+		{"startedDateTime":"2024-01-02T03:04:06Z","_resourceType":"websocket",
+		 "request":{"method":"GET","url":"wss://example.com/ws","httpVersion":"HTTP/1.1","headers":[]},
+		 "response":{"status":101,"headers":[],"content":{}},"timings":{},
+		 "_webSocketMessages":[
+			{"type":"send","time":1704164766.5,"opcode":1,"data":"hello"},
+			{"type":"receive","time":1704164766.75,"opcode":2,"data":"AAE="}]}
+	]}}`
+
+	h, err := Read(strings.NewReader(doc))
+	require.NoError(t, err)
+	entries := ToEntries(h)
+	require.Len(t, entries, 2)
+
+	first := entries[0]
+	assert.Equal(t, "1", first.ID)
+	assert.Equal(t, "h2", first.HTTPVersion)
+	assert.Nil(t, first.Response)
+	assert.Equal(t, int64(13), *first.Timings.Wait)
+	assert.Equal(t, "812", *first.TLS.ConnectionID)
+
+	ws := entries[1]
+	assert.Equal(t, "2", ws.ID)
+	assert.True(t, ws.IsWebSocket)
+	assert.Nil(t, ws.TLS.ConnectionID)
+
+	msgs := ToWebSocketMessages(h.Log.Entries[1])
+	require.Len(t, msgs, 2)
+	assert.Equal(t, client.WSSideClient, msgs[0].Side)
+	assert.Equal(t, client.WSMessageText, msgs[0].Content.Type)
+	assert.Equal(t, "hello", msgs[0].Content.Text)
+	assert.Equal(t, int64(1704164766500), *msgs[0].StartedAt)
+	assert.Equal(t, client.WSSideServer, msgs[1].Side)
+	assert.Equal(t, client.WSMessageBinary, msgs[1].Content.Type)
+	assert.Equal(t, "AAE=", msgs[1].Content.Data)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("not json")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+// Package har converts captured powhttp entries to and from HTTP Archive
+// (HAR) 1.2 documents. Bodies are decoded from the Data API's base64 form; text bodies
+// are written verbatim and binary bodies as base64. TLS, HTTP/2 and process
+// details that HAR has no field for are carried in underscore-prefixed custom
+// fields (_tls, _http2, _process), as the spec allows.
+package har
+
+// Version is the HAR format version written by this package.
+const Version = "1.2"
+
+// HAR is the root of a HAR document.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log is the top-level HAR log object.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+	Comment string  `json:"comment,omitempty"`
+}
+
+// Creator identifies the application that produced the log.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a single request/response pair.
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           Cache    `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	ServerIPAddress string   `json:"serverIPAddress,omitempty"`
+	Connection      string   `json:"connection,omitempty"`
+	Comment         string   `json:"comment,omitempty"`
+
+	// Custom fields (HAR allows fields prefixed with an underscore)
+	EntryID   string      `json:"_entryId,omitempty"`
+	WebSocket bool        `json:"_webSocket,omitempty"`
+	TLS       *TLS        `json:"_tls,omitempty"`
+	HTTP2     *HTTP2      `json:"_http2,omitempty"`
+	Process   *Process    `json:"_process,omitempty"`
+	Client    *RemoteAddr `json:"_clientAddress,omitempty"`
+
+	// Fields written by browser devtools exports, read on import
+	ResourceType      string             `json:"_resourceType,omitempty"`
+	WebSocketMessages []WebSocketMessage `json:"_webSocketMessages,omitempty"`
+}
+
+// WebSocketMessage is a frame in the Chrome devtools _webSocketMessages extension.
+type WebSocketMessage struct {
+	Type   string  `json:"type"`   // "send" or "receive"
+	Time   float64 `json:"time"`   // Unix time in seconds
+	Opcode int     `json:"opcode"` // 1 text, 2 binary, 8 close, 9 ping, 10 pong
+	Data   string  `json:"data"`   // text, or base64 for binary frames
+}
+
+// Request is the HAR request object.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is the HAR response object.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header or query string pair.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Cookie is a HAR cookie.
+type Cookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// PostData describes a request body.
+type PostData struct {
+	MimeType string      `json:"mimeType"`
+	Params   []NameValue `json:"params,omitempty"`
+	Text     string      `json:"text"`
+	Encoding string      `json:"_encoding,omitempty"` // "base64" for binary bodies (non-standard)
+	Comment  string      `json:"comment,omitempty"`
+}
+
+// Content describes a response body.
+type Content struct {
+	Size        int    `json:"size"`
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// Cache is the (always empty) HAR cache object.
+type Cache struct{}
+
+// Timings holds phase durations in milliseconds; -1 means not applicable.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// TLS carries the entry's TLS connection details.
+type TLS struct {
+	ConnectionID string `json:"connectionId,omitempty"`
+	Version      string `json:"version,omitempty"`
+	CipherSuite  string `json:"cipherSuite,omitempty"`
+	JA3          string `json:"ja3,omitempty"`
+	JA3Hash      string `json:"ja3Hash,omitempty"`
+	JA4          string `json:"ja4,omitempty"`
+	JA4Raw       string `json:"ja4Raw,omitempty"`
+}
+
+// HTTP2 carries the entry's HTTP/2 connection and stream.
+type HTTP2 struct {
+	ConnectionID string `json:"connectionId"`
+	StreamID     int    `json:"streamId"`
+}
+
+// Process identifies the process that made the request.
+type Process struct {
+	PID  int    `json:"pid"`
+	Name string `json:"name,omitempty"`
+}
+
+// RemoteAddr is a socket address.
+type RemoteAddr struct {
+	IP   string `json:"ip"`
+	Port int    `json:"port,omitempty"`
+}
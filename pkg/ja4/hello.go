@@ -0,0 +1,241 @@
+package ja4
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// Extensions read for the hello breakdown only.
+const (
+	extRecordSizeLimit = 0x001c
+	extKeyShare        = 0x0033
+)
+
+// Value is a TLS code point with its registered name, if known.
+type Value struct {
+	Code uint16
+	Name string
+}
+
+// Hello holds the fields of a ClientHello or ServerHello read by JA4 and
+// JA4S, and the key shares and record size limit that set clients apart in
+// a diff. A ServerHello has a single cipher suite.
+type Hello struct {
+	Version             Value
+	CipherSuites        []Value
+	Extensions          []Value // In the order sent
+	SupportedVersions   []Value
+	SupportedGroups     []Value
+	SignatureAlgorithms []Value
+	ALPN                []string
+	KeyShareGroups      []Value
+	RecordSizeLimit     uint16 // 0 if the extension is absent
+	ServerName          string // SNI host name
+}
+
+// ParseClientHello reads a ClientHello event.
+func ParseClientHello(ch *client.TLSClientHello) (*Hello, error) {
+	h := &Hello{Version: namedValue(ch.Version)}
+	for _, c := range ch.CipherSuites {
+		h.CipherSuites = append(h.CipherSuites, namedValue(c))
+	}
+	if err := h.parseExtensions(ch.Extensions); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ParseServerHello reads a ServerHello event.
+func ParseServerHello(sh *client.TLSServerHello) (*Hello, error) {
+	h := &Hello{
+		Version:      namedValue(sh.Version),
+		CipherSuites: []Value{namedValue(sh.CipherSuite)},
+	}
+	if err := h.parseExtensions(sh.Extensions); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// namedValue converts a decoded TLS code point.
+func namedValue(v client.TLSNamedValue) Value {
+	return Value{Code: uint16(v.Value), Name: v.Name}
+}
+
+// parseExtensions reads the extension list. Each extension is an object with
+// its code ("value") and name; the remaining fields vary by extension, so
+// the code points and strings of the extensions JA4 reads are collected from
+// them whatever their layout.
+func (h *Hello) parseExtensions(raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var extensions []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &extensions); err != nil {
+		return fmt.Errorf("decoding TLS extensions: %w", err)
+	}
+
+	for _, fields := range extensions {
+		var ext client.TLSNamedValue
+		if err := json.Unmarshal(fields["value"], &ext.Value); err != nil {
+			continue
+		}
+		if name, ok := fields["name"]; ok {
+			_ = json.Unmarshal(name, &ext.Name)
+		}
+		h.Extensions = append(h.Extensions, namedValue(ext))
+
+		delete(fields, "value")
+		delete(fields, "name")
+		var c collector
+		c.walkFields(fields)
+		switch uint16(ext.Value) {
+		case extServerName:
+			if len(c.strings) > 0 {
+				h.ServerName = c.strings[0]
+			}
+		case extSupportedVersions:
+			h.SupportedVersions = c.values
+		case extSupportedGroups:
+			h.SupportedGroups = c.values
+		case extSignatureAlgorithms:
+			h.SignatureAlgorithms = c.values
+		case extALPN:
+			h.ALPN = c.strings
+		case extKeyShare:
+			// Key shares pair a named group with key bytes; keep the groups
+			h.KeyShareGroups = c.named
+			if len(h.KeyShareGroups) == 0 {
+				h.KeyShareGroups = c.values
+			}
+		case extRecordSizeLimit:
+			if len(c.values) > 0 {
+				h.RecordSizeLimit = c.values[0].Code
+			}
+		}
+	}
+	return nil
+}
+
+// version returns the highest version offered in supported_versions, or the
+// hello's version if the extension is absent.
+func (h *Hello) version() uint16 {
+	var highest uint16
+	for _, v := range h.SupportedVersions {
+		if !IsGREASE(v.Code) && v.Code > highest {
+			highest = v.Code
+		}
+	}
+	if highest == 0 {
+		return h.Version.Code
+	}
+	return highest
+}
+
+// Breakdown lists the hello's fields in readable form, with GREASE values
+// normalized to "GREASE" so that hellos differing only in GREASE compare
+// equal.
+func (h *Hello) Breakdown() *types.TLSHelloBreakdown {
+	version := h.version()
+	versionName := h.Version.Name
+	for _, v := range h.SupportedVersions {
+		if v.Code == version && v.Name != "" {
+			versionName = v.Name
+		}
+	}
+	return &types.TLSHelloBreakdown{
+		Version:             label(Value{Code: version, Name: versionName}),
+		CipherSuites:        labels(h.CipherSuites),
+		Extensions:          labels(h.Extensions),
+		SupportedVersions:   labels(h.SupportedVersions),
+		SupportedGroups:     labels(h.SupportedGroups),
+		SignatureAlgorithms: labels(h.SignatureAlgorithms),
+		ALPN:                h.ALPN,
+		KeyShareGroups:      labels(h.KeyShareGroups),
+		RecordSizeLimit:     int(h.RecordSizeLimit),
+	}
+}
+
+// labels renders code points with label.
+func labels(values []Value) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, label(v))
+	}
+	return out
+}
+
+// label renders a code point as "name (0x1301)", "0x1301", or "GREASE".
+func label(v Value) string {
+	switch {
+	case IsGREASE(v.Code):
+		return "GREASE"
+	case v.Name != "":
+		return fmt.Sprintf("%s (0x%04x)", v.Name, v.Code)
+	default:
+		return fmt.Sprintf("0x%04x", v.Code)
+	}
+}
+
+// collector gathers the code points and strings in an extension's fields.
+// Objects with a numeric "value" are code points; other objects are walked
+// field by field in name order.
+type collector struct {
+	values  []Value
+	named   []Value // The code points given as objects, not bare numbers
+	strings []string
+}
+
+// walkFields walks the fields of an object in name order.
+func (c *collector) walkFields(fields map[string]json.RawMessage) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.walk(fields[name])
+	}
+}
+
+// walk collects from one JSON value.
+func (c *collector) walk(raw json.RawMessage) {
+	var n uint16
+	if err := json.Unmarshal(raw, &n); err == nil {
+		c.values = append(c.values, Value{Code: n})
+		return
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		c.strings = append(c.strings, s)
+		return
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, item := range list {
+			c.walk(item)
+		}
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return
+	}
+	var named client.TLSNamedValue
+	if value, ok := fields["value"]; ok && json.Unmarshal(value, &named.Value) == nil {
+		if name, ok := fields["name"]; ok {
+			_ = json.Unmarshal(name, &named.Name)
+		}
+		c.values = append(c.values, namedValue(named))
+		c.named = append(c.named, namedValue(named))
+		return
+	}
+	c.walkFields(fields)
+}
@@ -0,0 +1,91 @@
+package ja4
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// httpVersions maps HTTP versions to their two-character JA4H code.
+var httpVersions = map[string]string{
+	"http/1.0": "10",
+	"http/1.1": "11",
+	"h2":       "20",
+	"http/2":   "20",
+	"http/2.0": "20",
+	"h3":       "30",
+	"http/3":   "30",
+	"http/3.0": "30",
+}
+
+// JA4H returns the JA4H fingerprint of an HTTP request and its raw form,
+// which lists the header names and cookies instead of hashing them. headers
+// are [name, value] pairs in the order sent; pseudo-headers are ignored.
+func JA4H(method, httpVersion string, headers [][]string) (fingerprint, raw string) {
+	var names, cookieFields []string
+	hasReferer := false
+	language := "0000"
+	for _, pair := range headers {
+		if len(pair) < 2 || strings.HasPrefix(pair[0], ":") {
+			continue
+		}
+		switch strings.ToLower(pair[0]) {
+		case "cookie":
+			for _, field := range strings.Split(pair[1], ";") {
+				if field = strings.TrimSpace(field); field != "" {
+					cookieFields = append(cookieFields, field)
+				}
+			}
+			continue
+		case "referer":
+			hasReferer = true
+			continue
+		case "accept-language":
+			if language == "0000" {
+				language = languageCode(pair[1])
+			}
+		}
+		names = append(names, pair[0])
+	}
+
+	version, ok := httpVersions[strings.ToLower(httpVersion)]
+	if !ok {
+		version = "00"
+	}
+	cookie, referer := "n", "n"
+	if len(cookieFields) > 0 {
+		cookie = "c"
+	}
+	if hasReferer {
+		referer = "r"
+	}
+	methodCode := strings.ToLower(method)
+	if len(methodCode) > 2 {
+		methodCode = methodCode[:2]
+	}
+	a := fmt.Sprintf("%s%s%s%s%s%s", methodCode, version, cookie, referer, count(len(names)), language)
+
+	cookieNames := make([]string, 0, len(cookieFields))
+	for _, field := range cookieFields {
+		name, _, _ := strings.Cut(field, "=")
+		cookieNames = append(cookieNames, name)
+	}
+	sort.Strings(cookieNames)
+	sort.Strings(cookieFields)
+
+	fingerprint = strings.Join([]string{a, hashList(names), hashList(cookieNames), hashList(cookieFields)}, "_")
+	raw = strings.Join([]string{a, strings.Join(names, ","), strings.Join(cookieNames, ","), strings.Join(cookieFields, ",")}, "_")
+	return fingerprint, raw
+}
+
+// languageCode returns the first four characters of the primary
+// Accept-Language value, lowercase and without hyphens, padded with zeros.
+func languageCode(value string) string {
+	primary, _, _ := strings.Cut(value, ",")
+	primary, _, _ = strings.Cut(primary, ";")
+	primary = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(primary), "-", ""))
+	if len(primary) > 4 {
+		primary = primary[:4]
+	}
+	return primary + strings.Repeat("0", 4-len(primary))
+}
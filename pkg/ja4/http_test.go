@@ -0,0 +1,36 @@
+package ja4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJA4H(t *testing.T) {
+	headers := [][]string{
+		{":method", "GET"},
+		{"Host", "example.com"},
+		{"User-Agent", "test"},
+		{"Cookie", "b=2; a=1"},
+		{"Accept", "*/*"},
+		{"Referer", "https://example.com/"},
+		{"Accept-Language", "en-US,en;q=0.9"},
+	}
+
+	fp, raw := JA4H("GET", "HTTP/1.1", headers)
+	assert.Equal(t, "ge11cr04enus_8ddaef5d77af_1eb7c54d5283_06beefe2b477", fp)
+	assert.Equal(t, "ge11cr04enus_Host,User-Agent,Accept,Accept-Language_a,b_a=1,b=2", raw)
+}
+
+func TestJA4H_NoCookies(t *testing.T) {
+	fp, raw := JA4H("POST", "h2", [][]string{{"accept", "*/*"}})
+	assert.Equal(t, "po20nn010000", fp[:12])
+	assert.Equal(t, "po20nn010000_accept__", raw)
+	assert.Contains(t, fp, "_000000000000_000000000000")
+}
+
+func TestLanguageCode(t *testing.T) {
+	assert.Equal(t, "enus", languageCode("en-US,en;q=0.9"))
+	assert.Equal(t, "fr00", languageCode("fr;q=0.8"))
+	assert.Equal(t, "0000", languageCode(""))
+}
@@ -0,0 +1,162 @@
+// Package ja4 computes JA4+ fingerprints following the FoxIO specification:
+// JA4 for the TLS ClientHello, JA4S for the ServerHello, JA4H for the HTTP
+// request, JA4X for certificates, and the latency part of JA4L.
+package ja4
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// emptyHash stands in for the hash of an empty list.
+const emptyHash = "000000000000"
+
+// Extension codes with a role in JA4.
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extSignatureAlgorithms = 0x000d
+	extALPN                = 0x0010
+	extSupportedVersions   = 0x002b
+)
+
+// tlsVersions maps protocol versions to their two-character JA4 code.
+var tlsVersions = map[uint16]string{
+	0x0304: "13",
+	0x0303: "12",
+	0x0302: "11",
+	0x0301: "10",
+	0x0300: "s3",
+	0x0002: "s2",
+	0xfeff: "d1",
+	0xfefd: "d2",
+	0xfefc: "d3",
+}
+
+// IsGREASE reports whether v is a GREASE value (RFC 8701): 0x0a0a, 0x1a1a,
+// ..., 0xfafa.
+func IsGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// JA4 returns the JA4 fingerprint of a ClientHello and its raw form, which
+// lists the sorted cipher suites, the sorted extensions, and the signature
+// algorithms instead of hashing them. quic selects the QUIC transport marker.
+func JA4(h *Hello, quic bool) (fingerprint, raw string) {
+	transport := "t"
+	if quic {
+		transport = "q"
+	}
+	sni := "i"
+	for _, ext := range h.Extensions {
+		if ext.Code == extServerName {
+			sni = "d"
+			break
+		}
+	}
+
+	ciphers := hexList(h.CipherSuites, nil)
+	sort.Strings(ciphers)
+	extensions := hexList(h.Extensions, func(code uint16) bool {
+		return code == extServerName || code == extALPN
+	})
+	sort.Strings(extensions)
+	// The count keeps SNI and ALPN, which the hashed list leaves out
+	extensionCount := len(hexList(h.Extensions, nil))
+
+	a := fmt.Sprintf("%s%s%s%s%s%s", transport, versionCode(h.version()), sni,
+		count(len(ciphers)), count(extensionCount), alpnCode(h.ALPN))
+
+	extensionPart := strings.Join(extensions, ",")
+	if sigAlgs := hexList(h.SignatureAlgorithms, nil); len(sigAlgs) > 0 {
+		extensionPart += "_" + strings.Join(sigAlgs, ",")
+	}
+	c := emptyHash
+	if len(extensions) > 0 {
+		c = hash12(extensionPart)
+	}
+
+	fingerprint = strings.Join([]string{a, hashList(ciphers), c}, "_")
+	raw = strings.Join([]string{a, strings.Join(ciphers, ","), extensionPart}, "_")
+	return fingerprint, raw
+}
+
+// JA4S returns the JA4S fingerprint of a ServerHello and its raw form, which
+// lists the extensions instead of hashing them.
+func JA4S(h *Hello) (fingerprint, raw string) {
+	extensions := hexList(h.Extensions, nil)
+	a := fmt.Sprintf("t%s%s%s", versionCode(h.version()), count(len(extensions)), alpnCode(h.ALPN))
+
+	cipher := "0000"
+	if ciphers := hexList(h.CipherSuites, nil); len(ciphers) > 0 {
+		cipher = ciphers[0]
+	}
+
+	fingerprint = strings.Join([]string{a, cipher, hashList(extensions)}, "_")
+	raw = strings.Join([]string{a, cipher, strings.Join(extensions, ",")}, "_")
+	return fingerprint, raw
+}
+
+// hexList renders codes as 4-digit lowercase hex, in order, leaving out
+// GREASE values and codes for which skip returns true.
+func hexList(values []Value, skip func(code uint16) bool) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if IsGREASE(v.Code) || (skip != nil && skip(v.Code)) {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%04x", v.Code))
+	}
+	return out
+}
+
+// versionCode returns the two-character code of a protocol version.
+func versionCode(version uint16) string {
+	if code, ok := tlsVersions[version]; ok {
+		return code
+	}
+	return "00"
+}
+
+// count renders a list length as two digits, capped at 99.
+func count(n int) string {
+	return fmt.Sprintf("%02d", min(n, 99))
+}
+
+// alpnCode returns the first and last characters of the first ALPN value,
+// or of its hex encoding when either is not alphanumeric, or "00" if none.
+func alpnCode(alpn []string) string {
+	if len(alpn) == 0 || alpn[0] == "" {
+		return "00"
+	}
+	v := alpn[0]
+	first, last := v[0], v[len(v)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	h := hex.EncodeToString([]byte(v))
+	return string([]byte{h[0], h[len(h)-1]})
+}
+
+// isAlphanumeric reports whether c is an ASCII letter or digit.
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// hashList returns the truncated hash of a comma-joined list, or the empty
+// hash if the list is empty.
+func hashList(items []string) string {
+	if len(items) == 0 {
+		return emptyHash
+	}
+	return hash12(strings.Join(items, ","))
+}
+
+// hash12 returns the first 12 hex characters of the SHA-256 of s.
+func hash12(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,133 @@
+package ja4
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func values(codes ...uint16) []Value {
+	out := make([]Value, 0, len(codes))
+	for _, c := range codes {
+		out = append(out, Value{Code: c})
+	}
+	return out
+}
+
+// chromeHello is the example ClientHello from the JA4 specification, with
+// GREASE values added that must not change the fingerprint.
+func chromeHello() *Hello {
+	return &Hello{
+		Version: Value{Code: 0x0303},
+		CipherSuites: values(0x3a3a, 0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030,
+			0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035),
+		Extensions: values(0x2a2a, 0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005,
+			0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x4469, 0x0015),
+		SupportedVersions:   values(0x1a1a, 0x0304, 0x0303),
+		SignatureAlgorithms: values(0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601),
+		ALPN:                []string{"h2", "http/1.1"},
+	}
+}
+
+func TestJA4(t *testing.T) {
+	fp, raw := JA4(chromeHello(), false)
+	assert.Equal(t, "t13d1516h2_8daaf6152771_e5627efa2ab1", fp)
+	assert.Equal(t, "t13d1516h2_002f,0035,009c,009d,1301,1302,1303,c013,c014,c02b,c02c,c02f,c030,cca8,cca9_"+
+		"0005,000a,000b,000d,0012,0015,0017,001b,0023,002b,002d,0033,4469,ff01_0403,0804,0401,0503,0805,0501,0806,0601", raw)
+
+	quic, _ := JA4(chromeHello(), true)
+	assert.Equal(t, "q13d1516h2_8daaf6152771_e5627efa2ab1", quic)
+}
+
+func TestJA4_NoSNIOrALPN(t *testing.T) {
+	h := &Hello{
+		Version:      Value{Code: 0x0303},
+		CipherSuites: values(0xc02f),
+		Extensions:   values(0x000b),
+	}
+	fp, _ := JA4(h, false)
+	assert.Equal(t, "t12i010100_", fp[:11])
+}
+
+func TestIsGREASE(t *testing.T) {
+	for _, v := range []uint16{0x0a0a, 0x1a1a, 0xaaaa, 0xfafa} {
+		assert.True(t, IsGREASE(v), "%04x", v)
+	}
+	for _, v := range []uint16{0x0a1a, 0x1301, 0x0000, 0xabab} {
+		assert.False(t, IsGREASE(v), "%04x", v)
+	}
+}
+
+func TestJA4S(t *testing.T) {
+	h := &Hello{
+		Version:           Value{Code: 0x0303},
+		CipherSuites:      values(0x1301),
+		Extensions:        values(0x002b, 0x0033),
+		SupportedVersions: values(0x0304),
+	}
+	fp, raw := JA4S(h)
+	assert.Equal(t, "t130200_1301_a56c5b993250", fp)
+	assert.Equal(t, "t130200_1301_002b,0033", raw)
+}
+
+func TestParseClientHello(t *testing.T) {
+	ch := &client.TLSClientHello{
+		Version:      client.TLSNamedValue{Value: 0x0303, Name: "TLS 1.2"},
+		CipherSuites: []client.TLSNamedValue{{Value: 0x0a0a, Name: "GREASE"}, {Value: 0x1301, Name: "TLS_AES_128_GCM_SHA256"}},
+		Extensions: json.RawMessage(`[
+			{"value": 2570, "name": "GREASE"},
+			{"value": 0, "name": "server_name", "server_name": "example.com"},
+			{"value": 43, "name": "supported_versions", "versions": [{"value": 6682, "name": "GREASE"}, {"value": 772, "name": "TLS 1.3"}]},
+			{"value": 10, "name": "supported_groups", "named_group_list": [{"value": 29, "name": "x25519"}]},
+			{"value": 13, "name": "signature_algorithms", "supported_signature_algorithms": [1027, 2052]},
+			{"value": 16, "name": "application_layer_protocol_negotiation", "protocol_name_list": ["h2", "http/1.1"]}
+		]`),
+	}
+
+	h, err := ParseClientHello(ch)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"h2", "http/1.1"}, h.ALPN)
+	assert.Equal(t, "example.com", h.ServerName)
+	assert.Equal(t, uint16(0x0304), h.version())
+
+	fp, _ := JA4(h, false)
+	assert.Equal(t, "t13d0105h2", fp[:10])
+
+	b := h.Breakdown()
+	assert.Equal(t, "TLS 1.3 (0x0304)", b.Version)
+	assert.Equal(t, []string{"GREASE", "TLS_AES_128_GCM_SHA256 (0x1301)"}, b.CipherSuites)
+	assert.Equal(t, []string{"GREASE", "server_name (0x0000)", "supported_versions (0x002b)",
+		"supported_groups (0x000a)", "signature_algorithms (0x000d)",
+		"application_layer_protocol_negotiation (0x0010)"}, b.Extensions)
+	assert.Equal(t, []string{"GREASE", "TLS 1.3 (0x0304)"}, b.SupportedVersions)
+	assert.Equal(t, []string{"x25519 (0x001d)"}, b.SupportedGroups)
+	assert.Equal(t, []string{"0x0403", "0x0804"}, b.SignatureAlgorithms)
+}
+
+func TestParseClientHello_KeyShareAndRecordSizeLimit(t *testing.T) {
+	ch := &client.TLSClientHello{
+		Version: client.TLSNamedValue{Value: 0x0303, Name: "TLS 1.2"},
+		Extensions: json.RawMessage(`[
+			{"value": 51, "name": "key_share", "client_shares": [
+				{"group": {"value": 2570, "name": "GREASE"}, "key_exchange": [0]},
+				{"group": {"value": 29, "name": "x25519"}, "key_exchange": [1, 2, 3]}
+			]},
+			{"value": 28, "name": "record_size_limit", "record_size_limit": 16385}
+		]`),
+	}
+
+	h, err := ParseClientHello(ch)
+	require.NoError(t, err)
+	b := h.Breakdown()
+	assert.Equal(t, []string{"GREASE", "x25519 (0x001d)"}, b.KeyShareGroups)
+	assert.Equal(t, 16385, b.RecordSizeLimit)
+}
+
+func TestParseClientHello_InvalidExtensions(t *testing.T) {
+	_, err := ParseClientHello(&client.TLSClientHello{Extensions: json.RawMessage(`{"bad": true}`)})
+	assert.Error(t, err)
+}
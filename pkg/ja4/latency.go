@@ -0,0 +1,28 @@
+package ja4
+
+import (
+	"strconv"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// JA4L returns the latency part of the server's JA4L fingerprint (JA4L-S):
+// the one-way latency to the server in microseconds, estimated as half the
+// TCP handshake, which is the connect time less the TLS time. powhttp
+// records timings in whole milliseconds, so the estimate is a multiple of
+// 500. The TTL part of JA4L-S comes from the IP header, which captures do not
+// record, so it is left out. ok is false when the entry did not open its
+// connection.
+func JA4L(t client.Timings) (latency string, ok bool) {
+	if t.Connect == nil || *t.Connect <= 0 {
+		return "", false
+	}
+	handshake := *t.Connect
+	if t.SSL != nil && *t.SSL > 0 {
+		handshake -= *t.SSL
+	}
+	if handshake <= 0 {
+		return "", false
+	}
+	return strconv.FormatInt(handshake*1000/2, 10), true
+}
@@ -0,0 +1,32 @@
+package ja4
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+func TestJA4L(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name    string
+		timings client.Timings
+		want    string
+		ok      bool
+	}{
+		{"tls", client.Timings{Connect: ms(30), SSL: ms(20)}, "5000", true},
+		{"plain tcp", client.Timings{Connect: ms(7)}, "3500", true},
+		{"reused connection", client.Timings{Connect: ms(-1), SSL: ms(-1)}, "", false},
+		{"no timings", client.Timings{}, "", false},
+		{"tls time only", client.Timings{Connect: ms(20), SSL: ms(20)}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := JA4L(tt.timings)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
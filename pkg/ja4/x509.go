@@ -0,0 +1,86 @@
+package ja4
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// JA4X returns the JA4X fingerprint of a DER-encoded certificate: hashes of
+// the issuer attribute OIDs, the subject attribute OIDs, and the extension
+// OIDs, each in the order they appear. It identifies how a certificate was
+// generated rather than what it certifies.
+func JA4X(der []byte) (string, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return "", fmt.Errorf("parsing certificate: %w", err)
+	}
+	issuer, err := nameOIDs(cert.RawIssuer)
+	if err != nil {
+		return "", fmt.Errorf("parsing issuer: %w", err)
+	}
+	subject, err := nameOIDs(cert.RawSubject)
+	if err != nil {
+		return "", fmt.Errorf("parsing subject: %w", err)
+	}
+	extensions := make([]string, 0, len(cert.Extensions))
+	for _, ext := range cert.Extensions {
+		extensions = append(extensions, oidHex(ext.Id))
+	}
+	return strings.Join([]string{hashList(issuer), hashList(subject), hashList(extensions)}, "_"), nil
+}
+
+// JA4XHex is JA4X for a hex-encoded certificate, as TLS events carry them.
+func JA4XHex(certHex string) (string, error) {
+	der, err := hex.DecodeString(certHex)
+	if err != nil {
+		return "", fmt.Errorf("decoding certificate: %w", err)
+	}
+	return JA4X(der)
+}
+
+// ChainJA4X returns the JA4X of each certificate in a Certificate message, in
+// chain order. Certificates that cannot be parsed are left out.
+func ChainJA4X(cert *client.TLSCertificate) []string {
+	var out []string
+	for _, entry := range cert.CertificateList {
+		if fp, err := JA4XHex(entry.CertData); err == nil {
+			out = append(out, fp)
+		}
+	}
+	return out
+}
+
+// nameOIDs lists the attribute type OIDs of a DER-encoded distinguished name.
+func nameOIDs(raw []byte) ([]string, error) {
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(raw, &rdns); err != nil {
+		return nil, err
+	}
+	var oids []string
+	for _, rdn := range rdns {
+		for _, attr := range rdn {
+			oids = append(oids, oidHex(attr.Type))
+		}
+	}
+	return oids, nil
+}
+
+// oidHex returns the hex of an OID's encoded content bytes, e.g. "550403"
+// for 2.5.4.3 (commonName).
+func oidHex(oid asn1.ObjectIdentifier) string {
+	der, err := asn1.Marshal(oid)
+	if err != nil {
+		return ""
+	}
+	var value asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &value); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(value.Bytes)
+}
@@ -0,0 +1,65 @@
+package ja4
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+)
+
+// testCertificate issues a certificate for example.com with issuer CN, and
+// subject O and CN, and a single extension (subjectAltName).
+func testCertificate(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuer := &x509.Certificate{Subject: pkix.Name{CommonName: "Test CA"}}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, key)
+	require.NoError(t, err)
+	return der
+}
+
+func TestJA4X(t *testing.T) {
+	der := testCertificate(t)
+
+	fp, err := JA4X(der)
+	require.NoError(t, err)
+	assert.Equal(t, "7022c563de38_769119f9990f_6ea8df877ef2", fp)
+
+	fromHex, err := JA4XHex(hex.EncodeToString(der))
+	require.NoError(t, err)
+	assert.Equal(t, fp, fromHex)
+}
+
+func TestJA4X_Invalid(t *testing.T) {
+	_, err := JA4X([]byte("not a certificate"))
+	assert.Error(t, err)
+	_, err = JA4XHex("zz")
+	assert.Error(t, err)
+}
+
+func TestChainJA4X(t *testing.T) {
+	cert := &client.TLSCertificate{CertificateList: []client.TLSCertificateEntry{
+		{CertData: hex.EncodeToString(testCertificate(t))},
+		{CertData: "00"},
+	}}
+	assert.Equal(t, []string{"7022c563de38_769119f9990f_6ea8df877ef2"}, ChainJA4X(cert))
+}
@@ -0,0 +1,91 @@
+package jsonschema
+
+import (
+	"sort"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Field change kinds reported by Diff.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeType    = "type_changed"
+)
+
+// FieldChange describes how one field differs between two schemas.
+type FieldChange struct {
+	Path   string `json:"path"`             // JSON path (e.g., "user.name", "items[].id"); empty for the root
+	Change string `json:"change"`           // added, removed, or type_changed
+	Before string `json:"before,omitempty"` // Type before; empty when added
+	After  string `json:"after,omitempty"`  // Type after; empty when removed
+}
+
+// Diff compares two inferred schemas and returns the fields that were added,
+// removed, or changed type, ordered by path. Children of an added or removed
+// field are not listed separately, and arrays observed only empty on one side
+// are not descended into.
+func Diff(before, after *jsonschema.Schema) []FieldChange {
+	if before == nil || after == nil {
+		return nil
+	}
+	var changes []FieldChange
+	diffSchema("", before, after, &changes)
+	return changes
+}
+
+// diffSchema appends the differences between a and b at path to changes.
+func diffSchema(path string, a, b *jsonschema.Schema, changes *[]FieldChange) {
+	ta, tb := resolveType(a), resolveType(b)
+	if ta != tb {
+		*changes = append(*changes, FieldChange{Path: path, Change: ChangeType, Before: ta, After: tb})
+		return
+	}
+
+	switch ta {
+	case "object":
+		keys := make(map[string]bool)
+		for _, s := range []*jsonschema.Schema{a, b} {
+			if s.Properties == nil {
+				continue
+			}
+			for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				keys[pair.Key] = true
+			}
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			fieldPath := k
+			if path != "" {
+				fieldPath = path + "." + k
+			}
+			pa, pb := property(a, k), property(b, k)
+			switch {
+			case pb == nil:
+				*changes = append(*changes, FieldChange{Path: fieldPath, Change: ChangeRemoved, Before: resolveType(pa)})
+			case pa == nil:
+				*changes = append(*changes, FieldChange{Path: fieldPath, Change: ChangeAdded, After: resolveType(pb)})
+			default:
+				diffSchema(fieldPath, pa, pb, changes)
+			}
+		}
+	case "array":
+		if a.Items != nil && b.Items != nil {
+			diffSchema(path+"[]", a.Items, b.Items, changes)
+		}
+	}
+}
+
+// property returns the schema of an object property, or nil if absent.
+func property(s *jsonschema.Schema, name string) *jsonschema.Schema {
+	if s.Properties == nil {
+		return nil
+	}
+	v, _ := s.Properties.Get(name)
+	return v
+}
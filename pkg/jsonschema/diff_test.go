@@ -0,0 +1,46 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before, err := Infer(
+		[]byte(`{"id": 1, "name": "a", "legacy": true, "owner": {"id": 1}, "tags": [{"k": "x"}], "empty": []}`),
+	)
+	require.NoError(t, err)
+	after, err := Infer(
+		[]byte(`{"id": "u-1", "name": "a", "owner": {"id": 1, "email": "a@b.c"}, "tags": [{"k": 1}], "empty": [{"x": 1}], "meta": {"v": 2}}`),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []FieldChange{
+		{Path: "id", Change: ChangeType, Before: "integer", After: "string"},
+		{Path: "legacy", Change: ChangeRemoved, Before: "boolean"},
+		{Path: "meta", Change: ChangeAdded, After: "object"},
+		{Path: "owner.email", Change: ChangeAdded, After: "string"},
+		{Path: "tags[].k", Change: ChangeType, Before: "string", After: "integer"},
+	}, Diff(before.Schema, after.Schema))
+}
+
+func TestDiff_Identical(t *testing.T) {
+	a, err := Infer([]byte(`{"id": 1, "items": [{"n": "x"}]}`))
+	require.NoError(t, err)
+	b, err := Infer([]byte(`{"items": [{"n": "y"}], "id": 2}`))
+	require.NoError(t, err)
+
+	assert.Empty(t, Diff(a.Schema, b.Schema))
+	assert.Nil(t, Diff(nil, b.Schema))
+}
+
+func TestDiff_RootType(t *testing.T) {
+	a, err := Infer([]byte(`[1, 2]`))
+	require.NoError(t, err)
+	b, err := Infer([]byte(`{"items": [1, 2]}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []FieldChange{{Path: "", Change: ChangeType, Before: "array", After: "object"}}, Diff(a.Schema, b.Schema))
+}
@@ -5,11 +5,13 @@ import (
 
 	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
 	"github.com/usestring/powhttp-mcp/internal/compare"
 	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/internal/entryfetch"
 	"github.com/usestring/powhttp-mcp/internal/flow"
 	"github.com/usestring/powhttp-mcp/internal/indexer"
+	"github.com/usestring/powhttp-mcp/internal/replay"
 	"github.com/usestring/powhttp-mcp/internal/search"
 	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/textquery"
@@ -18,7 +20,7 @@ import (
 // Deps contains all dependencies available to custom tools.
 // This gives custom tools access to the same infrastructure as builtin tools.
 type Deps struct {
-	Client       *client.Client
+	Client       client.DataSource
 	Indexer      *indexer.Indexer
 	Cache        *cache.EntryCache
 	Config       *config.Config
@@ -27,9 +29,12 @@ type Deps struct {
 	Diff         *compare.DiffEngine
 	Cluster      *catalog.ClusterEngine
 	Describe     *catalog.DescribeEngine
+	CatalogDiff  *catalog.CatalogDiffEngine
 	ClusterStore *catalog.ClusterStore
 	Flow         *flow.FlowEngine
 	TextQuery    *textquery.Engine
+	Replay       *replay.Sender // nil when POWHTTP_PROXY_URL is invalid
+	Clients      *clientdb.DB   // Reference fingerprints of known clients
 }
 
 // FetchEntry retrieves an entry by ID, checking the cache first.
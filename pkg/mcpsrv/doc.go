@@ -14,6 +14,16 @@
 //	}
 //	server.Run(ctx)
 //
+// # Offline Captures
+//
+// Serve HAR or JSONL files instead of a running powhttp app:
+//
+//	src, err := capture.Open("./traffic.har")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	server, err := mcpsrv.NewServer(src)
+//
 // # Extension
 //
 // Add custom tools using MCP SDK types directly:
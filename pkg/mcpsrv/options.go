@@ -18,6 +18,12 @@ type serverConfig struct {
 	logLevel string
 	logFile  string
 
+	// HTTP transport overrides (empty values fall back to config)
+	httpAddr           string
+	httpAllowedOrigins []string
+	httpAllowedHosts   []string
+	httpBearerToken    string
+
 	// Extension toggles
 	disableBuiltinTools   bool
 	disableBuiltinPrompts bool
@@ -57,6 +63,41 @@ func WithHTTPClient(c *http.Client) Option {
 	}
 }
 
+// WithHTTPAddr serves MCP over HTTP on addr (e.g. "127.0.0.1:8765") instead of stdio.
+// The streamable HTTP transport is served at /mcp with an SSE fallback at /sse,
+// so one long-running server with a warm index can be shared by several clients.
+func WithHTTPAddr(addr string) Option {
+	return func(cfg *serverConfig) {
+		cfg.httpAddr = addr
+	}
+}
+
+// WithAllowedOrigins sets the browser origins permitted to connect over HTTP.
+// By default only localhost origins are accepted; pass "*" to accept any origin.
+// Requests without an Origin header (non-browser clients) are always accepted.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(cfg *serverConfig) {
+		cfg.httpAllowedOrigins = append(cfg.httpAllowedOrigins, origins...)
+	}
+}
+
+// WithAllowedHosts sets the Host header values the HTTP transport answers to,
+// such as "mcp.internal" or "mcp.internal:8765". By default only loopback
+// hosts are accepted when bound to loopback; pass "*" to accept any host.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(cfg *serverConfig) {
+		cfg.httpAllowedHosts = append(cfg.httpAllowedHosts, hosts...)
+	}
+}
+
+// WithBearerToken requires HTTP clients to send "Authorization: Bearer <token>".
+// It has no effect on the stdio transport.
+func WithBearerToken(token string) Option {
+	return func(cfg *serverConfig) {
+		cfg.httpBearerToken = token
+	}
+}
+
 // WithoutBuiltinTools disables all builtin powhttp tools.
 // Use this if you want to register only your own tools.
 func WithoutBuiltinTools() Option {
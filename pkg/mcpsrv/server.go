@@ -3,11 +3,15 @@ package mcpsrv
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
 
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/usestring/powhttp-mcp/internal/cache"
 	"github.com/usestring/powhttp-mcp/internal/catalog"
+	"github.com/usestring/powhttp-mcp/internal/clientdb"
 	"github.com/usestring/powhttp-mcp/internal/compare"
 	"github.com/usestring/powhttp-mcp/internal/config"
 	"github.com/usestring/powhttp-mcp/internal/flow"
@@ -15,6 +19,7 @@ import (
 	"github.com/usestring/powhttp-mcp/internal/logging"
 	"github.com/usestring/powhttp-mcp/internal/mcp"
 	"github.com/usestring/powhttp-mcp/internal/mcp/tools"
+	"github.com/usestring/powhttp-mcp/internal/replay"
 	"github.com/usestring/powhttp-mcp/internal/search"
 	"github.com/usestring/powhttp-mcp/pkg/client"
 	"github.com/usestring/powhttp-mcp/pkg/textquery"
@@ -26,17 +31,25 @@ type Server struct {
 	internal   *mcp.Server
 	indexer    *indexer.Indexer
 	deps       *Deps
+	httpAddr   string
+	httpOpts   mcp.HTTPOptions
 	logCleanup func() error
 }
 
 // NewServer creates a new MCP server with builtin powhttp tools.
 //
-// The client parameter is required and provides access to the powhttp API.
+// The client parameter is required and provides access to captured traffic:
+// a *client.Client for the live powhttp API, or a *capture.Source serving
+// HAR/JSONL files offline.
 // Use functional options to configure logging, add custom tools, etc.
-func NewServer(c *client.Client, opts ...Option) (*Server, error) {
+func NewServer(c client.DataSource, opts ...Option) (*Server, error) {
 	if c == nil {
 		return nil, fmt.Errorf("client is required")
 	}
+	// A typed nil (e.g. a nil *client.Client) is non-nil as an interface
+	if v := reflect.ValueOf(c); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, fmt.Errorf("client is required")
+	}
 
 	// Build configuration from options
 	cfg := &serverConfig{
@@ -71,6 +84,14 @@ func NewServer(c *client.Client, opts ...Option) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entry cache: %w", err)
 	}
+	harExports, err := cache.NewExportCache(cfg.config.ExportCacheMaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export cache: %w", err)
+	}
+	openAPIExports, err := cache.NewExportCache(cfg.config.ExportCacheMaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export cache: %w", err)
+	}
 
 	idx := indexer.New(c, entryCache, cfg.config)
 	clusterStore := catalog.NewClusterStore()
@@ -81,23 +102,37 @@ func NewServer(c *client.Client, opts ...Option) (*Server, error) {
 	diffEngine := compare.NewDiffEngine(fpEngine)
 	clusterEngine := catalog.NewClusterEngine(idx, cfg.config, clusterStore)
 	describeEngine := catalog.NewDescribeEngine(idx, c, entryCache, cfg.config, clusterStore)
-	flowEngine := flow.NewFlowEngine(idx, cfg.config)
+	catalogDiffEngine := catalog.NewCatalogDiffEngine(idx, c, entryCache, cfg.config)
+	flowEngine := flow.NewFlowEngine(idx, c, entryCache, cfg.config)
 	textQueryEngine := textquery.NewEngine()
+	replaySender, err := replay.NewSender(cfg.config.PowHTTPProxyURL, cfg.config.ReplayTimeout, cfg.config.ReplayInsecureTLS)
+	if err != nil {
+		slog.Warn("replay disabled", slog.String("error", err.Error()))
+	}
+	clientDB, err := clientdb.New(cfg.config.ClientProfilesFile)
+	if err != nil {
+		slog.Warn("client profiles not loaded", slog.String("error", err.Error()))
+	}
 
 	// Create deps for internal tools and custom tools
 	toolDeps := &tools.Deps{
-		Client:       c,
-		Indexer:      idx,
-		Cache:        entryCache,
-		Config:       cfg.config,
-		Search:       searchEngine,
-		Fingerprint:  fpEngine,
-		Diff:         diffEngine,
-		Cluster:      clusterEngine,
-		Describe:     describeEngine,
-		ClusterStore: clusterStore,
-		Flow:         flowEngine,
-		TextQuery:    textQueryEngine,
+		Client:         c,
+		Indexer:        idx,
+		Cache:          entryCache,
+		Config:         cfg.config,
+		Search:         searchEngine,
+		Fingerprint:    fpEngine,
+		Diff:           diffEngine,
+		Cluster:        clusterEngine,
+		Describe:       describeEngine,
+		CatalogDiff:    catalogDiffEngine,
+		ClusterStore:   clusterStore,
+		Flow:           flowEngine,
+		TextQuery:      textQueryEngine,
+		Replay:         replaySender,
+		Clients:        clientDB,
+		HARExports:     harExports,
+		OpenAPIExports: openAPIExports,
 	}
 
 	// Create public deps (same values, different type for public API)
@@ -111,9 +146,12 @@ func NewServer(c *client.Client, opts ...Option) (*Server, error) {
 		Diff:         diffEngine,
 		Cluster:      clusterEngine,
 		Describe:     describeEngine,
+		CatalogDiff:  catalogDiffEngine,
 		ClusterStore: clusterStore,
 		Flow:         flowEngine,
 		TextQuery:    textQueryEngine,
+		Replay:       replaySender,
+		Clients:      clientDB,
 	}
 
 	// Build internal server options
@@ -150,24 +188,65 @@ func NewServer(c *client.Client, opts ...Option) (*Server, error) {
 		return nil, fmt.Errorf("failed to create server: %w", err)
 	}
 
+	// Resolve HTTP transport settings (options override environment)
+	httpAddr := cfg.config.HTTPAddr
+	if cfg.httpAddr != "" {
+		httpAddr = cfg.httpAddr
+	}
+	httpOpts := mcp.HTTPOptions{
+		AllowedOrigins: cfg.config.HTTPAllowedOrigins,
+		AllowedHosts:   cfg.config.HTTPAllowedHosts,
+		BearerToken:    cfg.config.HTTPBearerToken,
+	}
+	if len(cfg.httpAllowedOrigins) > 0 {
+		httpOpts.AllowedOrigins = cfg.httpAllowedOrigins
+	}
+	if len(cfg.httpAllowedHosts) > 0 {
+		httpOpts.AllowedHosts = cfg.httpAllowedHosts
+	}
+	if cfg.httpBearerToken != "" {
+		httpOpts.BearerToken = cfg.httpBearerToken
+	}
+
 	return &Server{
 		internal:   internal,
 		indexer:    idx,
 		deps:       deps,
+		httpAddr:   httpAddr,
+		httpOpts:   httpOpts,
 		logCleanup: logCleanup,
 	}, nil
 }
 
-// Run starts the MCP server with stdio transport.
+// Run starts the MCP server with stdio transport, or with the HTTP transport
+// when an address was set via WithHTTPAddr or MCP_HTTP_ADDR.
 // It also starts background refresh for all sessions.
 // The server runs until the context is cancelled.
 func (s *Server) Run(ctx context.Context) error {
 	go s.indexer.StartBackgroundRefresh(ctx)
+	if s.httpAddr != "" {
+		return s.internal.RunHTTP(ctx, s.httpAddr, s.httpOpts)
+	}
 	return s.internal.Run(ctx)
 }
 
-// Close cleans up server resources.
+// HTTPHandler returns the HTTP transport handler for mounting in an existing
+// http.Server. Host, origin, and bearer token checks are applied as configured;
+// set WithAllowedHosts when the outer server is reachable by a non-loopback name.
+// When served this way instead of via Run, there is no background refresh;
+// the index is refreshed lazily by the tools that query it.
+func (s *Server) HTTPHandler() http.Handler {
+	return s.internal.HTTPHandler(s.httpOpts)
+}
+
+// HTTPAddr returns the configured HTTP listen address, or "" for stdio.
+func (s *Server) HTTPAddr() string {
+	return s.httpAddr
+}
+
+// Close flushes pending index snapshots and cleans up server resources.
 func (s *Server) Close() error {
+	s.indexer.FlushSnapshots()
 	if s.logCleanup != nil {
 		return s.logCleanup()
 	}
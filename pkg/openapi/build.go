@@ -0,0 +1,652 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/invopop/jsonschema"
+	"gopkg.in/yaml.v3"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/contenttype"
+	js "github.com/usestring/powhttp-mcp/pkg/jsonschema"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+// DefaultMaxEnumValues is the largest set of distinct query values reported as an enum.
+const DefaultMaxEnumValues = 10
+
+// Output formats accepted by Marshal and WriteFile.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Endpoint is one observed endpoint (typically an extract_endpoints cluster)
+// together with the entries used to describe it.
+type Endpoint struct {
+	ClusterID    string
+	Host         string
+	Method       string
+	PathTemplate string // e.g. /users/{id}/posts
+	Count        int    // total requests observed, may exceed len(Entries)
+
+	Auth           types.AuthSignals
+	SessionCookies []string // cookie names that carry the session, for cookie auth
+
+	Entries []*client.SessionEntry
+}
+
+// Options controls document generation.
+type Options struct {
+	Title         string // default "<host> API", or "Captured API" for several hosts
+	Version       string // info.version, default "1.0.0"
+	Description   string
+	MaxEnumValues int // default DefaultMaxEnumValues; <0 disables enums
+}
+
+// placeholderPattern matches template segments such as {id}, {uuid}, {hex}.
+var placeholderPattern = regexp.MustCompile(`^\{([^{}/]+)\}$`)
+
+// Build assembles an OpenAPI document from observed endpoints.
+func Build(endpoints []Endpoint, opts Options) *Document {
+	if opts.MaxEnumValues == 0 {
+		opts.MaxEnumValues = DefaultMaxEnumValues
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+
+	sorted := append([]Endpoint(nil), endpoints...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].PathTemplate != sorted[j].PathTemplate {
+			return sorted[i].PathTemplate < sorted[j].PathTemplate
+		}
+		if sorted[i].Method != sorted[j].Method {
+			return methodRank(sorted[i].Method) < methodRank(sorted[j].Method)
+		}
+		return sorted[i].Host < sorted[j].Host
+	})
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: opts.Title, Version: opts.Version, Description: opts.Description},
+		Paths:   make(map[string]*PathItem),
+	}
+
+	allServers := newServerSet()
+	pathServers := make(map[string]*serverSet)
+	schemes := make(map[string]*SecurityScheme)
+	operationIDs := make(map[string]bool)
+
+	for _, ep := range sorted {
+		path, pathParams := convertPath(ep)
+
+		servers := endpointServers(ep)
+		allServers.addAll(servers)
+		if pathServers[path] == nil {
+			pathServers[path] = newServerSet()
+		}
+		pathServers[path].addAll(servers)
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		slot := item.operation(ep.Method)
+		if slot == nil {
+			continue // method not representable in OpenAPI (e.g. CONNECT)
+		}
+		if *slot != nil {
+			// Same method and path on another host: keep one operation
+			(*slot).ObservedCount += ep.Count
+			continue
+		}
+
+		op := &Operation{
+			OperationID:   uniqueOperationID(operationID(ep.Method, path), operationIDs),
+			Summary:       fmt.Sprintf("%s %s%s", strings.ToUpper(ep.Method), ep.Host, ep.PathTemplate),
+			Parameters:    append(pathParams, queryParameters(ep.Entries, opts.MaxEnumValues)...),
+			RequestBody:   requestBody(ep.Entries),
+			Responses:     responses(ep.Entries),
+			ClusterID:     ep.ClusterID,
+			ObservedCount: ep.Count,
+		}
+		if req := security(ep, schemes); len(req) > 0 {
+			op.Security = []SecurityRequirement{req}
+		}
+		*slot = op
+	}
+
+	doc.Servers = allServers.list()
+	if len(doc.Servers) > 1 {
+		for path, item := range doc.Paths {
+			item.Servers = pathServers[path].list()
+		}
+	}
+
+	if doc.Info.Title == "" {
+		doc.Info.Title = "Captured API"
+		if len(doc.Servers) == 1 {
+			if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+				doc.Info.Title = u.Host + " API"
+			}
+		}
+	}
+
+	if len(schemes) > 0 {
+		doc.Components = &Components{SecuritySchemes: schemes}
+	}
+
+	return doc
+}
+
+// Marshal encodes doc as JSON (default) or YAML.
+func Marshal(doc *Document, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", FormatJSON:
+		return buf.Bytes(), nil
+	case FormatYAML:
+		// Round-trip through a yaml.Node to keep the JSON key order
+		var node yaml.Node
+		if err := yaml.Unmarshal(buf.Bytes(), &node); err != nil {
+			return nil, err
+		}
+		clearStyle(&node)
+		var out bytes.Buffer
+		enc := yaml.NewEncoder(&out)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q (use json or yaml)", format)
+	}
+}
+
+// WriteFile writes doc to path, creating parent directories as needed.
+func WriteFile(path string, doc *Document, format string) (int, error) {
+	data, err := Marshal(doc, format)
+	if err != nil {
+		return 0, fmt.Errorf("encoding OpenAPI document: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("creating directory for %q: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return 0, fmt.Errorf("writing OpenAPI document %q: %w", path, err)
+	}
+	return len(data), nil
+}
+
+// clearStyle switches JSON's flow style and quoting to YAML block defaults.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// operation returns the slot for a method, or nil if OpenAPI has no field for it.
+func (p *PathItem) operation(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return &p.Get
+	case http.MethodPut:
+		return &p.Put
+	case http.MethodPost:
+		return &p.Post
+	case http.MethodDelete:
+		return &p.Delete
+	case http.MethodOptions:
+		return &p.Options
+	case http.MethodHead:
+		return &p.Head
+	case http.MethodPatch:
+		return &p.Patch
+	case http.MethodTrace:
+		return &p.Trace
+	}
+	return nil
+}
+
+func methodRank(method string) int {
+	order := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE"}
+	for i, m := range order {
+		if strings.EqualFold(m, method) {
+			return i
+		}
+	}
+	return len(order)
+}
+
+// convertPath names the template placeholders uniquely and returns the
+// matching path parameters. Examples come from the first entry's URL.
+func convertPath(ep Endpoint) (string, []Parameter) {
+	segments := strings.Split(ep.PathTemplate, "/")
+
+	var observed []string
+	if len(ep.Entries) > 0 {
+		if u, err := url.Parse(ep.Entries[0].URL); err == nil {
+			observed = strings.Split(u.Path, "/")
+		}
+	}
+
+	var params []Parameter
+	used := make(map[string]int)
+	for i, seg := range segments {
+		m := placeholderPattern.FindStringSubmatch(seg)
+		if m == nil {
+			continue
+		}
+		kind := m[1]
+		name := kind
+		if used[kind]++; used[kind] > 1 {
+			name = fmt.Sprintf("%s%d", kind, used[kind])
+		}
+		segments[i] = "{" + name + "}"
+
+		param := Parameter{Name: name, In: "path", Required: true, Schema: placeholderSchema(kind)}
+		if len(observed) == len(segments) {
+			if v, err := url.PathUnescape(observed[i]); err == nil {
+				param.Example = scalarExample(param.Schema, v)
+			}
+		}
+		params = append(params, param)
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// placeholderSchema maps the clustering placeholders to schemas.
+func placeholderSchema(kind string) *jsonschema.Schema {
+	switch kind {
+	case "id":
+		return &jsonschema.Schema{Type: "integer"}
+	case "uuid":
+		return &jsonschema.Schema{Type: "string", Format: "uuid"}
+	case "hex":
+		return &jsonschema.Schema{Type: "string", Pattern: "^[0-9a-fA-F]+$"}
+	}
+	return &jsonschema.Schema{Type: "string"}
+}
+
+// queryParameters describes every query key seen across entries.
+func queryParameters(entries []*client.SessionEntry, maxEnum int) []Parameter {
+	type keyStats struct {
+		present int
+		values  []string
+	}
+	stats := make(map[string]*keyStats)
+	parsed := 0
+
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			continue
+		}
+		parsed++
+		for key, values := range u.Query() {
+			s := stats[key]
+			if s == nil {
+				s = &keyStats{}
+				stats[key] = s
+			}
+			s.present++
+			s.values = append(s.values, values...)
+		}
+	}
+
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]Parameter, 0, len(keys))
+	for _, key := range keys {
+		s := stats[key]
+		schema := scalarSchema(s.values)
+
+		// Report an enum only when values repeat; a set of unique values
+		// (ids, timestamps, nonces) says nothing about the allowed range.
+		distinct := uniqueStrings(s.values)
+		if maxEnum > 0 && len(distinct) <= maxEnum && len(distinct) < len(s.values) {
+			for _, v := range distinct {
+				schema.Enum = append(schema.Enum, scalarExample(schema, v))
+			}
+		}
+
+		param := Parameter{Name: key, In: "query", Required: s.present == parsed, Schema: schema}
+		if len(s.values) > 0 {
+			param.Example = scalarExample(schema, s.values[0])
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// scalarSchema picks the narrowest type matching every observed value.
+func scalarSchema(values []string) *jsonschema.Schema {
+	if len(values) == 0 {
+		return &jsonschema.Schema{Type: "string"}
+	}
+	isInt, isNum, isBool := true, true, true
+	for _, v := range values {
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			isInt = false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			isNum = false
+		}
+		if v != "true" && v != "false" {
+			isBool = false
+		}
+	}
+	switch {
+	case isInt:
+		return &jsonschema.Schema{Type: "integer"}
+	case isNum:
+		return &jsonschema.Schema{Type: "number"}
+	case isBool:
+		return &jsonschema.Schema{Type: "boolean"}
+	}
+	return &jsonschema.Schema{Type: "string"}
+}
+
+// scalarExample converts a raw string value to the schema's type.
+func scalarExample(schema *jsonschema.Schema, v string) any {
+	switch schema.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		return v == "true"
+	}
+	return v
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// requestBody describes the observed request payloads, or nil if none had a body.
+func requestBody(entries []*client.SessionEntry) *RequestBody {
+	bodies := make(map[string][][]byte)
+	withBody := 0
+	for _, e := range entries {
+		body := decode(e.Request.Body, e.Request.Headers)
+		if len(body) == 0 {
+			continue
+		}
+		withBody++
+		mt := mediaType(e.Request.Headers)
+		bodies[mt] = append(bodies[mt], body)
+	}
+	if withBody == 0 {
+		return nil
+	}
+	return &RequestBody{
+		Required: withBody == len(entries),
+		Content:  content(bodies),
+	}
+}
+
+// responses builds one response per observed status code.
+func responses(entries []*client.SessionEntry) map[string]*Response {
+	byStatus := make(map[int]map[string][][]byte)
+	for _, e := range entries {
+		if e.Response == nil || e.Response.StatusCode == nil {
+			continue
+		}
+		status := *e.Response.StatusCode
+		if byStatus[status] == nil {
+			byStatus[status] = make(map[string][][]byte)
+		}
+		if body := decode(e.Response.Body, e.Response.Headers); len(body) > 0 {
+			mt := mediaType(e.Response.Headers)
+			byStatus[status][mt] = append(byStatus[status][mt], body)
+		}
+	}
+
+	out := make(map[string]*Response, len(byStatus))
+	for status, bodies := range byStatus {
+		desc := http.StatusText(status)
+		if desc == "" {
+			desc = "Observed response"
+		}
+		resp := &Response{Description: desc}
+		if len(bodies) > 0 {
+			resp.Content = content(bodies)
+		}
+		out[strconv.Itoa(status)] = resp
+	}
+	if len(out) == 0 {
+		out["default"] = &Response{Description: "No response captured"}
+	}
+	return out
+}
+
+// content infers a schema for each media type.
+func content(bodies map[string][][]byte) map[string]MediaType {
+	out := make(map[string]MediaType, len(bodies))
+	for mt, samples := range bodies {
+		out[mt] = MediaType{Schema: bodySchema(mt, samples)}
+	}
+	return out
+}
+
+// bodySchema infers JSON bodies and describes other payloads by their shape.
+func bodySchema(mt string, samples [][]byte) *jsonschema.Schema {
+	switch contenttype.Classify(mt) {
+	case contenttype.JSON:
+		if inferred, err := js.Infer(samples...); err == nil && inferred != nil {
+			return inferred.Schema
+		}
+		return &jsonschema.Schema{}
+	case contenttype.Form:
+		return formSchema(samples)
+	case contenttype.Binary:
+		return &jsonschema.Schema{Type: "string", ContentMediaType: mt}
+	}
+	return &jsonschema.Schema{Type: "string"}
+}
+
+// formSchema describes urlencoded bodies as an object of string fields.
+func formSchema(samples [][]byte) *jsonschema.Schema {
+	schema := &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+	counts := make(map[string]int)
+	parsed := 0
+	for _, s := range samples {
+		values, err := url.ParseQuery(string(s))
+		if err != nil {
+			continue
+		}
+		parsed++
+		for k := range values {
+			counts[k]++
+		}
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		schema.Properties.Set(k, &jsonschema.Schema{Type: "string"})
+		if counts[k] == parsed {
+			schema.Required = append(schema.Required, k)
+		}
+	}
+	return schema
+}
+
+// security registers the endpoint's auth schemes and returns its requirement.
+// Every observed mechanism is required together, since captured requests carried all of them.
+func security(ep Endpoint, schemes map[string]*SecurityScheme) SecurityRequirement {
+	req := SecurityRequirement{}
+	if ep.Auth.BearerPresent {
+		schemes["bearerAuth"] = &SecurityScheme{Type: "http", Scheme: "bearer"}
+		req["bearerAuth"] = []string{}
+	}
+	for _, h := range ep.Auth.CustomAuthHeaders {
+		name := schemeName(h)
+		schemes[name] = &SecurityScheme{Type: "apiKey", In: "header", Name: h}
+		req[name] = []string{}
+	}
+	if ep.Auth.CookiesPresent {
+		for _, c := range ep.SessionCookies {
+			name := "cookie_" + schemeName(c)
+			schemes[name] = &SecurityScheme{Type: "apiKey", In: "cookie", Name: c}
+			req[name] = []string{}
+		}
+	}
+	return req
+}
+
+// schemeName keeps only characters allowed in component keys.
+func schemeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// operationID derives a camelCase ID such as getUsersById from method and path.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" {
+			continue
+		}
+		if m := placeholderPattern.FindStringSubmatch(seg); m != nil {
+			b.WriteString("By")
+			seg = m[1]
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func uniqueOperationID(id string, used map[string]bool) string {
+	candidate := id
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", id, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// endpointServers returns the scheme://host origins the endpoint was seen on.
+func endpointServers(ep Endpoint) []string {
+	var out []string
+	for _, e := range ep.Entries {
+		if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+			out = append(out, u.Scheme+"://"+u.Host)
+		}
+	}
+	if len(out) == 0 && ep.Host != "" {
+		out = append(out, "https://"+ep.Host)
+	}
+	return out
+}
+
+// serverSet collects unique server URLs in first-seen order.
+type serverSet struct {
+	seen map[string]bool
+	urls []string
+}
+
+func newServerSet() *serverSet {
+	return &serverSet{seen: make(map[string]bool)}
+}
+
+func (s *serverSet) addAll(urls []string) {
+	for _, u := range urls {
+		if !s.seen[u] {
+			s.seen[u] = true
+			s.urls = append(s.urls, u)
+		}
+	}
+}
+
+func (s *serverSet) list() []Server {
+	out := make([]Server, 0, len(s.urls))
+	for _, u := range s.urls {
+		out = append(out, Server{URL: u})
+	}
+	return out
+}
+
+// mediaType returns the lowercased media type without parameters.
+func mediaType(headers client.Headers) string {
+	ct := ""
+	for _, h := range headers {
+		if len(h) >= 2 && strings.EqualFold(h[0], "content-type") {
+			ct = h[1]
+			break
+		}
+	}
+	if ct == "" {
+		return "application/octet-stream"
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(ct))
+	}
+	return mt
+}
+
+func decode(body *string, headers client.Headers) []byte {
+	if body == nil || *body == "" {
+		return nil
+	}
+	b, err := client.DecodeContent(body, client.ContentEncoding(headers))
+	if err != nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/usestring/powhttp-mcp/pkg/client"
+	"github.com/usestring/powhttp-mcp/pkg/types"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+
+func b64(s string) *string {
+	v := base64.StdEncoding.EncodeToString([]byte(s))
+	return &v
+}
+
+func makeEntry(method, rawURL string, status int, reqBody, respBody string) *client.SessionEntry {
+	e := &client.SessionEntry{
+		URL: rawURL,
+		Request: client.Request{
+			Method:  strPtr(method),
+			Headers: client.Headers{{"content-type", "application/json; charset=utf-8"}},
+		},
+		Response: &client.Response{
+			StatusCode: intPtr(status),
+			Headers:    client.Headers{{"content-type", "application/json"}},
+		},
+	}
+	if reqBody != "" {
+		e.Request.Body = b64(reqBody)
+	}
+	if respBody != "" {
+		e.Response.Body = b64(respBody)
+	}
+	return e
+}
+
+func usersEndpoint() Endpoint {
+	return Endpoint{
+		ClusterID:      "c1",
+		Host:           "api.example.com",
+		Method:         "GET",
+		PathTemplate:   "/users/{id}/posts/{id}",
+		Count:          12,
+		Auth:           types.AuthSignals{BearerPresent: true, CookiesPresent: true, CustomAuthHeaders: []string{"x-api-key"}},
+		SessionCookies: []string{"sid"},
+		Entries: []*client.SessionEntry{
+			makeEntry("GET", "https://api.example.com/users/42/posts/7?sort=asc&page=1", 200, "", `{"id":7,"title":"a"}`),
+			makeEntry("GET", "https://api.example.com/users/43/posts/8?sort=desc&page=2", 200, "", `{"id":8,"title":"b","draft":true}`),
+			makeEntry("GET", "https://api.example.com/users/44/posts/9?sort=asc", 404, "", `{"error":"not found"}`),
+		},
+	}
+}
+
+func TestBuild_PathParameters(t *testing.T) {
+	doc := Build([]Endpoint{usersEndpoint()}, Options{})
+
+	item := doc.Paths["/users/{id}/posts/{id2}"]
+	require.NotNil(t, item)
+	require.NotNil(t, item.Get)
+
+	params := item.Get.Parameters
+	require.GreaterOrEqual(t, len(params), 2)
+	assert.Equal(t, "id", params[0].Name)
+	assert.Equal(t, "path", params[0].In)
+	assert.True(t, params[0].Required)
+	assert.Equal(t, "integer", params[0].Schema.Type)
+	assert.Equal(t, int64(42), params[0].Example)
+	assert.Equal(t, "id2", params[1].Name)
+	assert.Equal(t, int64(7), params[1].Example)
+}
+
+func TestBuild_QueryParameters(t *testing.T) {
+	doc := Build([]Endpoint{usersEndpoint()}, Options{})
+	params := doc.Paths["/users/{id}/posts/{id2}"].Get.Parameters
+
+	byName := make(map[string]Parameter)
+	for _, p := range params {
+		if p.In == "query" {
+			byName[p.Name] = p
+		}
+	}
+
+	sort := byName["sort"]
+	assert.True(t, sort.Required)
+	assert.Equal(t, "string", sort.Schema.Type)
+	assert.Equal(t, []any{"asc", "desc"}, sort.Schema.Enum)
+
+	page := byName["page"]
+	assert.False(t, page.Required)
+	assert.Equal(t, "integer", page.Schema.Type)
+	assert.Nil(t, page.Schema.Enum, "unique values are not an enum")
+	assert.Equal(t, int64(1), page.Example)
+}
+
+func TestBuild_ResponsesAndSecurity(t *testing.T) {
+	doc := Build([]Endpoint{usersEndpoint()}, Options{})
+	op := doc.Paths["/users/{id}/posts/{id2}"].Get
+
+	assert.Equal(t, "getUsersByIdPostsById2", op.OperationID)
+	assert.Equal(t, "c1", op.ClusterID)
+	assert.Equal(t, 12, op.ObservedCount)
+	assert.Nil(t, op.RequestBody)
+
+	require.Contains(t, op.Responses, "200")
+	require.Contains(t, op.Responses, "404")
+	assert.Equal(t, "OK", op.Responses["200"].Description)
+	ok := op.Responses["200"].Content["application/json"].Schema
+	require.NotNil(t, ok)
+	assert.Equal(t, "object", ok.Type)
+	_, hasTitle := ok.Properties.Get("title")
+	assert.True(t, hasTitle)
+	notFound := op.Responses["404"].Content["application/json"].Schema
+	_, hasError := notFound.Properties.Get("error")
+	assert.True(t, hasError)
+
+	require.Len(t, op.Security, 1)
+	assert.Contains(t, op.Security[0], "bearerAuth")
+	assert.Contains(t, op.Security[0], "x-api-key")
+	assert.Contains(t, op.Security[0], "cookie_sid")
+
+	require.NotNil(t, doc.Components)
+	assert.Equal(t, &SecurityScheme{Type: "http", Scheme: "bearer"}, doc.Components.SecuritySchemes["bearerAuth"])
+	assert.Equal(t, &SecurityScheme{Type: "apiKey", In: "cookie", Name: "sid"}, doc.Components.SecuritySchemes["cookie_sid"])
+
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Servers)
+	assert.Equal(t, "api.example.com API", doc.Info.Title)
+	assert.Nil(t, doc.Paths["/users/{id}/posts/{id2}"].Servers)
+}
+
+func TestBuild_RequestBodies(t *testing.T) {
+	form := makeEntry("POST", "https://auth.example.com/login", 302, "user=a&pass=b", "")
+	form.Request.Headers = client.Headers{{"Content-Type", "application/x-www-form-urlencoded"}}
+	form2 := makeEntry("POST", "https://auth.example.com/login", 302, "user=c", "")
+	form2.Request.Headers = form.Request.Headers
+
+	doc := Build([]Endpoint{
+		{Host: "auth.example.com", Method: "POST", PathTemplate: "/login", Entries: []*client.SessionEntry{form, form2}},
+		{Host: "api.example.com", Method: "POST", PathTemplate: "/items", Entries: []*client.SessionEntry{
+			makeEntry("POST", "https://api.example.com/items", 201, `{"name":"x"}`, ""),
+			makeEntry("POST", "https://api.example.com/items", 201, "", ""),
+		}},
+	}, Options{Title: "Test"})
+
+	login := doc.Paths["/login"].Post.RequestBody
+	require.NotNil(t, login)
+	assert.True(t, login.Required)
+	schema := login.Content["application/x-www-form-urlencoded"].Schema
+	assert.Equal(t, []string{"user"}, schema.Required)
+
+	items := doc.Paths["/items"].Post.RequestBody
+	require.NotNil(t, items)
+	assert.False(t, items.Required)
+	assert.Contains(t, items.Content, "application/json")
+
+	// Several hosts: servers are listed per path too
+	assert.Len(t, doc.Servers, 2)
+	assert.Equal(t, []Server{{URL: "https://auth.example.com"}}, doc.Paths["/login"].Servers)
+	assert.Equal(t, "Test", doc.Info.Title)
+	assert.Equal(t, "Created", doc.Paths["/items"].Post.Responses["201"].Description)
+	assert.Nil(t, doc.Paths["/items"].Post.Responses["201"].Content)
+}
+
+func TestBuild_UniqueOperationIDs(t *testing.T) {
+	doc := Build([]Endpoint{
+		{Method: "GET", PathTemplate: "/a-b", Host: "x.com"},
+		{Method: "GET", PathTemplate: "/a_b", Host: "x.com"},
+	}, Options{})
+
+	assert.Equal(t, "getAB", doc.Paths["/a-b"].Get.OperationID)
+	assert.Equal(t, "getAB2", doc.Paths["/a_b"].Get.OperationID)
+	assert.Contains(t, doc.Paths["/a-b"].Get.Responses, "default")
+}
+
+func TestMarshal_Formats(t *testing.T) {
+	doc := Build([]Endpoint{usersEndpoint()}, Options{})
+
+	data, err := Marshal(doc, FormatJSON)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "3.1.0", decoded["openapi"])
+
+	yamlData, err := Marshal(doc, FormatYAML)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(yamlData), "openapi: 3.1.0\n"))
+
+	_, err = Marshal(doc, "xml")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "spec", "api.yaml")
+	n, err := WriteFile(path, doc, FormatYAML)
+	require.NoError(t, err)
+	onDisk, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, len(onDisk), n)
+}
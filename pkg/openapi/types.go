@@ -0,0 +1,102 @@
+// Package openapi builds OpenAPI 3.1 documents from observed HTTP traffic.
+//
+// Schemas are JSON Schema Draft 2020-12 (as produced by pkg/jsonschema),
+// which OpenAPI 3.1 accepts directly.
+package openapi
+
+import (
+	"github.com/invopop/jsonschema"
+)
+
+// Version is the OpenAPI version written to documents.
+const Version = "3.1.0"
+
+// Document is an OpenAPI 3.1 document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is served from.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations for one path template.
+type PathItem struct {
+	Servers []Server   `json:"servers,omitempty"`
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation is a single method on a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+
+	// Traceability back to the capture
+	ClusterID     string `json:"x-powhttp-cluster-id,omitempty"`
+	ObservedCount int    `json:"x-powhttp-observed-count,omitempty"`
+}
+
+// Parameter is a path, query, or header parameter.
+type Parameter struct {
+	Name     string             `json:"name"`
+	In       string             `json:"in"` // "path", "query", "header"
+	Required bool               `json:"required,omitempty"`
+	Schema   *jsonschema.Schema `json:"schema,omitempty"`
+	Example  any                `json:"example,omitempty"`
+}
+
+// RequestBody describes the request payload by media type.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes one status code.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema for one content type.
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+// Components holds reusable definitions.
+type Components struct {
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate.
+type SecurityScheme struct {
+	Type   string `json:"type"`             // "http" or "apiKey"
+	Scheme string `json:"scheme,omitempty"` // "bearer" for http
+	In     string `json:"in,omitempty"`     // "header" or "cookie" for apiKey
+	Name   string `json:"name,omitempty"`   // header or cookie name for apiKey
+}
+
+// SecurityRequirement maps security scheme names to scopes (always empty here).
+type SecurityRequirement map[string][]string
@@ -0,0 +1,92 @@
+package typegen
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+func renderGo(m *model, pkg string) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n", pkg)
+
+	if m.Root.Kind != kindObject {
+		fmt.Fprintf(&sb, "\ntype %s %s\n", m.Name, goType(m.Root, true))
+	}
+	for _, obj := range m.topDown() {
+		fmt.Fprintf(&sb, "\ntype %s struct {\n", obj.Name)
+		used := make(map[string]bool)
+		for _, f := range obj.Fields {
+			name := TypeName(f.Name)
+			for i := 2; used[name]; i++ {
+				name = TypeName(f.Name) + strconv.Itoa(i)
+			}
+			used[name] = true
+
+			tag := f.Name
+			if !f.Required {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&sb, "\t%s %s `json:%s`", name, goType(f.Type, f.Required), strconv.Quote(tag))
+			if c := comment(f.Type); c != "" {
+				sb.WriteString(" // " + c)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}\n")
+	}
+
+	src, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("formatting Go: %w", err)
+	}
+	return string(src), nil
+}
+
+// goType returns the Go type for t. Nullable values become pointers, as do
+// optional structs so that omitempty can drop them; slices, maps, and any are
+// already nil-able.
+func goType(t *typeExpr, required bool) string {
+	var base string
+	switch t.Kind {
+	case kindString:
+		base = "string"
+	case kindInteger:
+		base = "int64"
+	case kindNumber:
+		base = "float64"
+	case kindBool:
+		base = "bool"
+	case kindObject:
+		base = t.Object.Name
+		if t.Nullable || !required {
+			return "*" + base
+		}
+		return base
+	case kindMap:
+		return "map[string]any"
+	case kindArray:
+		return "[]" + goType(t.Elem, true)
+	default:
+		return "any"
+	}
+	if t.Nullable {
+		return "*" + base
+	}
+	return base
+}
+
+// comment describes what a type alone cannot express.
+func comment(t *typeExpr) string {
+	for t.Kind == kindArray {
+		t = t.Elem
+	}
+	if len(t.Enum) > 0 {
+		return "One of: " + strings.Join(t.Enum, ", ")
+	}
+	if t.Format != "" {
+		return "Format: " + t.Format
+	}
+	return ""
+}
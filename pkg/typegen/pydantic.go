@@ -0,0 +1,160 @@
+package typegen
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// pyIdent matches names usable as-is for Pydantic fields. A leading
+// underscore makes a private attribute, so it is excluded.
+var pyIdent = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// pyReserved lists Python keywords and BaseModel attributes that fields must not shadow.
+var pyReserved = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true,
+	"finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true,
+	"not": true, "or": true, "pass": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true,
+	"construct": true, "copy": true, "dict": true, "json": true, "schema": true,
+	"validate": true,
+}
+
+// pyField returns the attribute name for a JSON key and whether it needs an alias.
+func pyField(key string) (string, bool) {
+	if pyIdent.MatchString(key) && !pyReserved[key] && !strings.HasPrefix(key, "model_") {
+		return key, false
+	}
+	ws := words(key)
+	for i := range ws {
+		ws[i] = strings.ToLower(ws[i])
+	}
+	name := strings.Join(ws, "_")
+	switch {
+	case name == "":
+		name = "field"
+	case name[0] >= '0' && name[0] <= '9':
+		name = "f_" + name
+	}
+	if pyReserved[name] || strings.HasPrefix(name, "model_") {
+		name += "_"
+	}
+	return name, true
+}
+
+// pyImports collects the typing names a module uses.
+type pyImports map[string]bool
+
+func renderPydantic(m *model) string {
+	imports := pyImports{}
+	usesField := false
+
+	var body strings.Builder
+	for _, obj := range m.Objects {
+		fmt.Fprintf(&body, "\n\nclass %s(BaseModel):\n", obj.Name)
+		if len(obj.Fields) == 0 {
+			body.WriteString("    pass\n")
+		}
+		used := make(map[string]bool)
+		for _, f := range obj.Fields {
+			name, aliased := pyField(f.Name)
+			for base, i := name, 2; used[name]; i++ {
+				name, aliased = fmt.Sprintf("%s_%d", base, i), true
+			}
+			used[name] = true
+
+			typ := pyType(f.Type, imports)
+			var args []string
+			if aliased {
+				args = append(args, "alias="+jsonQuote(f.Name))
+			}
+			if !f.Required && typ != "Any" && !strings.HasPrefix(typ, "Optional[") {
+				typ = "Optional[" + typ + "]"
+				imports["Optional"] = true
+			}
+
+			line := fmt.Sprintf("    %s: %s", name, typ)
+			switch {
+			case len(args) > 0 && !f.Required:
+				usesField = true
+				line += fmt.Sprintf(" = Field(default=None, %s)", strings.Join(args, ", "))
+			case len(args) > 0:
+				usesField = true
+				line += fmt.Sprintf(" = Field(%s)", strings.Join(args, ", "))
+			case !f.Required:
+				line += " = None"
+			}
+			if c := comment(f.Type); c != "" && len(f.Type.Enum) == 0 {
+				line += "  # " + c
+			}
+			body.WriteString(line + "\n")
+		}
+	}
+	rootModel := m.Root.Kind != kindObject
+	if rootModel {
+		fmt.Fprintf(&body, "\n\nclass %s(RootModel[%s]):\n    pass\n", m.Name, pyType(m.Root, imports))
+	}
+
+	var sb strings.Builder
+	if len(imports) > 0 {
+		names := make([]string, 0, len(imports))
+		for n := range imports {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		fmt.Fprintf(&sb, "from typing import %s\n\n", strings.Join(names, ", "))
+	}
+	pydanticNames := []string{"BaseModel"}
+	if usesField {
+		pydanticNames = append(pydanticNames, "Field")
+	}
+	if rootModel {
+		pydanticNames = append(pydanticNames, "RootModel")
+	}
+	fmt.Fprintf(&sb, "from pydantic import %s\n", strings.Join(pydanticNames, ", "))
+	sb.WriteString(body.String())
+	return sb.String()
+}
+
+func pyType(t *typeExpr, imports pyImports) string {
+	var base string
+	switch t.Kind {
+	case kindString:
+		if len(t.Enum) > 0 {
+			quoted := make([]string, len(t.Enum))
+			for i, v := range t.Enum {
+				quoted[i] = jsonQuote(v)
+			}
+			imports["Literal"] = true
+			base = "Literal[" + strings.Join(quoted, ", ") + "]"
+		} else {
+			base = "str"
+		}
+	case kindInteger:
+		base = "int"
+	case kindNumber:
+		base = "float"
+	case kindBool:
+		base = "bool"
+	case kindObject:
+		base = t.Object.Name
+	case kindMap:
+		imports["Any"], imports["Dict"] = true, true
+		base = "Dict[str, Any]"
+	case kindArray:
+		imports["List"] = true
+		base = "List[" + pyType(t.Elem, imports) + "]"
+	default:
+		imports["Any"] = true
+		return "Any"
+	}
+	if t.Nullable {
+		imports["Optional"] = true
+		return "Optional[" + base + "]"
+	}
+	return base
+}
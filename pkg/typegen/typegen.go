@@ -0,0 +1,312 @@
+// Package typegen turns inferred JSON Schemas into type definitions: Go
+// structs, TypeScript interfaces, Zod schemas, and Pydantic models.
+//
+// It is the reverse of internal/schema, which parses Go structs and Zod into
+// JSON Schema. Required fields come from the schema, nullability from null
+// members of anyOf (or field stats), and string formats and enums from
+// jsonschema.FieldStat.
+package typegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/invopop/jsonschema"
+
+	js "github.com/usestring/powhttp-mcp/pkg/jsonschema"
+)
+
+// Supported output formats.
+const (
+	Go         = "go"
+	TypeScript = "typescript"
+	Zod        = "zod"
+	Pydantic   = "pydantic"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{Go, TypeScript, Zod, Pydantic}
+
+// Options tunes generation.
+type Options struct {
+	RootName string // Name of the top-level type (default "Response")
+	Package  string // Go package name (default "main")
+}
+
+// Generate renders schema as type definitions in format. stats may be nil;
+// when given, it supplies nullability, string formats, and enum values.
+func Generate(format string, schema *jsonschema.Schema, stats []js.FieldStat, opts Options) (string, error) {
+	if schema == nil {
+		return "", fmt.Errorf("no schema to generate types from")
+	}
+	if opts.RootName == "" {
+		opts.RootName = "Response"
+	}
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+
+	m := buildModel(schema, stats, TypeName(opts.RootName))
+	switch format {
+	case Go:
+		return renderGo(m, opts.Package)
+	case TypeScript:
+		return renderTypeScript(m), nil
+	case Zod:
+		return renderZod(m), nil
+	case Pydantic:
+		return renderPydantic(m), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// kind is the shape of a type expression.
+type kind int
+
+const (
+	kindAny kind = iota
+	kindString
+	kindInteger
+	kindNumber
+	kindBool
+	kindObject // Named object with known fields
+	kindMap    // Object without known fields
+	kindArray
+)
+
+// typeExpr is a language-neutral type.
+type typeExpr struct {
+	Kind     kind
+	Object   *objectDef // kindObject
+	Elem     *typeExpr  // kindArray
+	Nullable bool
+	Format   string   // String format from field stats (uuid, iso8601, url, email)
+	Enum     []string // String enum values from field stats
+}
+
+// objectDef is a named object type.
+type objectDef struct {
+	Name   string
+	Fields []*field
+}
+
+// field is one property of an object.
+type field struct {
+	Name     string // JSON key
+	Type     *typeExpr
+	Required bool
+}
+
+// model is the set of types generated from one schema.
+type model struct {
+	Root    *typeExpr
+	Name    string       // Name of the root type
+	Objects []*objectDef // Every named object, children before parents
+	names   map[string]bool
+	stats   map[string]js.FieldStat
+}
+
+func buildModel(schema *jsonschema.Schema, stats []js.FieldStat, rootName string) *model {
+	m := &model{Name: rootName, names: make(map[string]bool), stats: make(map[string]js.FieldStat, len(stats))}
+	for _, s := range stats {
+		m.stats[s.Path] = s
+	}
+	// Reserve the root name so nested objects cannot take it, whatever the root's kind.
+	m.names[rootName] = true
+	m.Root = m.build(schema, "", rootName, "")
+	return m
+}
+
+// build converts schema at path to a type expression. name is the preferred
+// type name should the schema be an object; parent names disambiguate clashes.
+func (m *model) build(schema *jsonschema.Schema, path, name, parent string) *typeExpr {
+	if schema == nil {
+		return &typeExpr{Kind: kindAny}
+	}
+
+	if len(schema.AnyOf) > 0 {
+		var nonNull []*jsonschema.Schema
+		for _, s := range schema.AnyOf {
+			if s.Type != "null" {
+				nonNull = append(nonNull, s)
+			}
+		}
+		nullable := len(nonNull) < len(schema.AnyOf)
+		var t *typeExpr
+		switch {
+		case len(nonNull) == 1:
+			t = m.build(nonNull[0], path, name, parent)
+		case len(nonNull) == 2 && isNumeric(nonNull[0]) && isNumeric(nonNull[1]):
+			t = &typeExpr{Kind: kindNumber}
+		default:
+			t = &typeExpr{Kind: kindAny}
+		}
+		t.Nullable = t.Nullable || nullable
+		return t
+	}
+
+	t := &typeExpr{}
+	switch schema.Type {
+	case "string":
+		t.Kind = kindString
+		if st, ok := m.stats[path]; ok {
+			// A single observed value is more likely a constant of the sample
+			// than a closed set, so it stays a plain string.
+			switch {
+			case st.Format == "enum" && len(st.EnumValues) > 1:
+				t.Enum = st.EnumValues
+			case st.Format != "enum":
+				t.Format = st.Format
+			}
+		}
+	case "integer":
+		t.Kind = kindInteger
+	case "number":
+		t.Kind = kindNumber
+	case "boolean":
+		t.Kind = kindBool
+	case "null":
+		t.Kind, t.Nullable = kindAny, true
+	case "array":
+		t.Kind = kindArray
+		t.Elem = m.build(schema.Items, path+"[]", singular(name), parent)
+	case "object":
+		if schema.Properties == nil || schema.Properties.Len() == 0 {
+			t.Kind = kindMap
+			break
+		}
+		t.Kind = kindObject
+		t.Object = m.buildObject(schema, path, name, parent)
+	default:
+		t.Kind = kindAny
+	}
+
+	if st, ok := m.stats[path]; ok && st.Nullable {
+		t.Nullable = true
+	}
+	return t
+}
+
+func (m *model) buildObject(schema *jsonschema.Schema, path, name, parent string) *objectDef {
+	obj := &objectDef{Name: name}
+	if path != "" {
+		if m.names[name] && parent != "" {
+			name = parent + name
+		}
+		obj.Name = m.uniqueName(name)
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, r := range schema.Required {
+		required[r] = true
+	}
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		childPath := pair.Key
+		if path != "" {
+			childPath = path + "." + pair.Key
+		}
+		obj.Fields = append(obj.Fields, &field{
+			Name:     pair.Key,
+			Type:     m.build(pair.Value, childPath, TypeName(pair.Key), obj.Name),
+			Required: required[pair.Key],
+		})
+	}
+
+	m.Objects = append(m.Objects, obj)
+	return obj
+}
+
+// uniqueName reserves name, appending a number if it is taken.
+func (m *model) uniqueName(name string) string {
+	candidate := name
+	for i := 2; m.names[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	m.names[candidate] = true
+	return candidate
+}
+
+func isNumeric(s *jsonschema.Schema) bool {
+	return s.Type == "integer" || s.Type == "number"
+}
+
+// topDown returns the objects with parents before their children.
+func (m *model) topDown() []*objectDef {
+	out := make([]*objectDef, len(m.Objects))
+	for i, o := range m.Objects {
+		out[len(m.Objects)-1-i] = o
+	}
+	return out
+}
+
+// initialisms are kept upper-case in Go identifiers, as golint expects.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "ttl": true, "ui": true, "uri": true, "url": true,
+	"uuid": true, "xml": true,
+}
+
+// words splits an identifier on separators and lower-to-upper case changes.
+func words(s string) []string {
+	var out []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, string(cur))
+			cur = nil
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return out
+}
+
+// TypeName converts a JSON key or phrase to an exported PascalCase identifier.
+func TypeName(s string) string {
+	var sb strings.Builder
+	for _, w := range words(s) {
+		lw := strings.ToLower(w)
+		if initialisms[lw] {
+			sb.WriteString(strings.ToUpper(lw))
+			continue
+		}
+		r := []rune(w)
+		sb.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		return "F" + name
+	}
+	return name
+}
+
+// singular makes a best-effort singular of a plural type name, for array items.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 4:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ses"), strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s") && len(name) > 3:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
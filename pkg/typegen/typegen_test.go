@@ -0,0 +1,175 @@
+package typegen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	js "github.com/usestring/powhttp-mcp/pkg/jsonschema"
+)
+
+// inferred infers a schema and field stats from samples.
+func inferred(t *testing.T, samples ...string) (*js.InferredSchema, []js.FieldStat) {
+	t.Helper()
+	raw := make([][]byte, len(samples))
+	for i, s := range samples {
+		raw[i] = []byte(s)
+	}
+	inf, err := js.Infer(raw...)
+	require.NoError(t, err)
+	return inf, js.ComputeFieldStats(inf.Schema, raw)
+}
+
+// userSamples cover required, optional, nullable, nested, array, enum, and format fields.
+func userSamples() []string {
+	statuses := []string{"active", "inactive", "active", "banned", "active"}
+	out := make([]string, len(statuses))
+	for i, s := range statuses {
+		extra := ""
+		if i == 0 {
+			extra = `,"nickname":"n"`
+		}
+		bio := `null`
+		if i%2 == 0 {
+			bio = `"hi"`
+		}
+		out[i] = fmt.Sprintf(`{"id":%d,"uuid":"%08d-0000-4000-8000-000000000000","status":%q,"user-id":"u","class":"c",`+
+			`"profile":{"avatarURL":"https://x/%d","bio":%s},"orders":[{"sku":"s","qty":1.5}],"meta":{}%s}`,
+			i, i, s, i, bio, extra)
+	}
+	return out
+}
+
+// squash collapses runs of spaces and tabs so assertions do not depend on gofmt alignment.
+func squash(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' }), " ")
+}
+
+func TestGenerate_Go(t *testing.T) {
+	inf, stats := inferred(t, userSamples()...)
+	code, err := Generate(Go, inf.Schema, stats, Options{RootName: "user", Package: "api"})
+	require.NoError(t, err)
+
+	assert.Contains(t, code, "package api\n")
+	for _, want := range []string{
+		"type User struct {",
+		"ID int64 `json:\"id\"`",
+		"Nickname string `json:\"nickname,omitempty\"`",
+		"Orders []Order `json:\"orders\"`",
+		"Profile Profile `json:\"profile\"`",
+		"Meta map[string]any `json:\"meta\"`",
+		"UserID string `json:\"user-id\"`",
+		"Status string `json:\"status\"` // One of: active, banned, inactive",
+		"UUID string `json:\"uuid\"` // Format: uuid",
+		"Bio *string `json:\"bio,omitempty\"`",
+		"type Order struct {",
+		"Qty float64 `json:\"qty\"`",
+	} {
+		assert.Contains(t, squash(code), want)
+	}
+	assert.Less(t, strings.Index(code, "type User struct"), strings.Index(code, "type Profile struct"), "root type comes first")
+}
+
+func TestGenerate_TypeScript(t *testing.T) {
+	inf, stats := inferred(t, userSamples()...)
+	code, err := Generate(TypeScript, inf.Schema, stats, Options{RootName: "User"})
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		"export interface User {",
+		"  id: number;",
+		"  nickname?: string;",
+		`  status: "active" | "banned" | "inactive";`,
+		`  "user-id": string;`,
+		"  orders: Order[];",
+		"  meta: Record<string, unknown>;",
+		"  bio?: string | null;",
+		"  /** Format: uuid */\n  uuid: string;",
+	} {
+		assert.Contains(t, code, want)
+	}
+}
+
+func TestGenerate_Zod(t *testing.T) {
+	inf, stats := inferred(t, userSamples()...)
+	code, err := Generate(Zod, inf.Schema, stats, Options{RootName: "User"})
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		`import { z } from "zod";`,
+		"  id: z.number().int(),",
+		"  nickname: z.string().optional(),",
+		`  status: z.enum(["active", "banned", "inactive"]),`,
+		"  uuid: z.string().uuid(),",
+		"  bio: z.string().nullable().optional(),",
+		"  orders: z.array(Order),",
+		"export type User = z.infer<typeof User>;",
+	} {
+		assert.Contains(t, code, want)
+	}
+	assert.Less(t, strings.Index(code, "export const Order"), strings.Index(code, "export const User"), "schemas are declared before use")
+}
+
+func TestGenerate_Pydantic(t *testing.T) {
+	inf, stats := inferred(t, userSamples()...)
+	code, err := Generate(Pydantic, inf.Schema, stats, Options{RootName: "User"})
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		"from typing import Any, Dict, List, Literal, Optional\n\nfrom pydantic import BaseModel, Field\n",
+		"class User(BaseModel):",
+		"    id: int\n",
+		"    nickname: Optional[str] = None\n",
+		`    status: Literal["active", "banned", "inactive"]`,
+		`    class_: str = Field(alias="class")`,
+		`    user_id: str = Field(alias="user-id")`,
+		"    orders: List[Order]\n",
+		"    meta: Dict[str, Any]\n",
+		"    bio: Optional[str] = None\n",
+	} {
+		assert.Contains(t, code, want)
+	}
+	assert.Less(t, strings.Index(code, "class Order("), strings.Index(code, "class User("), "models are declared before use")
+}
+
+func TestGenerate_RootArray(t *testing.T) {
+	inf, _ := inferred(t, `[{"id":1,"response":{"a":1}}]`)
+
+	code, err := Generate(Go, inf.Schema, nil, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, code, "type Response []ResponseItem")
+	assert.Contains(t, code, "Response *ResponseItemResponse", "nested names avoid the reserved root name")
+
+	code, err = Generate(Pydantic, inf.Schema, nil, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, code, "class Response(RootModel[List[ResponseItem]]):")
+
+	code, err = Generate(Zod, inf.Schema, nil, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, code, "export const Response = z.array(ResponseItem);")
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	inf, _ := inferred(t, `{"a":1}`)
+	_, err := Generate("rust", inf.Schema, nil, Options{})
+	assert.Error(t, err)
+	_, err = Generate(Go, nil, nil, Options{})
+	assert.Error(t, err)
+}
+
+func TestTypeName(t *testing.T) {
+	for in, want := range map[string]string{
+		"user_id":     "UserID",
+		"avatarURL":   "AvatarURL",
+		"x-api-key":   "XAPIKey",
+		"2fa":         "F2fa",
+		"":            "Field",
+		"créé":        "Créé",
+		"userProfile": "UserProfile",
+	} {
+		assert.Equal(t, want, TypeName(in), in)
+	}
+}
@@ -0,0 +1,163 @@
+package typegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// jsIdent matches property names that need no quoting in TypeScript.
+var jsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// jsonQuote returns s as a JSON string, which is also a valid TypeScript and
+// Python string literal.
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func propName(name string) string {
+	if jsIdent.MatchString(name) {
+		return name
+	}
+	return jsonQuote(name)
+}
+
+func renderTypeScript(m *model) string {
+	var sb strings.Builder
+	first := true
+	sep := func() {
+		if !first {
+			sb.WriteString("\n")
+		}
+		first = false
+	}
+
+	if m.Root.Kind != kindObject {
+		sep()
+		fmt.Fprintf(&sb, "export type %s = %s;\n", m.Name, tsType(m.Root))
+	}
+	for _, obj := range m.topDown() {
+		sep()
+		fmt.Fprintf(&sb, "export interface %s {\n", obj.Name)
+		for _, f := range obj.Fields {
+			if c := comment(f.Type); c != "" && len(f.Type.Enum) == 0 {
+				fmt.Fprintf(&sb, "  /** %s */\n", c)
+			}
+			opt := ""
+			if !f.Required {
+				opt = "?"
+			}
+			fmt.Fprintf(&sb, "  %s%s: %s;\n", propName(f.Name), opt, tsType(f.Type))
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+func tsType(t *typeExpr) string {
+	var base string
+	switch t.Kind {
+	case kindString:
+		if len(t.Enum) > 0 {
+			quoted := make([]string, len(t.Enum))
+			for i, v := range t.Enum {
+				quoted[i] = jsonQuote(v)
+			}
+			base = strings.Join(quoted, " | ")
+		} else {
+			base = "string"
+		}
+	case kindInteger, kindNumber:
+		base = "number"
+	case kindBool:
+		base = "boolean"
+	case kindObject:
+		base = t.Object.Name
+	case kindMap:
+		base = "Record<string, unknown>"
+	case kindArray:
+		elem := tsType(t.Elem)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		base = elem + "[]"
+	default:
+		return "unknown"
+	}
+	if t.Nullable {
+		return base + " | null"
+	}
+	return base
+}
+
+func renderZod(m *model) string {
+	var sb strings.Builder
+	sb.WriteString("import { z } from \"zod\";\n")
+
+	// Zod schemas are values, so each must be declared before it is used.
+	for _, obj := range m.Objects {
+		fmt.Fprintf(&sb, "\nexport const %s = z.object({\n", obj.Name)
+		for _, f := range obj.Fields {
+			expr := zodType(f.Type)
+			if !f.Required {
+				expr += ".optional()"
+			}
+			fmt.Fprintf(&sb, "  %s: %s,\n", propName(f.Name), expr)
+		}
+		sb.WriteString("});\n")
+		fmt.Fprintf(&sb, "export type %s = z.infer<typeof %s>;\n", obj.Name, obj.Name)
+	}
+	if m.Root.Kind != kindObject {
+		fmt.Fprintf(&sb, "\nexport const %s = %s;\n", m.Name, zodType(m.Root))
+		fmt.Fprintf(&sb, "export type %s = z.infer<typeof %s>;\n", m.Name, m.Name)
+	}
+	return sb.String()
+}
+
+// zodFormats maps field-stat string formats to Zod refinements. iso8601 is
+// left out because it also matches bare dates, which z.string().datetime() rejects.
+var zodFormats = map[string]string{
+	"uuid":  ".uuid()",
+	"url":   ".url()",
+	"email": ".email()",
+}
+
+func zodType(t *typeExpr) string {
+	var base string
+	switch t.Kind {
+	case kindString:
+		if len(t.Enum) > 0 {
+			quoted := make([]string, len(t.Enum))
+			for i, v := range t.Enum {
+				quoted[i] = jsonQuote(v)
+			}
+			base = "z.enum([" + strings.Join(quoted, ", ") + "])"
+		} else {
+			base = "z.string()" + zodFormats[t.Format]
+		}
+	case kindInteger:
+		base = "z.number().int()"
+	case kindNumber:
+		base = "z.number()"
+	case kindBool:
+		base = "z.boolean()"
+	case kindObject:
+		base = t.Object.Name
+	case kindMap:
+		base = "z.record(z.string(), z.unknown())"
+	case kindArray:
+		base = "z.array(" + zodType(t.Elem) + ")"
+	default:
+		return "z.unknown()"
+	}
+	if t.Nullable {
+		return base + ".nullable()"
+	}
+	return base
+}
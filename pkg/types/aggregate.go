@@ -0,0 +1,52 @@
+package types
+
+// AggregateRequest contains parameters for grouping the entries a search matches.
+type AggregateRequest struct {
+	SessionID  string
+	SessionIDs []string       // Sessions to aggregate across instead of SessionID; "all" for every session
+	Query      string         // Search query selecting entries
+	Filters    *SearchFilters // Optional structured filters
+	GroupBy    []string       // Group keys (host, status, header:<name>, ...); none yields only the total
+	BucketMs   int64          // Time bucket width for histograms; 0 disables
+	SortBy     string         // count (default), errors, error_rate, bytes, p50, p90, p99
+	Limit      int            // Max groups returned (default 20)
+}
+
+// AggregateGroup holds the statistics of one group of entries.
+type AggregateGroup struct {
+	Key             map[string]string `json:"key,omitempty"` // Group key name -> value
+	Count           int               `json:"count"`
+	Errors          int               `json:"errors"`     // Status >= 400 or no response
+	ErrorRate       float64           `json:"error_rate"` // Errors / Count (0.0-1.0)
+	ReqBytes        int64             `json:"req_bytes"`
+	RespBytes       int64             `json:"resp_bytes"`
+	Latency         *LatencyStats     `json:"latency_ms,omitempty"`
+	Buckets         []TimeBucket      `json:"buckets,omitzero"`
+	ExampleEntryIDs []string          `json:"example_entry_ids,omitzero"`
+}
+
+// LatencyStats summarizes entry durations in milliseconds.
+type LatencyStats struct {
+	Samples int     `json:"samples"` // Entries with recorded timings
+	Min     int64   `json:"min"`
+	P50     int64   `json:"p50"`
+	P90     int64   `json:"p90"`
+	P99     int64   `json:"p99"`
+	Max     int64   `json:"max"`
+	Avg     float64 `json:"avg"`
+}
+
+// TimeBucket counts the entries that started within one histogram bucket.
+type TimeBucket struct {
+	StartMs int64 `json:"start_ms"`
+	Count   int   `json:"count"`
+	Errors  int   `json:"errors,omitempty"`
+}
+
+// AggregateResponse contains the grouped statistics.
+type AggregateResponse struct {
+	Groups     []AggregateGroup
+	Total      AggregateGroup // All matched entries; buckets include empty ones
+	GroupCount int            // Distinct groups before the limit
+	SyncedAtMs int64
+}
@@ -38,16 +38,18 @@ type Cluster struct {
 	Stats           ClusterStats     `json:"stats"`
 	ExampleEntryIDs []string         `json:"example_entry_ids,omitzero"`
 	ContentTypeHint string           `json:"content_type_hint,omitempty"`
+	SessionIDs      []string         `json:"session_ids,omitzero"` // Sessions with entries in the cluster, for multi-session extraction
 }
 
 // ExtractRequest contains parameters for cluster extraction.
 type ExtractRequest struct {
-	SessionID string
-	Scope     *ClusterScope
-	Filters   *ClusterFilters
-	Options   *ClusterOptions
-	Limit     int // Default 50, for returned clusters
-	Offset    int
+	SessionID  string
+	SessionIDs []string // Sessions to cluster across instead of SessionID; "all" for every session
+	Scope      *ClusterScope
+	Filters    *ClusterFilters
+	Options    *ClusterOptions
+	Limit      int // Default 50, for returned clusters
+	Offset     int
 }
 
 // ClusterScope defines pre-clustering filters that narrow the input entries.
@@ -128,3 +130,70 @@ type ExampleEntry struct {
 	EntryID string        `json:"entry_id"`
 	Summary *EntrySummary `json:"summary"`
 }
+
+// CatalogDiffRequest contains parameters for comparing the endpoint catalogs
+// of two sessions, or of two time windows of one session.
+type CatalogDiffRequest struct {
+	Before  CatalogSide
+	After   CatalogSide
+	Scope   *ClusterScope   // Applied to both sides; the time bounds come from each side
+	Filters *ClusterFilters // Applied to both sides
+	Samples int             // Entries sampled per endpoint and side for schema comparison (default 10, max 50)
+}
+
+// CatalogSide selects the entries of one side of a catalog diff.
+type CatalogSide struct {
+	SessionID string
+	SinceMs   int64 // Unix ms lower bound (0 for none)
+	UntilMs   int64 // Unix ms upper bound (0 for none)
+}
+
+// CatalogDiff reports how the endpoint catalog changed between two captures.
+type CatalogDiff struct {
+	BeforeEndpoints int              `json:"before_endpoints"`
+	AfterEndpoints  int              `json:"after_endpoints"`
+	Added           []Cluster        `json:"added,omitzero"`   // Endpoints only seen after
+	Removed         []Cluster        `json:"removed,omitzero"` // Endpoints only seen before
+	Changed         []EndpointChange `json:"changed,omitzero"`
+	Unchanged       int              `json:"unchanged"` // Endpoints seen on both sides with no detected change
+}
+
+// EndpointChange lists what changed on an endpoint seen in both captures.
+type EndpointChange struct {
+	ClusterID        string         `json:"cluster_id"`
+	Host             string         `json:"host"`
+	Method           string         `json:"method"`
+	PathTemplate     string         `json:"path_template"`
+	BeforeCount      int            `json:"before_count"`
+	AfterCount       int            `json:"after_count"`
+	QueryKeysAdded   []string       `json:"query_keys_added,omitzero"`
+	QueryKeysRemoved []string       `json:"query_keys_removed,omitzero"`
+	Auth             *AuthChange    `json:"auth,omitempty"`
+	Status           *StatusChange  `json:"status,omitempty"`
+	RequestSchema    []SchemaChange `json:"request_schema,omitzero"`
+	ResponseSchema   []SchemaChange `json:"response_schema,omitzero"`
+}
+
+// AuthChange holds an endpoint's auth signals before and after.
+type AuthChange struct {
+	Before AuthSignals `json:"before"`
+	After  AuthSignals `json:"after"`
+}
+
+// StatusChange holds an endpoint's status profile before and after, reported
+// when the share of a status class moved by at least 20 points.
+type StatusChange struct {
+	Before          map[string]int `json:"before,omitzero"` // e.g. {"2xx": 95, "4xx": 3}
+	After           map[string]int `json:"after,omitzero"`
+	BeforeErrorRate float64        `json:"before_error_rate"`
+	AfterErrorRate  float64        `json:"after_error_rate"`
+}
+
+// SchemaChange describes how one body field differs between the inferred
+// schemas of both sides.
+type SchemaChange struct {
+	Path   string `json:"path"`             // JSON path (e.g., "user.name", "items[].id"); empty for the whole body
+	Change string `json:"change"`           // added, removed, or type_changed
+	Before string `json:"before,omitempty"` // Type before; empty when added
+	After  string `json:"after,omitempty"`  // Type after; empty when removed
+}
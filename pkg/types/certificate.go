@@ -0,0 +1,49 @@
+package types
+
+// CertificateChain is a decoded server certificate chain and the problems
+// found in it.
+type CertificateChain struct {
+	Hostname     string             `json:"hostname,omitempty"`    // Name the leaf was checked against
+	CheckedAt    string             `json:"checked_at"`            // Time validity was checked at (RFC 3339)
+	Certificates []CertificateInfo  `json:"certificates,omitzero"` // Leaf first, in the order sent
+	Issues       []CertificateIssue `json:"issues,omitzero"`
+}
+
+// CertificateInfo describes one X.509 certificate.
+type CertificateInfo struct {
+	Index                  int                          `json:"index"` // Position in the chain, 0 for the leaf
+	Subject                string                       `json:"subject,omitempty"`
+	Issuer                 string                       `json:"issuer,omitempty"`
+	SerialNumber           string                       `json:"serial_number,omitempty"` // Hex
+	DNSNames               []string                     `json:"dns_names,omitzero"`
+	IPAddresses            []string                     `json:"ip_addresses,omitzero"`
+	NotBefore              string                       `json:"not_before,omitempty"` // RFC 3339
+	NotAfter               string                       `json:"not_after,omitempty"`  // RFC 3339
+	KeyType                string                       `json:"key_type,omitempty"`   // RSA, ECDSA, or Ed25519
+	KeyBits                int                          `json:"key_bits,omitempty"`
+	SignatureAlgorithm     string                       `json:"signature_algorithm,omitempty"`
+	IsCA                   bool                         `json:"is_ca,omitempty"`
+	SelfSigned             bool                         `json:"self_signed,omitempty"`
+	OCSPServers            []string                     `json:"ocsp_servers,omitzero"`
+	CRLDistributionPoints  []string                     `json:"crl_distribution_points,omitzero"`
+	IssuingCertificateURLs []string                     `json:"issuing_certificate_urls,omitzero"`
+	SCTs                   []SignedCertificateTimestamp `json:"scts,omitzero"`         // Embedded Certificate Transparency timestamps
+	SHA256                 string                       `json:"sha256,omitempty"`      // Hex SHA-256 of the DER certificate
+	SPKISHA256             string                       `json:"spki_sha256,omitempty"` // Base64 SHA-256 of the public key, as certificate pins use
+	JA4X                   string                       `json:"ja4x,omitempty"`
+	Error                  string                       `json:"error,omitempty"` // Set when the certificate could not be parsed
+}
+
+// SignedCertificateTimestamp is a Certificate Transparency log's promise to
+// include a certificate.
+type SignedCertificateTimestamp struct {
+	LogID     string `json:"log_id"`    // Base64
+	Timestamp string `json:"timestamp"` // RFC 3339
+}
+
+// CertificateIssue is a problem found in a certificate chain.
+type CertificateIssue struct {
+	Code        string `json:"code"`
+	Certificate int    `json:"certificate"` // Index of the certificate at fault
+	Message     string `json:"message"`
+}
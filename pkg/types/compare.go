@@ -9,6 +9,20 @@ type Fingerprint struct {
 	Body               BodyFingerprint     `json:"body"`
 	TLSSummary         *TLSFingerprint     `json:"tls_summary,omitempty"`
 	HTTP2Summary       *HTTP2Fingerprint   `json:"http2_summary,omitempty"`
+	JA4H               string              `json:"ja4h,omitempty"`          // JA4H of the request
+	JA4HRaw            string              `json:"ja4h_r,omitempty"`        // JA4H with header names and cookies listed instead of hashed
+	JA4L               string              `json:"ja4l,omitempty"`          // JA4L-S latency in microseconds, estimated from the connect timing; no TTL part
+	HTTP1Headers       *HTTP1Headers       `json:"http1_headers,omitempty"` // HTTP/1.x only: header names as sent
+}
+
+// HTTP1Headers describes HTTP/1.x request headers as they were sent. HTTP/2
+// and HTTP/3 require lowercase names, so only HTTP/1.x carries the casing
+// that sets clients apart, e.g. Go's canonical "User-Agent".
+type HTTP1Headers struct {
+	Names         []string `json:"names,omitzero"`           // Header names in order, with their casing and repeats
+	Duplicates    []string `json:"duplicates,omitzero"`      // Lowercase names sent on more than one line
+	CookieHeaders int      `json:"cookie_headers,omitempty"` // Cookie header lines; browsers send a single one
+	CookieNames   []string `json:"cookie_names,omitzero"`    // Cookie names in the order sent, across Cookie lines
 }
 
 // BodyFingerprint contains SHA256 hashes and byte counts for request/response bodies.
@@ -27,13 +41,56 @@ type TLSFingerprint struct {
 	JA3          string `json:"ja3,omitempty"`
 	JA4          string `json:"ja4,omitempty"`
 	ALPN         string `json:"alpn,omitempty"`
+
+	// Computed locally from the handshake
+	JA4Raw      string             `json:"ja4_r,omitempty"`  // JA4 with cipher suites, extensions and signature algorithms listed instead of hashed
+	JA4S        string             `json:"ja4s,omitempty"`   // JA4S of the ServerHello
+	JA4SRaw     string             `json:"ja4s_r,omitempty"` // JA4S with extensions listed instead of hashed
+	JA4X        []string           `json:"ja4x,omitzero"`    // JA4X of each certificate, leaf first
+	ClientHello *TLSHelloBreakdown `json:"client_hello,omitempty"`
+	ServerHello *TLSHelloBreakdown `json:"server_hello,omitempty"`
+}
+
+// TLSHelloBreakdown lists the fields of a ClientHello or ServerHello behind
+// its fingerprints, as "name (0x1301)" labels. GREASE values read "GREASE",
+// so hellos that differ only in GREASE compare equal.
+type TLSHelloBreakdown struct {
+	Version             string   `json:"version,omitempty"`
+	CipherSuites        []string `json:"cipher_suites,omitzero"`
+	Extensions          []string `json:"extensions,omitzero"`
+	SupportedVersions   []string `json:"supported_versions,omitzero"`
+	SupportedGroups     []string `json:"supported_groups,omitzero"`
+	SignatureAlgorithms []string `json:"signature_algorithms,omitzero"`
+	ALPN                []string `json:"alpn,omitzero"`
+	KeyShareGroups      []string `json:"key_share_groups,omitzero"`
+	RecordSizeLimit     int      `json:"record_size_limit,omitempty"`
 }
 
 // HTTP2Fingerprint contains HTTP/2 connection details for fingerprint comparison.
 type HTTP2Fingerprint struct {
-	ConnectionID string         `json:"connection_id,omitempty"`
-	StreamID     int            `json:"stream_id,omitempty"`
-	FrameCounts  map[string]int `json:"frame_counts,omitempty"`
+	ConnectionID      string          `json:"connection_id,omitempty"`
+	StreamID          int             `json:"stream_id,omitempty"`
+	FrameCounts       map[string]int  `json:"frame_counts,omitempty"`
+	Akamai            string          `json:"akamai,omitempty"`              // Akamai fingerprint: SETTINGS|WINDOW_UPDATE|PRIORITY|pseudo-header order
+	Settings          []HTTP2Setting  `json:"settings,omitzero"`             // Client SETTINGS in the order sent
+	WindowUpdate      int64           `json:"window_update,omitempty"`       // Increment of the client's first connection-level WINDOW_UPDATE
+	Priorities        []HTTP2Priority `json:"priorities,omitzero"`           // PRIORITY frames sent by the client
+	PseudoHeaderOrder string          `json:"pseudo_header_order,omitempty"` // First letters of the pseudo-headers, e.g. "m,a,s,p"
+}
+
+// HTTP2Setting is one SETTINGS parameter.
+type HTTP2Setting struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// HTTP2Priority is one PRIORITY frame.
+type HTTP2Priority struct {
+	StreamID  int  `json:"stream_id"`
+	Exclusive bool `json:"exclusive"`
+	DependsOn int  `json:"depends_on"`
+	Weight    int  `json:"weight"`
 }
 
 // FingerprintOptions controls fingerprint generation behavior.
@@ -45,10 +102,11 @@ type FingerprintOptions struct {
 
 // DiffRequest contains parameters for comparing two entries.
 type DiffRequest struct {
-	BaselineEntryID  string
-	CandidateEntryID string
-	SessionID        string // Default "active"
-	Options          *DiffOptions
+	BaselineEntryID    string
+	CandidateEntryID   string
+	SessionID          string // Default "active"
+	CandidateSessionID string // Default SessionID (set when the candidate was recorded into another session)
+	Options            *DiffOptions
 }
 
 // DiffOptions controls diff behavior.
@@ -59,6 +117,7 @@ type DiffOptions struct {
 	CompareHTTP2        bool     // Default true
 	IgnoreHeaders       []string // Default from DefaultIgnoreHeaders
 	IgnoreQueryKeys     []string // Default from DefaultIgnoreQueryKeys
+	CompareCookies      bool     // Compare cookie names and order inside Cookie headers, default false
 	MaxBytes            int      // Default from config
 }
 
@@ -72,13 +131,16 @@ type DiffResult struct {
 
 // ImportantDiffs contains differences that are likely meaningful for anti-bot detection.
 type ImportantDiffs struct {
-	Protocol            *ProtocolDiff     `json:"protocol,omitempty"`
-	TLS                 *TLSDiff          `json:"tls,omitempty"`
-	HTTP2               *HTTP2Diff        `json:"http2,omitempty"`
-	HeadersMissing      []string          `json:"headers_missing,omitempty"`
-	HeadersExtra        []string          `json:"headers_extra,omitempty"`
-	HeadersValueChanged []HeaderValueDiff `json:"headers_value_changed,omitempty"`
-	HeaderOrderChanges  *HeaderOrderDiff  `json:"header_order_changes,omitempty"`
+	Protocol            *ProtocolDiff         `json:"protocol,omitempty"`
+	TLS                 *TLSDiff              `json:"tls,omitempty"`
+	HTTP2               *HTTP2Diff            `json:"http2,omitempty"`
+	HeadersMissing      []string              `json:"headers_missing,omitempty"`
+	HeadersExtra        []string              `json:"headers_extra,omitempty"`
+	HeadersValueChanged []HeaderValueDiff     `json:"headers_value_changed,omitempty"`
+	HeaderOrderChanges  *HeaderOrderDiff      `json:"header_order_changes,omitempty"`
+	HeaderCasing        []HeaderCasingDiff    `json:"header_casing,omitzero"`      // HTTP/1.x only
+	DuplicateHeaders    *DuplicateHeadersDiff `json:"duplicate_headers,omitempty"` // HTTP/1.x only
+	Cookies             *CookieDiff           `json:"cookies,omitempty"`           // With CompareCookies
 }
 
 // NoisyDiffs contains differences that are typically not meaningful.
@@ -103,6 +165,35 @@ type TLSDiff struct {
 	CandidateJA4     string `json:"candidate_ja4,omitempty"`
 	CipherDifferent  bool   `json:"cipher_different,omitempty"`
 	VersionDifferent bool   `json:"version_different,omitempty"`
+
+	// ClientHello compares the hellos field by field
+	ClientHello *TLSHelloDiff `json:"client_hello,omitempty"`
+}
+
+// TLSHelloDiff compares two ClientHellos field by field. Lists compare by
+// their breakdown labels, so GREASE values of different code points are
+// equal, and GREASE positions do not count as reordering.
+type TLSHelloDiff struct {
+	Changes                  []string     `json:"changes,omitzero"` // One line per difference, e.g. "candidate omits extension application_settings (0x4469)"
+	CipherSuites             *TLSListDiff `json:"cipher_suites,omitempty"`
+	Extensions               *TLSListDiff `json:"extensions,omitempty"`
+	SupportedVersions        *TLSListDiff `json:"supported_versions,omitempty"`
+	SupportedGroups          *TLSListDiff `json:"supported_groups,omitempty"`
+	SignatureAlgorithms      *TLSListDiff `json:"signature_algorithms,omitempty"`
+	ALPN                     *TLSListDiff `json:"alpn,omitempty"`
+	KeyShareGroups           *TLSListDiff `json:"key_share_groups,omitempty"`
+	RecordSizeLimitDiff      bool         `json:"record_size_limit_diff,omitempty"`
+	BaselineRecordSizeLimit  int          `json:"baseline_record_size_limit,omitempty"`
+	CandidateRecordSizeLimit int          `json:"candidate_record_size_limit,omitempty"`
+}
+
+// TLSListDiff compares an ordered list of ClientHello values.
+type TLSListDiff struct {
+	Added          []string `json:"added,omitzero"`           // Sent by the candidate only
+	Removed        []string `json:"removed,omitzero"`         // Sent by the baseline only
+	Reordered      bool     `json:"reordered,omitempty"`      // Values both send are in a different order
+	BaselineOrder  []string `json:"baseline_order,omitzero"`  // The baseline's list, when reordered
+	CandidateOrder []string `json:"candidate_order,omitzero"` // The candidate's list, when reordered
 }
 
 // HTTP2Diff represents differences in HTTP/2 metadata.
@@ -110,6 +201,32 @@ type HTTP2Diff struct {
 	BaselineStreamID  int  `json:"baseline_stream_id"`
 	CandidateStreamID int  `json:"candidate_stream_id"`
 	PseudoHeadersDiff bool `json:"pseudo_headers_diff"`
+
+	// Akamai fingerprint, then each of its parts
+	AkamaiDifferent            bool               `json:"akamai_different,omitempty"`
+	BaselineAkamai             string             `json:"baseline_akamai,omitempty"`
+	CandidateAkamai            string             `json:"candidate_akamai,omitempty"`
+	SettingsDiff               []HTTP2SettingDiff `json:"settings_diff,omitzero"`
+	SettingsOrderDiff          bool               `json:"settings_order_diff,omitempty"`
+	BaselineSettingsOrder      string             `json:"baseline_settings_order,omitempty"`
+	CandidateSettingsOrder     string             `json:"candidate_settings_order,omitempty"`
+	WindowUpdateDiff           bool               `json:"window_update_diff,omitempty"`
+	BaselineWindowUpdate       int64              `json:"baseline_window_update,omitempty"`
+	CandidateWindowUpdate      int64              `json:"candidate_window_update,omitempty"`
+	PrioritiesDiff             bool               `json:"priorities_diff,omitempty"`
+	BaselinePriorities         string             `json:"baseline_priorities,omitempty"`
+	CandidatePriorities        string             `json:"candidate_priorities,omitempty"`
+	PseudoHeaderOrderDiff      bool               `json:"pseudo_header_order_diff,omitempty"`
+	BaselinePseudoHeaderOrder  string             `json:"baseline_pseudo_header_order,omitempty"`
+	CandidatePseudoHeaderOrder string             `json:"candidate_pseudo_header_order,omitempty"`
+}
+
+// HTTP2SettingDiff represents a SETTINGS parameter whose value differs or that
+// only one side sent.
+type HTTP2SettingDiff struct {
+	Name      string `json:"name"`
+	Baseline  *int64 `json:"baseline,omitempty"`
+	Candidate *int64 `json:"candidate,omitempty"`
 }
 
 // HeaderValueDiff represents a difference in header values.
@@ -119,6 +236,33 @@ type HeaderValueDiff struct {
 	Candidate []string `json:"candidate,omitzero"`
 }
 
+// HeaderCasingDiff represents a header sent by both sides with differently
+// cased names.
+type HeaderCasingDiff struct {
+	Name      string `json:"name"`      // Lowercase
+	Baseline  string `json:"baseline"`  // As sent
+	Candidate string `json:"candidate"` // As sent
+}
+
+// DuplicateHeadersDiff lists the headers each side sent on more than one line,
+// when they differ.
+type DuplicateHeadersDiff struct {
+	Baseline  []string `json:"baseline,omitzero"`
+	Candidate []string `json:"candidate,omitzero"`
+}
+
+// CookieDiff compares the cookies sent in Cookie headers by name, ignoring
+// their values.
+type CookieDiff struct {
+	Missing          []string `json:"missing,omitzero"` // Sent by the baseline only
+	Extra            []string `json:"extra,omitzero"`   // Sent by the candidate only
+	Reordered        bool     `json:"reordered,omitempty"`
+	BaselineOrder    []string `json:"baseline_order,omitzero"`
+	CandidateOrder   []string `json:"candidate_order,omitzero"`
+	BaselineHeaders  int      `json:"baseline_headers"`  // Cookie header lines
+	CandidateHeaders int      `json:"candidate_headers"` // Cookie header lines
+}
+
 // HeaderOrderDiff represents differences in header ordering.
 type HeaderOrderDiff struct {
 	BaselineOrder  []string      `json:"baseline_order,omitzero"`
@@ -132,3 +276,54 @@ type OrderChange struct {
 	BaselinePos  int    `json:"baseline_pos"`
 	CandidatePos int    `json:"candidate_pos"`
 }
+
+// GroupDiffRequest contains parameters for comparing two groups of entries,
+// e.g. browser traffic against a program's traffic.
+type GroupDiffRequest struct {
+	SessionID         string
+	BaselineEntryIDs  []string
+	CandidateEntryIDs []string
+	IgnoreHeaders     []string // Default from DefaultIgnoreHeaders
+	MinScore          float64  // Default 0.5
+	Limit             int      // Max separators returned, default 25
+}
+
+// GroupDiffResult lists the features that separate two groups of entries,
+// most consistent first.
+type GroupDiffResult struct {
+	BaselineEntries  int         `json:"baseline_entries"`  // Entries fingerprinted in the baseline group
+	CandidateEntries int         `json:"candidate_entries"` // Entries fingerprinted in the candidate group
+	Separators       []Separator `json:"separators,omitzero"`
+	SeparatorCount   int         `json:"separator_count"` // Separators scoring at least MinScore, before the limit
+}
+
+// Separator kinds.
+const (
+	SeparatorJA4           = "ja4"
+	SeparatorJA3           = "ja3"
+	SeparatorHTTPVersion   = "http_version"
+	SeparatorH2Settings    = "h2_settings"
+	SeparatorH2Setting     = "h2_setting"
+	SeparatorH2PseudoOrder = "h2_pseudo_order"
+	SeparatorHeaderPresent = "header_presence"
+	SeparatorHeaderCasing  = "header_casing"
+	SeparatorHeaderOrder   = "header_order"
+	SeparatorHeaderValue   = "header_value"
+)
+
+// Separator is one feature whose values are distributed differently in the
+// two groups.
+type Separator struct {
+	Kind      string       `json:"kind"`           // ja4, ja3, http_version, h2_settings, h2_setting, h2_pseudo_order, header_presence, header_casing, header_order, header_value
+	Name      string       `json:"name,omitempty"` // Header name, "a < b" header pair, or setting name
+	Score     float64      `json:"score"`          // 0-1 total variation distance between the groups' distributions; 1 means no value is shared
+	Baseline  []ValueShare `json:"baseline,omitzero"`
+	Candidate []ValueShare `json:"candidate,omitzero"`
+}
+
+// ValueShare is how often one value of a feature occurred in a group.
+type ValueShare struct {
+	Value string  `json:"value"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"` // Fraction of the group's entries with the feature (0.0-1.0)
+}
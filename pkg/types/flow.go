@@ -10,6 +10,10 @@ const (
 	EdgeReasonSameSessionCookie   = "same_session_cookie"   // Same session cookie
 	EdgeReasonSameAPIKey          = "same_api_key"          // Same API key header
 	EdgeReasonSessionCookieOrigin = "session_cookie_origin" // Set-Cookie -> Cookie chain
+	EdgeReasonRedirect            = "redirect"              // 3xx Location -> followed request
+	EdgeReasonReferer             = "referer"               // Page -> request naming it in Referer/Origin
+	EdgeReasonInitiatorPage       = "initiator_page"        // HTML page -> its subresources
+	EdgeReasonValuePropagation    = "value_propagation"     // Response value -> later request using it
 )
 
 // TraceRequest contains parameters for flow tracing.
@@ -26,6 +30,10 @@ type TraceOptions struct {
 	TimeWindowMs int64 // Default 120000 (2 minutes)
 	SamePIDOnly  bool  // Default true
 	SameHostOnly bool  // Default true
+
+	// ValuePropagation adds value_propagation edges, which requires fetching
+	// entry bodies. Default false.
+	ValuePropagation bool
 }
 
 // FlowGraph represents a graph of related HTTP requests.
@@ -44,5 +52,12 @@ type FlowNode struct {
 type FlowEdge struct {
 	From   string `json:"from"`   // Entry ID
 	To     string `json:"to"`     // Entry ID
-	Reason string `json:"reason"` // temporal, same_tls, same_h2, auth_chain, same_auth, same_session_cookie, same_api_key, session_cookie_origin
+	Reason string `json:"reason"` // temporal, same_tls, same_h2, auth_chain, same_auth, same_session_cookie, same_api_key, session_cookie_origin, redirect, referer, initiator_page, value_propagation
+
+	// Set on value_propagation edges: where the value appeared in the From
+	// response and where the To request sent it (e.g. "body.data.session.csrf"
+	// and "header.x-csrf-token"), and a preview of the value.
+	FromPath string `json:"from_path,omitempty"`
+	ToPath   string `json:"to_path,omitempty"`
+	Value    string `json:"value,omitempty"`
 }
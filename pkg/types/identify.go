@@ -0,0 +1,47 @@
+package types
+
+// ClientProfile is a reference fingerprint of a known client: a browser, an
+// HTTP library, or a tool. Each signal lists accepted values; JA3, JA4,
+// Akamai, and user-agent values may use * and ? wildcards. Signals left
+// empty are not compared.
+type ClientProfile struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`                   // e.g. "Chrome 120+"
+	Family       string     `json:"family"`                 // browser, library, or tool
+	Platform     string     `json:"platform,omitempty"`     // e.g. "Windows, macOS, Linux"
+	Notes        string     `json:"notes,omitempty"`        // Caveats, such as versions known to differ
+	Source       string     `json:"source,omitempty"`       // builtin or user
+	JA3          []string   `json:"ja3,omitzero"`           // JA3 hashes
+	JA4          []string   `json:"ja4,omitzero"`           // JA4 fingerprints
+	Akamai       []string   `json:"akamai,omitzero"`        // Akamai HTTP/2 fingerprints
+	HeaderOrders [][]string `json:"header_orders,omitzero"` // Lowercase request header names in the order sent, one list per variant
+	UserAgent    []string   `json:"user_agent,omitzero"`    // User-Agent values
+}
+
+// Client profile sources.
+const (
+	ClientProfileBuiltin = "builtin"
+	ClientProfileUser    = "user"
+)
+
+// ClientSignals are the signals of one request compared against profiles.
+type ClientSignals struct {
+	JA3         string   `json:"ja3,omitempty"`
+	JA4         string   `json:"ja4,omitempty"`
+	Akamai      string   `json:"akamai,omitempty"`
+	HeaderOrder []string `json:"header_order,omitzero"` // Lowercase, without pseudo-headers
+	UserAgent   string   `json:"user_agent,omitempty"`
+}
+
+// ClientMatch scores how well a request matches a profile.
+type ClientMatch struct {
+	ProfileID  string   `json:"profile_id"`
+	Name       string   `json:"name"`
+	Family     string   `json:"family"`
+	Platform   string   `json:"platform,omitempty"`
+	Source     string   `json:"source"`
+	Score      float64  `json:"score"`               // 0-1, weighted over the signals compared
+	Matched    []string `json:"matched,omitzero"`    // Signals that match fully
+	Partial    []string `json:"partial,omitzero"`    // Signals that match in part, e.g. JA4 with the same cipher suites
+	Mismatched []string `json:"mismatched,omitzero"` // Signals that do not match
+}
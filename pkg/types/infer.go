@@ -9,10 +9,20 @@ type InferSchemaOutput struct {
 	// Summary of the inference process
 	Summary InferSchemaSummary `json:"summary"`
 
+	// Type definitions generated from the schema when a code format is requested
+	Types *InferSchemaTypes `json:"types,omitempty"`
+
 	// Hint for the next step
 	Hint string `json:"hint,omitempty"`
 }
 
+// InferSchemaTypes holds type definitions generated from an inferred schema.
+type InferSchemaTypes struct {
+	Format   string `json:"format"`    // go, typescript, zod, or pydantic
+	RootName string `json:"root_name"` // Name of the top-level type
+	Code     string `json:"code"`
+}
+
 // InferSchemaSummary describes the inference process.
 type InferSchemaSummary struct {
 	EntriesRequested int    `json:"entries_requested"`
@@ -2,11 +2,12 @@ package types
 
 // SearchRequest contains parameters for a search query.
 type SearchRequest struct {
-	SessionID string         // Session to search within
-	Query     string         // Free text query
-	Filters   *SearchFilters // Optional structured filters
-	Limit     int            // Default 20, safety cap via MAX_SEARCH_RESULTS (default 10000)
-	Offset    int            // Pagination offset
+	SessionID  string         // Session to search within
+	SessionIDs []string       // Sessions to search across instead of SessionID; "all" for every session
+	Query      string         // Free text and field terms with AND/OR/NOT
+	Filters    *SearchFilters // Optional structured filters
+	Limit      int            // Default 20, safety cap via MAX_SEARCH_RESULTS (default 10000)
+	Offset     int            // Pagination offset
 }
 
 // SearchFilters contains structured filter criteria.
@@ -25,6 +26,9 @@ type SearchFilters struct {
 	TLSConnectionID string
 	JA3             string
 	JA4             string
+	JA4H            string
+	JA4S            string
+	JA4X            string // Matches entries whose server chain includes the certificate
 	SinceMs         int64 // Unix timestamp in ms
 	UntilMs         int64 // Unix timestamp in ms
 	TimeWindowMs    int64 // Alternative to since/until (relative to now)
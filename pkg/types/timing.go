@@ -0,0 +1,107 @@
+package types
+
+// TimingPhases holds the HAR timing phases of one entry in milliseconds.
+// A phase that was not recorded or does not apply is -1. SSL is part of
+// Connect, as in HAR.
+type TimingPhases struct {
+	Blocked int64 `json:"blocked"`
+	DNS     int64 `json:"dns"`
+	Connect int64 `json:"connect"`
+	SSL     int64 `json:"ssl"`
+	Send    int64 `json:"send"`
+	Wait    int64 `json:"wait"`
+	Receive int64 `json:"receive"`
+}
+
+// TimingAnalysis summarizes where time goes across a set of entries.
+type TimingAnalysis struct {
+	Entries      int           `json:"entries"`
+	TimedEntries int           `json:"timed_entries"` // Entries with recorded timings
+	Duration     *LatencyStats `json:"duration_ms,omitempty"`
+	TTFB         *LatencyStats `json:"ttfb_ms,omitempty"` // Start to first response byte (all phases before receive)
+
+	Phases      []PhaseStats       `json:"phases,omitzero"`
+	Clusters    []ClusterTiming    `json:"clusters,omitzero"`
+	Slowest     []SlowEntry        `json:"slowest,omitzero"`
+	Connections []ConnectionTiming `json:"connections,omitzero"`
+
+	ClusterCount    int `json:"cluster_count,omitempty"`    // Distinct clusters before the limit
+	ConnectionCount int `json:"connection_count,omitempty"` // Distinct TLS connections before the limit
+}
+
+// PhaseStats summarizes one timing phase across entries that recorded it.
+type PhaseStats struct {
+	Phase   string  `json:"phase"` // blocked, dns, connect, ssl, send, wait, receive
+	Samples int     `json:"samples"`
+	P50     int64   `json:"p50"`
+	P90     int64   `json:"p90"`
+	P99     int64   `json:"p99"`
+	Avg     float64 `json:"avg"`
+	Share   float64 `json:"share"` // Fraction of total recorded time (0.0-1.0); ssl overlaps connect
+}
+
+// ClusterTiming holds the latency percentiles of one endpoint cluster.
+type ClusterTiming struct {
+	ClusterID    string        `json:"cluster_id"` // Same ID as extract_endpoints
+	Host         string        `json:"host"`
+	Method       string        `json:"method"`
+	PathTemplate string        `json:"path_template"`
+	Count        int           `json:"count"`
+	Duration     *LatencyStats `json:"duration_ms,omitempty"`
+	TTFB         *LatencyStats `json:"ttfb_ms,omitempty"`
+}
+
+// SlowEntry is one of the slowest entries with its phase breakdown.
+type SlowEntry struct {
+	EntryID    string       `json:"entry_id"`
+	Method     string       `json:"method"`
+	URL        string       `json:"url"`
+	Status     int          `json:"status"`
+	DurationMs int64        `json:"duration_ms"`
+	TTFBMs     int64        `json:"ttfb_ms"`
+	Phases     TimingPhases `json:"phases"`
+}
+
+// ConnectionTiming holds the setup cost of one TLS connection, paid by the
+// first entry on it, and how many later entries reused it.
+type ConnectionTiming struct {
+	ConnectionID string `json:"connection_id"`
+	Host         string `json:"host"`
+	FirstEntryID string `json:"first_entry_id"`
+	Entries      int    `json:"entries"`
+	DNSMs        int64  `json:"dns_ms"`     // -1 if not recorded
+	ConnectMs    int64  `json:"connect_ms"` // TCP and TLS handshake; -1 if not recorded
+	SSLMs        int64  `json:"ssl_ms"`     // TLS handshake alone; -1 if not recorded
+	SetupMs      int64  `json:"setup_ms"`   // DNS plus connect
+}
+
+// Waterfall lays out the entries of a page load on a shared timeline,
+// grouped by the navigation flow that initiated them.
+type Waterfall struct {
+	PageEntryID string           `json:"page_entry_id"`
+	StartMs     int64            `json:"start_ms"` // Start of the earliest entry
+	TotalMs     int64            `json:"total_ms"` // Earliest start to latest end
+	Groups      []WaterfallGroup `json:"groups,omitzero"`
+	ASCII       string           `json:"ascii,omitempty"`
+}
+
+// WaterfallGroup holds the entries initiated by one navigation: a page, the
+// redirects leading to it, and the subresources it loaded.
+type WaterfallGroup struct {
+	RootEntryID string         `json:"root_entry_id,omitempty"` // Empty for entries with no navigation link
+	RootURL     string         `json:"root_url,omitempty"`
+	Rows        []WaterfallRow `json:"rows,omitzero"`
+}
+
+// WaterfallRow is one entry on the waterfall timeline.
+type WaterfallRow struct {
+	EntryID    string       `json:"entry_id"`
+	Method     string       `json:"method"`
+	URL        string       `json:"url"`
+	Status     int          `json:"status"`
+	OffsetMs   int64        `json:"offset_ms"`   // Start relative to Waterfall.StartMs
+	DurationMs int64        `json:"duration_ms"` // -1 if no timings were recorded
+	Phases     TimingPhases `json:"phases"`
+	Via        string       `json:"via,omitempty"` // Edge linking the entry to its initiator: redirect, initiator_page, referer
+	ParentID   string       `json:"parent_id,omitempty"`
+}
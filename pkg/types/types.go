@@ -22,6 +22,7 @@ func ToAny(v any) (any, error) {
 // EntrySummary is a compact entry representation for search results.
 type EntrySummary struct {
 	EntryID     string       `json:"entry_id"`
+	SessionID   string       `json:"session_id,omitempty"` // Set in results spanning several sessions
 	TsMs        int64        `json:"ts_ms"`
 	Method      string       `json:"method"`
 	URL         string       `json:"url"`